@@ -1,16 +1,29 @@
 package main
 
 import (
+	"context"
 	"creatly-task/internal/config"
 	"creatly-task/internal/handlers"
 	"creatly-task/internal/mongodb"
 	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/memory"
+	"creatly-task/internal/repo/postgres"
+	"creatly-task/internal/repo/resilient"
 	"creatly-task/internal/server"
 	"creatly-task/internal/services"
 	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/auth/oidc"
 	"creatly-task/pkg/hasher"
+	"creatly-task/pkg/mailer"
+	"creatly-task/pkg/resilience"
 	"creatly-task/pkg/storage"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -19,24 +32,54 @@ func main() {
 		log.Fatalf(" - - - - - - - CONFIG NOT INIT.\n%s", err)
 	}
 
-	db, err := mongodb.New(config.Repo)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = migrate(config, os.Args[2:])
+		if err != nil {
+			log.Fatalf(" - - - - - - - MIGRATIONS FAILED.\n%s", err)
+		}
+		return
+	}
+
+	repo, err := newRepo(config)
 	if err != nil {
 		log.Fatalf(" - - - - - - - DATABASE NOT INIT.\n%s", err)
 	}
 
-	storage, err := storage.New(config.Storage)
+	storage, err := newStorage(config)
 	if err != nil {
 		log.Fatalf(" - - - - - - - STORAGE NOT INIT.\n%s", err)
 	}
 
-	repo := repo.New(db, config.Repo)
+	mailer, err := mailer.New(config.Mail)
 	if err != nil {
-		log.Fatalf(" - - - - - - - REPOSITORY NOT INIT.\n%s", err)
+		log.Fatalf(" - - - - - - - MAILER NOT INIT.\n%s", err)
 	}
 
-	tokener := jwtauth.New(config.JWT)
+	tokener, err := jwtauth.New(config.JWT)
+	if err != nil {
+		log.Fatalf(" - - - - - - - TOKENER NOT INIT.\n%s", err)
+	}
+
+	providers := make(map[string]services.OIDCProvider, len(config.OIDC.Configs))
+	for name, provider := range config.OIDC.Configs {
+		redirectURL := provider.RedirectURL
+		if redirectURL == "" {
+			redirectURL = fmt.Sprintf("%s/oidc/%s/callback", strings.TrimSuffix(config.Server.PublicURL, "/"), name)
+		}
+
+		providers[name] = oidc.NewProvider(&oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       provider.Scopes,
+		}, nil)
+	}
 
-	services := services.New(repo, tokener, storage)
+	services := services.New(repo, tokener, storage, mailer, providers, config)
+	go services.RunReconciler(context.Background())
+	go services.RunWorkers(context.Background())
+	go services.RunOutboxRelay(context.Background())
 
 	hasher := hasher.New(config.Auth.Salt)
 	handlers := handlers.New(services, config.Files.Limit, hasher, config.JWT.TokenHeaderName, config.Auth.HeaderUserId)
@@ -47,3 +90,136 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// newRepo connects to the database selected by DATABASE_DRIVER, calls of a real database are retried
+func newRepo(cfg *config.Config) (*repo.Repo, error) {
+	if cfg.Database.Driver == config.DriverMemory {
+		log.Println("demo mode - data is kept in memory and lost on restart")
+		return memory.New(), nil
+	}
+
+	if cfg.Database.Driver == config.DriverPostgres {
+		db, err := postgres.Connect(cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+
+		if !cfg.Database.SkipMigrations {
+			err = postgres.Migrate(db)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return resilient.New(postgres.New(db), newResilienceConfig(cfg)), nil
+	}
+
+	db, err := mongodb.New(cfg.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	// The outbox relies on transactions, on a standalone server events may be lost by crashes
+	err = repo.CheckTransactions(context.Background(), db, cfg.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.Database.SkipMigrations {
+		err = repo.Migrate(context.Background(), db, cfg.Repo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resilient.New(repo.New(db, cfg.Repo), newResilienceConfig(cfg)), nil
+}
+
+// migrate runs "migrate [up | down <version> | status]" command. Rollback and status are supported for MongoDB only
+func migrate(cfg *config.Config, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch cfg.Database.Driver {
+	case config.DriverMemory:
+		return errors.New("memory database has no migrations")
+	case config.DriverPostgres:
+		if command != "up" {
+			return fmt.Errorf("%q is not supported for postgres", command)
+		}
+
+		db, err := postgres.Connect(cfg.Database)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		return postgres.Migrate(db)
+	}
+
+	db, err := mongodb.New(cfg.Repo)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		return repo.Migrate(ctx, db, cfg.Repo)
+	case "down":
+		if len(args) < 2 {
+			return errors.New("usage: migrate down <version>, 0 reverts all migrations")
+		}
+
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+
+		return repo.Rollback(ctx, db, cfg.Repo, version)
+	case "status":
+		statuses, err := repo.Migrations(ctx, db, cfg.Repo)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt > 0 {
+				applied = time.Unix(status.AppliedAt, 0).Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-25s  %s\n", status.Version, applied, status.Description)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown migrate command %q", command)
+}
+
+// newStorage keeps uploads in memory in demo mode, so it runs without S3 as well
+func newStorage(cfg *config.Config) (services.CloudStorage, error) {
+	if cfg.Database.Driver == config.DriverMemory {
+		return storage.NewMemory(), nil
+	}
+
+	s3, err := storage.New(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewResilient(s3, newResilienceConfig(cfg)), nil
+}
+
+// newResilienceConfig sets retries and circuit breakers of database and storage calls
+func newResilienceConfig(cfg *config.Config) *resilience.Config {
+	return &resilience.Config{
+		Attempts:         cfg.Resilience.Attempts,
+		BaseDelay:        cfg.Resilience.BaseDelay,
+		MaxDelay:         cfg.Resilience.MaxDelay,
+		BreakerThreshold: cfg.Resilience.BreakerThreshold,
+		BreakerTimeout:   cfg.Resilience.BreakerTimeout,
+	}
+}
@@ -9,6 +9,7 @@ require (
 	github.com/golang/mock v1.6.0
 	github.com/joho/godotenv v1.4.0
 	github.com/kelseyhightower/envconfig v1.4.0
+	github.com/lib/pq v1.10.9
 	github.com/stretchr/testify v1.6.1
 	go.mongodb.org/mongo-driver v1.8.1
 )
@@ -1,6 +1,10 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -8,17 +12,32 @@ import (
 )
 
 const (
-	SERVER_PREFIX     = "SERVER"
-	REPOSITORY_PREFIX = "MONGO"
-	FILE_PREFIX       = "FILE"
-	STORAGE_PREFIX    = "STORAGE"
-	JWT_PREFIX        = "JWT"
-	AUTH_PREFIX       = "AUTH"
+	SERVER_PREFIX      = "SERVER"
+	REPOSITORY_PREFIX  = "MONGO"
+	FILE_PREFIX        = "FILE"
+	STORAGE_PREFIX     = "STORAGE"
+	JWT_PREFIX         = "JWT"
+	AUTH_PREFIX        = "AUTH"
+	MAIL_PREFIX        = "MAIL"
+	LOCKOUT_PREFIX     = "LOCKOUT"
+	OIDC_PREFIX        = "OIDC"
+	ORG_PREFIX         = "ORG"
+	DATABASE_PREFIX    = "DATABASE"
+	RESILIENCE_PREFIX  = "RESILIENCE"
+	IDEMPOTENCY_PREFIX = "IDEMPOTENCY"
+	JOBS_PREFIX        = "JOBS"
+	WEBHOOKS_PREFIX    = "WEBHOOKS"
+	OUTBOX_PREFIX      = "OUTBOX"
+	SCANNER_PREFIX     = "SCANNER"
+	MODERATION_PREFIX  = "MODERATION"
 )
 
 type Server struct {
-	Port string
-	Host string
+	Port      string
+	Host      string
+	PublicURL string // Base URL for links in emails
+
+	RequestTimeout time.Duration // Deadline of one request for database and storage calls, 30s by default
 }
 
 func newServer(prefix string) (*Server, error) {
@@ -37,6 +56,20 @@ type Repo struct {
 	UsersCollection  string
 	FilesCollection  string
 	TokensCollection string
+
+	AttemptsCollection string
+	APIKeysCollection  string
+
+	OrganizationsCollection string
+	MigrationsCollection    string // Applied migrations, "migrations" by default
+	UploadsCollection       string // Pending uploads, "pendingUploads" by default
+	IdempotencyCollection   string // Outcomes of requests with Idempotency-Key, "idempotencyKeys" by default
+	JobsCollection          string // Background jobs queue, "jobs" by default
+	WebhooksCollection      string // "webhooks" by default
+	DeliveriesCollection    string // Webhook deliveries log, "webhookDeliveries" by default
+	OutboxCollection        string // Domain events waiting for the relay, "outbox" by default
+
+	AllowStandalone bool // Start on a server without transactions, outbox events are written after their changes
 }
 
 func newRepo(prefix string) (*Repo, error) {
@@ -48,8 +81,44 @@ func newRepo(prefix string) (*Repo, error) {
 	return &r, nil
 }
 
+const (
+	DriverMongo    = "mongo"
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+type Database struct {
+	Driver       string // mongo (default, configured by MONGO_*), postgres or memory for demo mode
+	PostgresURL  string // lib/pq connection string
+	MaxOpenConns int
+
+	SkipMigrations bool // Don't migrate on start, migrations are run by "migrate" command
+}
+
+func newDatabaseConfig(prefix string) (*Database, error) {
+	var d Database
+	err := envconfig.Process(prefix, &d)
+	if err != nil {
+		return nil, err
+	}
+
+	switch d.Driver {
+	case "":
+		d.Driver = DriverMongo
+	case DriverMongo, DriverPostgres, DriverMemory:
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", d.Driver)
+	}
+
+	return &d, nil
+}
+
 type File struct {
 	Limit int
+
+	ReconcileInterval time.Duration // How often interrupted uploads and orphaned objects are fixed, 15m by default, negative disables
+	ReconcileGrace    time.Duration // Younger pending uploads and objects are left alone, 1h by default
+	ReconcileDelete   bool          // Remove objects of interrupted uploads and orphaned objects, otherwise they are only reported
 }
 
 func newFileConfig(prefix string) (*File, error) {
@@ -67,6 +136,7 @@ type Storage struct {
 	Region     string
 	BucketName string
 	Timeout    time.Duration
+	KeyPrefix  string // Objects are kept under the prefix, the reconciler lists only them
 }
 
 func newStorageConfig(prefix string) (*Storage, error) {
@@ -75,13 +145,35 @@ func newStorageConfig(prefix string) (*Storage, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	if s.KeyPrefix != "" && !strings.HasSuffix(s.KeyPrefix, "/") {
+		s.KeyPrefix += "/"
+	}
+
 	return &s, nil
 }
 
+// validateReconcile keeps the reconciler from removing objects of other applications:
+// orphans are found by listing the bucket, so removal needs own key prefix. Demo storage is in memory
+func validateReconcile(database *Database, file *File, storage *Storage) error {
+	if file.ReconcileDelete && database.Driver != DriverMemory && storage.KeyPrefix == "" {
+		return fmt.Errorf("FILE_RECONCILEDELETE requires STORAGE_KEYPREFIX")
+	}
+	return nil
+}
+
 type JWT struct {
 	SigningKey      string
 	TokenTTL        int64
 	TokenHeaderName string
+
+	Algorithm   string            // HS256 (default, uses SigningKey), RS256 or EdDSA
+	Keys        map[string]string // kid:path to PEM file. Public-only keys verify tokens of rotated keys
+	ActiveKeyID string            // kid of the key used for signing new tokens
+
+	Issuer   string        // "iss" of issued tokens, checked on parse when set
+	Audience string        // "aud" of issued tokens, checked on parse when set
+	Leeway   time.Duration // Allowed clock skew for exp/nbf/iat checks
 }
 
 func newJWTConfig(prefix string) (*JWT, error) {
@@ -96,6 +188,13 @@ func newJWTConfig(prefix string) (*JWT, error) {
 type Auth struct {
 	Salt         string
 	HeaderUserId string
+	AdminEmails  []string // Accounts signed up with these emails get the admin role
+
+	RequireVerified bool          // Block uploads from accounts with unverified email
+	VerificationTTL time.Duration // TimeToLife email verification token
+	ResetTokenTTL   time.Duration // TimeToLife password reset token
+	ResetURL        string        // Frontend page of the reset link, gets ?token=. SERVER_PUBLICURL/password/reset form by default
+	MFAIssuer       string        // Issuer name shown in authenticator apps
 }
 
 func newAuthConfig(prefix string) (*Auth, error) {
@@ -107,13 +206,397 @@ func newAuthConfig(prefix string) (*Auth, error) {
 	return &a, nil
 }
 
+type Mail struct {
+	Driver   string // smtp or file
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	Dir      string // Directory for file driver. Mails are written to log if empty
+}
+
+func newMailConfig(prefix string) (*Mail, error) {
+	var m Mail
+	err := envconfig.Process(prefix, &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+type Lockout struct {
+	AccountThreshold int           // Failed attempts per account before lockout
+	IPThreshold      int           // Failed attempts per IP before lockout
+	Window           time.Duration // Failed attempts older than window are forgotten
+	BaseDuration     time.Duration // First lockout duration, doubled for every next failure
+	MaxDuration      time.Duration
+}
+
+func newLockoutConfig(prefix string) (*Lockout, error) {
+	var l Lockout
+	err := envconfig.Process(prefix, &l)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+type OIDCProvider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // SERVER_PUBLICURL/oidc/<name>/callback by default
+	Scopes       []string
+}
+
+type OIDC struct {
+	Providers []string                 // Provider names, each configured with OIDC_<NAME>_* variables
+	Configs   map[string]*OIDCProvider `ignored:"true"`
+}
+
+func newOIDCConfig(prefix string) (*OIDC, error) {
+	var o OIDC
+	err := envconfig.Process(prefix, &o)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range o.Providers {
+		var p OIDCProvider
+		err = envconfig.Process(prefix+"_"+name, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.Issuer == "" || p.ClientID == "" {
+			return nil, fmt.Errorf("oidc provider %s: issuer and client id are required", name)
+		}
+
+		if o.Configs == nil {
+			o.Configs = make(map[string]*OIDCProvider)
+		}
+		o.Configs[name] = &p
+	}
+
+	return &o, nil
+}
+
+type Org struct {
+	DefaultQuota  int64         // Storage quota of new organizations in bytes, 0 - unlimited
+	InvitationTTL time.Duration // TimeToLife invitation link
+}
+
+func newOrgConfig(prefix string) (*Org, error) {
+	var o Org
+	err := envconfig.Process(prefix, &o)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+type Resilience struct {
+	Attempts         int           // Calls of one operation including the first, 3 by default, 1 disables retries
+	BaseDelay        time.Duration // Delay before the first retry, doubled for every next one, 100ms by default
+	MaxDelay         time.Duration // 2s by default
+	BreakerThreshold int           // Consecutive failures opening the circuit, 5 by default
+	BreakerTimeout   time.Duration // Open circuit fails calls fast for this time before a probe, 30s by default
+}
+
+func newResilienceConfig(prefix string) (*Resilience, error) {
+	var r Resilience
+	err := envconfig.Process(prefix, &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+type Idempotency struct {
+	TTL         time.Duration // How long responses are replayed to retries, 24h by default
+	Wait        time.Duration // Retry of a request in progress waits for it, then gets 409. 10s by default
+	LockTimeout time.Duration // Key of a request which never finished is taken over after it, 2m by default
+}
+
+func newIdempotencyConfig(prefix string) (*Idempotency, error) {
+	var i Idempotency
+	err := envconfig.Process(prefix, &i)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+type Jobs struct {
+	Workers       int           // Workers of this instance, 4 by default, negative disables
+	PollInterval  time.Duration // How often idle workers look for due jobs, 1s by default
+	Lease         time.Duration // Time limit of one run, then another worker takes the job over. 1m by default
+	MaxAttempts   int           // Failed job is moved to dead state after it, 5 by default
+	RetryDelay    time.Duration // Delay before the first retry, doubled for every next one, 10s by default
+	MaxRetryDelay time.Duration // 10m by default
+}
+
+func newJobsConfig(prefix string) (*Jobs, error) {
+	var j Jobs
+	err := envconfig.Process(prefix, &j)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+type Webhooks struct {
+	Timeout time.Duration // Time limit of one delivery attempt, 10s by default
+}
+
+func newWebhooksConfig(prefix string) (*Webhooks, error) {
+	var w Webhooks
+	err := envconfig.Process(prefix, &w)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Publishers of domain events from the outbox
+const (
+	PublisherLog    = "log"
+	PublisherHTTP   = "http"
+	PublisherMemory = "memory" // Kept in process, for tests
+	PublisherNone   = "none"   // Events are only delivered to webhooks
+)
+
+type Outbox struct {
+	Publisher     string        // "log" by default
+	URL           string        // Endpoint of "http" publisher
+	Timeout       time.Duration // Time limit of one publish, 10s by default
+	PollInterval  time.Duration // How often the relay looks for new events, 1s by default
+	Lease         time.Duration // Event claimed by a relay which crashed is published again after it, 1m by default
+	RetryDelay    time.Duration // Delay before the first retry, doubled for every next one, 10s by default
+	MaxRetryDelay time.Duration // 10m by default
+	Retention     time.Duration // Published events are deleted after it, 24h by default
+}
+
+func newOutboxConfig(prefix string) (*Outbox, error) {
+	var o Outbox
+	err := envconfig.Process(prefix, &o)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Publisher {
+	case "":
+		o.Publisher = PublisherLog
+	case PublisherLog, PublisherMemory, PublisherNone:
+	case PublisherHTTP:
+		if o.URL == "" {
+			return nil, fmt.Errorf("OUTBOX_URL is required by http publisher")
+		}
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher %q", o.Publisher)
+	}
+
+	return &o, nil
+}
+
+// Uploads while the scanner is unavailable are rejected in closed mode and stored unscanned in open mode
+const (
+	ScanFailClosed = "closed"
+	ScanFailOpen   = "open"
+)
+
+type Scanner struct {
+	Address  string        // host:port of clamd, empty - uploads aren't scanned
+	Timeout  time.Duration // Time limit of one scan, 30s by default
+	FailMode string        // "closed" by default
+}
+
+func newScannerConfig(prefix string) (*Scanner, error) {
+	var sc Scanner
+	err := envconfig.Process(prefix, &sc)
+	if err != nil {
+		return nil, err
+	}
+
+	switch sc.FailMode {
+	case "":
+		sc.FailMode = ScanFailClosed
+	case ScanFailClosed, ScanFailOpen:
+	default:
+		return nil, fmt.Errorf("unknown scanner fail mode %q", sc.FailMode)
+	}
+
+	return &sc, nil
+}
+
+// Default moderation keeps images to formats processed by jobs and stops decompression bombs
+var (
+	defaultPolicyFormats   = map[string][]string{AnyRole: {"png", "jpeg"}}
+	defaultPolicyMaxPixels = int64(100_000_000)
+)
+
+const AnyRole = "*" // Key of formats allowed to roles without their own list
+
+type Moderation struct {
+	PolicyFile string  // JSON file of Policy, the default policy is used without it
+	Policy     *Policy `ignored:"true"`
+}
+
+// Policy is what images may be uploaded. Organization rules override set fields of the default ones
+type Policy struct {
+	PolicyRules
+	Orgs map[string]PolicyRules `json:"orgs,omitempty"` // By organization ID
+}
+
+// PolicyRules limits decoded images, zero fields aren't checked
+type PolicyRules struct {
+	MinWidth       int                 `json:"minWidth,omitempty"`
+	MinHeight      int                 `json:"minHeight,omitempty"`
+	MaxWidth       int                 `json:"maxWidth,omitempty"`
+	MaxHeight      int                 `json:"maxHeight,omitempty"`
+	MinAspectRatio float64             `json:"minAspectRatio,omitempty"` // Width divided by height
+	MaxAspectRatio float64             `json:"maxAspectRatio,omitempty"`
+	MaxPixels      int64               `json:"maxPixels,omitempty"`
+	Formats        map[string][]string `json:"formats,omitempty"` // Allowed formats by user role, like {"admin": ["png", "jpeg", "gif"], "*": ["png"]}
+}
+
+func (r *PolicyRules) validate() error {
+	if r.MinWidth < 0 || r.MinHeight < 0 || r.MaxWidth < 0 || r.MaxHeight < 0 || r.MaxPixels < 0 || r.MinAspectRatio < 0 || r.MaxAspectRatio < 0 {
+		return fmt.Errorf("policy limits can't be negative")
+	}
+	if r.MaxWidth > 0 && r.MinWidth > r.MaxWidth || r.MaxHeight > 0 && r.MinHeight > r.MaxHeight {
+		return fmt.Errorf("policy minimal dimensions are over the maximal")
+	}
+	if r.MaxAspectRatio > 0 && r.MinAspectRatio > r.MaxAspectRatio {
+		return fmt.Errorf("policy minimal aspect ratio is over the maximal")
+	}
+	return nil
+}
+
+// RulesFor returns rules of the organization, its set fields and format lists replace the default ones
+func (p *Policy) RulesFor(orgID string) PolicyRules {
+	rules := p.PolicyRules
+	override, ok := p.Orgs[orgID]
+	if orgID == "" || !ok {
+		return rules
+	}
+
+	if override.MinWidth != 0 {
+		rules.MinWidth = override.MinWidth
+	}
+	if override.MinHeight != 0 {
+		rules.MinHeight = override.MinHeight
+	}
+	if override.MaxWidth != 0 {
+		rules.MaxWidth = override.MaxWidth
+	}
+	if override.MaxHeight != 0 {
+		rules.MaxHeight = override.MaxHeight
+	}
+	if override.MinAspectRatio != 0 {
+		rules.MinAspectRatio = override.MinAspectRatio
+	}
+	if override.MaxAspectRatio != 0 {
+		rules.MaxAspectRatio = override.MaxAspectRatio
+	}
+	if override.MaxPixels != 0 {
+		rules.MaxPixels = override.MaxPixels
+	}
+
+	if len(override.Formats) > 0 {
+		formats := make(map[string][]string, len(rules.Formats)+len(override.Formats))
+		for role, allowed := range rules.Formats {
+			formats[role] = allowed
+		}
+		for role, allowed := range override.Formats {
+			formats[role] = allowed
+		}
+		rules.Formats = formats
+	}
+
+	return rules
+}
+
+func newModerationConfig(prefix string) (*Moderation, error) {
+	var m Moderation
+	err := envconfig.Process(prefix, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Policy = defaultPolicy()
+	if m.PolicyFile == "" {
+		return &m, nil
+	}
+
+	err = loadPolicy(m.PolicyFile, m.Policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func defaultPolicy() *Policy {
+	formats := make(map[string][]string, len(defaultPolicyFormats))
+	for role, allowed := range defaultPolicyFormats {
+		formats[role] = allowed
+	}
+
+	return &Policy{PolicyRules: PolicyRules{Formats: formats, MaxPixels: defaultPolicyMaxPixels}}
+}
+
+// loadPolicy reads policy JSON on top of the given one, so rules missing in the file keep their defaults.
+// Unknown fields are errors so misspelled rules aren't ignored
+func loadPolicy(filename string, policy *Policy) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(policy)
+	if err != nil {
+		return fmt.Errorf("error with policy file %s - %w", filename, err)
+	}
+
+	err = policy.validate()
+	if err != nil {
+		return err
+	}
+	for orgID, rules := range policy.Orgs {
+		err = rules.validate()
+		if err != nil {
+			return fmt.Errorf("organization %s: %w", orgID, err)
+		}
+	}
+
+	return nil
+}
+
 type Config struct {
-	Server  *Server
-	Repo    *Repo
-	Files   *File
-	Storage *Storage
-	JWT     *JWT
-	Auth    *Auth
+	Server   *Server
+	Database *Database
+	Repo     *Repo
+	Files    *File
+	Storage  *Storage
+	JWT      *JWT
+	Auth     *Auth
+	Mail     *Mail
+	Lockout  *Lockout
+	OIDC     *OIDC
+	Org      *Org
+
+	Resilience  *Resilience
+	Idempotency *Idempotency
+	Jobs        *Jobs
+	Webhooks    *Webhooks
+	Outbox      *Outbox
+	Scanner     *Scanner
+	Moderation  *Moderation
 }
 
 func New(filename string) (*Config, error) {
@@ -127,6 +610,11 @@ func New(filename string) (*Config, error) {
 		return nil, err
 	}
 
+	database, err := newDatabaseConfig(DATABASE_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
 	repo, err := newRepo(REPOSITORY_PREFIX)
 	if err != nil {
 		return nil, err
@@ -141,6 +629,11 @@ func New(filename string) (*Config, error) {
 		return nil, err
 	}
 
+	err = validateReconcile(database, file, storage)
+	if err != nil {
+		return nil, err
+	}
+
 	jwtConfig, err := newJWTConfig(JWT_PREFIX)
 	if err != nil {
 		return nil, err
@@ -151,12 +644,80 @@ func New(filename string) (*Config, error) {
 		return nil, err
 	}
 
+	mailConfig, err := newMailConfig(MAIL_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutConfig, err := newLockoutConfig(LOCKOUT_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcConfig, err := newOIDCConfig(OIDC_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	orgConfig, err := newOrgConfig(ORG_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	resilienceConfig, err := newResilienceConfig(RESILIENCE_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyConfig, err := newIdempotencyConfig(IDEMPOTENCY_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	jobsConfig, err := newJobsConfig(JOBS_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooksConfig, err := newWebhooksConfig(WEBHOOKS_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	outboxConfig, err := newOutboxConfig(OUTBOX_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	scannerConfig, err := newScannerConfig(SCANNER_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	moderationConfig, err := newModerationConfig(MODERATION_PREFIX)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		Server:  server,
-		Repo:    repo,
-		Files:   file,
-		Storage: storage,
-		JWT:     jwtConfig,
-		Auth:    authConfig,
+		Server:   server,
+		Database: database,
+		Repo:     repo,
+		Files:    file,
+		Storage:  storage,
+		JWT:      jwtConfig,
+		Auth:     authConfig,
+		Mail:     mailConfig,
+		Lockout:  lockoutConfig,
+		OIDC:     oidcConfig,
+		Org:      orgConfig,
+
+		Resilience:  resilienceConfig,
+		Idempotency: idempotencyConfig,
+		Jobs:        jobsConfig,
+		Webhooks:    webhooksConfig,
+		Outbox:      outboxConfig,
+		Scanner:     scannerConfig,
+		Moderation:  moderationConfig,
 	}, nil
 }
@@ -256,6 +256,27 @@ func Test_newStorageConfig(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name:   "OK: key prefix ends with slash",
+			prefix: "STORAGE",
+			envMap: map[string]string{
+				"STORAGE_ACCESSKEY":  "179g381vdyo",
+				"STORAGE_SECRETKEY":  "18e721gf2fg01g711378gfjksog",
+				"STORAGE_REGION":     "eu-west",
+				"STORAGE_BUCKETNAME": "my-bucket",
+				"STORAGE_TIMEOUT":    "60s",
+				"STORAGE_KEYPREFIX":  "uploads",
+			},
+			expect: &Storage{
+				AccessKey:  "179g381vdyo",
+				SecretKey:  "18e721gf2fg01g711378gfjksog",
+				Region:     "eu-west",
+				BucketName: "my-bucket",
+				Timeout:    time.Second * 60,
+				KeyPrefix:  "uploads/",
+			},
+			wantError: false,
+		},
 		{
 			name:   "FAIL: accessKey not initialize",
 			prefix: "STORAGE",
@@ -323,6 +344,51 @@ func Test_newStorageConfig(t *testing.T) {
 	}
 }
 
+func Test_validateReconcile(t *testing.T) {
+	testTable := []struct {
+		name      string
+		database  *Database
+		file      *File
+		storage   *Storage
+		wantError bool
+	}{
+		{
+			name:     "OK: report only",
+			database: &Database{Driver: DriverMongo},
+			file:     &File{},
+			storage:  &Storage{},
+		},
+		{
+			name:     "OK: delete under own prefix",
+			database: &Database{Driver: DriverMongo},
+			file:     &File{ReconcileDelete: true},
+			storage:  &Storage{KeyPrefix: "uploads/"},
+		},
+		{
+			name:     "OK: delete in demo mode",
+			database: &Database{Driver: DriverMemory},
+			file:     &File{ReconcileDelete: true},
+			storage:  &Storage{},
+		},
+		{
+			name:      "FAIL: delete in the whole bucket",
+			database:  &Database{Driver: DriverPostgres},
+			file:      &File{ReconcileDelete: true},
+			storage:   &Storage{},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateReconcile(test.database, test.file, test.storage)
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+		})
+	}
+}
+
 func Test_newJWTConfig(t *testing.T) {
 	testTable := []struct {
 		name      string
@@ -455,6 +521,406 @@ func Test_newAuthConfig(t *testing.T) {
 	}
 }
 
+func Test_newMailConfig(t *testing.T) {
+	testTable := []struct {
+		name      string
+		envMap    map[string]string
+		wantError bool
+		expect    *Mail
+		prefix    string
+	}{
+		{
+			name:   "OK",
+			prefix: "MAIL",
+			envMap: map[string]string{
+				"MAIL_DRIVER":   "smtp",
+				"MAIL_HOST":     "smtp.mail.com",
+				"MAIL_PORT":     "587",
+				"MAIL_USERNAME": "user",
+				"MAIL_PASSWORD": "password",
+				"MAIL_FROM":     "noreply@mail.com",
+			},
+			expect: &Mail{
+				Driver:   "smtp",
+				Host:     "smtp.mail.com",
+				Port:     "587",
+				Username: "user",
+				Password: "password",
+				From:     "noreply@mail.com",
+			},
+			wantError: false,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			err := setEnv(test.envMap)
+			if err != nil {
+				t.Fatalf("setEnv error - %s\n", err.Error())
+			}
+
+			config, err := newMailConfig(test.prefix)
+			if err != nil && !test.wantError {
+				t.Fatalf("config init error - %s\n", err.Error())
+			}
+
+			if !reflect.DeepEqual(config, test.expect) && !test.wantError {
+				t.Fatalf("configs not equals\nReceived - %+v\nWant - %+v\n", config, test.expect)
+			}
+
+			err = unsetEnv(test.envMap)
+			if err != nil {
+				t.Fatalf("unsetEnv error - %s\n", err.Error())
+			}
+		})
+	}
+}
+
+func Test_newOIDCConfig(t *testing.T) {
+	testTable := []struct {
+		name      string
+		envMap    map[string]string
+		wantError bool
+		expect    *OIDC
+		prefix    string
+	}{
+		{
+			name:   "OK",
+			prefix: "OIDC",
+			envMap: map[string]string{
+				"OIDC_PROVIDERS":         "okta",
+				"OIDC_OKTA_ISSUER":       "https://company.okta.com",
+				"OIDC_OKTA_CLIENTID":     "client",
+				"OIDC_OKTA_CLIENTSECRET": "secret",
+				"OIDC_OKTA_SCOPES":       "openid,email",
+			},
+			expect: &OIDC{
+				Providers: []string{"okta"},
+				Configs: map[string]*OIDCProvider{
+					"okta": {
+						Issuer:       "https://company.okta.com",
+						ClientID:     "client",
+						ClientSecret: "secret",
+						Scopes:       []string{"openid", "email"},
+					},
+				},
+			},
+			wantError: false,
+		},
+		{
+			name:   "FAIL: provider without issuer",
+			prefix: "OIDC",
+			envMap: map[string]string{
+				"OIDC_PROVIDERS":     "okta",
+				"OIDC_OKTA_CLIENTID": "client",
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			err := setEnv(test.envMap)
+			if err != nil {
+				t.Fatalf("setEnv error - %s\n", err.Error())
+			}
+
+			config, err := newOIDCConfig(test.prefix)
+			if err != nil && !test.wantError {
+				t.Fatalf("config init error - %s\n", err.Error())
+			}
+
+			if err == nil && test.wantError {
+				t.Fatal("expected error")
+			}
+
+			if !reflect.DeepEqual(config, test.expect) && !test.wantError {
+				t.Fatalf("configs not equals\nReceived - %+v\nWant - %+v\n", config, test.expect)
+			}
+
+			err = unsetEnv(test.envMap)
+			if err != nil {
+				t.Fatalf("unsetEnv error - %s\n", err.Error())
+			}
+		})
+	}
+}
+
+func Test_newDatabaseConfig(t *testing.T) {
+	testTable := []struct {
+		name      string
+		envMap    map[string]string
+		wantError bool
+		expect    *Database
+	}{
+		{
+			name:   "OK: mongo by default",
+			envMap: map[string]string{"DATABASE_DRIVER": ""},
+			expect: &Database{Driver: DriverMongo},
+		},
+		{
+			name: "OK: postgres",
+			envMap: map[string]string{
+				"DATABASE_DRIVER":      "postgres",
+				"DATABASE_POSTGRESURL": "postgres://localhost/creatly?sslmode=disable",
+			},
+			expect: &Database{Driver: DriverPostgres, PostgresURL: "postgres://localhost/creatly?sslmode=disable"},
+		},
+		{
+			name: "OK: skip migrations",
+			envMap: map[string]string{
+				"DATABASE_DRIVER":         "mongo",
+				"DATABASE_SKIPMIGRATIONS": "true",
+			},
+			expect: &Database{Driver: DriverMongo, SkipMigrations: true},
+		},
+		{
+			name:   "OK: memory",
+			envMap: map[string]string{"DATABASE_DRIVER": "memory"},
+			expect: &Database{Driver: DriverMemory},
+		},
+		{
+			name:      "FAIL: unknown driver",
+			envMap:    map[string]string{"DATABASE_DRIVER": "mysql"},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			err := setEnv(test.envMap)
+			if err != nil {
+				t.Fatalf("setEnv error - %s\n", err.Error())
+			}
+			defer unsetEnv(test.envMap)
+
+			config, err := newDatabaseConfig("DATABASE")
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+
+			if !test.wantError && !reflect.DeepEqual(config, test.expect) {
+				t.Fatalf("configs not equals\nReceived - %+v\nWant - %+v\n", config, test.expect)
+			}
+		})
+	}
+}
+
+func Test_newOutboxConfig(t *testing.T) {
+	testTable := []struct {
+		name      string
+		envMap    map[string]string
+		wantError bool
+		expect    *Outbox
+	}{
+		{
+			name:   "OK: log by default",
+			envMap: map[string]string{"OUTBOX_PUBLISHER": ""},
+			expect: &Outbox{Publisher: PublisherLog},
+		},
+		{
+			name: "OK: http",
+			envMap: map[string]string{
+				"OUTBOX_PUBLISHER": "http",
+				"OUTBOX_URL":       "https://events.example.com",
+				"OUTBOX_RETENTION": "1h",
+			},
+			expect: &Outbox{Publisher: PublisherHTTP, URL: "https://events.example.com", Retention: time.Hour},
+		},
+		{
+			name:      "FAIL: http without url",
+			envMap:    map[string]string{"OUTBOX_PUBLISHER": "http"},
+			wantError: true,
+		},
+		{
+			name:      "FAIL: unknown publisher",
+			envMap:    map[string]string{"OUTBOX_PUBLISHER": "kafka"},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			err := setEnv(test.envMap)
+			if err != nil {
+				t.Fatalf("setEnv error - %s\n", err.Error())
+			}
+			defer unsetEnv(test.envMap)
+
+			config, err := newOutboxConfig("OUTBOX")
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+
+			if !test.wantError && !reflect.DeepEqual(config, test.expect) {
+				t.Fatalf("configs not equals\nReceived - %+v\nWant - %+v\n", config, test.expect)
+			}
+		})
+	}
+}
+
+func Test_newScannerConfig(t *testing.T) {
+	testTable := []struct {
+		name      string
+		envMap    map[string]string
+		wantError bool
+		expect    *Scanner
+	}{
+		{
+			name:   "OK: fail closed by default",
+			envMap: map[string]string{"SCANNER_ADDRESS": "localhost:3310"},
+			expect: &Scanner{Address: "localhost:3310", FailMode: ScanFailClosed},
+		},
+		{
+			name: "OK: fail open",
+			envMap: map[string]string{
+				"SCANNER_ADDRESS":  "localhost:3310",
+				"SCANNER_TIMEOUT":  "5s",
+				"SCANNER_FAILMODE": "open",
+			},
+			expect: &Scanner{Address: "localhost:3310", Timeout: 5 * time.Second, FailMode: ScanFailOpen},
+		},
+		{
+			name:      "FAIL: unknown fail mode",
+			envMap:    map[string]string{"SCANNER_FAILMODE": "ignore"},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			err := setEnv(test.envMap)
+			if err != nil {
+				t.Fatalf("setEnv error - %s\n", err.Error())
+			}
+			defer unsetEnv(test.envMap)
+
+			config, err := newScannerConfig("SCANNER")
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+
+			if !test.wantError && !reflect.DeepEqual(config, test.expect) {
+				t.Fatalf("configs not equals\nReceived - %+v\nWant - %+v\n", config, test.expect)
+			}
+		})
+	}
+}
+
+func Test_newModerationConfig(t *testing.T) {
+	testTable := []struct {
+		name      string
+		envMap    map[string]string
+		wantError bool
+		expect    *Policy
+	}{
+		{
+			name:   "OK: default policy",
+			envMap: map[string]string{"MODERATION_POLICYFILE": ""},
+			expect: &Policy{PolicyRules: PolicyRules{Formats: map[string][]string{AnyRole: {"png", "jpeg"}}, MaxPixels: 100000000}},
+		},
+		{
+			name:   "OK: policy file",
+			envMap: map[string]string{"MODERATION_POLICYFILE": "test files/policy.json"},
+			expect: &Policy{
+				PolicyRules: PolicyRules{
+					MaxWidth:       4096,
+					MaxHeight:      4096,
+					MinAspectRatio: 0.25,
+					MaxAspectRatio: 4,
+					MaxPixels:      16000000,
+					Formats:        map[string][]string{AnyRole: {"png", "jpeg"}, "admin": {"png", "jpeg", "gif"}},
+				},
+				Orgs: map[string]PolicyRules{
+					"62a1f0c8e4b0a1b2c3d4e5f6": {MinWidth: 512, MinHeight: 512, Formats: map[string][]string{AnyRole: {"png"}}},
+				},
+			},
+		},
+		{
+			name:   "OK: partial policy file keeps defaults",
+			envMap: map[string]string{"MODERATION_POLICYFILE": "test files/policy_partial.json"},
+			expect: &Policy{PolicyRules: PolicyRules{
+				MaxWidth:  2048,
+				MaxPixels: 100000000,
+				Formats:   map[string][]string{AnyRole: {"png", "jpeg"}, "admin": {"png", "jpeg", "gif"}},
+			}},
+		},
+		{
+			name:      "FAIL: minimum over maximum",
+			envMap:    map[string]string{"MODERATION_POLICYFILE": "test files/policy_invalid.json"},
+			wantError: true,
+		},
+		{
+			name:      "FAIL: unknown rule",
+			envMap:    map[string]string{"MODERATION_POLICYFILE": "test files/policy_unknown.json"},
+			wantError: true,
+		},
+		{
+			name:      "FAIL: missing file",
+			envMap:    map[string]string{"MODERATION_POLICYFILE": "test files/missing.json"},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			err := setEnv(test.envMap)
+			if err != nil {
+				t.Fatalf("setEnv error - %s\n", err.Error())
+			}
+			defer unsetEnv(test.envMap)
+
+			config, err := newModerationConfig("MODERATION")
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+
+			if !test.wantError && !reflect.DeepEqual(config.Policy, test.expect) {
+				t.Fatalf("policies not equals\nReceived - %+v\nWant - %+v\n", config.Policy, test.expect)
+			}
+		})
+	}
+}
+
+func Test_RulesFor(t *testing.T) {
+	policy := &Policy{
+		PolicyRules: PolicyRules{MaxWidth: 4096, MaxPixels: 1000, Formats: map[string][]string{AnyRole: {"png", "jpeg"}, "admin": {"gif"}}},
+		Orgs: map[string]PolicyRules{
+			"org": {MinWidth: 512, MaxPixels: 2000, Formats: map[string][]string{AnyRole: {"png"}}},
+		},
+	}
+
+	testTable := []struct {
+		name   string
+		orgID  string
+		expect PolicyRules
+	}{
+		{
+			name:   "OK: personal space",
+			expect: policy.PolicyRules,
+		},
+		{
+			name:   "OK: organization without overrides",
+			orgID:  "other",
+			expect: policy.PolicyRules,
+		},
+		{
+			name:   "OK: organization overrides",
+			orgID:  "org",
+			expect: PolicyRules{MinWidth: 512, MaxWidth: 4096, MaxPixels: 2000, Formats: map[string][]string{AnyRole: {"png"}, "admin": {"gif"}}},
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			rules := policy.RulesFor(test.orgID)
+			if !reflect.DeepEqual(rules, test.expect) {
+				t.Fatalf("rules not equals\nReceived - %+v\nWant - %+v\n", rules, test.expect)
+			}
+		})
+	}
+}
+
 func Test_New(t *testing.T) {
 	testTable := []struct {
 		name      string
@@ -471,6 +937,7 @@ func Test_New(t *testing.T) {
 					Host: "localhost",
 					Port: "8000",
 				},
+				Database: &Database{Driver: DriverMongo},
 				Repo: &Repo{
 					Host:             "mongodb",
 					Port:             "27017",
@@ -498,6 +965,21 @@ func Test_New(t *testing.T) {
 					Salt:         "923undwpinpwq3bp",
 					HeaderUserId: "userID",
 				},
+				Mail:    &Mail{},
+				Lockout: &Lockout{},
+				OIDC:    &OIDC{},
+				Org:     &Org{},
+
+				Resilience:  &Resilience{},
+				Idempotency: &Idempotency{},
+				Jobs:        &Jobs{},
+				Webhooks:    &Webhooks{},
+				Outbox:      &Outbox{Publisher: PublisherLog},
+				Scanner:     &Scanner{FailMode: ScanFailClosed},
+				Moderation: &Moderation{Policy: &Policy{PolicyRules: PolicyRules{
+					Formats:   map[string][]string{AnyRole: {"png", "jpeg"}},
+					MaxPixels: 100000000,
+				}}},
 			},
 			wantError: false,
 		},
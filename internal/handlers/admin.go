@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const tempPasswordLength = 12 // Random bytes count
+
+func (h *Handlers) AdminMiddleware(c *gin.Context) {
+	userID := c.GetString(h.userHeaderName)
+	if userID == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, textToMap("userID not found"))
+		return
+	}
+
+	isAdmin, err := h.services.IsAdmin(c.Request.Context(), userID)
+	if isUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, textToMap("error checking permissions"))
+		return
+	}
+
+	if !isAdmin {
+		c.AbortWithStatusJSON(http.StatusForbidden, textToMap("access denied"))
+		return
+	}
+}
+
+func (h *Handlers) AdminUsers(c *gin.Context) {
+	page, err := queryInt(c, "page")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap("invalid page"))
+		return
+	}
+
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap("invalid limit"))
+		return
+	}
+
+	users, err := h.services.Users(c.Request.Context(), &models.UsersFilter{
+		Search: c.Query("search"),
+		Page:   page,
+		Limit:  limit,
+	})
+	if isUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error getting users"))
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *Handlers) AdminUserStats(c *gin.Context) {
+	stats, err := h.services.UserStats(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.adminError(c, err, "error getting user stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *Handlers) AdminDisableUser(c *gin.Context) {
+	h.setUserDisabled(c, true)
+}
+
+func (h *Handlers) AdminEnableUser(c *gin.Context) {
+	h.setUserDisabled(c, false)
+}
+
+func (h *Handlers) setUserDisabled(c *gin.Context, disabled bool) {
+	err := h.services.SetUserDisabled(c.Request.Context(), c.Param("id"), disabled)
+	if err != nil {
+		h.adminError(c, err, "error updating user")
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("success"))
+}
+
+// AdminResetPassword replaces the user password with a random temporary one
+// and returns it once, so the admin can hand it over to the user
+func (h *Handlers) AdminResetPassword(c *gin.Context) {
+	password, err := randomPassword()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error while generating password"))
+		return
+	}
+
+	passwordHash, err := h.hasher.Hash(password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error while encrypting password"))
+		return
+	}
+
+	err = h.services.ResetUserPassword(c.Request.Context(), c.Param("id"), passwordHash)
+	if err != nil {
+		h.adminError(c, err, "error updating user")
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]string{"password": password})
+}
+
+func (h *Handlers) AdminDeleteUser(c *gin.Context) {
+	err := h.services.DeleteUser(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.adminError(c, err, "error deleting user")
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("success"))
+}
+
+// AdminReconcileUploads runs the uploads reconciler now, it also runs periodically
+func (h *Handlers) AdminReconcileUploads(c *gin.Context) {
+	report, err := h.services.ReconcileUploads(c.Request.Context())
+	if err != nil {
+		h.adminError(c, err, "error reconciling uploads")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handlers) AdminReconcileStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.services.ReconcileStats())
+}
+
+// AdminDeadJobs lists background jobs which failed every attempt
+func (h *Handlers) AdminDeadJobs(c *gin.Context) {
+	jobs, err := h.services.DeadJobs(c.Request.Context())
+	if err != nil {
+		h.adminError(c, err, "error getting jobs")
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// AdminRequeueJob runs the dead job again
+func (h *Handlers) AdminRequeueJob(c *gin.Context) {
+	err := h.services.RequeueJob(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.adminError(c, err, "error requeueing job")
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("success"))
+}
+
+func (h *Handlers) adminError(c *gin.Context, err error, message string) {
+	if isUnavailable(c, err) {
+		return
+	}
+
+	if errors.Is(err, models.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, textToMap("user not found"))
+		return
+	}
+	if errors.Is(err, models.ErrJobNotFound) || errors.Is(err, models.ErrWebhookNotFound) || errors.Is(err, models.ErrDeliveryNotFound) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrLastOwner) {
+		c.JSON(http.StatusConflict, textToMap(err.Error()))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, textToMap(message))
+}
+
+func queryInt(c *gin.Context, key string) (int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func randomPassword() (string, error) {
+	buf := make([]byte, tempPasswordLength)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
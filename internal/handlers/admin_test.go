@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"context"
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AdminMiddleware(t *testing.T) {
+	testTable := []struct {
+		name          string
+		userID        string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+	}{
+		{
+			name:   "OK",
+			userID: "1",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().IsAdmin(gomock.Any(), "1").Return(true, nil)
+			},
+			outStatusCode: 200,
+		},
+		{
+			name:   "ERROR: not admin",
+			userID: "1",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().IsAdmin(gomock.Any(), "1").Return(false, nil)
+			},
+			outStatusCode: 403,
+		},
+		{
+			name:          "ERROR: no user in context",
+			userID:        "",
+			behavior:      func(s *mock_handlers.MockServices) {},
+			outStatusCode: 401,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hasher := mock_handlers.NewMockHasher(ctrl)
+			services := mock_handlers.NewMockServices(ctrl)
+
+			test.behavior(services)
+
+			handlers := New(services, 100000, hasher, "Authorization", "userId")
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("userId", test.userID)
+			})
+			r.GET("/admin", handlers.AdminMiddleware, func(c *gin.Context) {
+				c.Status(200)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+		})
+	}
+}
+
+func Test_AdminUsers(t *testing.T) {
+	testTable := []struct {
+		name          string
+		query         string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name:  "OK",
+			query: "?search=mail&page=2&limit=1",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().Users(gomock.Any(), &models.UsersFilter{Search: "mail", Page: 2, Limit: 1}).Return(&models.UsersPage{
+					Users: []models.User{},
+					Total: 1,
+					Page:  2,
+					Limit: 1,
+				}, nil)
+			},
+			outStatusCode: 200,
+			outBody:       `{"users":[],"total":1,"page":2,"limit":1}`,
+		},
+		{
+			name:          "ERROR: invalid page",
+			query:         "?page=first",
+			behavior:      func(s *mock_handlers.MockServices) {},
+			outStatusCode: 400,
+			outBody:       `{"message":"invalid page"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hasher := mock_handlers.NewMockHasher(ctrl)
+			services := mock_handlers.NewMockServices(ctrl)
+
+			test.behavior(services)
+
+			handlers := New(services, 100000, hasher, "Authorization", "userId")
+
+			r := gin.New()
+			r.GET("/admin/users", handlers.AdminUsers)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/users"+test.query, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_AdminDeleteUser(t *testing.T) {
+	testTable := []struct {
+		name          string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name: "OK",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().DeleteUser(gomock.Any(), "1").Return(nil)
+			},
+			outStatusCode: 200,
+			outBody:       `{"message":"success"}`,
+		},
+		{
+			name: "ERROR: user not found",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().DeleteUser(gomock.Any(), "1").Return(models.ErrUserNotFound)
+			},
+			outStatusCode: 404,
+			outBody:       `{"message":"user not found"}`,
+		},
+		{
+			name: "ERROR: service error",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().DeleteUser(gomock.Any(), "1").Return(errors.New("storage error"))
+			},
+			outStatusCode: 500,
+			outBody:       `{"message":"error deleting user"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hasher := mock_handlers.NewMockHasher(ctrl)
+			services := mock_handlers.NewMockServices(ctrl)
+
+			test.behavior(services)
+
+			handlers := New(services, 100000, hasher, "Authorization", "userId")
+
+			r := gin.New()
+			r.DELETE("/admin/users/:id", handlers.AdminDeleteUser)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/admin/users/1", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_AdminReconcileUploads(t *testing.T) {
+	testTable := []struct {
+		name          string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name: "OK",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ReconcileUploads(gomock.Any()).Return(&models.ReconcileReport{StartedAt: 100, FinishedAt: 101, Cleaned: 1, Orphans: 2}, nil)
+			},
+			outStatusCode: 200,
+			outBody:       `{"startedAt":100,"finishedAt":101,"dryRun":false,"completed":0,"recorded":0,"cleaned":1,"orphans":2,"failed":0}`,
+		},
+		{
+			name: "ERROR: timeout",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ReconcileUploads(gomock.Any()).Return(&models.ReconcileReport{}, context.DeadlineExceeded)
+			},
+			outStatusCode: 504,
+			outBody:       `{"message":"request timeout"}`,
+		},
+		{
+			name: "ERROR: service error",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ReconcileUploads(gomock.Any()).Return(&models.ReconcileReport{}, errors.New("storage error"))
+			},
+			outStatusCode: 500,
+			outBody:       `{"message":"error reconciling uploads"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hasher := mock_handlers.NewMockHasher(ctrl)
+			services := mock_handlers.NewMockServices(ctrl)
+
+			test.behavior(services)
+
+			handlers := New(services, 100000, hasher, "Authorization", "userId")
+
+			r := gin.New()
+			r.POST("/admin/uploads/reconcile", handlers.AdminReconcileUploads)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/uploads/reconcile", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_AdminReconcileStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	services := mock_handlers.NewMockServices(ctrl)
+	services.EXPECT().ReconcileStats().Return(&models.ReconcileStats{Runs: 2, Orphans: 3})
+
+	handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+	r := gin.New()
+	r.GET("/admin/uploads/reconcile", handlers.AdminReconcileStats)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/uploads/reconcile", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"runs":2,"completed":0,"recorded":0,"cleaned":0,"orphans":3,"failed":0}`, w.Body.String())
+}
+
+func Test_AdminDeadJobs(t *testing.T) {
+	testTable := []struct {
+		name          string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name: "OK",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().DeadJobs(gomock.Any()).Return([]models.Job{{Type: models.JobProcessImage, Payload: "file", State: models.JobDead, Attempts: 5, RunAt: 100, LastError: "storage error", CreatedAt: 10}}, nil)
+			},
+			outStatusCode: 200,
+			outBody:       `[{"id":"000000000000000000000000","type":"process-image","payload":"file","state":"dead","attempts":5,"runAt":100,"lastError":"storage error","createdAt":10}]`,
+		},
+		{
+			name: "ERROR: service error",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().DeadJobs(gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			outStatusCode: 500,
+			outBody:       `{"message":"error getting jobs"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.GET("/admin/jobs/dead", handlers.AdminDeadJobs)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/jobs/dead", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_AdminRequeueJob(t *testing.T) {
+	testTable := []struct {
+		name          string
+		err           error
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name:          "OK",
+			outStatusCode: 200,
+			outBody:       `{"message":"success"}`,
+		},
+		{
+			name:          "ERROR: job not found",
+			err:           models.ErrJobNotFound,
+			outStatusCode: 404,
+			outBody:       `{"message":"job not found"}`,
+		},
+		{
+			name:          "ERROR: service error",
+			err:           errors.New("db error"),
+			outStatusCode: 500,
+			outBody:       `{"message":"error requeueing job"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().RequeueJob(gomock.Any(), "job1").Return(test.err)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.POST("/admin/jobs/:id/requeue", handlers.AdminRequeueJob)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/jobs/job1/requeue", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
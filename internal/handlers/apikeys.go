@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	bearerScheme     = "Bearer"
+	apiKeyScheme     = "ApiKey"
+	apiKeyHeaderName = "X-API-Key"
+
+	scopesKey = "apiKeyScopes" // Set in context only for requests authorized by API key
+)
+
+func (h *Handlers) apiKeyAuth(c *gin.Context, key string) {
+	userID, scopes, err := h.services.ParseAPIKey(c.Request.Context(), key)
+	if isUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+			"code":    tokenErrorCode(err),
+		})
+		return
+	}
+
+	c.Set(h.userHeaderName, userID)
+	c.Set(scopesKey, scopes)
+}
+
+// RequireScope lets through access tokens and API keys with the given scope
+func (h *Handlers) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, ok := c.Get(scopesKey)
+		if !ok {
+			return
+		}
+
+		scopes, _ := value.([]string)
+		for _, s := range scopes {
+			if s == scope {
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, textToMap(models.ErrInsufficientScope.Error()))
+	}
+}
+
+// SessionOnlyMiddleware rejects API keys on account management routes
+func (h *Handlers) SessionOnlyMiddleware(c *gin.Context) {
+	if _, ok := c.Get(scopesKey); ok {
+		c.AbortWithStatusJSON(http.StatusForbidden, textToMap("api keys are not allowed"))
+	}
+}
+
+func (h *Handlers) CreateAPIKey(c *gin.Context) {
+	var input models.APIKeyInput
+
+	err := c.BindJSON(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	output, err := h.services.CreateAPIKey(c.Request.Context(), c.GetString(h.userHeaderName), &input)
+	if err != nil {
+		h.apiKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
+
+func (h *Handlers) APIKeys(c *gin.Context) {
+	keys, err := h.services.APIKeys(c.Request.Context(), c.GetString(h.userHeaderName))
+	if err != nil {
+		h.apiKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *Handlers) RevokeAPIKey(c *gin.Context) {
+	err := h.services.RevokeAPIKey(c.Request.Context(), c.GetString(h.userHeaderName), c.Param("id"))
+	if err != nil {
+		h.apiKeyError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) apiKeyError(c *gin.Context, err error) {
+	if isUnavailable(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, models.ErrInvalidScope), errors.Is(err, models.ErrInvalidExpiry):
+		c.JSON(http.StatusBadRequest, textToMap(err.Error()))
+	case errors.Is(err, models.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, textToMap("error processing api keys"))
+	}
+}
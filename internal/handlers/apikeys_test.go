@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_APIKeyAuth(t *testing.T) {
+	testTable := []struct {
+		name       string
+		headers    map[string]string
+		behavior   func(s *mock_handlers.MockServices)
+		statusCode int
+	}{
+		{
+			name:    "OK: key header with scope",
+			headers: map[string]string{"X-API-Key": "ck_key"},
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ParseAPIKey(gomock.Any(), "ck_key").Return("1", []string{models.ScopeFilesRead}, nil)
+			},
+			statusCode: 200,
+		},
+		{
+			name:    "OK: auth header scheme",
+			headers: map[string]string{"Authorization": "ApiKey ck_key"},
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ParseAPIKey(gomock.Any(), "ck_key").Return("1", []string{models.ScopeFilesRead}, nil)
+			},
+			statusCode: 200,
+		},
+		{
+			name:    "OK: access token has all scopes",
+			headers: map[string]string{"Authorization": "Bearer token"},
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ParseToken(gomock.Any(), "token").Return(&models.Principal{UserID: "1", SessionID: "s1"}, nil)
+			},
+			statusCode: 200,
+		},
+		{
+			name:    "ERROR: missing scope",
+			headers: map[string]string{"X-API-Key": "ck_key"},
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ParseAPIKey(gomock.Any(), "ck_key").Return("1", []string{models.ScopeFilesUpload}, nil)
+			},
+			statusCode: 403,
+		},
+		{
+			name:    "ERROR: expired key",
+			headers: map[string]string{"X-API-Key": "ck_key"},
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ParseAPIKey(gomock.Any(), "ck_key").Return("", nil, models.ErrAPIKeyExpired)
+			},
+			statusCode: 401,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.Default()
+			r.GET("/test", handlers.AuthMiddleware, handlers.RequireScope(models.ScopeFilesRead), func(c *gin.Context) {
+				c.String(200, c.GetString("userId"))
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/test", nil)
+			for name, value := range test.headers {
+				req.Header.Add(name, value)
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_SessionOnlyMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	services := mock_handlers.NewMockServices(ctrl)
+	services.EXPECT().ParseAPIKey(gomock.Any(), "ck_key").Return("1", models.APIKeyScopes, nil)
+
+	handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+	r := gin.Default()
+	r.GET("/test", handlers.AuthMiddleware, handlers.SessionOnlyMiddleware, func(c *gin.Context) {
+		c.Status(200)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Add("X-API-Key", "ck_key")
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 403, w.Result().StatusCode)
+}
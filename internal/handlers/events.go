@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const keepAliveInterval = 15 * time.Second // Comment lines keep proxies from closing idle streams
+
+// FileEvents streams events of files in the active space as Server-Sent Events.
+// The stream ends when the client can't keep up, clients reconnect and refetch GET /files
+func (h *Handlers) FileEvents(c *gin.Context) {
+	events, err := h.services.FileEvents(c.Request.Context(), h.principal(c))
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrNotOrgMember) {
+		c.JSON(http.StatusForbidden, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error subscribing to events"))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			c.SSEvent(event.Type, event.File)
+		case <-keepAlive.C:
+			_, err := io.WriteString(c.Writer, ": keep-alive\n\n")
+			if err != nil {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_FileEvents(t *testing.T) {
+	fileID := primitive.NewObjectID()
+	stream := func(events ...models.FileEvent) <-chan models.FileEvent {
+		ch := make(chan models.FileEvent, len(events))
+		for _, event := range events {
+			ch <- event
+		}
+		close(ch)
+		return ch
+	}
+
+	testTable := []struct {
+		name          string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name: "OK",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().FileEvents(gomock.Any(), &models.Principal{UserID: "1"}).Return(stream(
+					models.FileEvent{Type: models.FileEventCreated, File: &models.FileOut{ID: fileID, Filename: "file1", UserId: "1", Status: models.FileProcessing}},
+					models.FileEvent{Type: models.FileEventDeleted, File: &models.FileOut{ID: fileID, Filename: "file1", UserId: "1", Status: models.FileReady}},
+				), nil)
+			},
+			outStatusCode: 200,
+			outBody: "event:file.created\ndata:" + `{"id":"` + fileID.Hex() + `","filename":"file1","size":0,"uploadDate":0,"userId":"1","url":"","status":"processing"}` + "\n\n" +
+				"event:file.deleted\ndata:" + `{"id":"` + fileID.Hex() + `","filename":"file1","size":0,"uploadDate":0,"userId":"1","url":"","status":"ready"}` + "\n\n",
+		},
+		{
+			name: "ERROR: not a member",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().FileEvents(gomock.Any(), gomock.Any()).Return(nil, models.ErrNotOrgMember)
+			},
+			outStatusCode: 403,
+			outBody:       `{"message":"` + models.ErrNotOrgMember.Error() + `"}`,
+		},
+		{
+			name: "ERROR: service error",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().FileEvents(gomock.Any(), gomock.Any()).Return(nil, errors.New("broker error"))
+			},
+			outStatusCode: 500,
+			outBody:       `{"message":"error subscribing to events"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("userId", "1")
+			})
+			r.GET("/files/events", handlers.FileEvents)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/files/events", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
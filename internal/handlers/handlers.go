@@ -1,16 +1,27 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"creatly-task/internal/models"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/resilience"
 	"errors"
 	"fmt"
+	"image"
+	_ "image/gif" // Formats detected for object names
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"net/mail"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 //go:generate mockgen -source=handlers.go -destination=mocks/mock.go
@@ -20,11 +31,65 @@ type Hasher interface {
 }
 
 type Services interface {
-	SignUp(user *models.UserSignUpInput) error
-	SignIn(user *models.UserSignInInput) (string, error)
-	Files() ([]models.FileOut, error)
-	UploadFile(file *models.FileUploadInput) error
-	ParseToken(token string) (string, error)
+	SignUp(ctx context.Context, user *models.UserSignUpInput) error
+	SignIn(ctx context.Context, user *models.UserSignInInput) (*models.SignInResult, error)
+	Files(ctx context.Context, principal *models.Principal) ([]models.FileOut, error)
+	File(ctx context.Context, principal *models.Principal, id string) (*models.FileOut, error)
+	UploadFile(ctx context.Context, file *models.FileUploadInput) (*models.FileOut, error)
+	DownloadFile(ctx context.Context, principal *models.Principal, id string) (*models.FileOut, io.ReadCloser, error)
+	DeleteFile(ctx context.Context, principal *models.Principal, id string) error
+	FileEvents(ctx context.Context, principal *models.Principal) (<-chan models.FileEvent, error)
+	ParseToken(ctx context.Context, token string) (*models.Principal, error)
+	IsAdmin(ctx context.Context, userID string) (bool, error)
+	Users(ctx context.Context, filter *models.UsersFilter) (*models.UsersPage, error)
+	UserStats(ctx context.Context, userID string) (*models.UserStats, error)
+	SetUserDisabled(ctx context.Context, userID string, disabled bool) error
+	ResetUserPassword(ctx context.Context, userID, passwordHash string) error
+	DeleteUser(ctx context.Context, userID string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, userID string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, passwordHash string) error
+	ChangePassword(ctx context.Context, userID, currentPasswordHash, newPasswordHash string, client *models.ClientInfo) (string, error)
+	EnrollMFA(ctx context.Context, userID string) (*models.MFAEnrollOutput, error)
+	ConfirmMFA(ctx context.Context, userID, code string) (*models.RecoveryCodesOutput, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*models.RecoveryCodesOutput, error)
+	DisableMFA(ctx context.Context, userID, code string) error
+	SignInMFA(ctx context.Context, mfaToken, code string, client *models.ClientInfo) (string, error)
+	JWKS() *jwtauth.JWKS
+	CreateAPIKey(ctx context.Context, userID string, input *models.APIKeyInput) (*models.APIKeyCreated, error)
+	APIKeys(ctx context.Context, userID string) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID string) error
+	ParseAPIKey(ctx context.Context, key string) (string, []string, error)
+	OIDCLogin(ctx context.Context, provider string) (*models.OIDCLoginOutput, error)
+	OIDCCallback(ctx context.Context, provider, signedState, state, code string, client *models.ClientInfo) (*models.SignInResult, error)
+	Sessions(ctx context.Context, userID, currentSessionID string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	Profile(ctx context.Context, userID string) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID string, input *models.ProfileUpdateInput) (*models.User, error)
+	ExportData(ctx context.Context, userID string, w io.Writer) error
+	CreateOrganization(ctx context.Context, userID string, input *models.OrganizationInput) (*models.OrganizationOut, error)
+	Organizations(ctx context.Context, userID string) ([]models.OrganizationOut, error)
+	Organization(ctx context.Context, userID, orgID string) (*models.Organization, error)
+	InviteMember(ctx context.Context, userID, orgID string, input *models.InvitationInput) error
+	AcceptInvitation(ctx context.Context, userID, token string) (*models.OrganizationOut, error)
+	SetMemberRole(ctx context.Context, userID, orgID, memberID, role string) error
+	RemoveMember(ctx context.Context, userID, orgID, memberID string) error
+	SwitchOrganization(ctx context.Context, principal *models.Principal, orgID string) (string, error)
+	SetOrganizationQuota(ctx context.Context, orgID string, quota int64) error
+	ReconcileUploads(ctx context.Context) (*models.ReconcileReport, error)
+	ReconcileStats() *models.ReconcileStats
+	Health() *models.Health
+	BeginIdempotent(ctx context.Context, scope, key, fingerprint string) (*models.IdempotentResponse, error)
+	CompleteIdempotent(ctx context.Context, scope, key string, response *models.IdempotentResponse) error
+	ReleaseIdempotent(ctx context.Context, scope, key string) error
+	DeadJobs(ctx context.Context) ([]models.Job, error)
+	RequeueJob(ctx context.Context, id string) error
+	CreateWebhook(ctx context.Context, userID string, input *models.WebhookInput) (*models.WebhookCreated, error)
+	Webhooks(ctx context.Context) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	WebhookDeliveries(ctx context.Context, webhookID string) ([]models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookID, deliveryID string) error
 }
 
 type Handlers struct {
@@ -54,13 +119,21 @@ func (h *Handlers) SignUp(c *gin.Context) {
 		return
 	}
 
+	if !isValidEmail(input.Email) {
+		c.JSON(http.StatusBadRequest, textToMap(models.ErrInvalidEmail.Error()))
+		return
+	}
+
 	input.Password, err = h.hasher.Hash(input.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, textToMap("error while encrypting password"))
 		return
 	}
 
-	err = h.services.SignUp(&input)
+	err = h.services.SignUp(c.Request.Context(), &input)
+	if isUnavailable(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, textToMap("error while creating an account"))
 		return
@@ -69,53 +142,217 @@ func (h *Handlers) SignUp(c *gin.Context) {
 	c.JSON(http.StatusOK, textToMap("success"))
 }
 
+func (h *Handlers) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, textToMap("empty token"))
+		return
+	}
+
+	err := h.services.VerifyEmail(c.Request.Context(), token)
+	if isUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, textToMap("user not found"))
+			return
+		}
+		c.JSON(http.StatusBadRequest, textToMap("invalid token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("email verified"))
+}
+
+func (h *Handlers) ResendVerification(c *gin.Context) {
+	err := h.services.ResendVerification(c.Request.Context(), c.GetString(h.userHeaderName))
+	if isUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error while sending verification email"))
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("success"))
+}
+
 func (h *Handlers) SignIn(c *gin.Context) {
 	var user models.UserSignInInput
 
-	fmt.Printf("%+v\n", user)
-
 	err := c.BindJSON(&user)
 	if err != nil || (user.Email == "" || user.PasswordHash == "") {
-		fmt.Printf("\n\n%+v\n", user)
 		c.JSON(http.StatusBadRequest, textToMap("invalid credentials"))
 		return
 	}
 
+	user.IP = c.ClientIP()
+	user.UserAgent = c.Request.UserAgent()
+
 	user.PasswordHash, err = h.hasher.Hash(user.PasswordHash)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, textToMap("error while encrypting password"))
 		return
 	}
 
-	token, err := h.services.SignIn(&user)
+	result, err := h.services.SignIn(c.Request.Context(), &user)
+	if isUnavailable(c, err) {
+		return
+	}
+	if isLocked(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, textToMap("invalid creds"))
 		return
 	}
 
-	c.Header("Authorization", fmt.Sprintf("Bearer %s", token))
-	c.JSON(http.StatusOK, map[string]string{"token": token}) // Additional return token in JSON response
+	if result.MFARequired {
+		// Access token is issued by /sign-in/mfa after the second factor check
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	c.Header("Authorization", fmt.Sprintf("Bearer %s", result.Token))
+	c.JSON(http.StatusOK, map[string]string{"token": result.Token}) // Additional return token in JSON response
+}
+
+// isUnavailable writes 504 response if a backend didn't answer before the request deadline
+// and 503 if calls of a failing backend are stopped by its circuit breaker
+func isUnavailable(c *gin.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		c.AbortWithStatusJSON(http.StatusGatewayTimeout, textToMap("request timeout"))
+		return true
+	}
+
+	if errors.Is(err, resilience.ErrCircuitOpen) {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, textToMap("service temporarily unavailable"))
+		return true
+	}
+
+	return false
+}
+
+type policyViolationResponse struct {
+	Message    string             `json:"message"`
+	Violations []models.Violation `json:"violations"`
+}
+
+// isPolicyViolation writes 422 response with every broken rule if err is PolicyError
+func isPolicyViolation(c *gin.Context, err error) bool {
+	var policyErr *models.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, &policyViolationResponse{
+		Message:    "upload violates content policy",
+		Violations: policyErr.Violations,
+	})
+	return true
+}
+
+// isLocked writes 429 response if err is LockedError
+func isLocked(c *gin.Context, err error) bool {
+	var lockedErr *models.LockedError
+	if !errors.As(err, &lockedErr) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, textToMap("too many failed attempts"))
+	return true
+}
+
+func (h *Handlers) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.services.JWKS())
+}
+
+// Health answers 503 while a backend is down, so load balancers stop routing to the instance
+func (h *Handlers) Health(c *gin.Context) {
+	health := h.services.Health()
+	if health.Status != models.HealthOK {
+		c.JSON(http.StatusServiceUnavailable, health)
+		return
+	}
+
+	c.JSON(http.StatusOK, health)
 }
 
 func (h *Handlers) AuthMiddleware(c *gin.Context) {
 
-	token, err := h.getTokenFromHeader(c)
+	token, scheme, err := h.getTokenFromHeader(c)
 	if err != nil {
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
 
-	userID, err := h.services.ParseToken(token)
+	if scheme == apiKeyScheme {
+		h.apiKeyAuth(c, token)
+		return
+	}
+
+	principal, err := h.services.ParseToken(c.Request.Context(), token)
+	if isUnavailable(c, err) {
+		return
+	}
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, textToMap(err.Error()))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{
+			"message": err.Error(),
+			"code":    tokenErrorCode(err),
+		})
 		return
 	}
 
-	c.Set(h.userHeaderName, userID)
+	c.Set(h.userHeaderName, principal.UserID)
+	c.Set(sessionKey, principal.SessionID)
+	c.Set(orgKey, principal.OrgID)
+}
+
+// principal returns caller set in context by AuthMiddleware
+func (h *Handlers) principal(c *gin.Context) *models.Principal {
+	return &models.Principal{
+		UserID:    c.GetString(h.userHeaderName),
+		SessionID: c.GetString(sessionKey),
+		OrgID:     c.GetString(orgKey),
+	}
+}
+
+// tokenErrorCode lets clients tell apart tokens to refresh from ones to drop
+func tokenErrorCode(err error) string {
+	switch {
+	case errors.Is(err, jwtauth.ErrTokenExpired):
+		return "token_expired"
+	case errors.Is(err, jwtauth.ErrTokenNotValidYet):
+		return "token_not_valid_yet"
+	case errors.Is(err, jwtauth.ErrInvalidIssuer):
+		return "invalid_issuer"
+	case errors.Is(err, jwtauth.ErrInvalidAudience):
+		return "invalid_audience"
+	case errors.Is(err, models.ErrSessionRevoked):
+		return "session_revoked"
+	case errors.Is(err, models.ErrUserDisabled):
+		return "user_disabled"
+	case errors.Is(err, models.ErrAPIKeyExpired):
+		return "api_key_expired"
+	case errors.Is(err, models.ErrInvalidAPIKey):
+		return "invalid_api_key"
+	default:
+		return "invalid_token"
+	}
 }
 
 func (h *Handlers) Files(c *gin.Context) {
-	files, err := h.services.Files()
+	files, err := h.services.Files(c.Request.Context(), h.principal(c))
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrNotOrgMember) {
+		c.JSON(http.StatusForbidden, textToMap(err.Error()))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, textToMap("error getting file data"))
 		return
@@ -124,13 +361,83 @@ func (h *Handlers) Files(c *gin.Context) {
 	c.JSON(http.StatusOK, files)
 }
 
-func (h *Handlers) UploadFile(c *gin.Context) {
-	contentType := c.Request.Header.Get("Content-Type")
-	if contentType != "image/jpeg" && contentType != "image/png" {
-		c.JSON(http.StatusBadRequest, textToMap("invalid content-type"))
+// File returns the file with its processing status
+func (h *Handlers) File(c *gin.Context) {
+	file, err := h.services.File(c.Request.Context(), h.principal(c), c.Param("id"))
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrFileNotFound) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrNotOrgMember) {
+		c.JSON(http.StatusForbidden, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error getting file data"))
+		return
+	}
+
+	c.JSON(http.StatusOK, file)
+}
+
+// DownloadFile streams content of the file, quarantined files are forbidden
+func (h *Handlers) DownloadFile(c *gin.Context) {
+	file, content, err := h.services.DownloadFile(c.Request.Context(), h.principal(c), c.Param("id"))
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrFileNotFound) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrNotOrgMember) || errors.Is(err, models.ErrFileQuarantined) {
+		c.JSON(http.StatusForbidden, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error downloading file"))
+		return
+	}
+	defer content.Close()
+
+	contentType := "application/octet-stream"
+	if file.Image != nil {
+		contentType = "image/" + file.Image.Format
+	}
+
+	c.DataFromReader(http.StatusOK, int64(file.Size), contentType, content, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", file.Filename),
+	})
+}
+
+// DeleteFile removes own file, files of organization are deleted by its owners and editors
+func (h *Handlers) DeleteFile(c *gin.Context) {
+	err := h.services.DeleteFile(c.Request.Context(), h.principal(c), c.Param("id"))
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrFileNotFound) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrFileForbidden) || errors.Is(err, models.ErrNotOrgMember) || errors.Is(err, models.ErrOrgForbidden) {
+		c.JSON(http.StatusForbidden, textToMap(err.Error()))
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error deleting file"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
 
+// UploadFile stores the file and responds before it's processed, its status is polled on GET /files/:id.
+// Format and dimensions are checked by the content policy, Content-Type isn't trusted
+func (h *Handlers) UploadFile(c *gin.Context) {
 	userIdValue := c.Keys[h.userHeaderName]
 	if userIdValue == nil {
 		c.JSON(http.StatusUnauthorized, textToMap("userID not found"))
@@ -149,7 +456,7 @@ func (h *Handlers) UploadFile(c *gin.Context) {
 		return
 	}
 
-	filename := fmt.Sprintf("%s-%d.png", userID, time.Now().Unix())
+	filename := objectName(body)
 	filesize := c.Request.ContentLength
 
 	if filesize >= int64(h.MaxSizeLimit) {
@@ -157,46 +464,92 @@ func (h *Handlers) UploadFile(c *gin.Context) {
 		return
 	}
 
-	err = h.services.UploadFile(&models.FileUploadInput{
+	file, err := h.services.UploadFile(c.Request.Context(), &models.FileUploadInput{
 		Filename: filename,
 		Size:     filesize,
 		UserId:   userID,
+		OrgId:    c.GetString(orgKey),
 		FileData: body,
 	})
+	if isUnavailable(c, err) || isPolicyViolation(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrUserNotVerified) {
+		c.JSON(http.StatusForbidden, textToMap("email not verified"))
+		return
+	}
+	if errors.Is(err, models.ErrNotOrgMember) || errors.Is(err, models.ErrOrgForbidden) {
+		c.JSON(http.StatusForbidden, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrQuotaExceeded) {
+		c.JSON(http.StatusRequestEntityTooLarge, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrFileQuarantined) {
+		c.JSON(http.StatusUnprocessableEntity, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrScannerUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, textToMap(err.Error()))
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, textToMap("error with upload file"))
 		return
 	}
 
-	c.JSON(http.StatusOK, textToMap("upload success"))
+	c.JSON(http.StatusAccepted, file)
 }
 
-func (h *Handlers) getTokenFromHeader(c *gin.Context) (string, error) {
+// getTokenFromHeader returns token and its scheme: "Bearer" for access tokens,
+// "ApiKey" for API keys sent in auth header or in X-API-Key header
+func (h *Handlers) getTokenFromHeader(c *gin.Context) (string, string, error) {
+	if key := c.GetHeader(apiKeyHeaderName); key != "" {
+		return key, apiKeyScheme, nil
+	}
+
 	header := c.GetHeader(h.tokenHeaderName)
 	if header == "" {
-		return "", errors.New("empty auth header")
+		return "", "", errors.New("empty auth header")
 	}
 
 	headerParts := strings.Split(header, " ")
 	if len(headerParts) != 2 {
-		return "", errors.New("invalid auth header value")
+		return "", "", errors.New("invalid auth header value")
 	}
 
-	if headerParts[0] != "Bearer" {
-		return "", errors.New("invalid auth header subject")
+	if headerParts[0] != bearerScheme && headerParts[0] != apiKeyScheme {
+		return "", "", errors.New("invalid auth header subject")
 	}
 
 	if len(headerParts[1]) == 0 {
-		return "", errors.New("invalid auth header token")
+		return "", "", errors.New("invalid auth header token")
 	}
 
-	return headerParts[1], nil
+	return headerParts[1], headerParts[0], nil
+}
+
+// isValidEmail accepts only a bare address like "user@mail.com" without display name
+func isValidEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	if err != nil {
+		return false
+	}
+	return address.Address == email && strings.Contains(email, ".")
 }
 
 func textToMap(text string) map[string]string {
 	return map[string]string{"message": text}
 }
 
+func clientInfo(c *gin.Context) *models.ClientInfo {
+	return &models.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
 func readBody(body io.ReadCloser) ([]byte, error) {
 	bytes, err := ioutil.ReadAll(body)
 	if err != nil {
@@ -204,3 +557,15 @@ func readBody(body io.ReadCloser) ([]byte, error) {
 	}
 	return bytes, nil
 }
+
+// objectName is unique for every upload, so deleting one file never removes the object of another.
+// The extension is the format detected in the content, which isn't an image without moderation
+func objectName(data []byte) string {
+	extension := "bin"
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err == nil {
+		extension = format
+	}
+
+	return primitive.NewObjectID().Hex() + "." + extension
+}
@@ -2,17 +2,28 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	mock_handlers "creatly-task/internal/handlers/mocks"
 	"creatly-task/internal/models"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/resilience"
 	"errors"
 	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
 	"net/http/httptest"
+	"reflect"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func Test_SignUp(t *testing.T) {
@@ -34,7 +45,7 @@ func Test_SignUp(t *testing.T) {
 			bodyInput:     `{"email": "some@mail.com", "password": "password"}`,
 			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices, passwordInput, passwordOutput string, hashErr error, signUp *models.UserSignUpInput, signUpError error) {
 				mh.EXPECT().Hash(passwordInput).Return(passwordOutput, hashErr)
-				s.EXPECT().SignUp(signUp).Return(signUpError)
+				s.EXPECT().SignUp(gomock.Any(), signUp).Return(signUpError)
 			},
 			outStatusCode:  200,
 			outMessage:     `{"message":"success"}`,
@@ -55,13 +66,22 @@ func Test_SignUp(t *testing.T) {
 			outStatusCode: 400,
 			outMessage:    `{"message":"invalid input"}`,
 		},
+		{
+			name:          "ERROR: invalid email",
+			inputPassword: "password",
+			bodyInput:     `{"email": "Some <some@mail.com>", "password": "password"}`,
+			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices, passwordInput, passwordOutput string, hashErr error, signUp *models.UserSignUpInput, signUpError error) {
+			},
+			outStatusCode: 400,
+			outMessage:    `{"message":"invalid email"}`,
+		},
 		{
 			name:          "ERROR: hasher error",
 			inputPassword: "password",
 			bodyInput:     `{"email": "some@mail.com", "password": "password"}`,
 			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices, passwordInput, passwordOutput string, hashErr error, signUp *models.UserSignUpInput, signUpError error) {
 				mh.EXPECT().Hash(passwordInput).Return(passwordOutput, hashErr)
-				s.EXPECT().SignUp(signUp).Return(signUpError)
+				s.EXPECT().SignUp(gomock.Any(), signUp).Return(signUpError)
 			},
 			outStatusCode:  500,
 			outMessage:     `{"message":"error while creating an account"}`,
@@ -104,6 +124,67 @@ func Test_SignUp(t *testing.T) {
 	}
 }
 
+func Test_VerifyEmail(t *testing.T) {
+	testTable := []struct {
+		name          string
+		query         string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+		outMessage    string
+	}{
+		{
+			name:  "OK",
+			query: "?token=token",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().VerifyEmail(gomock.Any(), "token").Return(nil)
+			},
+			outStatusCode: 200,
+			outMessage:    `{"message":"email verified"}`,
+		},
+		{
+			name:          "ERROR: empty token",
+			query:         "",
+			behavior:      func(s *mock_handlers.MockServices) {},
+			outStatusCode: 400,
+			outMessage:    `{"message":"empty token"}`,
+		},
+		{
+			name:  "ERROR: invalid token",
+			query: "?token=token",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().VerifyEmail(gomock.Any(), "token").Return(errors.New("token is expired"))
+			},
+			outStatusCode: 400,
+			outMessage:    `{"message":"invalid token"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hasher := mock_handlers.NewMockHasher(ctrl)
+			services := mock_handlers.NewMockServices(ctrl)
+
+			test.behavior(services)
+
+			handlers := New(services, 100000, hasher, "Authorization", "userId")
+
+			r := gin.New()
+			r.GET("/verify-email", handlers.VerifyEmail)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/verify-email"+test.query, nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outMessage, w.Body.String())
+		})
+	}
+}
+
 func Test_SignIn(t *testing.T) {
 	testTable := []struct {
 		name           string
@@ -112,6 +193,7 @@ func Test_SignIn(t *testing.T) {
 		outStatusCode  int
 		outMessage     string
 		outHeaderValue string
+		outRetryAfter  string
 	}{
 		{
 			name:          "OK",
@@ -120,10 +202,11 @@ func Test_SignIn(t *testing.T) {
 			outMessage:    `{"token":"token"}`,
 			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices) {
 				mh.EXPECT().Hash("qwerty").Return("ytrewq", nil)
-				s.EXPECT().SignIn(&models.UserSignInInput{
+				s.EXPECT().SignIn(gomock.Any(), &models.UserSignInInput{
 					Email:        "some@mail.com",
 					PasswordHash: "ytrewq",
-				}).Return("token", nil)
+					IP:           "192.0.2.1",
+				}).Return(&models.SignInResult{Token: "token"}, nil)
 			},
 			outHeaderValue: "Bearer token",
 		},
@@ -152,10 +235,34 @@ func Test_SignIn(t *testing.T) {
 			outMessage:    `{"message":"invalid creds"}`,
 			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices) {
 				mh.EXPECT().Hash("qwerty").Return("ytrewq", nil)
-				s.EXPECT().SignIn(&models.UserSignInInput{
+				s.EXPECT().SignIn(gomock.Any(), &models.UserSignInInput{
 					Email:        "some@mail.com",
 					PasswordHash: "ytrewq",
-				}).Return("", errors.New("internal error"))
+					IP:           "192.0.2.1",
+				}).Return(nil, errors.New("internal error"))
+			},
+			outHeaderValue: "",
+		},
+		{
+			name:          "ERROR: too many attempts",
+			bodyInput:     `{"email": "some@mail.com", "password": "qwerty"}`,
+			outStatusCode: 429,
+			outMessage:    `{"message":"too many failed attempts"}`,
+			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices) {
+				mh.EXPECT().Hash("qwerty").Return("ytrewq", nil)
+				s.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(nil, &models.LockedError{RetryAfter: time.Millisecond * 90500})
+			},
+			outHeaderValue: "",
+			outRetryAfter:  "91",
+		},
+		{
+			name:          "OK: second factor required",
+			bodyInput:     `{"email": "some@mail.com", "password": "qwerty"}`,
+			outStatusCode: 200,
+			outMessage:    `{"mfaRequired":true,"mfaToken":"pending"}`,
+			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices) {
+				mh.EXPECT().Hash("qwerty").Return("ytrewq", nil)
+				s.EXPECT().SignIn(gomock.Any(), gomock.Any()).Return(&models.SignInResult{MFARequired: true, MFAToken: "pending"}, nil)
 			},
 			outHeaderValue: "",
 		},
@@ -187,6 +294,7 @@ func Test_SignIn(t *testing.T) {
 			assert.Equal(t, test.outStatusCode, w.Code)
 			assert.Equal(t, test.outMessage, w.Body.String())
 			assert.Equal(t, w.Header().Get("Authorization"), test.outHeaderValue)
+			assert.Equal(t, test.outRetryAfter, w.Header().Get("Retry-After"))
 		})
 	}
 }
@@ -201,6 +309,7 @@ func Test_AuthMiddleware(t *testing.T) {
 		userIdHeaderValue string
 		behavior          func(s *mock_handlers.MockServices)
 		statusCode        int
+		code              string
 	}{
 		{
 			name:              "OK",
@@ -210,7 +319,7 @@ func Test_AuthMiddleware(t *testing.T) {
 			userIdHeaderName:  "userId",
 			userIdHeaderValue: "1",
 			behavior: func(s *mock_handlers.MockServices) {
-				s.EXPECT().ParseToken("token").Return("1", nil)
+				s.EXPECT().ParseToken(gomock.Any(), "token").Return(&models.Principal{UserID: "1", SessionID: "s1"}, nil)
 			},
 			statusCode: 200,
 		},
@@ -232,9 +341,61 @@ func Test_AuthMiddleware(t *testing.T) {
 			userIdHeaderName:  "userId",
 			userIdHeaderValue: "1",
 			behavior: func(s *mock_handlers.MockServices) {
-				s.EXPECT().ParseToken("token").Return("", errors.New("parse error"))
+				s.EXPECT().ParseToken(gomock.Any(), "token").Return(nil, errors.New("parse error"))
+			},
+			statusCode: 401,
+			code:       "invalid_token",
+		},
+		{
+			name:              "ERROR: token expired",
+			AuthHeaderName:    "Authorization",
+			AuthHeaderValue:   "Bearer token",
+			wantError:         true,
+			userIdHeaderName:  "userId",
+			userIdHeaderValue: "1",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ParseToken(gomock.Any(), "token").Return(nil, jwtauth.ErrTokenExpired)
 			},
 			statusCode: 401,
+			code:       "token_expired",
+		},
+		{
+			name:              "ERROR: bad audience",
+			AuthHeaderName:    "Authorization",
+			AuthHeaderValue:   "Bearer token",
+			wantError:         true,
+			userIdHeaderName:  "userId",
+			userIdHeaderValue: "1",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ParseToken(gomock.Any(), "token").Return(nil, jwtauth.ErrInvalidAudience)
+			},
+			statusCode: 401,
+			code:       "invalid_audience",
+		},
+		{
+			name:              "ERROR: session revoked",
+			AuthHeaderName:    "Authorization",
+			AuthHeaderValue:   "Bearer token",
+			wantError:         true,
+			userIdHeaderName:  "userId",
+			userIdHeaderValue: "1",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ParseToken(gomock.Any(), "token").Return(nil, models.ErrSessionRevoked)
+			},
+			statusCode: 401,
+			code:       "session_revoked",
+		},
+		{
+			name:              "ERROR: database timeout",
+			AuthHeaderName:    "Authorization",
+			AuthHeaderValue:   "Bearer token",
+			wantError:         true,
+			userIdHeaderName:  "userId",
+			userIdHeaderValue: "1",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ParseToken(gomock.Any(), "token").Return(nil, fmt.Errorf("get session: %w", context.DeadlineExceeded))
+			},
+			statusCode: 504,
 		},
 	}
 
@@ -260,6 +421,9 @@ func Test_AuthMiddleware(t *testing.T) {
 			r.ServeHTTP(w, req)
 
 			assert.Equal(t, w.Result().StatusCode, test.statusCode)
+			if test.code != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+test.code+`"`)
+			}
 		})
 	}
 }
@@ -274,7 +438,7 @@ func Test_Files(t *testing.T) {
 		{
 			name: "OK",
 			behavior: func(s *mock_handlers.MockServices) {
-				s.EXPECT().Files().Return([]models.FileOut{
+				s.EXPECT().Files(gomock.Any(), gomock.Any()).Return([]models.FileOut{
 					{
 						Filename: "file_1.png",
 						Size:     2000,
@@ -284,16 +448,23 @@ func Test_Files(t *testing.T) {
 					},
 				}, nil)
 			},
-			outBody:       `[{"filename":"file_1.png","size":2000,"uploadDate":19674823,"userId":"1","url":"https://s3.storage.com/file_1.png"}]`,
+			outBody:       `[{"id":"000000000000000000000000","filename":"file_1.png","size":2000,"uploadDate":19674823,"userId":"1","url":"https://s3.storage.com/file_1.png","status":""}]`,
 			outStatusCode: 200,
 		},
 		{
 			name: "ERROR: service files return error",
 			behavior: func(s *mock_handlers.MockServices) {
-				s.EXPECT().Files().Return([]models.FileOut{}, errors.New("error"))
+				s.EXPECT().Files(gomock.Any(), gomock.Any()).Return([]models.FileOut{}, errors.New("error"))
 			},
 			outBody:       `{"message":"error getting file data"}`,
 			outStatusCode: 500,
+		}, {
+			name: "ERROR: database circuit is open",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().Files(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("database: %w", resilience.ErrCircuitOpen))
+			},
+			outBody:       `{"message":"service temporarily unavailable"}`,
+			outStatusCode: 503,
 		},
 	}
 
@@ -326,7 +497,221 @@ func Test_Files(t *testing.T) {
 	}
 }
 
+func Test_File(t *testing.T) {
+	testTable := []struct {
+		name          string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name: "OK",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().File(gomock.Any(), &models.Principal{UserID: "1"}, "file1").Return(&models.FileOut{
+					Filename: "file_1.png",
+					Size:     2000,
+					UserId:   "1",
+					Status:   models.FileReady,
+					Image:    &models.ImageInfo{Format: "png", Width: 3, Height: 2},
+				}, nil)
+			},
+			outStatusCode: 200,
+			outBody:       `{"id":"000000000000000000000000","filename":"file_1.png","size":2000,"uploadDate":0,"userId":"1","url":"","status":"ready","image":{"format":"png","width":3,"height":2}}`,
+		},
+		{
+			name: "ERROR: not found",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().File(gomock.Any(), gomock.Any(), "file1").Return(nil, models.ErrFileNotFound)
+			},
+			outStatusCode: 404,
+			outBody:       `{"message":"file not found"}`,
+		},
+		{
+			name: "ERROR: not a member",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().File(gomock.Any(), gomock.Any(), "file1").Return(nil, models.ErrNotOrgMember)
+			},
+			outStatusCode: 403,
+			outBody:       `{"message":"` + models.ErrNotOrgMember.Error() + `"}`,
+		},
+		{
+			name: "ERROR: service error",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().File(gomock.Any(), gomock.Any(), "file1").Return(nil, errors.New("error"))
+			},
+			outStatusCode: 500,
+			outBody:       `{"message":"error getting file data"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("userId", "1")
+			})
+			r.GET("/files/:id", handlers.File)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/files/file1", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_DeleteFile(t *testing.T) {
+	testTable := []struct {
+		name          string
+		err           error
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name:          "OK",
+			outStatusCode: 204,
+		},
+		{
+			name:          "ERROR: not found",
+			err:           models.ErrFileNotFound,
+			outStatusCode: 404,
+			outBody:       `{"message":"` + models.ErrFileNotFound.Error() + `"}`,
+		},
+		{
+			name:          "ERROR: file of other user",
+			err:           models.ErrFileForbidden,
+			outStatusCode: 403,
+			outBody:       `{"message":"` + models.ErrFileForbidden.Error() + `"}`,
+		},
+		{
+			name:          "ERROR: viewer of organization",
+			err:           models.ErrOrgForbidden,
+			outStatusCode: 403,
+			outBody:       `{"message":"` + models.ErrOrgForbidden.Error() + `"}`,
+		},
+		{
+			name:          "ERROR: service error",
+			err:           errors.New("error"),
+			outStatusCode: 500,
+			outBody:       `{"message":"error deleting file"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().DeleteFile(gomock.Any(), &models.Principal{UserID: "1"}, "file1").Return(test.err)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("userId", "1")
+			})
+			r.DELETE("/files/:id", handlers.DeleteFile)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/files/file1", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_DownloadFile(t *testing.T) {
+	testTable := []struct {
+		name          string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+		outHeaders    map[string]string
+		outBody       string
+	}{
+		{
+			name: "OK",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().DownloadFile(gomock.Any(), gomock.Any(), "file1").Return(
+					&models.FileOut{Filename: "1.png", Size: 3, Status: models.FileReady, Image: &models.ImageInfo{Format: "png"}},
+					ioutil.NopCloser(bytes.NewBufferString("png")), nil)
+			},
+			outStatusCode: 200,
+			outHeaders:    map[string]string{"Content-Type": "image/png", "Content-Disposition": `attachment; filename="1.png"`},
+			outBody:       "png",
+		},
+		{
+			name: "ERROR: quarantined",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().DownloadFile(gomock.Any(), gomock.Any(), "file1").Return(nil, nil, models.ErrFileQuarantined)
+			},
+			outStatusCode: 403,
+			outBody:       `{"message":"` + models.ErrFileQuarantined.Error() + `"}`,
+		},
+		{
+			name: "ERROR: not found",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().DownloadFile(gomock.Any(), gomock.Any(), "file1").Return(nil, nil, models.ErrFileNotFound)
+			},
+			outStatusCode: 404,
+			outBody:       `{"message":"` + models.ErrFileNotFound.Error() + `"}`,
+		},
+		{
+			name: "ERROR: storage error",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().DownloadFile(gomock.Any(), gomock.Any(), "file1").Return(nil, nil, errors.New("storage error"))
+			},
+			outStatusCode: 500,
+			outBody:       `{"message":"error downloading file"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("userId", "1")
+			})
+			r.GET("/files/:id/download", handlers.DownloadFile)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/files/file1/download", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+			for name, value := range test.outHeaders {
+				assert.Equal(t, value, w.Header().Get(name))
+			}
+		})
+	}
+}
+
 func Test_UploadFile(t *testing.T) {
+	fileID := primitive.NewObjectID()
 	testTable := []struct {
 		name              string
 		behavior          func(s *mock_handlers.MockServices)
@@ -340,25 +725,30 @@ func Test_UploadFile(t *testing.T) {
 		{
 			name: "OK",
 			behavior: func(s *mock_handlers.MockServices) {
-				s.EXPECT().UploadFile(&models.FileUploadInput{
-					Filename: fmt.Sprintf("%s-%d.png", "1", time.Now().Unix()),
+				s.EXPECT().UploadFile(gomock.Any(), objectNamed{&models.FileUploadInput{
 					Size:     7,
 					UserId:   "1",
 					FileData: []byte{49, 50, 51, 52, 53, 54, 55},
-				}).Return(nil)
+				}, "bin"}).Return(&models.FileOut{ID: fileID, Filename: "1.png", Size: 7, UserId: "1", Status: models.FileProcessing}, nil)
 			},
-			outStatusCode:     200,
-			outBody:           `{"message":"upload success"}`,
+			outStatusCode:     202,
+			outBody:           `{"id":"` + fileID.Hex() + `","filename":"1.png","size":7,"uploadDate":0,"userId":"1","url":"","status":"processing"}`,
 			wantError:         false,
 			userIdHeaderName:  "userId",
 			userIdHeaderValue: "1",
 			contentType:       "image/png",
 		},
 		{
-			name:              "ERROR: wrong content-type",
-			behavior:          func(s *mock_handlers.MockServices) {},
-			outStatusCode:     400,
-			outBody:           `{"message":"invalid content-type"}`,
+			name: "ERROR: content policy violation",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().UploadFile(gomock.Any(), gomock.Any()).Return(nil, &models.PolicyError{Violations: []models.Violation{
+					{Rule: "formats", Message: "format gif is not allowed, allowed formats: png, jpeg"},
+					{Rule: "maxWidth", Message: "width 5000 is over 4096"},
+				}})
+			},
+			outStatusCode: 422,
+			outBody: `{"message":"upload violates content policy","violations":[` +
+				`{"rule":"formats","message":"format gif is not allowed, allowed formats: png, jpeg"},{"rule":"maxWidth","message":"width 5000 is over 4096"}]}`,
 			wantError:         true,
 			userIdHeaderName:  "userId",
 			userIdHeaderValue: "1",
@@ -377,12 +767,11 @@ func Test_UploadFile(t *testing.T) {
 		{
 			name: "ERROR: file uploading error",
 			behavior: func(s *mock_handlers.MockServices) {
-				s.EXPECT().UploadFile(&models.FileUploadInput{
-					Filename: fmt.Sprintf("%s-%d.png", "1", time.Now().Unix()),
+				s.EXPECT().UploadFile(gomock.Any(), objectNamed{&models.FileUploadInput{
 					Size:     7,
 					UserId:   "1",
 					FileData: []byte{49, 50, 51, 52, 53, 54, 55},
-				}).Return(errors.New("upload err"))
+				}, "bin"}).Return(nil, errors.New("upload err"))
 			},
 			outStatusCode:     500,
 			outBody:           `{"message":"error with upload file"}`,
@@ -391,6 +780,30 @@ func Test_UploadFile(t *testing.T) {
 			userIdHeaderValue: "1",
 			contentType:       "image/png",
 		},
+		{
+			name: "ERROR: file is quarantined",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().UploadFile(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("%w: Eicar-Test-Signature found", models.ErrFileQuarantined))
+			},
+			outStatusCode:     422,
+			outBody:           `{"message":"file is quarantined: Eicar-Test-Signature found"}`,
+			wantError:         true,
+			userIdHeaderName:  "userId",
+			userIdHeaderValue: "1",
+			contentType:       "image/png",
+		},
+		{
+			name: "ERROR: scanner unavailable",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().UploadFile(gomock.Any(), gomock.Any()).Return(nil, models.ErrScannerUnavailable)
+			},
+			outStatusCode:     503,
+			outBody:           `{"message":"` + models.ErrScannerUnavailable.Error() + `"}`,
+			wantError:         true,
+			userIdHeaderName:  "userId",
+			userIdHeaderValue: "1",
+			contentType:       "image/png",
+		},
 	}
 
 	for _, test := range testTable {
@@ -434,3 +847,113 @@ func Test_UploadFile(t *testing.T) {
 		})
 	}
 }
+
+func Test_Health(t *testing.T) {
+	testTable := []struct {
+		name          string
+		health        *models.Health
+		outBody       string
+		outStatusCode int
+	}{
+		{
+			name:          "OK",
+			health:        &models.Health{Status: models.HealthOK, Backends: map[string]string{"database": "closed"}},
+			outBody:       `{"status":"ok","backends":{"database":"closed"}}`,
+			outStatusCode: 200,
+		},
+		{
+			name:          "ERROR: degraded",
+			health:        &models.Health{Status: models.HealthDegraded, Backends: map[string]string{"database": "closed", "storage": "open"}},
+			outBody:       `{"status":"degraded","backends":{"database":"closed","storage":"open"}}`,
+			outStatusCode: 503,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().Health().Return(test.health)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.GET("/health", handlers.Health)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_objectName(t *testing.T) {
+	encode := func(encode func(w *bytes.Buffer, img image.Image) error) []byte {
+		var buf bytes.Buffer
+		err := encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	testTable := []struct {
+		name      string
+		data      []byte
+		extension string
+	}{
+		{
+			name:      "OK: png",
+			data:      encode(func(w *bytes.Buffer, img image.Image) error { return png.Encode(w, img) }),
+			extension: "png",
+		},
+		{
+			name:      "OK: jpeg",
+			data:      encode(func(w *bytes.Buffer, img image.Image) error { return jpeg.Encode(w, img, nil) }),
+			extension: "jpeg",
+		},
+		{
+			name:      "OK: gif",
+			data:      encode(func(w *bytes.Buffer, img image.Image) error { return gif.Encode(w, img, nil) }),
+			extension: "gif",
+		},
+		{
+			name:      "OK: not an image",
+			data:      []byte("1234567"),
+			extension: "bin",
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			name := objectName(test.data)
+			assert.Regexp(t, `^[0-9a-f]{24}\.`+test.extension+`$`, name)
+			assert.NotEqual(t, name, objectName(test.data), "every upload has own object")
+		})
+	}
+}
+
+// objectNamed matches upload input whose generated object name has the extension
+type objectNamed struct {
+	want      *models.FileUploadInput
+	extension string
+}
+
+func (m objectNamed) Matches(x interface{}) bool {
+	input, ok := x.(*models.FileUploadInput)
+	if !ok || !regexp.MustCompile(`^[0-9a-f]{24}\.`+m.extension+`$`).MatchString(input.Filename) {
+		return false
+	}
+
+	named := *input
+	named.Filename = ""
+	return reflect.DeepEqual(&named, m.want)
+}
+
+func (m objectNamed) String() string {
+	return fmt.Sprintf("is %+v named *.%s", m.want, m.extension)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"creatly-task/internal/models"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	anonymousIdempotency     = "anonymous:" // Scope prefix of requests without user, followed by the request fingerprint
+
+	// idempotencyFinishTimeout limits storing the outcome, which is done even when the client is gone
+	idempotencyFinishTimeout = 5 * time.Second
+)
+
+// Idempotency replays the response of the first request to its retries sent with the same Idempotency-Key.
+// Keys are separate per user, the same key with another body gets 422. Anonymous keys are bound to the
+// request body, so only the same request gets the replay. Server errors aren't stored,
+// the key is released so the retry runs the request again
+func (h *Handlers) Idempotency(c *gin.Context) {
+	key := c.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		c.Next()
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		c.AbortWithStatusJSON(http.StatusBadRequest, textToMap("invalid idempotency key"))
+		return
+	}
+
+	body, err := readBody(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, textToMap("error while read body"))
+		return
+	}
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// Salted like passwords, sign-up body carries one
+	fingerprint, err := h.hasher.Hash(c.Request.Method + " " + c.FullPath() + "\n" + string(body))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, textToMap("error while hashing request"))
+		return
+	}
+
+	// Anonymous clients can't be told apart, a shared scope would replay the response to anyone
+	// using the same key. Sign-up body carries the credentials, so only their owner repeats it
+	scope := c.GetString(h.userHeaderName)
+	if scope == "" {
+		scope = anonymousIdempotency + fingerprint
+	}
+
+	response, err := h.services.BeginIdempotent(c.Request.Context(), scope, key, fingerprint)
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrIdempotencyKeyInProgress) {
+		c.AbortWithStatusJSON(http.StatusConflict, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrIdempotencyKeyReused) {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, textToMap("error checking idempotency key"))
+		return
+	}
+
+	if response != nil {
+		c.Header(idempotentReplayedHeader, "true")
+		c.Data(response.Status, response.ContentType, response.Body)
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	c.Next()
+
+	ctx, cancel := context.WithTimeout(context.Background(), idempotencyFinishTimeout)
+	defer cancel()
+
+	if recorder.Status() >= http.StatusInternalServerError {
+		err = h.services.ReleaseIdempotent(ctx, scope, key)
+	} else {
+		err = h.services.CompleteIdempotent(ctx, scope, key, &models.IdempotentResponse{
+			Status:      recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+	}
+	if err != nil {
+		log.Printf("idempotency key %q of user %q is not finished - %s\n", key, scope, err.Error())
+	}
+}
+
+// responseRecorder keeps a copy of the response body for replays
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
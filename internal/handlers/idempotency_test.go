@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Idempotency(t *testing.T) {
+	stored := &models.IdempotentResponse{Status: 200, ContentType: "application/json; charset=utf-8", Body: []byte(`{"message":"stored"}`)}
+
+	testTable := []struct {
+		name          string
+		key           string
+		handlerStatus int
+		behavior      func(s *mock_handlers.MockServices)
+		outBody       string
+		outStatusCode int
+		replayed      bool
+	}{
+		{
+			name:          "OK: without key",
+			handlerStatus: 200,
+			behavior:      func(s *mock_handlers.MockServices) {},
+			outBody:       `{"message":"handled"}`,
+			outStatusCode: 200,
+		},
+		{
+			name:          "OK: first request is stored",
+			key:           "key",
+			handlerStatus: 200,
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().BeginIdempotent(gomock.Any(), "1", "key", "fingerprint").Return(nil, nil)
+				s.EXPECT().CompleteIdempotent(gomock.Any(), "1", "key", &models.IdempotentResponse{
+					Status:      200,
+					ContentType: "application/json; charset=utf-8",
+					Body:        []byte(`{"message":"handled"}`),
+				}).Return(nil)
+			},
+			outBody:       `{"message":"handled"}`,
+			outStatusCode: 200,
+		},
+		{
+			name:          "OK: client error is stored",
+			key:           "key",
+			handlerStatus: 403,
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().BeginIdempotent(gomock.Any(), "1", "key", "fingerprint").Return(nil, nil)
+				s.EXPECT().CompleteIdempotent(gomock.Any(), "1", "key", gomock.Any()).Return(nil)
+			},
+			outBody:       `{"message":"handled"}`,
+			outStatusCode: 403,
+		},
+		{
+			name:          "OK: server error releases key",
+			key:           "key",
+			handlerStatus: 500,
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().BeginIdempotent(gomock.Any(), "1", "key", "fingerprint").Return(nil, nil)
+				s.EXPECT().ReleaseIdempotent(gomock.Any(), "1", "key").Return(nil)
+			},
+			outBody:       `{"message":"handled"}`,
+			outStatusCode: 500,
+		},
+		{
+			name: "OK: retry is replayed",
+			key:  "key",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().BeginIdempotent(gomock.Any(), "1", "key", "fingerprint").Return(stored, nil)
+			},
+			outBody:       `{"message":"stored"}`,
+			outStatusCode: 200,
+			replayed:      true,
+		},
+		{
+			name: "ERROR: in progress",
+			key:  "key",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().BeginIdempotent(gomock.Any(), "1", "key", "fingerprint").Return(nil, models.ErrIdempotencyKeyInProgress)
+			},
+			outBody:       `{"message":"request with this idempotency key is in progress"}`,
+			outStatusCode: 409,
+		},
+		{
+			name: "ERROR: key reused",
+			key:  "key",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().BeginIdempotent(gomock.Any(), "1", "key", "fingerprint").Return(nil, models.ErrIdempotencyKeyReused)
+			},
+			outBody:       `{"message":"idempotency key was used for another request"}`,
+			outStatusCode: 422,
+		},
+		{
+			name: "ERROR: database",
+			key:  "key",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().BeginIdempotent(gomock.Any(), "1", "key", "fingerprint").Return(nil, errors.New("error"))
+			},
+			outBody:       `{"message":"error checking idempotency key"}`,
+			outStatusCode: 500,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hasher := mock_handlers.NewMockHasher(ctrl)
+			hasher.EXPECT().Hash("POST /upload\nimage").Return("fingerprint", nil).AnyTimes()
+			services := mock_handlers.NewMockServices(ctrl)
+
+			test.behavior(services)
+
+			handlers := New(services, 100000, hasher, "Authorization", "userId")
+
+			r := gin.New()
+			r.POST("/upload", func(c *gin.Context) {
+				c.Set("userId", "1")
+			}, handlers.Idempotency, func(c *gin.Context) {
+				body, _ := readBody(c.Request.Body)
+				assert.Equal(t, "image", string(body), "body is passed to the handler")
+
+				c.JSON(test.handlerStatus, textToMap("handled"))
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/upload", bytes.NewBufferString("image"))
+			if test.key != "" {
+				req.Header.Set("Idempotency-Key", test.key)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+			assert.Equal(t, test.replayed, w.Header().Get("Idempotent-Replayed") == "true")
+		})
+	}
+}
+
+func Test_IdempotencyAnonymous(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hasher := mock_handlers.NewMockHasher(ctrl)
+	hasher.EXPECT().Hash("POST /sign-up\n{\"email\":\"a@mail.com\"}").Return("fingerprint-a", nil)
+	hasher.EXPECT().Hash("POST /sign-up\n{\"email\":\"b@mail.com\"}").Return("fingerprint-b", nil)
+
+	services := mock_handlers.NewMockServices(ctrl)
+	services.EXPECT().BeginIdempotent(gomock.Any(), "anonymous:fingerprint-a", "key", "fingerprint-a").Return(nil, nil)
+	services.EXPECT().CompleteIdempotent(gomock.Any(), "anonymous:fingerprint-a", "key", gomock.Any()).Return(nil)
+	services.EXPECT().BeginIdempotent(gomock.Any(), "anonymous:fingerprint-b", "key", "fingerprint-b").Return(nil, nil)
+	services.EXPECT().CompleteIdempotent(gomock.Any(), "anonymous:fingerprint-b", "key", gomock.Any()).Return(nil)
+
+	handlers := New(services, 100000, hasher, "Authorization", "userId")
+
+	r := gin.New()
+	r.POST("/sign-up", handlers.Idempotency, func(c *gin.Context) {
+		c.JSON(200, textToMap("handled"))
+	})
+
+	// Two clients with the same key get own records instead of the replay of each other
+	for _, body := range []string{`{"email":"a@mail.com"}`, `{"email":"b@mail.com"}`} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/sign-up", bytes.NewBufferString(body))
+		req.Header.Set("Idempotency-Key", "key")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 200, w.Code)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) EnrollMFA(c *gin.Context) {
+	output, err := h.services.EnrollMFA(c.Request.Context(), c.GetString(h.userHeaderName))
+	if err != nil {
+		h.mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *Handlers) ConfirmMFA(c *gin.Context) {
+	var input models.MFACodeInput
+
+	err := c.BindJSON(&input)
+	if err != nil || input.Code == "" {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	output, err := h.services.ConfirmMFA(c.Request.Context(), c.GetString(h.userHeaderName), input.Code)
+	if err != nil {
+		h.mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *Handlers) RegenerateRecoveryCodes(c *gin.Context) {
+	var input models.MFACodeInput
+
+	err := c.BindJSON(&input)
+	if err != nil || input.Code == "" {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	output, err := h.services.RegenerateRecoveryCodes(c.Request.Context(), c.GetString(h.userHeaderName), input.Code)
+	if err != nil {
+		h.mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *Handlers) DisableMFA(c *gin.Context) {
+	var input models.MFACodeInput
+
+	err := c.BindJSON(&input)
+	if err != nil || input.Code == "" {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	err = h.services.DisableMFA(c.Request.Context(), c.GetString(h.userHeaderName), input.Code)
+	if err != nil {
+		h.mfaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("two-factor authentication disabled"))
+}
+
+func (h *Handlers) SignInMFA(c *gin.Context) {
+	var input models.MFASignInInput
+
+	err := c.BindJSON(&input)
+	if err != nil || input.MFAToken == "" || input.Code == "" {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	token, err := h.services.SignInMFA(c.Request.Context(), input.MFAToken, input.Code, clientInfo(c))
+	if isUnavailable(c, err) {
+		return
+	}
+	if isLocked(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, textToMap("invalid creds"))
+		return
+	}
+
+	c.Header("Authorization", fmt.Sprintf("Bearer %s", token))
+	c.JSON(http.StatusOK, map[string]string{"token": token})
+}
+
+func (h *Handlers) mfaError(c *gin.Context, err error) {
+	if isUnavailable(c, err) {
+		return
+	}
+	if isLocked(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, models.ErrInvalidMFACode):
+		c.JSON(http.StatusBadRequest, textToMap(err.Error()))
+	case errors.Is(err, models.ErrMFAAlreadyEnabled), errors.Is(err, models.ErrMFANotEnabled):
+		c.JSON(http.StatusConflict, textToMap(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, textToMap("error with two-factor authentication"))
+	}
+}
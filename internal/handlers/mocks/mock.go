@@ -5,7 +5,10 @@
 package mock_handlers
 
 import (
+	context "context"
 	models "creatly-task/internal/models"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -72,75 +75,864 @@ func (m *MockServices) EXPECT() *MockServicesMockRecorder {
 	return m.recorder
 }
 
+// APIKeys mocks base method.
+func (m *MockServices) APIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "APIKeys", ctx, userID)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// APIKeys indicates an expected call of APIKeys.
+func (mr *MockServicesMockRecorder) APIKeys(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "APIKeys", reflect.TypeOf((*MockServices)(nil).APIKeys), ctx, userID)
+}
+
+// AcceptInvitation mocks base method.
+func (m *MockServices) AcceptInvitation(ctx context.Context, userID, token string) (*models.OrganizationOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvitation", ctx, userID, token)
+	ret0, _ := ret[0].(*models.OrganizationOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptInvitation indicates an expected call of AcceptInvitation.
+func (mr *MockServicesMockRecorder) AcceptInvitation(ctx, userID, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvitation", reflect.TypeOf((*MockServices)(nil).AcceptInvitation), ctx, userID, token)
+}
+
+// BeginIdempotent mocks base method.
+func (m *MockServices) BeginIdempotent(ctx context.Context, scope, key, fingerprint string) (*models.IdempotentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginIdempotent", ctx, scope, key, fingerprint)
+	ret0, _ := ret[0].(*models.IdempotentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginIdempotent indicates an expected call of BeginIdempotent.
+func (mr *MockServicesMockRecorder) BeginIdempotent(ctx, scope, key, fingerprint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginIdempotent", reflect.TypeOf((*MockServices)(nil).BeginIdempotent), ctx, scope, key, fingerprint)
+}
+
+// ChangePassword mocks base method.
+func (m *MockServices) ChangePassword(ctx context.Context, userID, currentPasswordHash, newPasswordHash string, client *models.ClientInfo) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, currentPasswordHash, newPasswordHash, client)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockServicesMockRecorder) ChangePassword(ctx, userID, currentPasswordHash, newPasswordHash, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockServices)(nil).ChangePassword), ctx, userID, currentPasswordHash, newPasswordHash, client)
+}
+
+// CompleteIdempotent mocks base method.
+func (m *MockServices) CompleteIdempotent(ctx context.Context, scope, key string, response *models.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteIdempotent", ctx, scope, key, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteIdempotent indicates an expected call of CompleteIdempotent.
+func (mr *MockServicesMockRecorder) CompleteIdempotent(ctx, scope, key, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteIdempotent", reflect.TypeOf((*MockServices)(nil).CompleteIdempotent), ctx, scope, key, response)
+}
+
+// ConfirmMFA mocks base method.
+func (m *MockServices) ConfirmMFA(ctx context.Context, userID, code string) (*models.RecoveryCodesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmMFA", ctx, userID, code)
+	ret0, _ := ret[0].(*models.RecoveryCodesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmMFA indicates an expected call of ConfirmMFA.
+func (mr *MockServicesMockRecorder) ConfirmMFA(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmMFA", reflect.TypeOf((*MockServices)(nil).ConfirmMFA), ctx, userID, code)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockServices) CreateAPIKey(ctx context.Context, userID string, input *models.APIKeyInput) (*models.APIKeyCreated, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, userID, input)
+	ret0, _ := ret[0].(*models.APIKeyCreated)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockServicesMockRecorder) CreateAPIKey(ctx, userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockServices)(nil).CreateAPIKey), ctx, userID, input)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockServices) CreateOrganization(ctx context.Context, userID string, input *models.OrganizationInput) (*models.OrganizationOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, userID, input)
+	ret0, _ := ret[0].(*models.OrganizationOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockServicesMockRecorder) CreateOrganization(ctx, userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockServices)(nil).CreateOrganization), ctx, userID, input)
+}
+
+// CreateWebhook mocks base method.
+func (m *MockServices) CreateWebhook(ctx context.Context, userID string, input *models.WebhookInput) (*models.WebhookCreated, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", ctx, userID, input)
+	ret0, _ := ret[0].(*models.WebhookCreated)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockServicesMockRecorder) CreateWebhook(ctx, userID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockServices)(nil).CreateWebhook), ctx, userID, input)
+}
+
+// DeadJobs mocks base method.
+func (m *MockServices) DeadJobs(ctx context.Context) ([]models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadJobs", ctx)
+	ret0, _ := ret[0].([]models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeadJobs indicates an expected call of DeadJobs.
+func (mr *MockServicesMockRecorder) DeadJobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadJobs", reflect.TypeOf((*MockServices)(nil).DeadJobs), ctx)
+}
+
+// DeleteFile mocks base method.
+func (m *MockServices) DeleteFile(ctx context.Context, principal *models.Principal, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", ctx, principal, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockServicesMockRecorder) DeleteFile(ctx, principal, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockServices)(nil).DeleteFile), ctx, principal, id)
+}
+
+// DeleteUser mocks base method.
+func (m *MockServices) DeleteUser(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockServicesMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockServices)(nil).DeleteUser), ctx, userID)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockServices) DeleteWebhook(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockServicesMockRecorder) DeleteWebhook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockServices)(nil).DeleteWebhook), ctx, id)
+}
+
+// DisableMFA mocks base method.
+func (m *MockServices) DisableMFA(ctx context.Context, userID, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableMFA", ctx, userID, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableMFA indicates an expected call of DisableMFA.
+func (mr *MockServicesMockRecorder) DisableMFA(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableMFA", reflect.TypeOf((*MockServices)(nil).DisableMFA), ctx, userID, code)
+}
+
+// DownloadFile mocks base method.
+func (m *MockServices) DownloadFile(ctx context.Context, principal *models.Principal, id string) (*models.FileOut, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadFile", ctx, principal, id)
+	ret0, _ := ret[0].(*models.FileOut)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// DownloadFile indicates an expected call of DownloadFile.
+func (mr *MockServicesMockRecorder) DownloadFile(ctx, principal, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFile", reflect.TypeOf((*MockServices)(nil).DownloadFile), ctx, principal, id)
+}
+
+// EnrollMFA mocks base method.
+func (m *MockServices) EnrollMFA(ctx context.Context, userID string) (*models.MFAEnrollOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollMFA", ctx, userID)
+	ret0, _ := ret[0].(*models.MFAEnrollOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollMFA indicates an expected call of EnrollMFA.
+func (mr *MockServicesMockRecorder) EnrollMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollMFA", reflect.TypeOf((*MockServices)(nil).EnrollMFA), ctx, userID)
+}
+
+// ExportData mocks base method.
+func (m *MockServices) ExportData(ctx context.Context, userID string, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportData", ctx, userID, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportData indicates an expected call of ExportData.
+func (mr *MockServicesMockRecorder) ExportData(ctx, userID, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportData", reflect.TypeOf((*MockServices)(nil).ExportData), ctx, userID, w)
+}
+
+// File mocks base method.
+func (m *MockServices) File(ctx context.Context, principal *models.Principal, id string) (*models.FileOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "File", ctx, principal, id)
+	ret0, _ := ret[0].(*models.FileOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// File indicates an expected call of File.
+func (mr *MockServicesMockRecorder) File(ctx, principal, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "File", reflect.TypeOf((*MockServices)(nil).File), ctx, principal, id)
+}
+
+// FileEvents mocks base method.
+func (m *MockServices) FileEvents(ctx context.Context, principal *models.Principal) (<-chan models.FileEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FileEvents", ctx, principal)
+	ret0, _ := ret[0].(<-chan models.FileEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FileEvents indicates an expected call of FileEvents.
+func (mr *MockServicesMockRecorder) FileEvents(ctx, principal interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FileEvents", reflect.TypeOf((*MockServices)(nil).FileEvents), ctx, principal)
+}
+
 // Files mocks base method.
-func (m *MockServices) Files() ([]models.FileOut, error) {
+func (m *MockServices) Files(ctx context.Context, principal *models.Principal) ([]models.FileOut, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Files")
+	ret := m.ctrl.Call(m, "Files", ctx, principal)
 	ret0, _ := ret[0].([]models.FileOut)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Files indicates an expected call of Files.
-func (mr *MockServicesMockRecorder) Files() *gomock.Call {
+func (mr *MockServicesMockRecorder) Files(ctx, principal interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Files", reflect.TypeOf((*MockServices)(nil).Files))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Files", reflect.TypeOf((*MockServices)(nil).Files), ctx, principal)
 }
 
-// ParseToken mocks base method.
-func (m *MockServices) ParseToken(token string) (string, error) {
+// ForgotPassword mocks base method.
+func (m *MockServices) ForgotPassword(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockServicesMockRecorder) ForgotPassword(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockServices)(nil).ForgotPassword), ctx, email)
+}
+
+// Health mocks base method.
+func (m *MockServices) Health() *models.Health {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Health")
+	ret0, _ := ret[0].(*models.Health)
+	return ret0
+}
+
+// Health indicates an expected call of Health.
+func (mr *MockServicesMockRecorder) Health() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*MockServices)(nil).Health))
+}
+
+// InviteMember mocks base method.
+func (m *MockServices) InviteMember(ctx context.Context, userID, orgID string, input *models.InvitationInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InviteMember", ctx, userID, orgID, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InviteMember indicates an expected call of InviteMember.
+func (mr *MockServicesMockRecorder) InviteMember(ctx, userID, orgID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InviteMember", reflect.TypeOf((*MockServices)(nil).InviteMember), ctx, userID, orgID, input)
+}
+
+// IsAdmin mocks base method.
+func (m *MockServices) IsAdmin(ctx context.Context, userID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAdmin", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsAdmin indicates an expected call of IsAdmin.
+func (mr *MockServicesMockRecorder) IsAdmin(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockServices)(nil).IsAdmin), ctx, userID)
+}
+
+// JWKS mocks base method.
+func (m *MockServices) JWKS() *jwtauth.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(*jwtauth.JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockServicesMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockServices)(nil).JWKS))
+}
+
+// OIDCCallback mocks base method.
+func (m *MockServices) OIDCCallback(ctx context.Context, provider, signedState, state, code string, client *models.ClientInfo) (*models.SignInResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ParseToken", token)
+	ret := m.ctrl.Call(m, "OIDCCallback", ctx, provider, signedState, state, code, client)
+	ret0, _ := ret[0].(*models.SignInResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OIDCCallback indicates an expected call of OIDCCallback.
+func (mr *MockServicesMockRecorder) OIDCCallback(ctx, provider, signedState, state, code, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OIDCCallback", reflect.TypeOf((*MockServices)(nil).OIDCCallback), ctx, provider, signedState, state, code, client)
+}
+
+// OIDCLogin mocks base method.
+func (m *MockServices) OIDCLogin(ctx context.Context, provider string) (*models.OIDCLoginOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OIDCLogin", ctx, provider)
+	ret0, _ := ret[0].(*models.OIDCLoginOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OIDCLogin indicates an expected call of OIDCLogin.
+func (mr *MockServicesMockRecorder) OIDCLogin(ctx, provider interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OIDCLogin", reflect.TypeOf((*MockServices)(nil).OIDCLogin), ctx, provider)
+}
+
+// Organization mocks base method.
+func (m *MockServices) Organization(ctx context.Context, userID, orgID string) (*models.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Organization", ctx, userID, orgID)
+	ret0, _ := ret[0].(*models.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Organization indicates an expected call of Organization.
+func (mr *MockServicesMockRecorder) Organization(ctx, userID, orgID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Organization", reflect.TypeOf((*MockServices)(nil).Organization), ctx, userID, orgID)
+}
+
+// Organizations mocks base method.
+func (m *MockServices) Organizations(ctx context.Context, userID string) ([]models.OrganizationOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Organizations", ctx, userID)
+	ret0, _ := ret[0].([]models.OrganizationOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Organizations indicates an expected call of Organizations.
+func (mr *MockServicesMockRecorder) Organizations(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Organizations", reflect.TypeOf((*MockServices)(nil).Organizations), ctx, userID)
+}
+
+// ParseAPIKey mocks base method.
+func (m *MockServices) ParseAPIKey(ctx context.Context, key string) (string, []string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseAPIKey", ctx, key)
 	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ParseAPIKey indicates an expected call of ParseAPIKey.
+func (mr *MockServicesMockRecorder) ParseAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseAPIKey", reflect.TypeOf((*MockServices)(nil).ParseAPIKey), ctx, key)
+}
+
+// ParseToken mocks base method.
+func (m *MockServices) ParseToken(ctx context.Context, token string) (*models.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseToken", ctx, token)
+	ret0, _ := ret[0].(*models.Principal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ParseToken indicates an expected call of ParseToken.
-func (mr *MockServicesMockRecorder) ParseToken(token interface{}) *gomock.Call {
+func (mr *MockServicesMockRecorder) ParseToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockServices)(nil).ParseToken), ctx, token)
+}
+
+// Profile mocks base method.
+func (m *MockServices) Profile(ctx context.Context, userID string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Profile", ctx, userID)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Profile indicates an expected call of Profile.
+func (mr *MockServicesMockRecorder) Profile(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Profile", reflect.TypeOf((*MockServices)(nil).Profile), ctx, userID)
+}
+
+// ReconcileStats mocks base method.
+func (m *MockServices) ReconcileStats() *models.ReconcileStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileStats")
+	ret0, _ := ret[0].(*models.ReconcileStats)
+	return ret0
+}
+
+// ReconcileStats indicates an expected call of ReconcileStats.
+func (mr *MockServicesMockRecorder) ReconcileStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileStats", reflect.TypeOf((*MockServices)(nil).ReconcileStats))
+}
+
+// ReconcileUploads mocks base method.
+func (m *MockServices) ReconcileUploads(ctx context.Context) (*models.ReconcileReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileUploads", ctx)
+	ret0, _ := ret[0].(*models.ReconcileReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileUploads indicates an expected call of ReconcileUploads.
+func (mr *MockServicesMockRecorder) ReconcileUploads(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileUploads", reflect.TypeOf((*MockServices)(nil).ReconcileUploads), ctx)
+}
+
+// Redeliver mocks base method.
+func (m *MockServices) Redeliver(ctx context.Context, webhookID, deliveryID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeliver", ctx, webhookID, deliveryID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Redeliver indicates an expected call of Redeliver.
+func (mr *MockServicesMockRecorder) Redeliver(ctx, webhookID, deliveryID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeliver", reflect.TypeOf((*MockServices)(nil).Redeliver), ctx, webhookID, deliveryID)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockServices) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*models.RecoveryCodesOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", ctx, userID, code)
+	ret0, _ := ret[0].(*models.RecoveryCodesOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockServicesMockRecorder) RegenerateRecoveryCodes(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockServices)(nil).RegenerateRecoveryCodes), ctx, userID, code)
+}
+
+// ReleaseIdempotent mocks base method.
+func (m *MockServices) ReleaseIdempotent(ctx context.Context, scope, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseIdempotent", ctx, scope, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseIdempotent indicates an expected call of ReleaseIdempotent.
+func (mr *MockServicesMockRecorder) ReleaseIdempotent(ctx, scope, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdempotent", reflect.TypeOf((*MockServices)(nil).ReleaseIdempotent), ctx, scope, key)
+}
+
+// RemoveMember mocks base method.
+func (m *MockServices) RemoveMember(ctx context.Context, userID, orgID, memberID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, userID, orgID, memberID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockServicesMockRecorder) RemoveMember(ctx, userID, orgID, memberID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockServices)(nil).RemoveMember), ctx, userID, orgID, memberID)
+}
+
+// RequeueJob mocks base method.
+func (m *MockServices) RequeueJob(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueJob", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequeueJob indicates an expected call of RequeueJob.
+func (mr *MockServicesMockRecorder) RequeueJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueJob", reflect.TypeOf((*MockServices)(nil).RequeueJob), ctx, id)
+}
+
+// ResendVerification mocks base method.
+func (m *MockServices) ResendVerification(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockServicesMockRecorder) ResendVerification(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockServices)(nil).ResendVerification), ctx, userID)
+}
+
+// ResetPassword mocks base method.
+func (m *MockServices) ResetPassword(ctx context.Context, token, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, token, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockServicesMockRecorder) ResetPassword(ctx, token, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockServices)(nil).ResetPassword), ctx, token, passwordHash)
+}
+
+// ResetUserPassword mocks base method.
+func (m *MockServices) ResetUserPassword(ctx context.Context, userID, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetUserPassword", ctx, userID, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetUserPassword indicates an expected call of ResetUserPassword.
+func (mr *MockServicesMockRecorder) ResetUserPassword(ctx, userID, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetUserPassword", reflect.TypeOf((*MockServices)(nil).ResetUserPassword), ctx, userID, passwordHash)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockServices) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, userID, keyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockServicesMockRecorder) RevokeAPIKey(ctx, userID, keyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockServices)(nil).RevokeAPIKey), ctx, userID, keyID)
+}
+
+// RevokeSession mocks base method.
+func (m *MockServices) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockServicesMockRecorder) RevokeSession(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockServices)(nil).RevokeSession), ctx, userID, sessionID)
+}
+
+// Sessions mocks base method.
+func (m *MockServices) Sessions(ctx context.Context, userID, currentSessionID string) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sessions", ctx, userID, currentSessionID)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sessions indicates an expected call of Sessions.
+func (mr *MockServicesMockRecorder) Sessions(ctx, userID, currentSessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sessions", reflect.TypeOf((*MockServices)(nil).Sessions), ctx, userID, currentSessionID)
+}
+
+// SetMemberRole mocks base method.
+func (m *MockServices) SetMemberRole(ctx context.Context, userID, orgID, memberID, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMemberRole", ctx, userID, orgID, memberID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMemberRole indicates an expected call of SetMemberRole.
+func (mr *MockServicesMockRecorder) SetMemberRole(ctx, userID, orgID, memberID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMemberRole", reflect.TypeOf((*MockServices)(nil).SetMemberRole), ctx, userID, orgID, memberID, role)
+}
+
+// SetOrganizationQuota mocks base method.
+func (m *MockServices) SetOrganizationQuota(ctx context.Context, orgID string, quota int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOrganizationQuota", ctx, orgID, quota)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOrganizationQuota indicates an expected call of SetOrganizationQuota.
+func (mr *MockServicesMockRecorder) SetOrganizationQuota(ctx, orgID, quota interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOrganizationQuota", reflect.TypeOf((*MockServices)(nil).SetOrganizationQuota), ctx, orgID, quota)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockServices) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, userID, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockServicesMockRecorder) SetUserDisabled(ctx, userID, disabled interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockServices)(nil).ParseToken), token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockServices)(nil).SetUserDisabled), ctx, userID, disabled)
 }
 
 // SignIn mocks base method.
-func (m *MockServices) SignIn(user *models.UserSignInInput) (string, error) {
+func (m *MockServices) SignIn(ctx context.Context, user *models.UserSignInInput) (*models.SignInResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignIn", user)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "SignIn", ctx, user)
+	ret0, _ := ret[0].(*models.SignInResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SignIn indicates an expected call of SignIn.
-func (mr *MockServicesMockRecorder) SignIn(user interface{}) *gomock.Call {
+func (mr *MockServicesMockRecorder) SignIn(ctx, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockServices)(nil).SignIn), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockServices)(nil).SignIn), ctx, user)
+}
+
+// SignInMFA mocks base method.
+func (m *MockServices) SignInMFA(ctx context.Context, mfaToken, code string, client *models.ClientInfo) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignInMFA", ctx, mfaToken, code, client)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignInMFA indicates an expected call of SignInMFA.
+func (mr *MockServicesMockRecorder) SignInMFA(ctx, mfaToken, code, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignInMFA", reflect.TypeOf((*MockServices)(nil).SignInMFA), ctx, mfaToken, code, client)
 }
 
 // SignUp mocks base method.
-func (m *MockServices) SignUp(user *models.UserSignUpInput) error {
+func (m *MockServices) SignUp(ctx context.Context, user *models.UserSignUpInput) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignUp", user)
+	ret := m.ctrl.Call(m, "SignUp", ctx, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SignUp indicates an expected call of SignUp.
-func (mr *MockServicesMockRecorder) SignUp(user interface{}) *gomock.Call {
+func (mr *MockServicesMockRecorder) SignUp(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockServices)(nil).SignUp), ctx, user)
+}
+
+// SwitchOrganization mocks base method.
+func (m *MockServices) SwitchOrganization(ctx context.Context, principal *models.Principal, orgID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SwitchOrganization", ctx, principal, orgID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SwitchOrganization indicates an expected call of SwitchOrganization.
+func (mr *MockServicesMockRecorder) SwitchOrganization(ctx, principal, orgID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwitchOrganization", reflect.TypeOf((*MockServices)(nil).SwitchOrganization), ctx, principal, orgID)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockServices) UpdateProfile(ctx context.Context, userID string, input *models.ProfileUpdateInput) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, userID, input)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockServicesMockRecorder) UpdateProfile(ctx, userID, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockServices)(nil).SignUp), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockServices)(nil).UpdateProfile), ctx, userID, input)
 }
 
 // UploadFile mocks base method.
-func (m *MockServices) UploadFile(file *models.FileUploadInput) error {
+func (m *MockServices) UploadFile(ctx context.Context, file *models.FileUploadInput) (*models.FileOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadFile", ctx, file)
+	ret0, _ := ret[0].(*models.FileOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadFile indicates an expected call of UploadFile.
+func (mr *MockServicesMockRecorder) UploadFile(ctx, file interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockServices)(nil).UploadFile), ctx, file)
+}
+
+// UserStats mocks base method.
+func (m *MockServices) UserStats(ctx context.Context, userID string) (*models.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserStats", ctx, userID)
+	ret0, _ := ret[0].(*models.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserStats indicates an expected call of UserStats.
+func (mr *MockServicesMockRecorder) UserStats(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserStats", reflect.TypeOf((*MockServices)(nil).UserStats), ctx, userID)
+}
+
+// Users mocks base method.
+func (m *MockServices) Users(ctx context.Context, filter *models.UsersFilter) (*models.UsersPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Users", ctx, filter)
+	ret0, _ := ret[0].(*models.UsersPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Users indicates an expected call of Users.
+func (mr *MockServicesMockRecorder) Users(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Users", reflect.TypeOf((*MockServices)(nil).Users), ctx, filter)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockServices) VerifyEmail(ctx context.Context, token string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UploadFile", file)
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UploadFile indicates an expected call of UploadFile.
-func (mr *MockServicesMockRecorder) UploadFile(file interface{}) *gomock.Call {
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockServicesMockRecorder) VerifyEmail(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockServices)(nil).VerifyEmail), ctx, token)
+}
+
+// WebhookDeliveries mocks base method.
+func (m *MockServices) WebhookDeliveries(ctx context.Context, webhookID string) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WebhookDeliveries", ctx, webhookID)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WebhookDeliveries indicates an expected call of WebhookDeliveries.
+func (mr *MockServicesMockRecorder) WebhookDeliveries(ctx, webhookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WebhookDeliveries", reflect.TypeOf((*MockServices)(nil).WebhookDeliveries), ctx, webhookID)
+}
+
+// Webhooks mocks base method.
+func (m *MockServices) Webhooks(ctx context.Context) ([]models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Webhooks", ctx)
+	ret0, _ := ret[0].([]models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Webhooks indicates an expected call of Webhooks.
+func (mr *MockServicesMockRecorder) Webhooks(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadFile", reflect.TypeOf((*MockServices)(nil).UploadFile), file)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Webhooks", reflect.TypeOf((*MockServices)(nil).Webhooks), ctx)
 }
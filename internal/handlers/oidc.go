@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	oidcCookieAge   = 600 // Seconds, matches state token TTL
+)
+
+func (h *Handlers) OIDCLogin(c *gin.Context) {
+	output, err := h.services.OIDCLogin(c.Request.Context(), c.Param("provider"))
+	if errors.Is(err, models.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, textToMap("identity provider unavailable"))
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, output.State, oidcCookieAge, "/oidc", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, output.URL)
+}
+
+func (h *Handlers) OIDCCallback(c *gin.Context) {
+	signedState, err := c.Cookie(oidcStateCookie)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap(models.ErrInvalidOIDCState.Error()))
+		return
+	}
+
+	// State is single-use
+	c.SetCookie(oidcStateCookie, "", -1, "/oidc", "", c.Request.TLS != nil, true)
+
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, textToMap(fmt.Sprintf("identity provider error - %s", providerError)))
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	result, err := h.services.OIDCCallback(c.Request.Context(), c.Param("provider"), signedState, c.Query("state"), code, clientInfo(c))
+	if err != nil {
+		h.oidcError(c, err)
+		return
+	}
+
+	if result.MFARequired {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	c.Header("Authorization", fmt.Sprintf("Bearer %s", result.Token))
+	c.JSON(http.StatusOK, map[string]string{"token": result.Token})
+}
+
+func (h *Handlers) oidcError(c *gin.Context, err error) {
+	if isUnavailable(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, models.ErrUnknownProvider):
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+	case errors.Is(err, models.ErrInvalidOIDCState):
+		c.JSON(http.StatusBadRequest, textToMap(err.Error()))
+	case errors.Is(err, models.ErrUserDisabled), errors.Is(err, models.ErrExternalEmailNotVerified):
+		c.JSON(http.StatusForbidden, textToMap(err.Error()))
+	default:
+		c.JSON(http.StatusUnauthorized, textToMap("external sign in failed"))
+	}
+}
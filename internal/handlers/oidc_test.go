@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OIDCCallback(t *testing.T) {
+	testTable := []struct {
+		name           string
+		query          string
+		cookie         string
+		behavior       func(s *mock_handlers.MockServices)
+		statusCode     int
+		outHeaderValue string
+	}{
+		{
+			name:   "OK",
+			query:  "?code=code&state=state",
+			cookie: "signed",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().OIDCCallback(gomock.Any(), "stub", "signed", "state", "code", gomock.Any()).Return(&models.SignInResult{Token: "token"}, nil)
+			},
+			statusCode:     200,
+			outHeaderValue: "Bearer token",
+		},
+		{
+			name:       "ERROR: missing state cookie",
+			query:      "?code=code&state=state",
+			behavior:   func(s *mock_handlers.MockServices) {},
+			statusCode: 400,
+		},
+		{
+			name:       "ERROR: provider error",
+			query:      "?error=access_denied&state=state",
+			cookie:     "signed",
+			behavior:   func(s *mock_handlers.MockServices) {},
+			statusCode: 401,
+		},
+		{
+			name:   "ERROR: email not verified",
+			query:  "?code=code&state=state",
+			cookie: "signed",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().OIDCCallback(gomock.Any(), "stub", "signed", "state", "code", gomock.Any()).Return(nil, models.ErrExternalEmailNotVerified)
+			},
+			statusCode: 403,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.Default()
+			r.GET("/oidc/:provider/callback", handlers.OIDCCallback)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/oidc/stub/callback"+test.query, nil)
+			if test.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: test.cookie})
+			}
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Result().StatusCode)
+			assert.Equal(t, test.outHeaderValue, w.Header().Get("Authorization"))
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const orgKey = "orgId" // Active organization of access token, empty for personal space
+
+func (h *Handlers) CreateOrganization(c *gin.Context) {
+	var input models.OrganizationInput
+
+	err := c.BindJSON(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	org, err := h.services.CreateOrganization(c.Request.Context(), c.GetString(h.userHeaderName), &input)
+	if err != nil {
+		h.orgError(c, err, "error creating organization")
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+func (h *Handlers) Organizations(c *gin.Context) {
+	orgs, err := h.services.Organizations(c.Request.Context(), c.GetString(h.userHeaderName))
+	if err != nil {
+		h.orgError(c, err, "error getting organizations")
+		return
+	}
+
+	c.JSON(http.StatusOK, orgs)
+}
+
+func (h *Handlers) Organization(c *gin.Context) {
+	org, err := h.services.Organization(c.Request.Context(), c.GetString(h.userHeaderName), c.Param("id"))
+	if err != nil {
+		h.orgError(c, err, "error getting organization")
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func (h *Handlers) InviteMember(c *gin.Context) {
+	var input models.InvitationInput
+
+	err := c.BindJSON(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	if !isValidEmail(input.Email) {
+		c.JSON(http.StatusBadRequest, textToMap(models.ErrInvalidEmail.Error()))
+		return
+	}
+
+	err = h.services.InviteMember(c.Request.Context(), c.GetString(h.userHeaderName), c.Param("id"), &input)
+	if err != nil {
+		h.orgError(c, err, "error sending invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("invitation sent"))
+}
+
+func (h *Handlers) AcceptInvitation(c *gin.Context) {
+	var input models.InvitationAcceptInput
+
+	err := c.BindJSON(&input)
+	if err != nil || input.Token == "" {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	org, err := h.services.AcceptInvitation(c.Request.Context(), c.GetString(h.userHeaderName), input.Token)
+	if err != nil {
+		h.orgError(c, err, "error accepting invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+func (h *Handlers) SetMemberRole(c *gin.Context) {
+	var input models.MemberRoleInput
+
+	err := c.BindJSON(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	err = h.services.SetMemberRole(c.Request.Context(), c.GetString(h.userHeaderName), c.Param("id"), c.Param("userId"), input.Role)
+	if err != nil {
+		h.orgError(c, err, "error updating member")
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("success"))
+}
+
+func (h *Handlers) RemoveMember(c *gin.Context) {
+	err := h.services.RemoveMember(c.Request.Context(), c.GetString(h.userHeaderName), c.Param("id"), c.Param("userId"))
+	if err != nil {
+		h.orgError(c, err, "error removing member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SwitchOrganization returns token with another active organization, empty orgId switches to personal space
+func (h *Handlers) SwitchOrganization(c *gin.Context) {
+	var input models.OrgSwitchInput
+
+	err := c.BindJSON(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	token, err := h.services.SwitchOrganization(c.Request.Context(), h.principal(c), input.OrgID)
+	if err != nil {
+		h.orgError(c, err, "error switching organization")
+		return
+	}
+
+	c.Header("Authorization", fmt.Sprintf("Bearer %s", token))
+	c.JSON(http.StatusOK, map[string]string{"token": token})
+}
+
+func (h *Handlers) AdminSetOrganizationQuota(c *gin.Context) {
+	var input models.QuotaInput
+
+	err := c.BindJSON(&input)
+	if err != nil || input.Quota < 0 {
+		c.JSON(http.StatusBadRequest, textToMap("invalid quota"))
+		return
+	}
+
+	err = h.services.SetOrganizationQuota(c.Request.Context(), c.Param("id"), input.Quota)
+	if err != nil {
+		h.orgError(c, err, "error updating quota")
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("success"))
+}
+
+func (h *Handlers) orgError(c *gin.Context, err error, message string) {
+	if isUnavailable(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, models.ErrInvalidOrgName), errors.Is(err, models.ErrInvalidOrgRole),
+		errors.Is(err, models.ErrInvalidInvitation):
+		c.JSON(http.StatusBadRequest, textToMap(err.Error()))
+	case errors.Is(err, models.ErrNotOrgMember), errors.Is(err, models.ErrOrgForbidden):
+		c.JSON(http.StatusForbidden, textToMap(err.Error()))
+	case errors.Is(err, models.ErrOrganizationNotFound), errors.Is(err, models.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+	case errors.Is(err, models.ErrAlreadyMember), errors.Is(err, models.ErrLastOwner):
+		c.JSON(http.StatusConflict, textToMap(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, textToMap(message))
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SwitchOrganization(t *testing.T) {
+	testTable := []struct {
+		name       string
+		body       string
+		behavior   func(s *mock_handlers.MockServices)
+		statusCode int
+	}{
+		{
+			name: "OK",
+			body: `{"orgId":"org1"}`,
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().SwitchOrganization(gomock.Any(), &models.Principal{UserID: "1", SessionID: "s1"}, "org1").Return("org-token", nil)
+			},
+			statusCode: 200,
+		},
+		{
+			name: "ERROR: not a member",
+			body: `{"orgId":"org2"}`,
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().SwitchOrganization(gomock.Any(), gomock.Any(), "org2").Return("", models.ErrNotOrgMember)
+			},
+			statusCode: 403,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().ParseToken(gomock.Any(), "token").Return(&models.Principal{UserID: "1", SessionID: "s1"}, nil)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.Default()
+			r.POST("/me/organization", handlers.AuthMiddleware, handlers.SwitchOrganization)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/me/organization", bytes.NewBufferString(test.body))
+			req.Header.Add("Authorization", "Bearer token")
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_UploadFileToOrganization(t *testing.T) {
+	testTable := []struct {
+		name       string
+		err        error
+		statusCode int
+	}{
+		{
+			name:       "OK",
+			statusCode: 202,
+		},
+		{
+			name:       "ERROR: viewer",
+			err:        models.ErrOrgForbidden,
+			statusCode: 403,
+		},
+		{
+			name:       "ERROR: quota exceeded",
+			err:        models.ErrQuotaExceeded,
+			statusCode: 413,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().ParseToken(gomock.Any(), "token").Return(&models.Principal{UserID: "1", SessionID: "s1", OrgID: "org1"}, nil)
+			services.EXPECT().UploadFile(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, file *models.FileUploadInput) (*models.FileOut, error) {
+				assert.Equal(t, "org1", file.OrgId)
+				if test.err != nil {
+					return nil, test.err
+				}
+				return &models.FileOut{OrgId: file.OrgId, Status: models.FileProcessing}, nil
+			})
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.Default()
+			r.POST("/upload", handlers.AuthMiddleware, handlers.UploadFile)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/upload", bytes.NewBufferString("png"))
+			req.Header.Add("Authorization", "Bearer token")
+			req.Header.Add("Content-Type", "image/png")
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Result().StatusCode)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) ForgotPassword(c *gin.Context) {
+	var input models.PasswordForgotInput
+
+	err := c.BindJSON(&input)
+	if err != nil || input.Email == "" {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	err = h.services.ForgotPassword(c.Request.Context(), input.Email)
+	if isUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error while sending reset email"))
+		return
+	}
+
+	// The same answer for existing and unknown emails
+	c.JSON(http.StatusOK, textToMap("reset link sent if the account exists"))
+}
+
+// resetPasswordForm is served by the reset link when no frontend page is configured,
+// the token stays in the query and the form posts JSON to POST /password/reset
+const resetPasswordForm = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Password reset</title></head>
+<body>
+<form id="reset">
+<label>New password <input type="password" name="password" required></label>
+<button type="submit">Set password</button>
+</form>
+<p id="result"></p>
+<script>
+document.getElementById("reset").addEventListener("submit", function (event) {
+	event.preventDefault();
+	var token = new URLSearchParams(window.location.search).get("token") || "";
+	fetch(window.location.pathname, {
+		method: "POST",
+		headers: {"Content-Type": "application/json"},
+		body: JSON.stringify({token: token, password: event.target.password.value})
+	}).then(function (response) { return response.json(); }).then(function (body) {
+		document.getElementById("result").textContent = body.message;
+	});
+});
+</script>
+</body>
+</html>
+`
+
+func (h *Handlers) ResetPasswordForm(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(resetPasswordForm))
+}
+
+func (h *Handlers) ResetPassword(c *gin.Context) {
+	var input models.PasswordResetInput
+
+	err := c.BindJSON(&input)
+	if err != nil || input.Token == "" || input.Password == "" {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	passwordHash, err := h.hasher.Hash(input.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error while encrypting password"))
+		return
+	}
+
+	err = h.services.ResetPassword(c.Request.Context(), input.Token, passwordHash)
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrInvalidResetToken) {
+		c.JSON(http.StatusBadRequest, textToMap("invalid or expired token"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error while updating password"))
+		return
+	}
+
+	c.JSON(http.StatusOK, textToMap("password updated"))
+}
+
+func (h *Handlers) ChangePassword(c *gin.Context) {
+	var input models.PasswordChangeInput
+
+	err := c.BindJSON(&input)
+	if err != nil || input.CurrentPassword == "" || input.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	currentPasswordHash, err := h.hasher.Hash(input.CurrentPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error while encrypting password"))
+		return
+	}
+
+	newPasswordHash, err := h.hasher.Hash(input.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error while encrypting password"))
+		return
+	}
+
+	token, err := h.services.ChangePassword(c.Request.Context(), c.GetString(h.userHeaderName), currentPasswordHash, newPasswordHash, clientInfo(c))
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrWrongPassword) {
+		c.JSON(http.StatusBadRequest, textToMap("wrong current password"))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error while updating password"))
+		return
+	}
+
+	// Other sessions are revoked, so the client gets a new token
+	c.Header("Authorization", fmt.Sprintf("Bearer %s", token))
+	c.JSON(http.StatusOK, map[string]string{"token": token})
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResetPassword(t *testing.T) {
+	testTable := []struct {
+		name          string
+		bodyInput     string
+		behavior      func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices)
+		outStatusCode int
+		outMessage    string
+	}{
+		{
+			name:      "OK",
+			bodyInput: `{"token": "token", "password": "qwerty"}`,
+			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices) {
+				mh.EXPECT().Hash("qwerty").Return("ytrewq", nil)
+				s.EXPECT().ResetPassword(gomock.Any(), "token", "ytrewq").Return(nil)
+			},
+			outStatusCode: 200,
+			outMessage:    `{"message":"password updated"}`,
+		},
+		{
+			name:          "ERROR: empty password",
+			bodyInput:     `{"token": "token", "password": ""}`,
+			behavior:      func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices) {},
+			outStatusCode: 400,
+			outMessage:    `{"message":"invalid input"}`,
+		},
+		{
+			name:      "ERROR: expired token",
+			bodyInput: `{"token": "token", "password": "qwerty"}`,
+			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices) {
+				mh.EXPECT().Hash("qwerty").Return("ytrewq", nil)
+				s.EXPECT().ResetPassword(gomock.Any(), "token", "ytrewq").Return(models.ErrInvalidResetToken)
+			},
+			outStatusCode: 400,
+			outMessage:    `{"message":"invalid or expired token"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hasher := mock_handlers.NewMockHasher(ctrl)
+			services := mock_handlers.NewMockServices(ctrl)
+
+			test.behavior(hasher, services)
+
+			handlers := New(services, 100000, hasher, "Authorization", "userId")
+
+			r := gin.New()
+			r.POST("/password/reset", handlers.ResetPassword)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/password/reset", bytes.NewBufferString(test.bodyInput))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outMessage, w.Body.String())
+		})
+	}
+}
+
+func Test_ResetPasswordForm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handlers := New(mock_handlers.NewMockServices(ctrl), 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+	r := gin.New()
+	r.GET("/password/reset", handlers.ResetPasswordForm)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/password/reset?token=token", nil)
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	assert.Contains(t, w.Body.String(), `<form id="reset">`)
+}
+
+func Test_ChangePassword(t *testing.T) {
+	testTable := []struct {
+		name           string
+		bodyInput      string
+		behavior       func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices)
+		outStatusCode  int
+		outMessage     string
+		outHeaderValue string
+	}{
+		{
+			name:      "OK",
+			bodyInput: `{"currentPassword": "old", "newPassword": "new"}`,
+			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices) {
+				mh.EXPECT().Hash("old").Return("dlo", nil)
+				mh.EXPECT().Hash("new").Return("wen", nil)
+				s.EXPECT().ChangePassword(gomock.Any(), "1", "dlo", "wen", gomock.Any()).Return("token", nil)
+			},
+			outStatusCode:  200,
+			outMessage:     `{"token":"token"}`,
+			outHeaderValue: "Bearer token",
+		},
+		{
+			name:      "ERROR: wrong current password",
+			bodyInput: `{"currentPassword": "old", "newPassword": "new"}`,
+			behavior: func(mh *mock_handlers.MockHasher, s *mock_handlers.MockServices) {
+				mh.EXPECT().Hash("old").Return("dlo", nil)
+				mh.EXPECT().Hash("new").Return("wen", nil)
+				s.EXPECT().ChangePassword(gomock.Any(), "1", "dlo", "wen", gomock.Any()).Return("", models.ErrWrongPassword)
+			},
+			outStatusCode: 400,
+			outMessage:    `{"message":"wrong current password"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			hasher := mock_handlers.NewMockHasher(ctrl)
+			services := mock_handlers.NewMockServices(ctrl)
+
+			test.behavior(hasher, services)
+
+			handlers := New(services, 100000, hasher, "Authorization", "userId")
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("userId", "1")
+			})
+			r.POST("/password/change", handlers.ChangePassword)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/password/change", bytes.NewBufferString(test.bodyInput))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outMessage, w.Body.String())
+			assert.Equal(t, test.outHeaderValue, w.Header().Get("Authorization"))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) Profile(c *gin.Context) {
+	user, err := h.services.Profile(c.Request.Context(), c.GetString(h.userHeaderName))
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error getting profile"))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handlers) UpdateProfile(c *gin.Context) {
+	var input models.ProfileUpdateInput
+
+	err := c.BindJSON(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	user, err := h.services.UpdateProfile(c.Request.Context(), c.GetString(h.userHeaderName), &input)
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrInvalidDisplayName) || errors.Is(err, models.ErrAvatarNotFound) {
+		c.JSON(http.StatusBadRequest, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error updating profile"))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handlers) DeleteAccount(c *gin.Context) {
+	err := h.services.DeleteUser(c.Request.Context(), c.GetString(h.userHeaderName))
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	if errors.Is(err, models.ErrLastOwner) {
+		c.JSON(http.StatusConflict, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error deleting account"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ExportData streams ZIP archive, after the first written byte errors can only be logged
+func (h *Handlers) ExportData(c *gin.Context) {
+	userID := c.GetString(h.userHeaderName)
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%s.zip"`, time.Now().Format("20060102")))
+
+	err := h.services.ExportData(c.Request.Context(), userID, c.Writer)
+	if err == nil {
+		return
+	}
+
+	if c.Writer.Written() {
+		log.Printf("export for user %s interrupted - %s\n", userID, err.Error())
+		c.Abort()
+		return
+	}
+
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, textToMap("error exporting data"))
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UpdateProfile(t *testing.T) {
+	testTable := []struct {
+		name       string
+		body       string
+		behavior   func(s *mock_handlers.MockServices)
+		statusCode int
+	}{
+		{
+			name: "OK",
+			body: `{"displayName":"Jane"}`,
+			behavior: func(s *mock_handlers.MockServices) {
+				name := "Jane"
+				s.EXPECT().UpdateProfile(gomock.Any(), "1", &models.ProfileUpdateInput{DisplayName: &name}).Return(&models.User{DisplayName: name}, nil)
+			},
+			statusCode: 200,
+		},
+		{
+			name:       "ERROR: invalid json",
+			body:       `{"displayName":`,
+			behavior:   func(s *mock_handlers.MockServices) {},
+			statusCode: 400,
+		},
+		{
+			name: "ERROR: avatar not found",
+			body: `{"avatar":"2-1.png"}`,
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().UpdateProfile(gomock.Any(), "1", gomock.Any()).Return(nil, models.ErrAvatarNotFound)
+			},
+			statusCode: 400,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().ParseToken(gomock.Any(), "token").Return(&models.Principal{UserID: "1", SessionID: "s1"}, nil)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.Default()
+			r.PATCH("/me", handlers.AuthMiddleware, handlers.UpdateProfile)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PATCH", "/me", bytes.NewBufferString(test.body))
+			req.Header.Add("Authorization", "Bearer token")
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Result().StatusCode)
+		})
+	}
+}
+
+func Test_ExportData(t *testing.T) {
+	testTable := []struct {
+		name        string
+		behavior    func(s *mock_handlers.MockServices)
+		statusCode  int
+		contentType string
+	}{
+		{
+			name: "OK",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ExportData(gomock.Any(), "1", gomock.Any()).DoAndReturn(func(_ context.Context, userID string, w io.Writer) error {
+					_, err := w.Write([]byte("PK"))
+					return err
+				})
+			},
+			statusCode:  200,
+			contentType: "application/zip",
+		},
+		{
+			name: "ERROR: before streaming",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().ExportData(gomock.Any(), "1", gomock.Any()).Return(errors.New("db error"))
+			},
+			statusCode:  500,
+			contentType: "application/json; charset=utf-8",
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().ParseToken(gomock.Any(), "token").Return(&models.Principal{UserID: "1", SessionID: "s1"}, nil)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.Default()
+			r.GET("/me/export", handlers.AuthMiddleware, handlers.ExportData)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/me/export", nil)
+			req.Header.Add("Authorization", "Bearer token")
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Result().StatusCode)
+			assert.Equal(t, test.contentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const sessionKey = "sessionId" // Set in context for requests authorized by access token
+
+func (h *Handlers) Sessions(c *gin.Context) {
+	sessions, err := h.services.Sessions(c.Request.Context(), c.GetString(h.userHeaderName), c.GetString(sessionKey))
+	if isUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error getting sessions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *Handlers) RevokeSession(c *gin.Context) {
+	err := h.services.RevokeSession(c.Request.Context(), c.GetString(h.userHeaderName), c.Param("id"))
+	if isUnavailable(c, err) {
+		return
+	}
+	if errors.Is(err, models.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, textToMap("error revoking session"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Sessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	services := mock_handlers.NewMockServices(ctrl)
+	services.EXPECT().ParseToken(gomock.Any(), "token").Return(&models.Principal{UserID: "1", SessionID: "s1"}, nil)
+	services.EXPECT().Sessions(gomock.Any(), "1", "s1").Return([]models.Session{{UserAgent: "curl/8.0", Current: true}}, nil)
+
+	handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+	r := gin.Default()
+	r.GET("/me/sessions", handlers.AuthMiddleware, handlers.Sessions)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/me/sessions", nil)
+	req.Header.Add("Authorization", "Bearer token")
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Result().StatusCode)
+	assert.Contains(t, w.Body.String(), `"current":true`)
+}
+
+func Test_RevokeSession(t *testing.T) {
+	testTable := []struct {
+		name       string
+		behavior   func(s *mock_handlers.MockServices)
+		statusCode int
+	}{
+		{
+			name: "OK",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().RevokeSession(gomock.Any(), "1", "s2").Return(nil)
+			},
+			statusCode: 204,
+		},
+		{
+			name: "ERROR: session of another user",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().RevokeSession(gomock.Any(), "1", "s2").Return(models.ErrSessionNotFound)
+			},
+			statusCode: 404,
+		},
+		{
+			name: "ERROR: database timeout",
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().RevokeSession(gomock.Any(), "1", "s2").Return(context.DeadlineExceeded)
+			},
+			statusCode: 504,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.Default()
+			r.DELETE("/me/sessions/:id", func(c *gin.Context) {
+				c.Set("userId", "1")
+			}, handlers.RevokeSession)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/me/sessions/s2", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.statusCode, w.Result().StatusCode)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"creatly-task/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminCreateWebhook subscribes the URL to events, the secret is shown only in this response
+func (h *Handlers) AdminCreateWebhook(c *gin.Context) {
+	var input models.WebhookInput
+
+	err := c.BindJSON(&input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, textToMap("invalid input"))
+		return
+	}
+
+	output, err := h.services.CreateWebhook(c.Request.Context(), c.GetString(h.userHeaderName), &input)
+	if errors.Is(err, models.ErrInvalidWebhookURL) || errors.Is(err, models.ErrInvalidWebhookEvents) {
+		c.JSON(http.StatusBadRequest, textToMap(err.Error()))
+		return
+	}
+	if err != nil {
+		h.adminError(c, err, "error creating webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, output)
+}
+
+func (h *Handlers) AdminWebhooks(c *gin.Context) {
+	webhooks, err := h.services.Webhooks(c.Request.Context())
+	if err != nil {
+		h.adminError(c, err, "error getting webhooks")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (h *Handlers) AdminDeleteWebhook(c *gin.Context) {
+	err := h.services.DeleteWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.adminError(c, err, "error deleting webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AdminWebhookDeliveries lists the latest deliveries of the webhook with response codes of every attempt
+func (h *Handlers) AdminWebhookDeliveries(c *gin.Context) {
+	deliveries, err := h.services.WebhookDeliveries(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.adminError(c, err, "error getting deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// AdminRedeliver queues the delivery to be sent again
+func (h *Handlers) AdminRedeliver(c *gin.Context) {
+	err := h.services.Redeliver(c.Request.Context(), c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		h.adminError(c, err, "error redelivering webhook")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, textToMap("success"))
+}
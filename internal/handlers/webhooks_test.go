@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bytes"
+	mock_handlers "creatly-task/internal/handlers/mocks"
+	"creatly-task/internal/models"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_AdminCreateWebhook(t *testing.T) {
+	webhookID := primitive.NewObjectID()
+
+	testTable := []struct {
+		name          string
+		body          string
+		behavior      func(s *mock_handlers.MockServices)
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name: "OK",
+			body: `{"url":"https://example.com/hook","events":["file.uploaded"]}`,
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().CreateWebhook(gomock.Any(), "1", &models.WebhookInput{URL: "https://example.com/hook", Events: []string{models.EventFileUploaded}}).
+					Return(&models.WebhookCreated{
+						Webhook: models.Webhook{ID: webhookID, URL: "https://example.com/hook", Secret: "whsec_secret", Events: []string{models.EventFileUploaded}, CreatedBy: "1", CreatedAt: 1},
+						Secret:  "whsec_secret",
+					}, nil)
+			},
+			outStatusCode: 201,
+			outBody:       `{"id":"` + webhookID.Hex() + `","url":"https://example.com/hook","events":["file.uploaded"],"createdBy":"1","createdAt":1,"secret":"whsec_secret"}`,
+		},
+		{
+			name:          "ERROR: invalid input",
+			body:          `{"url":`,
+			behavior:      func(s *mock_handlers.MockServices) {},
+			outStatusCode: 400,
+			outBody:       `{"message":"invalid input"}`,
+		},
+		{
+			name: "ERROR: invalid events",
+			body: `{"url":"https://example.com/hook","events":["file.renamed"]}`,
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().CreateWebhook(gomock.Any(), "1", gomock.Any()).Return(nil, models.ErrInvalidWebhookEvents)
+			},
+			outStatusCode: 400,
+			outBody:       `{"message":"` + models.ErrInvalidWebhookEvents.Error() + `"}`,
+		},
+		{
+			name: "ERROR: service error",
+			body: `{"url":"https://example.com/hook","events":["file.uploaded"]}`,
+			behavior: func(s *mock_handlers.MockServices) {
+				s.EXPECT().CreateWebhook(gomock.Any(), "1", gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			outStatusCode: 500,
+			outBody:       `{"message":"error creating webhook"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			test.behavior(services)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("userId", "1")
+			})
+			r.POST("/admin/webhooks", handlers.AdminCreateWebhook)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/webhooks", bytes.NewBufferString(test.body))
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_AdminDeleteWebhook(t *testing.T) {
+	testTable := []struct {
+		name          string
+		err           error
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name:          "OK",
+			outStatusCode: 204,
+		},
+		{
+			name:          "ERROR: webhook not found",
+			err:           models.ErrWebhookNotFound,
+			outStatusCode: 404,
+			outBody:       `{"message":"` + models.ErrWebhookNotFound.Error() + `"}`,
+		},
+		{
+			name:          "ERROR: service error",
+			err:           errors.New("db error"),
+			outStatusCode: 500,
+			outBody:       `{"message":"error deleting webhook"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().DeleteWebhook(gomock.Any(), "hook1").Return(test.err)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.DELETE("/admin/webhooks/:id", handlers.AdminDeleteWebhook)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/admin/webhooks/hook1", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_AdminWebhookDeliveries(t *testing.T) {
+	deliveryID := primitive.NewObjectID()
+
+	testTable := []struct {
+		name          string
+		deliveries    []models.WebhookDelivery
+		err           error
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name: "OK",
+			deliveries: []models.WebhookDelivery{{
+				ID:        deliveryID,
+				WebhookID: "hook1",
+				Event:     models.EventUserCreated,
+				Payload:   `{}`,
+				Status:    models.DeliveryDelivered,
+				Attempts:  []models.DeliveryAttempt{{At: 1, Error: "timeout", DurationMs: 10}, {At: 2, StatusCode: 200, DurationMs: 5}},
+				CreatedAt: 1,
+			}},
+			outStatusCode: 200,
+			outBody: `[{"id":"` + deliveryID.Hex() + `","webhookId":"hook1","event":"user.created","payload":"{}","status":"delivered",` +
+				`"attempts":[{"at":1,"error":"timeout","durationMs":10},{"at":2,"statusCode":200,"durationMs":5}],"createdAt":1}]`,
+		},
+		{
+			name:          "ERROR: webhook not found",
+			err:           models.ErrWebhookNotFound,
+			outStatusCode: 404,
+			outBody:       `{"message":"` + models.ErrWebhookNotFound.Error() + `"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().WebhookDeliveries(gomock.Any(), "hook1").Return(test.deliveries, test.err)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.GET("/admin/webhooks/:id/deliveries", handlers.AdminWebhookDeliveries)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/webhooks/hook1/deliveries", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
+
+func Test_AdminRedeliver(t *testing.T) {
+	testTable := []struct {
+		name          string
+		err           error
+		outStatusCode int
+		outBody       string
+	}{
+		{
+			name:          "OK",
+			outStatusCode: 202,
+			outBody:       `{"message":"success"}`,
+		},
+		{
+			name:          "ERROR: delivery not found",
+			err:           models.ErrDeliveryNotFound,
+			outStatusCode: 404,
+			outBody:       `{"message":"` + models.ErrDeliveryNotFound.Error() + `"}`,
+		},
+		{
+			name:          "ERROR: service error",
+			err:           errors.New("db error"),
+			outStatusCode: 500,
+			outBody:       `{"message":"error redelivering webhook"}`,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			services := mock_handlers.NewMockServices(ctrl)
+			services.EXPECT().Redeliver(gomock.Any(), "hook1", "delivery1").Return(test.err)
+
+			handlers := New(services, 100000, mock_handlers.NewMockHasher(ctrl), "Authorization", "userId")
+
+			r := gin.New()
+			r.POST("/admin/webhooks/:id/deliveries/:deliveryId/redeliver", handlers.AdminRedeliver)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/admin/webhooks/hook1/deliveries/delivery1/redeliver", nil)
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.outStatusCode, w.Code)
+			assert.Equal(t, test.outBody, w.Body.String())
+		})
+	}
+}
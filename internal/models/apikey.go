@@ -0,0 +1,37 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// API key scopes. Keys without a scope get 403 on the matching routes
+const (
+	ScopeFilesRead   = "files:read"
+	ScopeFilesUpload = "files:upload"
+	ScopeFilesDelete = "files:delete"
+)
+
+var APIKeyScopes = []string{ScopeFilesRead, ScopeFilesUpload, ScopeFilesDelete}
+
+// APIKey is stored without the key itself, Prefix is kept to recognize the key in lists
+type APIKey struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     string             `json:"-" bson:"userId"`
+	Name       string             `json:"name" bson:"name"`
+	Prefix     string             `json:"prefix" bson:"prefix"`
+	KeyHash    string             `json:"-" bson:"keyHash"`
+	Scopes     []string           `json:"scopes" bson:"scopes"`
+	CreatedAt  int64              `json:"createdAt" bson:"createdAt"`
+	ExpiresAt  int64              `json:"expiresAt,omitempty" bson:"expiresAt"` // 0 - never expires
+	LastUsedAt int64              `json:"lastUsedAt,omitempty" bson:"lastUsedAt"`
+}
+
+type APIKeyInput struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt int64    `json:"expiresAt"` // Unix time, optional
+}
+
+// APIKeyCreated contains the key itself, it is shown only once
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type LoginAttempts struct {
+	Key           string `bson:"_id"`
+	Failures      int    `bson:"failures"`
+	LastFailureAt int64  `bson:"lastFailureAt"`
+	LockedUntil   int64  `bson:"lockedUntil"`
+}
+
+// LockedError is returned when sign-in is temporary blocked after too many failed attempts
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed attempts, retry after %s", e.RetryAfter)
+}
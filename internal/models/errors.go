@@ -0,0 +1,68 @@
+package models
+
+import "errors"
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUserExists        = errors.New("user exists")
+	ErrUserDisabled      = errors.New("user disabled")
+	ErrWrongPassword     = errors.New("wrong password")
+	ErrSessionRevoked    = errors.New("session revoked")
+	ErrInvalidResetToken = errors.New("invalid reset token")
+
+	ErrInvalidEmail    = errors.New("invalid email")
+	ErrUserNotVerified = errors.New("user email not verified")
+
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication not enabled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+
+	ErrSessionNotFound = errors.New("session not found")
+
+	ErrInvalidDisplayName = errors.New("invalid display name")
+	ErrAvatarNotFound     = errors.New("avatar file not found")
+
+	ErrAPIKeyNotFound    = errors.New("api key not found")
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrAPIKeyExpired     = errors.New("api key expired")
+	ErrInvalidScope      = errors.New("invalid api key scope")
+	ErrInsufficientScope = errors.New("api key scope not allowed")
+	ErrInvalidExpiry     = errors.New("api key expiry in the past")
+
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrNotOrgMember         = errors.New("not a member of the organization")
+	ErrOrgForbidden         = errors.New("organization role doesn't allow the action")
+	ErrInvalidOrgName       = errors.New("invalid organization name")
+	ErrInvalidOrgRole       = errors.New("invalid organization role")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrAlreadyMember        = errors.New("already a member of the organization")
+	ErrLastOwner            = errors.New("organization must keep at least one owner")
+	ErrInvalidInvitation    = errors.New("invalid or expired invitation")
+	ErrQuotaExceeded        = errors.New("organization storage quota exceeded")
+
+	ErrUnknownProvider          = errors.New("unknown identity provider")
+	ErrInvalidOIDCState         = errors.New("invalid login state")
+	ErrExternalEmailNotVerified = errors.New("email not verified by identity provider")
+
+	ErrIdempotencyKeyNotFound   = errors.New("idempotency key not found")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is in progress")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was used for another request")
+
+	ErrFileNotFound = errors.New("file not found")
+	ErrNoJobs       = errors.New("no jobs due")
+	ErrJobNotFound  = errors.New("job not found")
+	ErrJobLeaseLost = errors.New("job lease is taken by another worker")
+
+	ErrFileForbidden        = errors.New("not allowed to delete this file")
+	ErrWebhookNotFound      = errors.New("webhook not found")
+	ErrDeliveryNotFound     = errors.New("delivery not found")
+	ErrInvalidWebhookURL    = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidWebhookEvents = errors.New("unknown or missing webhook events")
+
+	ErrOutboxEmpty             = errors.New("no outbox events due")
+	ErrOutboxEventNotFound     = errors.New("outbox event not found")
+	ErrTransactionsUnsupported = errors.New("database doesn't support transactions")
+
+	ErrFileQuarantined    = errors.New("file is quarantined")
+	ErrScannerUnavailable = errors.New("file scanner is unavailable, try again later")
+)
@@ -0,0 +1,13 @@
+package models
+
+// Events of files streamed to clients of the file space
+const (
+	FileEventCreated = "file.created"
+	FileEventUpdated = "file.updated" // Processing finished or failed
+	FileEventDeleted = "file.deleted"
+)
+
+type FileEvent struct {
+	Type string   `json:"type"`
+	File *FileOut `json:"file"`
+}
@@ -1,24 +1,107 @@
 package models
 
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// File statuses. Uploaded file is processing until its job is done.
+// Quarantined file was flagged by the scanner, it isn't stored and isn't listed
+const (
+	FileProcessing  = "processing"
+	FileReady       = "ready"
+	FileFailed      = "failed"
+	FileQuarantined = "quarantined"
+)
+
 type FileOut struct {
-	Filename string `json:"filename",bson:"filename"`
-	Size     int    `json:"size",bson:"size"`
-	Date     int64  `json:"uploadDate",bson:"date"`
-	UserId   string `json:"userId",bson:"userId"`
-	Url      string `json:"url",bson:"url"`
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Filename string             `json:"filename" bson:"filename"`
+	Size     int                `json:"size" bson:"size"`
+	Date     int64              `json:"uploadDate" bson:"date"`
+	UserId   string             `json:"userId" bson:"userId"`
+	OrgId    string             `json:"orgId,omitempty" bson:"orgId,omitempty"`
+	Url      string             `json:"url" bson:"url"`
+	Status   string             `json:"status" bson:"status"`
+	Image    *ImageInfo         `json:"image,omitempty" bson:"image,omitempty"`
+	Error    string             `json:"error,omitempty" bson:"error,omitempty"` // Why processing failed
+}
+
+// ScanResult is the verdict of the content scanner
+type ScanResult struct {
+	Infected bool
+	Threat   string // Signature name, set when infected
+}
+
+// ImageInfo is extracted from the file by processing
+type ImageInfo struct {
+	Format string `json:"format" bson:"format"`
+	Width  int    `json:"width" bson:"width"`
+	Height int    `json:"height" bson:"height"`
+}
+
+// FileStatusUpdate is the outcome of file processing
+type FileStatusUpdate struct {
+	Status string
+	Image  *ImageInfo
+	Error  string
 }
 
 type FileUploadInput struct {
 	Filename string `json:"filename"`
 	Size     int64  `json:"size"`
 	UserId   string `json:"userId"`
+	OrgId    string `json:"orgId"` // Empty - personal space
 	FileData []byte
 }
 
 type FileUploadLogInput struct {
-	Size       int64  `bson:"size"`
-	UploadDate int64  `bson:"date"`
-	Filename   string `bson:"filename"`
-	UserId     string `bson:"userId"`
-	Url        string `bson:"url"`
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Size       int64              `bson:"size"`
+	UploadDate int64              `bson:"date"`
+	Filename   string             `bson:"filename"`
+	UserId     string             `bson:"userId"`
+	OrgId      string             `bson:"orgId,omitempty"`
+	Url        string             `bson:"url"`
+	Status     string             `bson:"status"`
+	Error      string             `bson:"error,omitempty"` // Threat found in quarantined file or why processing failed
+}
+
+// PendingUpload is written before the object is stored and removed when its metadata is recorded.
+// Records left by failed or interrupted uploads are resolved by the reconciler
+type PendingUpload struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Filename  string             `bson:"filename"`
+	Size      int64              `bson:"size"`
+	UserId    string             `bson:"userId"`
+	OrgId     string             `bson:"orgId,omitempty"`
+	CreatedAt int64              `bson:"createdAt"`
+}
+
+// StoredObject is an object in the cloud storage
+type StoredObject struct {
+	Key          string
+	Size         int64
+	LastModified int64
+	URL          string // The same as returned by the upload
+}
+
+// ReconcileReport counts what one reconciler run fixed
+type ReconcileReport struct {
+	StartedAt  int64 `json:"startedAt"`
+	FinishedAt int64 `json:"finishedAt"`
+	DryRun     bool  `json:"dryRun"`    // Nothing is removed, cleaned and orphans count what would be
+	Completed  int   `json:"completed"` // Pending uploads with recorded metadata, only the pending record is dropped
+	Recorded   int   `json:"recorded"`  // Interrupted uploads with stored object, the metadata is recorded
+	Cleaned    int   `json:"cleaned"`   // Interrupted uploads without complete object, what was stored is removed
+	Orphans    int   `json:"orphans"`   // Objects without metadata and pending upload, removed
+	Failed     int   `json:"failed"`    // Fixes failed, retried by the next run
+}
+
+// ReconcileStats sums reports of all runs since start
+type ReconcileStats struct {
+	Runs      int              `json:"runs"`
+	Completed int              `json:"completed"`
+	Recorded  int              `json:"recorded"`
+	Cleaned   int              `json:"cleaned"`
+	Orphans   int              `json:"orphans"`
+	Failed    int              `json:"failed"`
+	Last      *ReconcileReport `json:"last,omitempty"`
 }
@@ -0,0 +1,12 @@
+package models
+
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+)
+
+// Health is degraded while a circuit breaker of any backend isn't closed
+type Health struct {
+	Status   string            `json:"status"`
+	Backends map[string]string `json:"backends"` // Backend name - circuit state
+}
@@ -0,0 +1,18 @@
+package models
+
+// IdempotencyRecord keeps the outcome of the first request sent with an Idempotency-Key header,
+// retries with the same key get it instead of running again. Response is nil while the request is in progress
+type IdempotencyRecord struct {
+	Scope       string              `bson:"scope"` // User ID, empty for requests without authentication
+	Key         string              `bson:"key"`
+	Fingerprint string              `bson:"fingerprint"` // Hash of method, path and body
+	LockedUntil int64               `bson:"lockedUntil"` // Record of a crashed request is taken over after it
+	ExpiresAt   int64               `bson:"expiresAt"`
+	Response    *IdempotentResponse `bson:"response,omitempty"`
+}
+
+type IdempotentResponse struct {
+	Status      int    `bson:"status"`
+	ContentType string `bson:"contentType"`
+	Body        []byte `bson:"body"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// Identity links account to a user of external OpenID Connect provider
+type Identity struct {
+	Provider string `json:"provider" bson:"provider"`
+	Subject  string `json:"-" bson:"subject"`
+	Email    string `json:"email" bson:"email"`
+	LinkedAt int64  `json:"linkedAt" bson:"linkedAt"`
+}
+
+// OIDCLoginOutput contains provider login page url and signed state for the callback
+type OIDCLoginOutput struct {
+	URL   string
+	State string
+}
@@ -0,0 +1,27 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDead    = "dead" // Failed every attempt, kept until requeued by admin
+
+	JobProcessImage   = "process-image"   // Payload is the file ID
+	JobDeliverWebhook = "deliver-webhook" // Payload is the delivery ID
+)
+
+// Job is background work run by the worker pool. A running job is leased by one worker,
+// when the lease ends before the job is finished (crashed instance) another worker takes it over
+type Job struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Type        string             `json:"type" bson:"type"`
+	Payload     string             `json:"payload" bson:"payload"`
+	State       string             `json:"state" bson:"state"`
+	Attempts    int                `json:"attempts" bson:"attempts"` // Leases so far
+	RunAt       int64              `json:"runAt" bson:"runAt"`
+	LeasedBy    string             `json:"leasedBy,omitempty" bson:"leasedBy,omitempty"`
+	LeasedUntil int64              `json:"leasedUntil,omitempty" bson:"leasedUntil,omitempty"`
+	LastError   string             `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	CreatedAt   int64              `json:"createdAt" bson:"createdAt"`
+}
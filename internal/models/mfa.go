@@ -0,0 +1,33 @@
+package models
+
+type MFA struct {
+	Secret        string   `bson:"secret"`
+	Enabled       bool     `bson:"enabled"`
+	RecoveryCodes []string `bson:"recoveryCodes"` // Hashes of unused codes
+	LastUsedStep  int64    `bson:"lastUsedStep"`  // Protects from the same code reuse
+}
+
+type MFAEnrollOutput struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type MFACodeInput struct {
+	Code string `json:"code"`
+}
+
+type MFASignInInput struct {
+	MFAToken string `json:"mfaToken"`
+	Code     string `json:"code"`
+}
+
+type RecoveryCodesOutput struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// SignInResult contains access token, or pending token when the second factor is required
+type SignInResult struct {
+	Token       string `json:"token,omitempty"`
+	MFARequired bool   `json:"mfaRequired,omitempty"`
+	MFAToken    string `json:"mfaToken,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "strings"
+
+// ModerationInput is what the content policy knows about an upload. Format is empty when the content isn't a decodable image
+type ModerationInput struct {
+	UserID string
+	OrgID  string
+	Format string
+	Width  int
+	Height int
+}
+
+// Violation is a broken rule of the content policy
+type Violation struct {
+	Rule    string `json:"rule"` // Name of the policy field, like maxWidth
+	Message string `json:"message"`
+}
+
+// PolicyError rejects an upload which breaks the content policy, the client gets every violation
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return "upload violates content policy: " + strings.Join(messages, "; ")
+}
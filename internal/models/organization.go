@@ -0,0 +1,88 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Organization member roles. Owners manage members and invitations, editors upload, viewers only list files
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleEditor = "editor"
+	OrgRoleViewer = "viewer"
+)
+
+var OrgRoles = []string{OrgRoleOwner, OrgRoleEditor, OrgRoleViewer}
+
+type Organization struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Quota       int64              `json:"quota" bson:"quota"` // Bytes, 0 - unlimited
+	CreatedAt   int64              `json:"createdAt" bson:"createdAt"`
+	Members     []Member           `json:"members" bson:"members"`
+	Invitations []Invitation       `json:"-" bson:"invitations"`
+}
+
+// Member returns membership of the user or nil
+func (o *Organization) Member(userID string) *Member {
+	for i := range o.Members {
+		if o.Members[i].UserID == userID {
+			return &o.Members[i]
+		}
+	}
+	return nil
+}
+
+type Member struct {
+	UserID   string `json:"userId" bson:"userId"`
+	Email    string `json:"email" bson:"email"`
+	Role     string `json:"role" bson:"role"`
+	JoinedAt int64  `json:"joinedAt" bson:"joinedAt"`
+}
+
+// Invitation is accepted by the account with the same email, only the token hash is stored
+type Invitation struct {
+	Email     string `bson:"email"`
+	Role      string `bson:"role"`
+	TokenHash string `bson:"tokenHash"`
+	InvitedBy string `bson:"invitedBy"`
+	ExpiresAt int64  `bson:"expiresAt"`
+}
+
+// OrganizationOut is organization as seen by one of its members
+type OrganizationOut struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+	Quota int64  `json:"quota"`
+	Used  int64  `json:"used"` // Bytes
+}
+
+type OrganizationInput struct {
+	Name string `json:"name"`
+}
+
+type InvitationInput struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type InvitationAcceptInput struct {
+	Token string `json:"token"`
+}
+
+type MemberRoleInput struct {
+	Role string `json:"role"`
+}
+
+type OrgSwitchInput struct {
+	OrgID string `json:"orgId"` // Empty - personal space
+}
+
+type QuotaInput struct {
+	Quota int64 `json:"quota"`
+}
+
+// Principal is the caller of authenticated request
+type Principal struct {
+	UserID    string
+	SessionID string
+	OrgID     string // Active organization, empty for personal space
+}
@@ -0,0 +1,16 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// OutboxEvent is domain event saved in the transaction of its change, the relay publishes it at least once
+type OutboxEvent struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Type        string             `json:"type" bson:"type"`       // Event names of webhooks, like file.uploaded
+	Payload     string             `json:"payload" bson:"payload"` // JSON data of the event
+	AvailableAt int64              `json:"-" bson:"availableAt"`   // Claimed or failed events wait until it
+	Attempts    int                `json:"-" bson:"attempts"`      // Claims count
+	LastError   string             `json:"-" bson:"lastError"`     // Of the last failed publish
+	Dispatched  bool               `json:"-" bson:"dispatched"`    // Webhook deliveries are queued
+	PublishedAt int64              `json:"-" bson:"publishedAt"`   // 0 - not published yet
+	CreatedAt   int64              `json:"createdAt" bson:"createdAt"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Session is created on every sign-in, access tokens carry its id.
+// Deleted session invalidates its tokens before they expire
+type Session struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     string             `json:"-" bson:"userId"`
+	UserAgent  string             `json:"userAgent" bson:"userAgent"`
+	IP         string             `json:"ip" bson:"ip"`
+	CreatedAt  int64              `json:"createdAt" bson:"createdAt"`
+	LastSeenAt int64              `json:"lastSeenAt" bson:"lastSeenAt"`
+	ExpiresAt  int64              `json:"expiresAt" bson:"expiresAt"`
+	Current    bool               `json:"current" bson:"-"`
+}
+
+// ClientInfo describes the device a session is created for
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
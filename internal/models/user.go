@@ -2,19 +2,97 @@ package models
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type UserSignUpInput struct {
-	Email    string `json:"email",bson:"email"`
-	Password string `json:"password",bson:"password"`
+	ID        primitive.ObjectID `json:"-" bson:"_id,omitempty"`
+	Email     string             `json:"email" bson:"email"`
+	Password  string             `json:"password" bson:"password"`
+	Role      string             `json:"-" bson:"role"`
+	Verified  bool               `json:"-" bson:"verified"`
+	CreatedAt int64              `json:"-" bson:"createdAt"`
+
+	Identities []Identity `json:"-" bson:"identities,omitempty"`
 }
 
 type UserSignInInput struct {
 	Email        string `json:"email"`
 	PasswordHash string `json:"password"`
+	IP           string `json:"-"`
+	UserAgent    string `json:"-"`
 }
 
 type UserSignInOutput struct {
 	UserID primitive.ObjectID `bson:"_id"`
 	// UserID   string             `json:"id",bson:"_id"`
-	Email    string `json:"email",bson:"email"`
-	Password string `json:"password",bson:"password"`
+	Email    string `json:"email" bson:"email"`
+	Password string `json:"password" bson:"password"`
+	Disabled bool   `json:"disabled" bson:"disabled"`
+	MFA      *MFA   `json:"-" bson:"mfa,omitempty"`
+}
+
+type User struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Email     string             `json:"email" bson:"email"`
+	Password  string             `json:"-" bson:"password"`
+	Role      string             `json:"role" bson:"role"`
+	Disabled  bool               `json:"disabled" bson:"disabled"`
+	Verified  bool               `json:"verified" bson:"verified"`
+	CreatedAt int64              `json:"createdAt" bson:"createdAt"`
+
+	DisplayName string `json:"displayName,omitempty" bson:"displayName,omitempty"`
+	Avatar      string `json:"avatar,omitempty" bson:"avatar,omitempty"` // Filename of one of the user's uploaded files
+
+	SessionsRevokedAt int64               `json:"-" bson:"sessionsRevokedAt"` // Tokens issued before are invalid
+	PasswordReset     *PasswordResetToken `json:"-" bson:"passwordReset,omitempty"`
+	MFA               *MFA                `json:"-" bson:"mfa,omitempty"`
+	Identities        []Identity          `json:"identities,omitempty" bson:"identities,omitempty"`
+}
+
+type PasswordResetToken struct {
+	TokenHash string `bson:"tokenHash"`
+	ExpiresAt int64  `bson:"expiresAt"`
+}
+
+type PasswordForgotInput struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetInput struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type PasswordChangeInput struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type UsersFilter struct {
+	Search string
+	Page   int64
+	Limit  int64
+}
+
+type UsersPage struct {
+	Users []User `json:"users"`
+	Total int64  `json:"total"`
+	Page  int64  `json:"page"`
+	Limit int64  `json:"limit"`
+}
+
+type UserStats struct {
+	UserID     string `json:"userId" bson:"_id"`
+	FilesCount int64  `json:"filesCount" bson:"filesCount"`
+	TotalSize  int64  `json:"totalSize" bson:"totalSize"`
+	LastUpload int64  `json:"lastUpload" bson:"lastUpload"`
+}
+
+// ProfileUpdateInput changes only fields which are set, empty string clears the field
+type ProfileUpdateInput struct {
+	DisplayName *string `json:"displayName"`
+	Avatar      *string `json:"avatar"`
 }
@@ -0,0 +1,72 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Events sent to webhooks
+const (
+	EventFileUploaded = "file.uploaded"
+	EventFileDeleted  = "file.deleted"
+	EventUserCreated  = "user.created"
+)
+
+var WebhookEvents = []string{EventFileUploaded, EventFileDeleted, EventUserCreated}
+
+const (
+	DeliveryPending   = "pending" // Not sent yet or waiting for retry
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // All attempts failed, can be redelivered by admin
+)
+
+// Webhook subscribes the URL to events of all users. Deliveries are signed by the secret
+type Webhook struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	URL       string             `json:"url" bson:"url"`
+	Secret    string             `json:"-" bson:"secret"`
+	Events    []string           `json:"events" bson:"events"`
+	CreatedBy string             `json:"createdBy" bson:"createdBy"`
+	CreatedAt int64              `json:"createdAt" bson:"createdAt"`
+}
+
+type WebhookInput struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"` // Optional, generated when empty
+	Events []string `json:"events"`
+}
+
+// WebhookCreated contains the secret, it is shown only once
+type WebhookCreated struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookEvent is the JSON body of deliveries
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt int64       `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// UserEvent is data of user events
+type UserEvent struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+// WebhookDelivery is one event sent to one webhook with the log of its attempts
+type WebhookDelivery struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WebhookID string             `json:"webhookId" bson:"webhookId"`
+	Event     string             `json:"event" bson:"event"`
+	Payload   string             `json:"payload" bson:"payload"` // Body sent as is on every attempt
+	Status    string             `json:"status" bson:"status"`
+	Attempts  []DeliveryAttempt  `json:"attempts" bson:"attempts"`
+	CreatedAt int64              `json:"createdAt" bson:"createdAt"`
+}
+
+type DeliveryAttempt struct {
+	At         int64  `json:"at" bson:"at"`
+	StatusCode int    `json:"statusCode,omitempty" bson:"statusCode,omitempty"` // 0 - no response
+	Error      string `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs int64  `json:"durationMs" bson:"durationMs"`
+}
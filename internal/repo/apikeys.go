@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeysStorage struct {
+	db *mongo.Collection
+}
+
+func newAPIKeysRepo(mongo *mongodb.Mongo, collectionName string) *APIKeysStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &APIKeysStorage{
+		db: collection,
+	}
+}
+
+func (a *APIKeysStorage) Create(ctx context.Context, key *models.APIKey) error {
+	key.ID = primitive.NewObjectID()
+
+	_, err := a.db.InsertOne(ctx, key)
+	return err
+}
+
+func (a *APIKeysStorage) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	result := a.db.FindOne(ctx, bson.M{"keyHash": keyHash})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrAPIKeyNotFound
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var key models.APIKey
+	err := result.Decode(&key)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &key, nil
+}
+
+func (a *APIKeysStorage) ByUser(ctx context.Context, userId string) ([]models.APIKey, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+
+	cursor, err := a.db.Find(ctx, bson.M{"userId": userId}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.APIKey{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (a *APIKeysStorage) Touch(ctx context.Context, id string, at int64) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrAPIKeyNotFound
+	}
+
+	_, err = a.db.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"lastUsedAt": at}})
+	return err
+}
+
+// Delete removes key only if it belongs to the user, so users can't revoke keys of others
+func (a *APIKeysStorage) Delete(ctx context.Context, userId, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrAPIKeyNotFound
+	}
+
+	result, err := a.db.DeleteOne(ctx, bson.M{"_id": objectID, "userId": userId})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return models.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (a *APIKeysStorage) DeleteByUser(ctx context.Context, userId string) error {
+	_, err := a.db.DeleteMany(ctx, bson.M{"userId": userId})
+	return err
+}
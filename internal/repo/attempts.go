@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AttemptsStorage keeps failed sign-in counters in database, so all replicas share them
+type AttemptsStorage struct {
+	db *mongo.Collection
+}
+
+func newAttemptsRepo(mongo *mongodb.Mongo, collectionName string) *AttemptsStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &AttemptsStorage{
+		db: collection,
+	}
+}
+
+func (a *AttemptsStorage) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	result := a.db.FindOne(ctx, bson.M{"_id": key})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return &models.LoginAttempts{Key: key}, nil
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var attempts models.LoginAttempts
+	err := result.Decode(&attempts)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &attempts, nil
+}
+
+func (a *AttemptsStorage) AddFailure(ctx context.Context, key string, at int64) (*models.LoginAttempts, error) {
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	result := a.db.FindOneAndUpdate(ctx, bson.M{"_id": key}, bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"lastFailureAt": at},
+		"$max": bson.M{"expireAt": expireAttempts(at)},
+	}, opts)
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var attempts models.LoginAttempts
+	err := result.Decode(&attempts)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &attempts, nil
+}
+
+func (a *AttemptsStorage) Lock(ctx context.Context, key string, until int64) error {
+	_, err := a.db.UpdateOne(ctx, bson.M{"_id": key}, bson.M{
+		"$set": bson.M{"lockedUntil": until},
+		"$max": bson.M{"expireAt": expireAttempts(until)},
+	})
+	return err
+}
+
+func (a *AttemptsStorage) Reset(ctx context.Context, key string) error {
+	_, err := a.db.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// expireAttempts returns date for TTL index, counters are removed after attemptsRetention without failures or lockout
+func expireAttempts(at int64) time.Time {
+	return time.Unix(at, 0).Add(attemptsRetention)
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"creatly-task/internal/models"
 	"creatly-task/internal/mongodb"
+	"fmt"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type FilesRepo struct {
@@ -20,14 +23,17 @@ func newFilesRepo(mongo *mongodb.Mongo, collectionName string) *FilesRepo {
 	}
 }
 
-func (f *FilesRepo) All() ([]models.FileOut, error) {
-	cursor, err := f.db.Find(context.TODO(), bson.M{})
+// personal matches files uploaded outside of organizations
+var personal = bson.M{"orgId": bson.M{"$exists": false}}
+
+func (f *FilesRepo) All(ctx context.Context) ([]models.FileOut, error) {
+	cursor, err := f.db.Find(ctx, personal)
 	if err != nil {
 		return []models.FileOut{}, err
 	}
 
 	results := make([]models.FileOut, 1)
-	err = cursor.All(context.Background(), &results)
+	err = cursor.All(ctx, &results)
 	if err != nil {
 		return []models.FileOut{}, err
 	}
@@ -35,7 +41,191 @@ func (f *FilesRepo) All() ([]models.FileOut, error) {
 	return results, nil
 }
 
-func (f *FilesRepo) AddLog(log *models.FileUploadLogInput) error {
-	_, err := f.db.InsertOne(context.TODO(), log)
+func (f *FilesRepo) AddLog(ctx context.Context, log *models.FileUploadLogInput) error {
+	log.ID = primitive.NewObjectID()
+
+	_, err := f.db.InsertOne(ctx, log)
 	return err
 }
+
+func (f *FilesRepo) ByUser(ctx context.Context, userId string) ([]models.FileOut, error) {
+	cursor, err := f.db.Find(ctx, bson.M{"userId": userId, "orgId": personal["orgId"]})
+	if err != nil {
+		return []models.FileOut{}, err
+	}
+
+	results := []models.FileOut{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return []models.FileOut{}, err
+	}
+
+	return results, nil
+}
+
+func (f *FilesRepo) Stats(ctx context.Context, userId string) (*models.UserStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"userId": userId, "orgId": personal["orgId"]}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        "$userId",
+			"filesCount": bson.M{"$sum": 1},
+			"totalSize":  bson.M{"$sum": "$size"},
+			"lastUpload": bson.M{"$max": "$date"},
+		}}},
+	}
+
+	cursor, err := f.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.UserStats{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		// User has no uploads yet
+		return &models.UserStats{UserID: userId}, nil
+	}
+
+	return &results[0], nil
+}
+
+func (f *FilesRepo) DeleteByUser(ctx context.Context, userId string) error {
+	_, err := f.db.DeleteMany(ctx, bson.M{"userId": userId, "orgId": personal["orgId"]})
+	return err
+}
+
+func (f *FilesRepo) ByOrg(ctx context.Context, orgId string) ([]models.FileOut, error) {
+	cursor, err := f.db.Find(ctx, bson.M{"orgId": orgId})
+	if err != nil {
+		return []models.FileOut{}, err
+	}
+
+	results := []models.FileOut{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return []models.FileOut{}, err
+	}
+
+	return results, nil
+}
+
+// OrgUsage returns total size of organization files in bytes
+func (f *FilesRepo) OrgUsage(ctx context.Context, orgId string) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"orgId": orgId}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$orgId",
+			"total": bson.M{"$sum": "$size"},
+		}}},
+	}
+
+	cursor, err := f.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+
+	var results []struct {
+		Total int64 `bson:"total"`
+	}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	return results[0].Total, nil
+}
+
+func (f *FilesRepo) Exists(ctx context.Context, filename string) (bool, error) {
+	count, err := f.db.CountDocuments(ctx, bson.M{"filename": filename}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (f *FilesRepo) Get(ctx context.Context, id string) (*models.FileOut, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, models.ErrFileNotFound
+	}
+
+	result := f.db.FindOne(ctx, bson.M{"_id": objectID})
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrFileNotFound
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var file models.FileOut
+	err = result.Decode(&file)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &file, nil
+}
+
+func (f *FilesRepo) SetStatus(ctx context.Context, id string, update *models.FileStatusUpdate) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrFileNotFound
+	}
+
+	set := bson.M{"status": update.Status}
+	unset := bson.M{}
+	if update.Image != nil {
+		set["image"] = update.Image
+	} else {
+		unset["image"] = ""
+	}
+	if update.Error != "" {
+		set["error"] = update.Error
+	} else {
+		unset["error"] = ""
+	}
+
+	changes := bson.M{"$set": set}
+	if len(unset) > 0 {
+		changes["$unset"] = unset
+	}
+
+	result, err := f.db.UpdateOne(ctx, bson.M{"_id": objectID}, changes)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrFileNotFound
+	}
+
+	return nil
+}
+
+func (f *FilesRepo) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrFileNotFound
+	}
+
+	result, err := f.db.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return models.ErrFileNotFound
+	}
+
+	return nil
+}
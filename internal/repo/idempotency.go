@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultIdempotencyCollection = "idempotencyKeys"
+
+// IdempotencyStorage keeps outcomes of requests with Idempotency-Key header. Unique scope_key index
+// lets only one request reserve a key, TTL index removes expired records
+type IdempotencyStorage struct {
+	db *mongo.Collection
+}
+
+func newIdempotencyRepo(mongo *mongodb.Mongo, collectionName string) *IdempotencyStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &IdempotencyStorage{
+		db: collection,
+	}
+}
+
+func idempotencyCollection(cfg *config.Repo) string {
+	if cfg.IdempotencyCollection != "" {
+		return cfg.IdempotencyCollection
+	}
+	return defaultIdempotencyCollection
+}
+
+// Reserve upserts the record matching only an expired or abandoned one, a held key fails the insert with duplicate key error
+func (i *IdempotencyStorage) Reserve(ctx context.Context, record *models.IdempotencyRecord, now int64) (bool, error) {
+	filter := bson.M{
+		"scope": record.Scope,
+		"key":   record.Key,
+		"$or": bson.A{
+			bson.M{"response": nil, "lockedUntil": bson.M{"$lt": now}},
+			bson.M{"expiresAt": bson.M{"$lt": now}},
+		},
+	}
+
+	_, err := i.db.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{
+			"fingerprint": record.Fingerprint,
+			"lockedUntil": record.LockedUntil,
+			"expiresAt":   record.ExpiresAt,
+			"expireAt":    time.Unix(record.ExpiresAt, 0),
+		},
+		"$unset": bson.M{"response": ""},
+	}, options.Update().SetUpsert(true))
+
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (i *IdempotencyStorage) Get(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	result := i.db.FindOne(ctx, bson.M{"scope": scope, "key": key})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrIdempotencyKeyNotFound
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var record models.IdempotencyRecord
+	err := result.Decode(&record)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &record, nil
+}
+
+func (i *IdempotencyStorage) Complete(ctx context.Context, scope, key string, response *models.IdempotentResponse) error {
+	result, err := i.db.UpdateOne(ctx, bson.M{"scope": scope, "key": key}, bson.M{"$set": bson.M{"response": response}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrIdempotencyKeyNotFound
+	}
+
+	return nil
+}
+
+func (i *IdempotencyStorage) Release(ctx context.Context, scope, key string) error {
+	_, err := i.db.DeleteOne(ctx, bson.M{"scope": scope, "key": key})
+	return err
+}
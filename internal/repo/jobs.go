@@ -0,0 +1,163 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultJobsCollection = "jobs"
+
+// JobsStorage is the queue of background jobs shared by workers of all replicas.
+// Leases are taken with FindOneAndUpdate, so one job is run by one worker at a time
+type JobsStorage struct {
+	db *mongo.Collection
+}
+
+func newJobsRepo(mongo *mongodb.Mongo, collectionName string) *JobsStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &JobsStorage{
+		db: collection,
+	}
+}
+
+func jobsCollection(cfg *config.Repo) string {
+	if cfg.JobsCollection != "" {
+		return cfg.JobsCollection
+	}
+	return defaultJobsCollection
+}
+
+func (j *JobsStorage) Enqueue(ctx context.Context, job *models.Job) error {
+	job.ID = primitive.NewObjectID()
+
+	_, err := j.db.InsertOne(ctx, job)
+	return err
+}
+
+func (j *JobsStorage) Lease(ctx context.Context, worker string, now, until int64) (*models.Job, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"state": models.JobPending, "runAt": bson.M{"$lte": now}},
+		bson.M{"state": models.JobRunning, "leasedUntil": bson.M{"$lt": now}},
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "runAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	result := j.db.FindOneAndUpdate(ctx, filter, bson.M{
+		"$set": bson.M{"state": models.JobRunning, "leasedBy": worker, "leasedUntil": until},
+		"$inc": bson.M{"attempts": 1},
+	}, opts)
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrNoJobs
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var job models.Job
+	err := result.Decode(&job)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &job, nil
+}
+
+func (j *JobsStorage) Complete(ctx context.Context, id, worker string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrJobNotFound
+	}
+
+	result, err := j.db.DeleteOne(ctx, leased(objectID, worker))
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return models.ErrJobLeaseLost
+	}
+
+	return nil
+}
+
+func (j *JobsStorage) Retry(ctx context.Context, id, worker, failure string, runAt int64) error {
+	return j.release(ctx, id, worker, bson.M{"state": models.JobPending, "runAt": runAt, "lastError": failure})
+}
+
+func (j *JobsStorage) Bury(ctx context.Context, id, worker, failure string) error {
+	return j.release(ctx, id, worker, bson.M{"state": models.JobDead, "lastError": failure})
+}
+
+// release ends the lease of the worker with the changes
+func (j *JobsStorage) release(ctx context.Context, id, worker string, set bson.M) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrJobNotFound
+	}
+
+	result, err := j.db.UpdateOne(ctx, leased(objectID, worker), bson.M{
+		"$set":   set,
+		"$unset": bson.M{"leasedBy": "", "leasedUntil": ""},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrJobLeaseLost
+	}
+
+	return nil
+}
+
+func leased(id primitive.ObjectID, worker string) bson.M {
+	return bson.M{"_id": id, "state": models.JobRunning, "leasedBy": worker}
+}
+
+func (j *JobsStorage) Dead(ctx context.Context) ([]models.Job, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": 1})
+
+	cursor, err := j.db.Find(ctx, bson.M{"state": models.JobDead}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.Job{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (j *JobsStorage) Requeue(ctx context.Context, id string, runAt int64) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrJobNotFound
+	}
+
+	result, err := j.db.UpdateOne(ctx, bson.M{"_id": objectID, "state": models.JobDead}, bson.M{
+		"$set": bson.M{"state": models.JobPending, "runAt": runAt, "attempts": 0},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrJobNotFound
+	}
+
+	return nil
+}
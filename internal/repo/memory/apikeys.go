@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKeysStorage struct {
+	mu   sync.RWMutex
+	keys map[string]models.APIKey
+}
+
+func NewAPIKeys() *APIKeysStorage {
+	return &APIKeysStorage{
+		keys: make(map[string]models.APIKey),
+	}
+}
+
+func (a *APIKeysStorage) Create(ctx context.Context, key *models.APIKey) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key.ID = primitive.NewObjectID()
+	a.keys[key.ID.Hex()] = copyAPIKey(key)
+
+	return nil
+}
+
+func (a *APIKeysStorage) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, key := range a.keys {
+		if key.KeyHash == keyHash {
+			result := copyAPIKey(&key)
+			return &result, nil
+		}
+	}
+
+	return nil, models.ErrAPIKeyNotFound
+}
+
+func (a *APIKeysStorage) ByUser(ctx context.Context, userId string) ([]models.APIKey, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	results := []models.APIKey{}
+	for _, key := range a.keys {
+		if key.UserID == userId {
+			results = append(results, copyAPIKey(&key))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CreatedAt > results[j].CreatedAt
+	})
+
+	return results, nil
+}
+
+func (a *APIKeysStorage) Touch(ctx context.Context, id string, at int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key, ok := a.keys[id]
+	if ok {
+		key.LastUsedAt = at
+		a.keys[id] = key
+	}
+
+	return nil
+}
+
+// Delete removes key only if it belongs to the user, so users can't revoke keys of others
+func (a *APIKeysStorage) Delete(ctx context.Context, userId, id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key, ok := a.keys[id]
+	if !ok || key.UserID != userId {
+		return models.ErrAPIKeyNotFound
+	}
+	delete(a.keys, id)
+
+	return nil
+}
+
+func (a *APIKeysStorage) DeleteByUser(ctx context.Context, userId string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for id, key := range a.keys {
+		if key.UserID == userId {
+			delete(a.keys, id)
+		}
+	}
+
+	return nil
+}
+
+func copyAPIKey(key *models.APIKey) models.APIKey {
+	result := *key
+	result.Scopes = append([]string{}, key.Scopes...)
+	return result
+}
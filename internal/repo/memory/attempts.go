@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sync"
+)
+
+type AttemptsStorage struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempts
+}
+
+func NewAttempts() *AttemptsStorage {
+	return &AttemptsStorage{
+		attempts: make(map[string]models.LoginAttempts),
+	}
+}
+
+func (a *AttemptsStorage) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	attempts, ok := a.attempts[key]
+	if !ok {
+		attempts = models.LoginAttempts{Key: key}
+	}
+
+	return &attempts, nil
+}
+
+func (a *AttemptsStorage) AddFailure(ctx context.Context, key string, at int64) (*models.LoginAttempts, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	attempts := a.attempts[key]
+	attempts.Key = key
+	attempts.Failures++
+	attempts.LastFailureAt = at
+	a.attempts[key] = attempts
+
+	return &attempts, nil
+}
+
+func (a *AttemptsStorage) Lock(ctx context.Context, key string, until int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	attempts, ok := a.attempts[key]
+	if ok {
+		attempts.LockedUntil = until
+		a.attempts[key] = attempts
+	}
+
+	return nil
+}
+
+func (a *AttemptsStorage) Reset(ctx context.Context, key string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.attempts, key)
+
+	return nil
+}
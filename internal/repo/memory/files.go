@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FilesStorage keeps upload logs in insertion order
+type FilesStorage struct {
+	mu    sync.RWMutex
+	files []models.FileOut
+}
+
+func NewFiles() *FilesStorage {
+	return &FilesStorage{}
+}
+
+func (f *FilesStorage) All(ctx context.Context) ([]models.FileOut, error) {
+	return f.filter(func(file *models.FileOut) bool {
+		return file.OrgId == ""
+	}), nil
+}
+
+func (f *FilesStorage) AddLog(ctx context.Context, log *models.FileUploadLogInput) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	log.ID = primitive.NewObjectID()
+	f.files = append(f.files, models.FileOut{
+		ID:       log.ID,
+		Filename: log.Filename,
+		Size:     int(log.Size),
+		Date:     log.UploadDate,
+		UserId:   log.UserId,
+		OrgId:    log.OrgId,
+		Url:      log.Url,
+		Status:   log.Status,
+		Error:    log.Error,
+	})
+
+	return nil
+}
+
+func (f *FilesStorage) ByUser(ctx context.Context, userId string) ([]models.FileOut, error) {
+	return f.filter(func(file *models.FileOut) bool {
+		return file.UserId == userId && file.OrgId == ""
+	}), nil
+}
+
+func (f *FilesStorage) ByOrg(ctx context.Context, orgId string) ([]models.FileOut, error) {
+	return f.filter(func(file *models.FileOut) bool {
+		return file.OrgId == orgId
+	}), nil
+}
+
+func (f *FilesStorage) Stats(ctx context.Context, userId string) (*models.UserStats, error) {
+	stats := models.UserStats{UserID: userId}
+	files, _ := f.ByUser(ctx, userId)
+
+	for _, file := range files {
+		stats.FilesCount++
+		stats.TotalSize += int64(file.Size)
+		if file.Date > stats.LastUpload {
+			stats.LastUpload = file.Date
+		}
+	}
+
+	return &stats, nil
+}
+
+func (f *FilesStorage) OrgUsage(ctx context.Context, orgId string) (int64, error) {
+	var total int64
+	files, _ := f.ByOrg(ctx, orgId)
+
+	for _, file := range files {
+		total += int64(file.Size)
+	}
+
+	return total, nil
+}
+
+func (f *FilesStorage) DeleteByUser(ctx context.Context, userId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	kept := f.files[:0]
+	for _, file := range f.files {
+		if file.UserId != userId || file.OrgId != "" {
+			kept = append(kept, file)
+		}
+	}
+	f.files = kept
+
+	return nil
+}
+
+func (f *FilesStorage) filter(match func(file *models.FileOut) bool) []models.FileOut {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	results := []models.FileOut{}
+	for i := range f.files {
+		if match(&f.files[i]) {
+			results = append(results, f.files[i])
+		}
+	}
+
+	return results
+}
+
+func (f *FilesStorage) Exists(ctx context.Context, filename string) (bool, error) {
+	files := f.filter(func(file *models.FileOut) bool {
+		return file.Filename == filename
+	})
+
+	return len(files) > 0, nil
+}
+
+func (f *FilesStorage) Get(ctx context.Context, id string) (*models.FileOut, error) {
+	files := f.filter(func(file *models.FileOut) bool {
+		return file.ID.Hex() == id
+	})
+	if len(files) == 0 {
+		return nil, models.ErrFileNotFound
+	}
+
+	return &files[0], nil
+}
+
+func (f *FilesStorage) SetStatus(ctx context.Context, id string, update *models.FileStatusUpdate) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.files {
+		if f.files[i].ID.Hex() != id {
+			continue
+		}
+
+		f.files[i].Status = update.Status
+		f.files[i].Error = update.Error
+		f.files[i].Image = nil
+		if update.Image != nil {
+			image := *update.Image
+			f.files[i].Image = &image
+		}
+		return nil
+	}
+
+	return models.ErrFileNotFound
+}
+
+func (f *FilesStorage) Delete(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.files {
+		if f.files[i].ID.Hex() == id {
+			f.files = append(f.files[:i], f.files[i+1:]...)
+			return nil
+		}
+	}
+
+	return models.ErrFileNotFound
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sync"
+)
+
+type idempotencyKey struct {
+	scope string
+	key   string
+}
+
+type IdempotencyStorage struct {
+	mu      sync.Mutex
+	records map[idempotencyKey]models.IdempotencyRecord
+}
+
+func NewIdempotencyKeys() *IdempotencyStorage {
+	return &IdempotencyStorage{
+		records: make(map[idempotencyKey]models.IdempotencyRecord),
+	}
+}
+
+func (i *IdempotencyStorage) Reserve(ctx context.Context, record *models.IdempotencyRecord, now int64) (bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	id := idempotencyKey{scope: record.Scope, key: record.Key}
+	held, ok := i.records[id]
+	if ok && held.ExpiresAt >= now && (held.Response != nil || held.LockedUntil >= now) {
+		return false, nil
+	}
+
+	reserved := *record
+	reserved.Response = nil
+	i.records[id] = reserved
+
+	return true, nil
+}
+
+func (i *IdempotencyStorage) Get(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	record, ok := i.records[idempotencyKey{scope: scope, key: key}]
+	if !ok {
+		return nil, models.ErrIdempotencyKeyNotFound
+	}
+
+	return &record, nil
+}
+
+func (i *IdempotencyStorage) Complete(ctx context.Context, scope, key string, response *models.IdempotentResponse) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	id := idempotencyKey{scope: scope, key: key}
+	record, ok := i.records[id]
+	if !ok {
+		return models.ErrIdempotencyKeyNotFound
+	}
+
+	stored := *response
+	record.Response = &stored
+	i.records[id] = record
+
+	return nil
+}
+
+func (i *IdempotencyStorage) Release(ctx context.Context, scope, key string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.records, idempotencyKey{scope: scope, key: key})
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobsStorage struct {
+	mu   sync.Mutex
+	jobs map[string]models.Job
+}
+
+func NewJobs() *JobsStorage {
+	return &JobsStorage{
+		jobs: make(map[string]models.Job),
+	}
+}
+
+func (j *JobsStorage) Enqueue(ctx context.Context, job *models.Job) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job.ID = primitive.NewObjectID()
+	j.jobs[job.ID.Hex()] = *job
+
+	return nil
+}
+
+func (j *JobsStorage) Lease(ctx context.Context, worker string, now, until int64) (*models.Job, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var due *models.Job
+	for _, job := range j.jobs {
+		job := job
+		pending := job.State == models.JobPending && job.RunAt <= now
+		abandoned := job.State == models.JobRunning && job.LeasedUntil < now
+		if (pending || abandoned) && (due == nil || job.RunAt < due.RunAt) {
+			due = &job
+		}
+	}
+
+	if due == nil {
+		return nil, models.ErrNoJobs
+	}
+
+	due.State = models.JobRunning
+	due.LeasedBy = worker
+	due.LeasedUntil = until
+	due.Attempts++
+	j.jobs[due.ID.Hex()] = *due
+
+	return due, nil
+}
+
+func (j *JobsStorage) Complete(ctx context.Context, id, worker string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.isLeased(id, worker) {
+		return models.ErrJobLeaseLost
+	}
+
+	delete(j.jobs, id)
+
+	return nil
+}
+
+func (j *JobsStorage) Retry(ctx context.Context, id, worker, failure string, runAt int64) error {
+	return j.release(id, worker, func(job *models.Job) {
+		job.State = models.JobPending
+		job.RunAt = runAt
+		job.LastError = failure
+	})
+}
+
+func (j *JobsStorage) Bury(ctx context.Context, id, worker, failure string) error {
+	return j.release(id, worker, func(job *models.Job) {
+		job.State = models.JobDead
+		job.LastError = failure
+	})
+}
+
+func (j *JobsStorage) release(id, worker string, change func(job *models.Job)) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.isLeased(id, worker) {
+		return models.ErrJobLeaseLost
+	}
+
+	job := j.jobs[id]
+	change(&job)
+	job.LeasedBy = ""
+	job.LeasedUntil = 0
+	j.jobs[id] = job
+
+	return nil
+}
+
+func (j *JobsStorage) isLeased(id, worker string) bool {
+	job, ok := j.jobs[id]
+	return ok && job.State == models.JobRunning && job.LeasedBy == worker
+}
+
+func (j *JobsStorage) Dead(ctx context.Context) ([]models.Job, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	results := []models.Job{}
+	for _, job := range j.jobs {
+		if job.State == models.JobDead {
+			results = append(results, job)
+		}
+	}
+
+	sort.Slice(results, func(a, b int) bool { return results[a].CreatedAt < results[b].CreatedAt })
+
+	return results, nil
+}
+
+func (j *JobsStorage) Requeue(ctx context.Context, id string, runAt int64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok || job.State != models.JobDead {
+		return models.ErrJobNotFound
+	}
+
+	job.State = models.JobPending
+	job.RunAt = runAt
+	job.Attempts = 0
+	j.jobs[id] = job
+
+	return nil
+}
@@ -0,0 +1,47 @@
+// Package memory implements repo interfaces in process memory.
+// It is used by the demo mode and end-to-end tests, data is lost on restart
+package memory
+
+import (
+	"creatly-task/internal/repo"
+)
+
+func New() *repo.Repo {
+	return &repo.Repo{
+		Users:         NewUsers(),
+		Sessions:      NewSessions(),
+		Files:         NewFiles(),
+		Attempts:      NewAttempts(),
+		APIKeys:       NewAPIKeys(),
+		Organizations: NewOrganizations(),
+		Uploads:       NewUploads(),
+
+		IdempotencyKeys: NewIdempotencyKeys(),
+		Jobs:            NewJobs(),
+
+		Webhooks:   NewWebhooks(),
+		Deliveries: NewDeliveries(),
+
+		Outbox:       NewOutbox(),
+		Transactions: Transactions{},
+	}
+}
+
+// page returns the part of items for 1-based page, limit 0 - all items
+func page(total, pageNumber, limit int64) (from, to int64) {
+	if pageNumber < 1 {
+		pageNumber = 1
+	}
+
+	from = (pageNumber - 1) * limit
+	if from > total {
+		from = total
+	}
+
+	to = total
+	if limit > 0 && from+limit < total {
+		to = from + limit
+	}
+
+	return from, to
+}
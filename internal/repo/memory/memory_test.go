@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/repotest"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func Test_Contract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repo.Repo {
+		return New()
+	})
+}
+
+func Test_CreateUserConcurrent(t *testing.T) {
+	users := NewUsers()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := users.CreateUser(context.Background(), &models.UserSignUpInput{Email: fmt.Sprintf("user%d@mail.com", i%5)})
+			if err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if created != 5 {
+		t.Fatalf("expected 5 unique users, created %d", created)
+	}
+}
+
+func Test_page(t *testing.T) {
+	tests := []struct {
+		name                 string
+		total, page, limit   int64
+		expectFrom, expectTo int64
+	}{
+		{name: "first page", total: 10, page: 1, limit: 4, expectFrom: 0, expectTo: 4},
+		{name: "last page", total: 10, page: 3, limit: 4, expectFrom: 8, expectTo: 10},
+		{name: "after last page", total: 10, page: 4, limit: 4, expectFrom: 10, expectTo: 10},
+		{name: "no limit", total: 10, page: 1, limit: 0, expectFrom: 0, expectTo: 10},
+		{name: "zero page", total: 10, page: 0, limit: 4, expectFrom: 0, expectTo: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from, to := page(test.total, test.page, test.limit)
+			if from != test.expectFrom || to != test.expectTo {
+				t.Fatalf("expected [%d:%d], got [%d:%d]", test.expectFrom, test.expectTo, from, to)
+			}
+		})
+	}
+}
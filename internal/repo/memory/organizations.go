@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OrganizationsStorage struct {
+	mu   sync.RWMutex
+	orgs map[string]*models.Organization
+}
+
+func NewOrganizations() *OrganizationsStorage {
+	return &OrganizationsStorage{
+		orgs: make(map[string]*models.Organization),
+	}
+}
+
+func (o *OrganizationsStorage) Create(ctx context.Context, org *models.Organization) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	org.ID = primitive.NewObjectID()
+	o.orgs[org.ID.Hex()] = copyOrganization(org)
+
+	return nil
+}
+
+func (o *OrganizationsStorage) Get(ctx context.Context, id string) (*models.Organization, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	org, ok := o.orgs[id]
+	if !ok {
+		return nil, models.ErrOrganizationNotFound
+	}
+
+	return copyOrganization(org), nil
+}
+
+func (o *OrganizationsStorage) ByMember(ctx context.Context, userId string) ([]models.Organization, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	results := []models.Organization{}
+	for _, org := range o.orgs {
+		if org.Member(userId) != nil {
+			results = append(results, *copyOrganization(org))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+
+	return results, nil
+}
+
+func (o *OrganizationsStorage) AddMember(ctx context.Context, id string, member *models.Member) error {
+	return o.update(id, func(org *models.Organization) error {
+		org.Members = append(org.Members, *member)
+		org.Invitations = withoutInvitation(org.Invitations, member.Email)
+		return nil
+	})
+}
+
+func (o *OrganizationsStorage) SetMemberRole(ctx context.Context, id, userId, role string) error {
+	return o.update(id, func(org *models.Organization) error {
+		member := org.Member(userId)
+		if member == nil {
+			return models.ErrMemberNotFound
+		}
+		member.Role = role
+		return nil
+	})
+}
+
+func (o *OrganizationsStorage) RemoveMember(ctx context.Context, id, userId string) error {
+	err := o.update(id, func(org *models.Organization) error {
+		if org.Member(userId) == nil {
+			return models.ErrMemberNotFound
+		}
+		org.Members = withoutMember(org.Members, userId)
+		return nil
+	})
+	if err == models.ErrOrganizationNotFound {
+		return models.ErrMemberNotFound
+	}
+	return err
+}
+
+func (o *OrganizationsStorage) RemoveUser(ctx context.Context, userId string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, org := range o.orgs {
+		org.Members = withoutMember(org.Members, userId)
+	}
+
+	return nil
+}
+
+// AddInvitation replaces pending invitation for the same email
+func (o *OrganizationsStorage) AddInvitation(ctx context.Context, id string, invitation *models.Invitation) error {
+	return o.update(id, func(org *models.Organization) error {
+		org.Invitations = append(withoutInvitation(org.Invitations, invitation.Email), *invitation)
+		return nil
+	})
+}
+
+func (o *OrganizationsStorage) GetByInvitation(ctx context.Context, tokenHash string) (*models.Organization, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	for _, org := range o.orgs {
+		for _, invitation := range org.Invitations {
+			if invitation.TokenHash == tokenHash {
+				return copyOrganization(org), nil
+			}
+		}
+	}
+
+	return nil, models.ErrOrganizationNotFound
+}
+
+func (o *OrganizationsStorage) SetQuota(ctx context.Context, id string, quota int64) error {
+	return o.update(id, func(org *models.Organization) error {
+		org.Quota = quota
+		return nil
+	})
+}
+
+func (o *OrganizationsStorage) update(id string, apply func(org *models.Organization) error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	org, ok := o.orgs[id]
+	if !ok {
+		return models.ErrOrganizationNotFound
+	}
+
+	return apply(org)
+}
+
+func withoutMember(members []models.Member, userId string) []models.Member {
+	results := []models.Member{}
+	for _, member := range members {
+		if member.UserID != userId {
+			results = append(results, member)
+		}
+	}
+	return results
+}
+
+func withoutInvitation(invitations []models.Invitation, email string) []models.Invitation {
+	results := []models.Invitation{}
+	for _, invitation := range invitations {
+		if invitation.Email != email {
+			results = append(results, invitation)
+		}
+	}
+	return results
+}
+
+func copyOrganization(org *models.Organization) *models.Organization {
+	result := *org
+	result.Members = append([]models.Member{}, org.Members...)
+	result.Invitations = append([]models.Invitation{}, org.Invitations...)
+	return &result
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxStorage struct {
+	mu     sync.Mutex
+	events map[string]models.OutboxEvent
+}
+
+func NewOutbox() *OutboxStorage {
+	return &OutboxStorage{
+		events: make(map[string]models.OutboxEvent),
+	}
+}
+
+func (o *OutboxStorage) Add(ctx context.Context, event *models.OutboxEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	event.ID = primitive.NewObjectID()
+	o.events[event.ID.Hex()] = *event
+
+	return nil
+}
+
+func (o *OutboxStorage) Claim(ctx context.Context, now, until int64) (*models.OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var due *models.OutboxEvent
+	for _, event := range o.events {
+		event := event
+		if event.PublishedAt != 0 || event.AvailableAt > now {
+			continue
+		}
+		if due == nil || event.CreatedAt < due.CreatedAt || (event.CreatedAt == due.CreatedAt && event.ID.Hex() < due.ID.Hex()) {
+			due = &event
+		}
+	}
+
+	if due == nil {
+		return nil, models.ErrOutboxEmpty
+	}
+
+	due.AvailableAt = until
+	due.Attempts++
+	o.events[due.ID.Hex()] = *due
+
+	return due, nil
+}
+
+func (o *OutboxStorage) Retry(ctx context.Context, id, failure string, availableAt int64) error {
+	return o.update(id, func(event *models.OutboxEvent) {
+		event.AvailableAt = availableAt
+		event.LastError = failure
+	})
+}
+
+func (o *OutboxStorage) MarkPublished(ctx context.Context, id string, at int64) error {
+	return o.update(id, func(event *models.OutboxEvent) {
+		event.PublishedAt = at
+	})
+}
+
+func (o *OutboxStorage) MarkDispatched(ctx context.Context, id string) error {
+	return o.update(id, func(event *models.OutboxEvent) {
+		event.Dispatched = true
+	})
+}
+
+func (o *OutboxStorage) update(id string, change func(event *models.OutboxEvent)) error {
+	if !primitive.IsValidObjectID(id) {
+		return models.ErrOutboxEventNotFound
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	event, ok := o.events[id]
+	if !ok || event.PublishedAt != 0 {
+		return nil
+	}
+
+	change(&event)
+	o.events[id] = event
+	return nil
+}
+
+func (o *OutboxStorage) DeletePublished(ctx context.Context, before int64) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var deleted int64
+	for id, event := range o.events {
+		if event.PublishedAt > 0 && event.PublishedAt < before {
+			delete(o.events, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// Transactions run callbacks right away. Memory writes aren't rolled back, the data is lost on restart anyway
+type Transactions struct{}
+
+func (Transactions) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SessionsStorage struct {
+	mu       sync.RWMutex
+	sessions map[string]models.Session
+}
+
+func NewSessions() *SessionsStorage {
+	return &SessionsStorage{
+		sessions: make(map[string]models.Session),
+	}
+}
+
+func (s *SessionsStorage) Create(ctx context.Context, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session.ID = primitive.NewObjectID()
+	s.sessions[session.ID.Hex()] = *session
+
+	return nil
+}
+
+func (s *SessionsStorage) Get(ctx context.Context, id string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, models.ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+func (s *SessionsStorage) ByUser(ctx context.Context, userId string, now int64) ([]models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := []models.Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userId && session.ExpiresAt > now {
+			results = append(results, session)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].LastSeenAt > results[j].LastSeenAt
+	})
+
+	return results, nil
+}
+
+func (s *SessionsStorage) Touch(ctx context.Context, id string, at int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if ok {
+		session.LastSeenAt = at
+		s.sessions[id] = session
+	}
+
+	return nil
+}
+
+func (s *SessionsStorage) Delete(ctx context.Context, userId, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.UserID != userId {
+		return models.ErrSessionNotFound
+	}
+	delete(s.sessions, id)
+
+	return nil
+}
+
+func (s *SessionsStorage) DeleteByUser(ctx context.Context, userId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userId {
+			delete(s.sessions, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UploadsStorage struct {
+	mu      sync.RWMutex
+	uploads map[string]models.PendingUpload
+}
+
+func NewUploads() *UploadsStorage {
+	return &UploadsStorage{
+		uploads: make(map[string]models.PendingUpload),
+	}
+}
+
+func (u *UploadsStorage) Create(ctx context.Context, upload *models.PendingUpload) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	upload.ID = primitive.NewObjectID()
+	u.uploads[upload.ID.Hex()] = *upload
+
+	return nil
+}
+
+func (u *UploadsStorage) Delete(ctx context.Context, id string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	delete(u.uploads, id)
+
+	return nil
+}
+
+func (u *UploadsStorage) Stale(ctx context.Context, before int64) ([]models.PendingUpload, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	results := []models.PendingUpload{}
+	for _, upload := range u.uploads {
+		if upload.CreatedAt < before {
+			results = append(results, upload)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].CreatedAt < results[j].CreatedAt })
+
+	return results, nil
+}
@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UsersStorage struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]*models.User
+}
+
+func NewUsers() *UsersStorage {
+	return &UsersStorage{
+		users: make(map[primitive.ObjectID]*models.User),
+	}
+}
+
+func (u *UsersStorage) CreateUser(ctx context.Context, input *models.UserSignUpInput) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.byEmail(input.Email) != nil {
+		return models.ErrUserExists
+	}
+
+	user := &models.User{
+		ID:         primitive.NewObjectID(),
+		Email:      input.Email,
+		Password:   input.Password,
+		Role:       input.Role,
+		Verified:   input.Verified,
+		CreatedAt:  input.CreatedAt,
+		Identities: append([]models.Identity(nil), input.Identities...),
+	}
+	u.users[user.ID] = user
+	input.ID = user.ID
+
+	return nil
+}
+
+func (u *UsersStorage) GetUserByCreds(ctx context.Context, email string) (*models.UserSignInOutput, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	user := u.byEmail(email)
+	if user == nil {
+		return nil, models.ErrUserNotFound
+	}
+
+	return &models.UserSignInOutput{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Password: user.Password,
+		Disabled: user.Disabled,
+		MFA:      copyMFA(user.MFA),
+	}, nil
+}
+
+func (u *UsersStorage) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	user, err := u.byID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return copyUser(user), nil
+}
+
+func (u *UsersStorage) List(ctx context.Context, filter *models.UsersFilter) ([]models.User, int64, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	search := strings.ToLower(filter.Search)
+	matched := []*models.User{}
+	for _, user := range u.users {
+		if strings.Contains(strings.ToLower(user.Email), search) {
+			matched = append(matched, user)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].ID.Hex() < matched[j].ID.Hex()
+	})
+
+	total := int64(len(matched))
+	from, to := page(total, filter.Page, filter.Limit)
+
+	users := make([]models.User, 0, to-from)
+	for _, user := range matched[from:to] {
+		users = append(users, *copyUser(user))
+	}
+
+	return users, total, nil
+}
+
+func (u *UsersStorage) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return u.update(id, func(user *models.User) {
+		user.Disabled = disabled
+	})
+}
+
+// UpdatePassword sets new password, revokes all issued tokens and pending password reset
+func (u *UsersStorage) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	return u.update(id, func(user *models.User) {
+		user.Password = passwordHash
+		user.SessionsRevokedAt = time.Now().Unix()
+		user.PasswordReset = nil
+	})
+}
+
+func (u *UsersStorage) SetVerified(ctx context.Context, email string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user := u.byEmail(email)
+	if user == nil {
+		return models.ErrUserNotFound
+	}
+	user.Verified = true
+
+	return nil
+}
+
+func (u *UsersStorage) SetPasswordResetToken(ctx context.Context, id string, token *models.PasswordResetToken) error {
+	return u.update(id, func(user *models.User) {
+		reset := *token
+		user.PasswordReset = &reset
+	})
+}
+
+func (u *UsersStorage) GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	return u.find(func(user *models.User) bool {
+		return user.PasswordReset != nil && user.PasswordReset.TokenHash == tokenHash
+	})
+}
+
+func (u *UsersStorage) SetMFA(ctx context.Context, id string, mfa *models.MFA) error {
+	return u.update(id, func(user *models.User) {
+		user.MFA = copyMFA(mfa)
+	})
+}
+
+func (u *UsersStorage) GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	return u.find(func(user *models.User) bool {
+		for _, identity := range user.Identities {
+			if identity.Provider == provider && identity.Subject == subject {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (u *UsersStorage) AddIdentity(ctx context.Context, id string, identity *models.Identity) error {
+	return u.update(id, func(user *models.User) {
+		user.Identities = append(user.Identities, *identity)
+		user.Verified = true
+	})
+}
+
+func (u *UsersStorage) UpdateProfile(ctx context.Context, id string, input *models.ProfileUpdateInput) error {
+	return u.update(id, func(user *models.User) {
+		if input.DisplayName != nil {
+			user.DisplayName = *input.DisplayName
+		}
+		if input.Avatar != nil {
+			user.Avatar = *input.Avatar
+		}
+	})
+}
+
+func (u *UsersStorage) Delete(ctx context.Context, id string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, err := u.byID(id)
+	if err != nil {
+		return err
+	}
+	delete(u.users, user.ID)
+
+	return nil
+}
+
+func (u *UsersStorage) update(id string, apply func(user *models.User)) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	user, err := u.byID(id)
+	if err != nil {
+		return err
+	}
+	apply(user)
+
+	return nil
+}
+
+func (u *UsersStorage) find(match func(user *models.User) bool) (*models.User, error) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	for _, user := range u.users {
+		if match(user) {
+			return copyUser(user), nil
+		}
+	}
+
+	return nil, models.ErrUserNotFound
+}
+
+func (u *UsersStorage) byID(id string) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, models.ErrUserNotFound
+	}
+
+	user, ok := u.users[objectID]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (u *UsersStorage) byEmail(email string) *models.User {
+	for _, user := range u.users {
+		if user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
+
+// copyUser protects stored user from changes of the caller
+func copyUser(user *models.User) *models.User {
+	result := *user
+	result.MFA = copyMFA(user.MFA)
+	result.Identities = append([]models.Identity(nil), user.Identities...)
+	if user.PasswordReset != nil {
+		reset := *user.PasswordReset
+		result.PasswordReset = &reset
+	}
+	return &result
+}
+
+func copyMFA(mfa *models.MFA) *models.MFA {
+	if mfa == nil {
+		return nil
+	}
+
+	result := *mfa
+	result.RecoveryCodes = append([]string(nil), mfa.RecoveryCodes...)
+	return &result
+}
@@ -0,0 +1,200 @@
+package memory
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhooksStorage keeps webhooks in creation order
+type WebhooksStorage struct {
+	mu       sync.RWMutex
+	webhooks []models.Webhook
+}
+
+func NewWebhooks() *WebhooksStorage {
+	return &WebhooksStorage{}
+}
+
+func (w *WebhooksStorage) Create(ctx context.Context, webhook *models.Webhook) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	webhook.ID = primitive.NewObjectID()
+	stored := *webhook
+	stored.Events = append([]string(nil), webhook.Events...)
+	w.webhooks = append(w.webhooks, stored)
+
+	return nil
+}
+
+func (w *WebhooksStorage) Get(ctx context.Context, id string) (*models.Webhook, error) {
+	webhooks := w.filter(func(webhook *models.Webhook) bool {
+		return webhook.ID.Hex() == id
+	})
+	if len(webhooks) == 0 {
+		return nil, models.ErrWebhookNotFound
+	}
+
+	return &webhooks[0], nil
+}
+
+func (w *WebhooksStorage) All(ctx context.Context) ([]models.Webhook, error) {
+	return w.filter(func(webhook *models.Webhook) bool {
+		return true
+	}), nil
+}
+
+func (w *WebhooksStorage) ByEvent(ctx context.Context, event string) ([]models.Webhook, error) {
+	return w.filter(func(webhook *models.Webhook) bool {
+		for _, subscribed := range webhook.Events {
+			if subscribed == event {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (w *WebhooksStorage) Delete(ctx context.Context, id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.webhooks {
+		if w.webhooks[i].ID.Hex() == id {
+			w.webhooks = append(w.webhooks[:i], w.webhooks[i+1:]...)
+			return nil
+		}
+	}
+
+	return models.ErrWebhookNotFound
+}
+
+func (w *WebhooksStorage) filter(match func(webhook *models.Webhook) bool) []models.Webhook {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	results := []models.Webhook{}
+	for i := range w.webhooks {
+		if match(&w.webhooks[i]) {
+			webhook := w.webhooks[i]
+			webhook.Events = append([]string(nil), webhook.Events...)
+			results = append(results, webhook)
+		}
+	}
+
+	return results
+}
+
+// DeliveriesStorage keeps deliveries in creation order
+type DeliveriesStorage struct {
+	mu         sync.RWMutex
+	deliveries []models.WebhookDelivery
+}
+
+func NewDeliveries() *DeliveriesStorage {
+	return &DeliveriesStorage{}
+}
+
+func (d *DeliveriesStorage) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delivery.ID = primitive.NewObjectID()
+	if delivery.Attempts == nil {
+		delivery.Attempts = []models.DeliveryAttempt{}
+	}
+	stored := *delivery
+	stored.Attempts = append([]models.DeliveryAttempt{}, delivery.Attempts...)
+	d.deliveries = append(d.deliveries, stored)
+
+	return nil
+}
+
+func (d *DeliveriesStorage) Get(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	i := d.index(id)
+	if i < 0 {
+		return nil, models.ErrDeliveryNotFound
+	}
+
+	delivery := copyDelivery(&d.deliveries[i])
+	return &delivery, nil
+}
+
+func (d *DeliveriesStorage) ByWebhook(ctx context.Context, webhookID string, limit int64) ([]models.WebhookDelivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	results := []models.WebhookDelivery{}
+	for i := len(d.deliveries) - 1; i >= 0 && int64(len(results)) < limit; i-- {
+		if d.deliveries[i].WebhookID == webhookID {
+			results = append(results, copyDelivery(&d.deliveries[i]))
+		}
+	}
+
+	return results, nil
+}
+
+func (d *DeliveriesStorage) AddAttempt(ctx context.Context, id string, attempt *models.DeliveryAttempt, status string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := d.index(id)
+	if i < 0 {
+		return models.ErrDeliveryNotFound
+	}
+
+	d.deliveries[i].Status = status
+	d.deliveries[i].Attempts = append(d.deliveries[i].Attempts, *attempt)
+
+	return nil
+}
+
+func (d *DeliveriesStorage) SetStatus(ctx context.Context, id, status string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := d.index(id)
+	if i < 0 {
+		return models.ErrDeliveryNotFound
+	}
+
+	d.deliveries[i].Status = status
+
+	return nil
+}
+
+func (d *DeliveriesStorage) DeleteByWebhook(ctx context.Context, webhookID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := d.deliveries[:0]
+	for _, delivery := range d.deliveries {
+		if delivery.WebhookID != webhookID {
+			kept = append(kept, delivery)
+		}
+	}
+	d.deliveries = kept
+
+	return nil
+}
+
+func (d *DeliveriesStorage) index(id string) int {
+	for i := range d.deliveries {
+		if d.deliveries[i].ID.Hex() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func copyDelivery(delivery *models.WebhookDelivery) models.WebhookDelivery {
+	copied := *delivery
+	copied.Attempts = append([]models.DeliveryAttempt{}, delivery.Attempts...)
+	return copied
+}
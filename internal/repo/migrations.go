@@ -0,0 +1,451 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultMigrationsCollection = "migrations"
+
+	migrationsLockID    = "migrations"
+	migrationsLockTTL   = 10 * time.Minute // Lock of a crashed replica is taken over after it
+	migrationsLockRetry = time.Second
+
+	// attemptsRetention is how long failed sign-in counters are kept after the last failure or lockout end
+	attemptsRetention = 24 * time.Hour
+)
+
+// Migration is one versioned schema change. Down reverts Up
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+type MigrationStatus struct {
+	Version     int    `bson:"_id"`
+	Description string `bson:"description"`
+	AppliedAt   int64  `bson:"appliedAt"` // 0 - pending
+}
+
+// Migrate applies migrations which are not applied yet. Replicas started at the same time wait for the one holding the lock
+func Migrate(ctx context.Context, db *mongodb.Mongo, cfg *config.Repo) error {
+	records, unlock, err := lockMigrations(ctx, db, cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	applied, err := appliedMigrations(ctx, records)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range pending(migrations(cfg), applied) {
+		err = migration.Up(ctx, db.DB)
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %s", migration.Version, migration.Description, err.Error())
+		}
+
+		_, err = records.InsertOne(ctx, MigrationStatus{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now().Unix(),
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("migration %d %s applied\n", migration.Version, migration.Description)
+	}
+
+	return nil
+}
+
+// Rollback reverts applied migrations newer than version, the latest first
+func Rollback(ctx context.Context, db *mongodb.Mongo, cfg *config.Repo, version int) error {
+	records, unlock, err := lockMigrations(ctx, db, cfg)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	applied, err := appliedMigrations(ctx, records)
+	if err != nil {
+		return err
+	}
+
+	all := migrations(cfg)
+	for i := len(all) - 1; i >= 0; i-- {
+		migration := all[i]
+		if migration.Version <= version || applied[migration.Version] == 0 {
+			continue
+		}
+
+		err = migration.Down(ctx, db.DB)
+		if err != nil {
+			return fmt.Errorf("rollback %d %s: %s", migration.Version, migration.Description, err.Error())
+		}
+
+		_, err = records.DeleteOne(ctx, bson.M{"_id": migration.Version})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("migration %d %s reverted\n", migration.Version, migration.Description)
+	}
+
+	return nil
+}
+
+// Migrations returns all known migrations with the time they were applied
+func Migrations(ctx context.Context, db *mongodb.Mongo, cfg *config.Repo) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(ctx, db.DB.Collection(migrationsCollection(cfg)))
+	if err != nil {
+		return nil, err
+	}
+
+	all := migrations(cfg)
+	statuses := make([]MigrationStatus, 0, len(all))
+	for _, migration := range all {
+		statuses = append(statuses, MigrationStatus{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   applied[migration.Version],
+		})
+	}
+
+	return statuses, nil
+}
+
+// pending returns not applied migrations in version order
+func pending(all []Migration, applied map[int]int64) []Migration {
+	results := []Migration{}
+	for _, migration := range all {
+		if applied[migration.Version] == 0 {
+			results = append(results, migration)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Version < results[j].Version })
+	return results
+}
+
+func appliedMigrations(ctx context.Context, records *mongo.Collection) (map[int]int64, error) {
+	cursor, err := records.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	err = cursor.All(ctx, &statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]int64, len(statuses))
+	for _, status := range statuses {
+		applied[status.Version] = status.AppliedAt
+	}
+
+	return applied, nil
+}
+
+func migrationsCollection(cfg *config.Repo) string {
+	if cfg.MigrationsCollection != "" {
+		return cfg.MigrationsCollection
+	}
+	return defaultMigrationsCollection
+}
+
+// lockMigrations takes the lock document in <migrations>Lock collection. The upsert matches only an expired lock,
+// so while another replica holds it the insert fails with duplicate key and we wait
+func lockMigrations(ctx context.Context, db *mongodb.Mongo, cfg *config.Repo) (*mongo.Collection, func(), error) {
+	name := migrationsCollection(cfg)
+	locks := db.DB.Collection(name + "Lock")
+
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s-%s", hostname, primitive.NewObjectID().Hex())
+
+	for {
+		now := time.Now()
+		_, err := locks.UpdateOne(ctx,
+			bson.M{"_id": migrationsLockID, "lockedUntil": bson.M{"$lt": now.Unix()}},
+			bson.M{"$set": bson.M{"owner": owner, "lockedUntil": now.Add(migrationsLockTTL).Unix()}},
+			options.Update().SetUpsert(true),
+		)
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(migrationsLockRetry):
+		}
+	}
+
+	unlock := func() {
+		_, err := locks.DeleteOne(context.Background(), bson.M{"_id": migrationsLockID, "owner": owner})
+		if err != nil {
+			log.Printf("migrations lock is not released - %s\n", err.Error())
+		}
+	}
+
+	return db.DB.Collection(name), unlock, nil
+}
+
+// migrations are applied in version order. Never change released ones, add a new version instead
+func migrations(cfg *config.Repo) []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "unique user email",
+			Up: createIndexes(cfg.UsersCollection, mongo.IndexModel{
+				Keys:    bson.D{{Key: "email", Value: 1}},
+				Options: options.Index().SetName("email_unique").SetUnique(true),
+			}),
+			Down: dropIndexes(cfg.UsersCollection, "email_unique"),
+		},
+		{
+			Version:     2,
+			Description: "files by user and organization sorted by date",
+			Up: createIndexes(cfg.FilesCollection,
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "date", Value: -1}},
+					Options: options.Index().SetName("user_date"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "orgId", Value: 1}, {Key: "date", Value: -1}},
+					Options: options.Index().SetName("org_date").SetSparse(true),
+				},
+			),
+			Down: dropIndexes(cfg.FilesCollection, "user_date", "org_date"),
+		},
+		{
+			Version:     3,
+			Description: "lookup indexes of tokens, sessions, api keys and organizations",
+			Up: sequence(
+				createIndexes(cfg.UsersCollection,
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "passwordReset.tokenHash", Value: 1}},
+						Options: options.Index().SetName("reset_token").SetSparse(true),
+					},
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+						Options: options.Index().SetName("identity"),
+					},
+				),
+				createIndexes(cfg.TokensCollection, mongo.IndexModel{
+					Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "lastSeenAt", Value: -1}},
+					Options: options.Index().SetName("user_last_seen"),
+				}),
+				createIndexes(cfg.APIKeysCollection,
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "keyHash", Value: 1}},
+						Options: options.Index().SetName("key_hash_unique").SetUnique(true),
+					},
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+						Options: options.Index().SetName("user_created"),
+					},
+				),
+				createIndexes(cfg.OrganizationsCollection,
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "members.userId", Value: 1}},
+						Options: options.Index().SetName("member"),
+					},
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "invitations.tokenHash", Value: 1}},
+						Options: options.Index().SetName("invitation_token"),
+					},
+				),
+			),
+			Down: sequence(
+				dropIndexes(cfg.UsersCollection, "reset_token", "identity"),
+				dropIndexes(cfg.TokensCollection, "user_last_seen"),
+				dropIndexes(cfg.APIKeysCollection, "key_hash_unique", "user_created"),
+				dropIndexes(cfg.OrganizationsCollection, "member", "invitation_token"),
+			),
+		},
+		{
+			Version:     4,
+			Description: "expire sessions and sign-in attempts",
+			Up: sequence(
+				// expireAt dates of documents written before, TTL indexes work only with dates
+				updateMany(cfg.TokensCollection, bson.A{
+					bson.M{"$set": bson.M{"expireAt": bson.M{"$toDate": bson.M{"$multiply": bson.A{"$expiresAt", 1000}}}}},
+				}),
+				updateMany(cfg.AttemptsCollection, bson.A{
+					bson.M{"$set": bson.M{"expireAt": bson.M{"$toDate": bson.M{"$add": bson.A{
+						bson.M{"$multiply": bson.A{bson.M{"$max": bson.A{"$lastFailureAt", "$lockedUntil"}}, 1000}},
+						attemptsRetention.Milliseconds(),
+					}}}}},
+				}),
+				createIndexes(cfg.TokensCollection, mongo.IndexModel{
+					Keys:    bson.D{{Key: "expireAt", Value: 1}},
+					Options: options.Index().SetName("expire").SetExpireAfterSeconds(0),
+				}),
+				createIndexes(cfg.AttemptsCollection, mongo.IndexModel{
+					Keys:    bson.D{{Key: "expireAt", Value: 1}},
+					Options: options.Index().SetName("expire").SetExpireAfterSeconds(0),
+				}),
+			),
+			Down: sequence(
+				dropIndexes(cfg.TokensCollection, "expire"),
+				dropIndexes(cfg.AttemptsCollection, "expire"),
+				updateMany(cfg.TokensCollection, bson.M{"$unset": bson.M{"expireAt": ""}}),
+				updateMany(cfg.AttemptsCollection, bson.M{"$unset": bson.M{"expireAt": ""}}),
+			),
+		},
+		{
+			Version:     5,
+			Description: "pending uploads and files by name for upload reconciler",
+			Up: sequence(
+				createIndexes(uploadsCollection(cfg), mongo.IndexModel{
+					Keys:    bson.D{{Key: "createdAt", Value: 1}},
+					Options: options.Index().SetName("created"),
+				}),
+				createIndexes(cfg.FilesCollection, mongo.IndexModel{
+					Keys:    bson.D{{Key: "filename", Value: 1}},
+					Options: options.Index().SetName("filename"),
+				}),
+			),
+			Down: sequence(
+				dropIndexes(uploadsCollection(cfg), "created"),
+				dropIndexes(cfg.FilesCollection, "filename"),
+			),
+		},
+		{
+			Version:     6,
+			Description: "idempotency keys",
+			Up: createIndexes(idempotencyCollection(cfg),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "scope", Value: 1}, {Key: "key", Value: 1}},
+					Options: options.Index().SetName("scope_key_unique").SetUnique(true),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "expireAt", Value: 1}},
+					Options: options.Index().SetName("expire").SetExpireAfterSeconds(0),
+				},
+			),
+			Down: dropIndexes(idempotencyCollection(cfg), "scope_key_unique", "expire"),
+		},
+		{
+			Version:     7,
+			Description: "jobs queue and file statuses",
+			Up: sequence(
+				createIndexes(jobsCollection(cfg),
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "state", Value: 1}, {Key: "runAt", Value: 1}},
+						Options: options.Index().SetName("state_run_at"),
+					},
+					mongo.IndexModel{
+						Keys:    bson.D{{Key: "state", Value: 1}, {Key: "leasedUntil", Value: 1}},
+						Options: options.Index().SetName("state_leased_until"),
+					},
+				),
+				// Files uploaded before processing was added
+				updateMany(cfg.FilesCollection, bson.A{
+					bson.M{"$set": bson.M{"status": bson.M{"$ifNull": bson.A{"$status", models.FileReady}}}},
+				}),
+			),
+			Down: dropIndexes(jobsCollection(cfg), "state_run_at", "state_leased_until"),
+		},
+		{
+			Version:     8,
+			Description: "webhooks and delivery log",
+			Up: sequence(
+				createIndexes(webhooksCollection(cfg), mongo.IndexModel{
+					Keys:    bson.D{{Key: "events", Value: 1}},
+					Options: options.Index().SetName("events"),
+				}),
+				createIndexes(deliveriesCollection(cfg), mongo.IndexModel{
+					Keys:    bson.D{{Key: "webhookId", Value: 1}, {Key: "createdAt", Value: -1}},
+					Options: options.Index().SetName("webhook_id_created_at"),
+				}),
+			),
+			Down: sequence(
+				dropIndexes(webhooksCollection(cfg), "events"),
+				dropIndexes(deliveriesCollection(cfg), "webhook_id_created_at"),
+			),
+		},
+		{
+			Version:     9,
+			Description: "outbox of domain events",
+			// Also creates the collection, inserts in transactions need it to exist
+			Up: createIndexes(outboxCollection(cfg), mongo.IndexModel{
+				Keys:    bson.D{{Key: "publishedAt", Value: 1}, {Key: "createdAt", Value: 1}},
+				Options: options.Index().SetName("published_at_created_at"),
+			}),
+			Down: dropIndexes(outboxCollection(cfg), "published_at_created_at"),
+		},
+	}
+}
+
+type migrationStep func(ctx context.Context, db *mongo.Database) error
+
+func sequence(steps ...migrationStep) migrationStep {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, step := range steps {
+			err := step(ctx, db)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func createIndexes(collection string, indexes ...mongo.IndexModel) migrationStep {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		return err
+	}
+}
+
+func dropIndexes(collection string, names ...string) migrationStep {
+	return func(ctx context.Context, db *mongo.Database) error {
+		for _, name := range names {
+			_, err := db.Collection(collection).Indexes().DropOne(ctx, name)
+			if err != nil && !isNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func updateMany(collection string, update interface{}) migrationStep {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).UpdateMany(ctx, bson.M{}, update)
+		return err
+	}
+}
+
+// isNotFound reports missing index or collection, reverting is idempotent with it
+func isNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code == 26 || cmdErr.Code == 27 // NamespaceNotFound, IndexNotFound
+	}
+	return false
+}
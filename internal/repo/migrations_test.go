@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"creatly-task/internal/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_migrations(t *testing.T) {
+	all := migrations(&config.Repo{})
+
+	for i, migration := range all {
+		assert.Equal(t, i+1, migration.Version, "versions must go one by one")
+		assert.NotEmpty(t, migration.Description)
+		assert.NotNil(t, migration.Up)
+		assert.NotNil(t, migration.Down)
+	}
+}
+
+func Test_pending(t *testing.T) {
+	all := []Migration{{Version: 3}, {Version: 1}, {Version: 2}}
+
+	testTable := []struct {
+		name    string
+		applied map[int]int64
+		expect  []int
+	}{
+		{
+			name:    "OK: nothing applied",
+			applied: map[int]int64{},
+			expect:  []int{1, 2, 3},
+		},
+		{
+			name:    "OK: gap is applied",
+			applied: map[int]int64{1: 1640995200, 3: 1640995200},
+			expect:  []int{2},
+		},
+		{
+			name:    "OK: all applied",
+			applied: map[int]int64{1: 1640995200, 2: 1640995200, 3: 1640995200},
+			expect:  []int{},
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			versions := []int{}
+			for _, migration := range pending(all, test.applied) {
+				versions = append(versions, migration.Version)
+			}
+
+			assert.Equal(t, test.expect, versions)
+		})
+	}
+}
@@ -5,6 +5,7 @@
 package mock_repo
 
 import (
+	context "context"
 	models "creatly-task/internal/models"
 	reflect "reflect"
 
@@ -34,71 +35,315 @@ func (m *MockUsers) EXPECT() *MockUsersMockRecorder {
 	return m.recorder
 }
 
+// AddIdentity mocks base method.
+func (m *MockUsers) AddIdentity(ctx context.Context, id string, identity *models.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIdentity", ctx, id, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddIdentity indicates an expected call of AddIdentity.
+func (mr *MockUsersMockRecorder) AddIdentity(ctx, id, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIdentity", reflect.TypeOf((*MockUsers)(nil).AddIdentity), ctx, id, identity)
+}
+
 // CreateUser mocks base method.
-func (m *MockUsers) CreateUser(arg0 *models.UserSignUpInput) error {
+func (m *MockUsers) CreateUser(ctx context.Context, input *models.UserSignUpInput) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, input)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUsersMockRecorder) CreateUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUsersMockRecorder) CreateUser(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUsers)(nil).CreateUser), ctx, input)
+}
+
+// Delete mocks base method.
+func (m *MockUsers) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUsersMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUsers)(nil).CreateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUsers)(nil).Delete), ctx, id)
 }
 
 // GetUserByCreds mocks base method.
-func (m *MockUsers) GetUserByCreds(email string) (*models.UserSignInOutput, error) {
+func (m *MockUsers) GetUserByCreds(ctx context.Context, email string) (*models.UserSignInOutput, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByCreds", email)
+	ret := m.ctrl.Call(m, "GetUserByCreds", ctx, email)
 	ret0, _ := ret[0].(*models.UserSignInOutput)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserByCreds indicates an expected call of GetUserByCreds.
-func (mr *MockUsersMockRecorder) GetUserByCreds(email interface{}) *gomock.Call {
+func (mr *MockUsersMockRecorder) GetUserByCreds(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByCreds", reflect.TypeOf((*MockUsers)(nil).GetUserByCreds), ctx, email)
+}
+
+// GetUserByID mocks base method.
+func (m *MockUsers) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockUsersMockRecorder) GetUserByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockUsers)(nil).GetUserByID), ctx, id)
+}
+
+// GetUserByIdentity mocks base method.
+func (m *MockUsers) GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdentity", ctx, provider, subject)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByIdentity indicates an expected call of GetUserByIdentity.
+func (mr *MockUsersMockRecorder) GetUserByIdentity(ctx, provider, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdentity", reflect.TypeOf((*MockUsers)(nil).GetUserByIdentity), ctx, provider, subject)
+}
+
+// GetUserByResetToken mocks base method.
+func (m *MockUsers) GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByResetToken", ctx, tokenHash)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByResetToken indicates an expected call of GetUserByResetToken.
+func (mr *MockUsersMockRecorder) GetUserByResetToken(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByResetToken", reflect.TypeOf((*MockUsers)(nil).GetUserByResetToken), ctx, tokenHash)
+}
+
+// List mocks base method.
+func (m *MockUsers) List(ctx context.Context, filter *models.UsersFilter) ([]models.User, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, filter)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockUsersMockRecorder) List(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUsers)(nil).List), ctx, filter)
+}
+
+// SetDisabled mocks base method.
+func (m *MockUsers) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDisabled", ctx, id, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDisabled indicates an expected call of SetDisabled.
+func (mr *MockUsersMockRecorder) SetDisabled(ctx, id, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDisabled", reflect.TypeOf((*MockUsers)(nil).SetDisabled), ctx, id, disabled)
+}
+
+// SetMFA mocks base method.
+func (m *MockUsers) SetMFA(ctx context.Context, id string, mfa *models.MFA) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMFA", ctx, id, mfa)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMFA indicates an expected call of SetMFA.
+func (mr *MockUsersMockRecorder) SetMFA(ctx, id, mfa interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMFA", reflect.TypeOf((*MockUsers)(nil).SetMFA), ctx, id, mfa)
+}
+
+// SetPasswordResetToken mocks base method.
+func (m *MockUsers) SetPasswordResetToken(ctx context.Context, id string, token *models.PasswordResetToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPasswordResetToken", ctx, id, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPasswordResetToken indicates an expected call of SetPasswordResetToken.
+func (mr *MockUsersMockRecorder) SetPasswordResetToken(ctx, id, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPasswordResetToken", reflect.TypeOf((*MockUsers)(nil).SetPasswordResetToken), ctx, id, token)
+}
+
+// SetVerified mocks base method.
+func (m *MockUsers) SetVerified(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVerified", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVerified indicates an expected call of SetVerified.
+func (mr *MockUsersMockRecorder) SetVerified(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVerified", reflect.TypeOf((*MockUsers)(nil).SetVerified), ctx, email)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUsers) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUsersMockRecorder) UpdatePassword(ctx, id, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUsers)(nil).UpdatePassword), ctx, id, passwordHash)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUsers) UpdateProfile(ctx context.Context, id string, input *models.ProfileUpdateInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, id, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUsersMockRecorder) UpdateProfile(ctx, id, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByCreds", reflect.TypeOf((*MockUsers)(nil).GetUserByCreds), email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUsers)(nil).UpdateProfile), ctx, id, input)
 }
 
-// MockTokens is a mock of Tokens interface.
-type MockTokens struct {
+// MockSessions is a mock of Sessions interface.
+type MockSessions struct {
 	ctrl     *gomock.Controller
-	recorder *MockTokensMockRecorder
+	recorder *MockSessionsMockRecorder
 }
 
-// MockTokensMockRecorder is the mock recorder for MockTokens.
-type MockTokensMockRecorder struct {
-	mock *MockTokens
+// MockSessionsMockRecorder is the mock recorder for MockSessions.
+type MockSessionsMockRecorder struct {
+	mock *MockSessions
 }
 
-// NewMockTokens creates a new mock instance.
-func NewMockTokens(ctrl *gomock.Controller) *MockTokens {
-	mock := &MockTokens{ctrl: ctrl}
-	mock.recorder = &MockTokensMockRecorder{mock}
+// NewMockSessions creates a new mock instance.
+func NewMockSessions(ctrl *gomock.Controller) *MockSessions {
+	mock := &MockSessions{ctrl: ctrl}
+	mock.recorder = &MockSessionsMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockTokens) EXPECT() *MockTokensMockRecorder {
+func (m *MockSessions) EXPECT() *MockSessionsMockRecorder {
 	return m.recorder
 }
 
-// GetUserIDByToken mocks base method.
-func (m *MockTokens) GetUserIDByToken(token string) (int64, error) {
+// ByUser mocks base method.
+func (m *MockSessions) ByUser(ctx context.Context, userId string, now int64) ([]models.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserIDByToken", token)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "ByUser", ctx, userId, now)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByUser indicates an expected call of ByUser.
+func (mr *MockSessionsMockRecorder) ByUser(ctx, userId, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByUser", reflect.TypeOf((*MockSessions)(nil).ByUser), ctx, userId, now)
+}
+
+// Create mocks base method.
+func (m *MockSessions) Create(ctx context.Context, session *models.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockSessionsMockRecorder) Create(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockSessions)(nil).Create), ctx, session)
+}
+
+// Delete mocks base method.
+func (m *MockSessions) Delete(ctx context.Context, userId, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockSessionsMockRecorder) Delete(ctx, userId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockSessions)(nil).Delete), ctx, userId, id)
+}
+
+// DeleteByUser mocks base method.
+func (m *MockSessions) DeleteByUser(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockSessionsMockRecorder) DeleteByUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockSessions)(nil).DeleteByUser), ctx, userId)
+}
+
+// Get mocks base method.
+func (m *MockSessions) Get(ctx context.Context, id string) (*models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*models.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserIDByToken indicates an expected call of GetUserIDByToken.
-func (mr *MockTokensMockRecorder) GetUserIDByToken(token interface{}) *gomock.Call {
+// Get indicates an expected call of Get.
+func (mr *MockSessionsMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSessions)(nil).Get), ctx, id)
+}
+
+// Touch mocks base method.
+func (m *MockSessions) Touch(ctx context.Context, id string, at int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Touch", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockSessionsMockRecorder) Touch(ctx, id, at interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByToken", reflect.TypeOf((*MockTokens)(nil).GetUserIDByToken), token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockSessions)(nil).Touch), ctx, id, at)
 }
 
 // MockFiles is a mock of Files interface.
@@ -125,30 +370,1139 @@ func (m *MockFiles) EXPECT() *MockFilesMockRecorder {
 }
 
 // AddLog mocks base method.
-func (m *MockFiles) AddLog(log *models.FileUploadLogInput) error {
+func (m *MockFiles) AddLog(ctx context.Context, log *models.FileUploadLogInput) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddLog", log)
+	ret := m.ctrl.Call(m, "AddLog", ctx, log)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddLog indicates an expected call of AddLog.
-func (mr *MockFilesMockRecorder) AddLog(log interface{}) *gomock.Call {
+func (mr *MockFilesMockRecorder) AddLog(ctx, log interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLog", reflect.TypeOf((*MockFiles)(nil).AddLog), log)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLog", reflect.TypeOf((*MockFiles)(nil).AddLog), ctx, log)
 }
 
 // All mocks base method.
-func (m *MockFiles) All() ([]models.FileOut, error) {
+func (m *MockFiles) All(ctx context.Context) ([]models.FileOut, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "All")
+	ret := m.ctrl.Call(m, "All", ctx)
 	ret0, _ := ret[0].([]models.FileOut)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // All indicates an expected call of All.
-func (mr *MockFilesMockRecorder) All() *gomock.Call {
+func (mr *MockFilesMockRecorder) All(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockFiles)(nil).All), ctx)
+}
+
+// ByOrg mocks base method.
+func (m *MockFiles) ByOrg(ctx context.Context, orgId string) ([]models.FileOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByOrg", ctx, orgId)
+	ret0, _ := ret[0].([]models.FileOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByOrg indicates an expected call of ByOrg.
+func (mr *MockFilesMockRecorder) ByOrg(ctx, orgId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByOrg", reflect.TypeOf((*MockFiles)(nil).ByOrg), ctx, orgId)
+}
+
+// ByUser mocks base method.
+func (m *MockFiles) ByUser(ctx context.Context, userId string) ([]models.FileOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByUser", ctx, userId)
+	ret0, _ := ret[0].([]models.FileOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByUser indicates an expected call of ByUser.
+func (mr *MockFilesMockRecorder) ByUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByUser", reflect.TypeOf((*MockFiles)(nil).ByUser), ctx, userId)
+}
+
+// Delete mocks base method.
+func (m *MockFiles) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockFilesMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockFiles)(nil).Delete), ctx, id)
+}
+
+// DeleteByUser mocks base method.
+func (m *MockFiles) DeleteByUser(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockFilesMockRecorder) DeleteByUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockFiles)(nil).DeleteByUser), ctx, userId)
+}
+
+// Exists mocks base method.
+func (m *MockFiles) Exists(ctx context.Context, filename string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exists", ctx, filename)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exists indicates an expected call of Exists.
+func (mr *MockFilesMockRecorder) Exists(ctx, filename interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockFiles)(nil).Exists), ctx, filename)
+}
+
+// Get mocks base method.
+func (m *MockFiles) Get(ctx context.Context, id string) (*models.FileOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*models.FileOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockFilesMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockFiles)(nil).Get), ctx, id)
+}
+
+// OrgUsage mocks base method.
+func (m *MockFiles) OrgUsage(ctx context.Context, orgId string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrgUsage", ctx, orgId)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrgUsage indicates an expected call of OrgUsage.
+func (mr *MockFilesMockRecorder) OrgUsage(ctx, orgId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrgUsage", reflect.TypeOf((*MockFiles)(nil).OrgUsage), ctx, orgId)
+}
+
+// SetStatus mocks base method.
+func (m *MockFiles) SetStatus(ctx context.Context, id string, update *models.FileStatusUpdate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", ctx, id, update)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockFilesMockRecorder) SetStatus(ctx, id, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockFiles)(nil).SetStatus), ctx, id, update)
+}
+
+// Stats mocks base method.
+func (m *MockFiles) Stats(ctx context.Context, userId string) (*models.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", ctx, userId)
+	ret0, _ := ret[0].(*models.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockFilesMockRecorder) Stats(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockFiles)(nil).Stats), ctx, userId)
+}
+
+// MockUploads is a mock of Uploads interface.
+type MockUploads struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadsMockRecorder
+}
+
+// MockUploadsMockRecorder is the mock recorder for MockUploads.
+type MockUploadsMockRecorder struct {
+	mock *MockUploads
+}
+
+// NewMockUploads creates a new mock instance.
+func NewMockUploads(ctrl *gomock.Controller) *MockUploads {
+	mock := &MockUploads{ctrl: ctrl}
+	mock.recorder = &MockUploadsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploads) EXPECT() *MockUploadsMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockUploads) Create(ctx context.Context, upload *models.PendingUpload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, upload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUploadsMockRecorder) Create(ctx, upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUploads)(nil).Create), ctx, upload)
+}
+
+// Delete mocks base method.
+func (m *MockUploads) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUploadsMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUploads)(nil).Delete), ctx, id)
+}
+
+// Stale mocks base method.
+func (m *MockUploads) Stale(ctx context.Context, before int64) ([]models.PendingUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stale", ctx, before)
+	ret0, _ := ret[0].([]models.PendingUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stale indicates an expected call of Stale.
+func (mr *MockUploadsMockRecorder) Stale(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stale", reflect.TypeOf((*MockUploads)(nil).Stale), ctx, before)
+}
+
+// MockIdempotencyKeys is a mock of IdempotencyKeys interface.
+type MockIdempotencyKeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyKeysMockRecorder
+}
+
+// MockIdempotencyKeysMockRecorder is the mock recorder for MockIdempotencyKeys.
+type MockIdempotencyKeysMockRecorder struct {
+	mock *MockIdempotencyKeys
+}
+
+// NewMockIdempotencyKeys creates a new mock instance.
+func NewMockIdempotencyKeys(ctrl *gomock.Controller) *MockIdempotencyKeys {
+	mock := &MockIdempotencyKeys{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyKeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyKeys) EXPECT() *MockIdempotencyKeysMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockIdempotencyKeys) Complete(ctx context.Context, scope, key string, response *models.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, scope, key, response)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockIdempotencyKeysMockRecorder) Complete(ctx, scope, key, response interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockIdempotencyKeys)(nil).Complete), ctx, scope, key, response)
+}
+
+// Get mocks base method.
+func (m *MockIdempotencyKeys) Get(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, scope, key)
+	ret0, _ := ret[0].(*models.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockIdempotencyKeysMockRecorder) Get(ctx, scope, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockIdempotencyKeys)(nil).Get), ctx, scope, key)
+}
+
+// Release mocks base method.
+func (m *MockIdempotencyKeys) Release(ctx context.Context, scope, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, scope, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockIdempotencyKeysMockRecorder) Release(ctx, scope, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockIdempotencyKeys)(nil).Release), ctx, scope, key)
+}
+
+// Reserve mocks base method.
+func (m *MockIdempotencyKeys) Reserve(ctx context.Context, record *models.IdempotencyRecord, now int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, record, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockIdempotencyKeysMockRecorder) Reserve(ctx, record, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockIdempotencyKeys)(nil).Reserve), ctx, record, now)
+}
+
+// MockJobs is a mock of Jobs interface.
+type MockJobs struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobsMockRecorder
+}
+
+// MockJobsMockRecorder is the mock recorder for MockJobs.
+type MockJobsMockRecorder struct {
+	mock *MockJobs
+}
+
+// NewMockJobs creates a new mock instance.
+func NewMockJobs(ctrl *gomock.Controller) *MockJobs {
+	mock := &MockJobs{ctrl: ctrl}
+	mock.recorder = &MockJobsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobs) EXPECT() *MockJobsMockRecorder {
+	return m.recorder
+}
+
+// Bury mocks base method.
+func (m *MockJobs) Bury(ctx context.Context, id, worker, failure string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Bury", ctx, id, worker, failure)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Bury indicates an expected call of Bury.
+func (mr *MockJobsMockRecorder) Bury(ctx, id, worker, failure interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bury", reflect.TypeOf((*MockJobs)(nil).Bury), ctx, id, worker, failure)
+}
+
+// Complete mocks base method.
+func (m *MockJobs) Complete(ctx context.Context, id, worker string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, id, worker)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockJobsMockRecorder) Complete(ctx, id, worker interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockJobs)(nil).Complete), ctx, id, worker)
+}
+
+// Dead mocks base method.
+func (m *MockJobs) Dead(ctx context.Context) ([]models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dead", ctx)
+	ret0, _ := ret[0].([]models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Dead indicates an expected call of Dead.
+func (mr *MockJobsMockRecorder) Dead(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dead", reflect.TypeOf((*MockJobs)(nil).Dead), ctx)
+}
+
+// Enqueue mocks base method.
+func (m *MockJobs) Enqueue(ctx context.Context, job *models.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockJobsMockRecorder) Enqueue(ctx, job interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockJobs)(nil).Enqueue), ctx, job)
+}
+
+// Lease mocks base method.
+func (m *MockJobs) Lease(ctx context.Context, worker string, now, until int64) (*models.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lease", ctx, worker, now, until)
+	ret0, _ := ret[0].(*models.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lease indicates an expected call of Lease.
+func (mr *MockJobsMockRecorder) Lease(ctx, worker, now, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lease", reflect.TypeOf((*MockJobs)(nil).Lease), ctx, worker, now, until)
+}
+
+// Requeue mocks base method.
+func (m *MockJobs) Requeue(ctx context.Context, id string, runAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Requeue", ctx, id, runAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Requeue indicates an expected call of Requeue.
+func (mr *MockJobsMockRecorder) Requeue(ctx, id, runAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Requeue", reflect.TypeOf((*MockJobs)(nil).Requeue), ctx, id, runAt)
+}
+
+// Retry mocks base method.
+func (m *MockJobs) Retry(ctx context.Context, id, worker, failure string, runAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retry", ctx, id, worker, failure, runAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Retry indicates an expected call of Retry.
+func (mr *MockJobsMockRecorder) Retry(ctx, id, worker, failure, runAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retry", reflect.TypeOf((*MockJobs)(nil).Retry), ctx, id, worker, failure, runAt)
+}
+
+// MockWebhooks is a mock of Webhooks interface.
+type MockWebhooks struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhooksMockRecorder
+}
+
+// MockWebhooksMockRecorder is the mock recorder for MockWebhooks.
+type MockWebhooksMockRecorder struct {
+	mock *MockWebhooks
+}
+
+// NewMockWebhooks creates a new mock instance.
+func NewMockWebhooks(ctrl *gomock.Controller) *MockWebhooks {
+	mock := &MockWebhooks{ctrl: ctrl}
+	mock.recorder = &MockWebhooksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhooks) EXPECT() *MockWebhooksMockRecorder {
+	return m.recorder
+}
+
+// All mocks base method.
+func (m *MockWebhooks) All(ctx context.Context) ([]models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All", ctx)
+	ret0, _ := ret[0].([]models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All.
+func (mr *MockWebhooksMockRecorder) All(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockWebhooks)(nil).All), ctx)
+}
+
+// ByEvent mocks base method.
+func (m *MockWebhooks) ByEvent(ctx context.Context, event string) ([]models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByEvent", ctx, event)
+	ret0, _ := ret[0].([]models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByEvent indicates an expected call of ByEvent.
+func (mr *MockWebhooksMockRecorder) ByEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByEvent", reflect.TypeOf((*MockWebhooks)(nil).ByEvent), ctx, event)
+}
+
+// Create mocks base method.
+func (m *MockWebhooks) Create(ctx context.Context, webhook *models.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebhooksMockRecorder) Create(ctx, webhook interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhooks)(nil).Create), ctx, webhook)
+}
+
+// Delete mocks base method.
+func (m *MockWebhooks) Delete(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockWebhooksMockRecorder) Delete(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWebhooks)(nil).Delete), ctx, id)
+}
+
+// Get mocks base method.
+func (m *MockWebhooks) Get(ctx context.Context, id string) (*models.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*models.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockWebhooksMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockWebhooks)(nil).Get), ctx, id)
+}
+
+// MockDeliveries is a mock of Deliveries interface.
+type MockDeliveries struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeliveriesMockRecorder
+}
+
+// MockDeliveriesMockRecorder is the mock recorder for MockDeliveries.
+type MockDeliveriesMockRecorder struct {
+	mock *MockDeliveries
+}
+
+// NewMockDeliveries creates a new mock instance.
+func NewMockDeliveries(ctrl *gomock.Controller) *MockDeliveries {
+	mock := &MockDeliveries{ctrl: ctrl}
+	mock.recorder = &MockDeliveriesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeliveries) EXPECT() *MockDeliveriesMockRecorder {
+	return m.recorder
+}
+
+// AddAttempt mocks base method.
+func (m *MockDeliveries) AddAttempt(ctx context.Context, id string, attempt *models.DeliveryAttempt, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAttempt", ctx, id, attempt, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAttempt indicates an expected call of AddAttempt.
+func (mr *MockDeliveriesMockRecorder) AddAttempt(ctx, id, attempt, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAttempt", reflect.TypeOf((*MockDeliveries)(nil).AddAttempt), ctx, id, attempt, status)
+}
+
+// ByWebhook mocks base method.
+func (m *MockDeliveries) ByWebhook(ctx context.Context, webhookID string, limit int64) ([]models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByWebhook", ctx, webhookID, limit)
+	ret0, _ := ret[0].([]models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByWebhook indicates an expected call of ByWebhook.
+func (mr *MockDeliveriesMockRecorder) ByWebhook(ctx, webhookID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByWebhook", reflect.TypeOf((*MockDeliveries)(nil).ByWebhook), ctx, webhookID, limit)
+}
+
+// Create mocks base method.
+func (m *MockDeliveries) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockDeliveriesMockRecorder) Create(ctx, delivery interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDeliveries)(nil).Create), ctx, delivery)
+}
+
+// DeleteByWebhook mocks base method.
+func (m *MockDeliveries) DeleteByWebhook(ctx context.Context, webhookID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByWebhook", ctx, webhookID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByWebhook indicates an expected call of DeleteByWebhook.
+func (mr *MockDeliveriesMockRecorder) DeleteByWebhook(ctx, webhookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByWebhook", reflect.TypeOf((*MockDeliveries)(nil).DeleteByWebhook), ctx, webhookID)
+}
+
+// Get mocks base method.
+func (m *MockDeliveries) Get(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*models.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDeliveriesMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDeliveries)(nil).Get), ctx, id)
+}
+
+// SetStatus mocks base method.
+func (m *MockDeliveries) SetStatus(ctx context.Context, id, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockDeliveriesMockRecorder) SetStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockDeliveries)(nil).SetStatus), ctx, id, status)
+}
+
+// MockOutbox is a mock of Outbox interface.
+type MockOutbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxMockRecorder
+}
+
+// MockOutboxMockRecorder is the mock recorder for MockOutbox.
+type MockOutboxMockRecorder struct {
+	mock *MockOutbox
+}
+
+// NewMockOutbox creates a new mock instance.
+func NewMockOutbox(ctrl *gomock.Controller) *MockOutbox {
+	mock := &MockOutbox{ctrl: ctrl}
+	mock.recorder = &MockOutboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutbox) EXPECT() *MockOutboxMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockOutbox) Add(ctx context.Context, event *models.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockOutboxMockRecorder) Add(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockOutbox)(nil).Add), ctx, event)
+}
+
+// Claim mocks base method.
+func (m *MockOutbox) Claim(ctx context.Context, now, until int64) (*models.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, now, until)
+	ret0, _ := ret[0].(*models.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockOutboxMockRecorder) Claim(ctx, now, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockOutbox)(nil).Claim), ctx, now, until)
+}
+
+// DeletePublished mocks base method.
+func (m *MockOutbox) DeletePublished(ctx context.Context, before int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePublished", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePublished indicates an expected call of DeletePublished.
+func (mr *MockOutboxMockRecorder) DeletePublished(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePublished", reflect.TypeOf((*MockOutbox)(nil).DeletePublished), ctx, before)
+}
+
+// MarkDispatched mocks base method.
+func (m *MockOutbox) MarkDispatched(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDispatched", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDispatched indicates an expected call of MarkDispatched.
+func (mr *MockOutboxMockRecorder) MarkDispatched(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDispatched", reflect.TypeOf((*MockOutbox)(nil).MarkDispatched), ctx, id)
+}
+
+// MarkPublished mocks base method.
+func (m *MockOutbox) MarkPublished(ctx context.Context, id string, at int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPublished", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPublished indicates an expected call of MarkPublished.
+func (mr *MockOutboxMockRecorder) MarkPublished(ctx, id, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPublished", reflect.TypeOf((*MockOutbox)(nil).MarkPublished), ctx, id, at)
+}
+
+// Retry mocks base method.
+func (m *MockOutbox) Retry(ctx context.Context, id, failure string, availableAt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Retry", ctx, id, failure, availableAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Retry indicates an expected call of Retry.
+func (mr *MockOutboxMockRecorder) Retry(ctx, id, failure, availableAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retry", reflect.TypeOf((*MockOutbox)(nil).Retry), ctx, id, failure, availableAt)
+}
+
+// MockTransactions is a mock of Transactions interface.
+type MockTransactions struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactionsMockRecorder
+}
+
+// MockTransactionsMockRecorder is the mock recorder for MockTransactions.
+type MockTransactionsMockRecorder struct {
+	mock *MockTransactions
+}
+
+// NewMockTransactions creates a new mock instance.
+func NewMockTransactions(ctrl *gomock.Controller) *MockTransactions {
+	mock := &MockTransactions{ctrl: ctrl}
+	mock.recorder = &MockTransactionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactions) EXPECT() *MockTransactionsMockRecorder {
+	return m.recorder
+}
+
+// WithTransaction mocks base method.
+func (m *MockTransactions) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTransaction indicates an expected call of WithTransaction.
+func (mr *MockTransactionsMockRecorder) WithTransaction(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTransaction", reflect.TypeOf((*MockTransactions)(nil).WithTransaction), ctx, fn)
+}
+
+// MockAttempts is a mock of Attempts interface.
+type MockAttempts struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttemptsMockRecorder
+}
+
+// MockAttemptsMockRecorder is the mock recorder for MockAttempts.
+type MockAttemptsMockRecorder struct {
+	mock *MockAttempts
+}
+
+// NewMockAttempts creates a new mock instance.
+func NewMockAttempts(ctrl *gomock.Controller) *MockAttempts {
+	mock := &MockAttempts{ctrl: ctrl}
+	mock.recorder = &MockAttemptsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttempts) EXPECT() *MockAttemptsMockRecorder {
+	return m.recorder
+}
+
+// AddFailure mocks base method.
+func (m *MockAttempts) AddFailure(ctx context.Context, key string, at int64) (*models.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFailure", ctx, key, at)
+	ret0, _ := ret[0].(*models.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddFailure indicates an expected call of AddFailure.
+func (mr *MockAttemptsMockRecorder) AddFailure(ctx, key, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFailure", reflect.TypeOf((*MockAttempts)(nil).AddFailure), ctx, key, at)
+}
+
+// Get mocks base method.
+func (m *MockAttempts) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(*models.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAttemptsMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAttempts)(nil).Get), ctx, key)
+}
+
+// Lock mocks base method.
+func (m *MockAttempts) Lock(ctx context.Context, key string, until int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockAttemptsMockRecorder) Lock(ctx, key, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockAttempts)(nil).Lock), ctx, key, until)
+}
+
+// Reset mocks base method.
+func (m *MockAttempts) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockAttemptsMockRecorder) Reset(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockAttempts)(nil).Reset), ctx, key)
+}
+
+// MockAPIKeys is a mock of APIKeys interface.
+type MockAPIKeys struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeysMockRecorder
+}
+
+// MockAPIKeysMockRecorder is the mock recorder for MockAPIKeys.
+type MockAPIKeysMockRecorder struct {
+	mock *MockAPIKeys
+}
+
+// NewMockAPIKeys creates a new mock instance.
+func NewMockAPIKeys(ctrl *gomock.Controller) *MockAPIKeys {
+	mock := &MockAPIKeys{ctrl: ctrl}
+	mock.recorder = &MockAPIKeysMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeys) EXPECT() *MockAPIKeysMockRecorder {
+	return m.recorder
+}
+
+// ByUser mocks base method.
+func (m *MockAPIKeys) ByUser(ctx context.Context, userId string) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByUser", ctx, userId)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByUser indicates an expected call of ByUser.
+func (mr *MockAPIKeysMockRecorder) ByUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByUser", reflect.TypeOf((*MockAPIKeys)(nil).ByUser), ctx, userId)
+}
+
+// Create mocks base method.
+func (m *MockAPIKeys) Create(ctx context.Context, key *models.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAPIKeysMockRecorder) Create(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAPIKeys)(nil).Create), ctx, key)
+}
+
+// Delete mocks base method.
+func (m *MockAPIKeys) Delete(ctx context.Context, userId, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAPIKeysMockRecorder) Delete(ctx, userId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAPIKeys)(nil).Delete), ctx, userId, id)
+}
+
+// DeleteByUser mocks base method.
+func (m *MockAPIKeys) DeleteByUser(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByUser", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByUser indicates an expected call of DeleteByUser.
+func (mr *MockAPIKeysMockRecorder) DeleteByUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUser", reflect.TypeOf((*MockAPIKeys)(nil).DeleteByUser), ctx, userId)
+}
+
+// GetByHash mocks base method.
+func (m *MockAPIKeys) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByHash", ctx, keyHash)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByHash indicates an expected call of GetByHash.
+func (mr *MockAPIKeysMockRecorder) GetByHash(ctx, keyHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByHash", reflect.TypeOf((*MockAPIKeys)(nil).GetByHash), ctx, keyHash)
+}
+
+// Touch mocks base method.
+func (m *MockAPIKeys) Touch(ctx context.Context, id string, at int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Touch", ctx, id, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Touch indicates an expected call of Touch.
+func (mr *MockAPIKeysMockRecorder) Touch(ctx, id, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Touch", reflect.TypeOf((*MockAPIKeys)(nil).Touch), ctx, id, at)
+}
+
+// MockOrganizations is a mock of Organizations interface.
+type MockOrganizations struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrganizationsMockRecorder
+}
+
+// MockOrganizationsMockRecorder is the mock recorder for MockOrganizations.
+type MockOrganizationsMockRecorder struct {
+	mock *MockOrganizations
+}
+
+// NewMockOrganizations creates a new mock instance.
+func NewMockOrganizations(ctrl *gomock.Controller) *MockOrganizations {
+	mock := &MockOrganizations{ctrl: ctrl}
+	mock.recorder = &MockOrganizationsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrganizations) EXPECT() *MockOrganizationsMockRecorder {
+	return m.recorder
+}
+
+// AddInvitation mocks base method.
+func (m *MockOrganizations) AddInvitation(ctx context.Context, id string, invitation *models.Invitation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddInvitation", ctx, id, invitation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddInvitation indicates an expected call of AddInvitation.
+func (mr *MockOrganizationsMockRecorder) AddInvitation(ctx, id, invitation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInvitation", reflect.TypeOf((*MockOrganizations)(nil).AddInvitation), ctx, id, invitation)
+}
+
+// AddMember mocks base method.
+func (m *MockOrganizations) AddMember(ctx context.Context, id string, member *models.Member) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddMember", ctx, id, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddMember indicates an expected call of AddMember.
+func (mr *MockOrganizationsMockRecorder) AddMember(ctx, id, member interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddMember", reflect.TypeOf((*MockOrganizations)(nil).AddMember), ctx, id, member)
+}
+
+// ByMember mocks base method.
+func (m *MockOrganizations) ByMember(ctx context.Context, userId string) ([]models.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ByMember", ctx, userId)
+	ret0, _ := ret[0].([]models.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ByMember indicates an expected call of ByMember.
+func (mr *MockOrganizationsMockRecorder) ByMember(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ByMember", reflect.TypeOf((*MockOrganizations)(nil).ByMember), ctx, userId)
+}
+
+// Create mocks base method.
+func (m *MockOrganizations) Create(ctx context.Context, org *models.Organization) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, org)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOrganizationsMockRecorder) Create(ctx, org interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrganizations)(nil).Create), ctx, org)
+}
+
+// Get mocks base method.
+func (m *MockOrganizations) Get(ctx context.Context, id string) (*models.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(*models.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockOrganizationsMockRecorder) Get(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockOrganizations)(nil).Get), ctx, id)
+}
+
+// GetByInvitation mocks base method.
+func (m *MockOrganizations) GetByInvitation(ctx context.Context, tokenHash string) (*models.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByInvitation", ctx, tokenHash)
+	ret0, _ := ret[0].(*models.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByInvitation indicates an expected call of GetByInvitation.
+func (mr *MockOrganizationsMockRecorder) GetByInvitation(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByInvitation", reflect.TypeOf((*MockOrganizations)(nil).GetByInvitation), ctx, tokenHash)
+}
+
+// RemoveMember mocks base method.
+func (m *MockOrganizations) RemoveMember(ctx context.Context, id, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveMember", ctx, id, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveMember indicates an expected call of RemoveMember.
+func (mr *MockOrganizationsMockRecorder) RemoveMember(ctx, id, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveMember", reflect.TypeOf((*MockOrganizations)(nil).RemoveMember), ctx, id, userId)
+}
+
+// RemoveUser mocks base method.
+func (m *MockOrganizations) RemoveUser(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveUser", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveUser indicates an expected call of RemoveUser.
+func (mr *MockOrganizationsMockRecorder) RemoveUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUser", reflect.TypeOf((*MockOrganizations)(nil).RemoveUser), ctx, userId)
+}
+
+// SetMemberRole mocks base method.
+func (m *MockOrganizations) SetMemberRole(ctx context.Context, id, userId, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMemberRole", ctx, id, userId, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMemberRole indicates an expected call of SetMemberRole.
+func (mr *MockOrganizationsMockRecorder) SetMemberRole(ctx, id, userId, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMemberRole", reflect.TypeOf((*MockOrganizations)(nil).SetMemberRole), ctx, id, userId, role)
+}
+
+// SetQuota mocks base method.
+func (m *MockOrganizations) SetQuota(ctx context.Context, id string, quota int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetQuota", ctx, id, quota)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetQuota indicates an expected call of SetQuota.
+func (mr *MockOrganizationsMockRecorder) SetQuota(ctx, id, quota interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockFiles)(nil).All))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQuota", reflect.TypeOf((*MockOrganizations)(nil).SetQuota), ctx, id, quota)
 }
@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrganizationsStorage keeps members and pending invitations inside the organization document
+type OrganizationsStorage struct {
+	db *mongo.Collection
+}
+
+func newOrganizationsRepo(mongo *mongodb.Mongo, collectionName string) *OrganizationsStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &OrganizationsStorage{
+		db: collection,
+	}
+}
+
+func (o *OrganizationsStorage) Create(ctx context.Context, org *models.Organization) error {
+	org.ID = primitive.NewObjectID()
+
+	// $push fails on null, arrays are stored empty
+	if org.Members == nil {
+		org.Members = []models.Member{}
+	}
+	if org.Invitations == nil {
+		org.Invitations = []models.Invitation{}
+	}
+
+	_, err := o.db.InsertOne(ctx, org)
+	return err
+}
+
+func (o *OrganizationsStorage) Get(ctx context.Context, id string) (*models.Organization, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, models.ErrOrganizationNotFound
+	}
+
+	return o.findOne(ctx, bson.M{"_id": objectID})
+}
+
+func (o *OrganizationsStorage) GetByInvitation(ctx context.Context, tokenHash string) (*models.Organization, error) {
+	return o.findOne(ctx, bson.M{"invitations.tokenHash": tokenHash})
+}
+
+func (o *OrganizationsStorage) findOne(ctx context.Context, filter bson.M) (*models.Organization, error) {
+	result := o.db.FindOne(ctx, filter)
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrOrganizationNotFound
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var org models.Organization
+	err := result.Decode(&org)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &org, nil
+}
+
+func (o *OrganizationsStorage) ByMember(ctx context.Context, userId string) ([]models.Organization, error) {
+	opts := options.Find().SetSort(bson.M{"name": 1})
+
+	cursor, err := o.db.Find(ctx, bson.M{"members.userId": userId}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.Organization{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (o *OrganizationsStorage) AddMember(ctx context.Context, id string, member *models.Member) error {
+	return o.update(ctx, id, bson.M{}, bson.M{
+		"$push": bson.M{"members": member},
+		"$pull": bson.M{"invitations": bson.M{"email": member.Email}},
+	})
+}
+
+func (o *OrganizationsStorage) SetMemberRole(ctx context.Context, id, userId, role string) error {
+	err := o.update(ctx, id, bson.M{"members.userId": userId}, bson.M{"$set": bson.M{"members.$.role": role}})
+	if err == models.ErrOrganizationNotFound {
+		return models.ErrMemberNotFound
+	}
+	return err
+}
+
+func (o *OrganizationsStorage) RemoveMember(ctx context.Context, id, userId string) error {
+	err := o.update(ctx, id, bson.M{"members.userId": userId}, bson.M{"$pull": bson.M{"members": bson.M{"userId": userId}}})
+	if err == models.ErrOrganizationNotFound {
+		return models.ErrMemberNotFound
+	}
+	return err
+}
+
+func (o *OrganizationsStorage) RemoveUser(ctx context.Context, userId string) error {
+	_, err := o.db.UpdateMany(ctx,
+		bson.M{"members.userId": userId},
+		bson.M{"$pull": bson.M{"members": bson.M{"userId": userId}}},
+	)
+	return err
+}
+
+// AddInvitation replaces pending invitation for the same email
+func (o *OrganizationsStorage) AddInvitation(ctx context.Context, id string, invitation *models.Invitation) error {
+	// The same field can't be pulled and pushed in one update
+	err := o.update(ctx, id, bson.M{}, bson.M{"$pull": bson.M{"invitations": bson.M{"email": invitation.Email}}})
+	if err != nil {
+		return err
+	}
+
+	return o.update(ctx, id, bson.M{}, bson.M{"$push": bson.M{"invitations": invitation}})
+}
+
+func (o *OrganizationsStorage) SetQuota(ctx context.Context, id string, quota int64) error {
+	return o.update(ctx, id, bson.M{}, bson.M{"$set": bson.M{"quota": quota}})
+}
+
+// update applies update to organization matching id and filter
+func (o *OrganizationsStorage) update(ctx context.Context, id string, filter bson.M, update bson.M) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrOrganizationNotFound
+	}
+	filter["_id"] = objectID
+
+	result, err := o.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrOrganizationNotFound
+	}
+
+	return nil
+}
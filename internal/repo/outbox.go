@@ -0,0 +1,184 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"fmt"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultOutboxCollection = "outbox"
+
+// OutboxStorage keeps domain events until the relay publishes them
+type OutboxStorage struct {
+	db *mongo.Collection
+}
+
+func newOutboxRepo(mongo *mongodb.Mongo, collectionName string) *OutboxStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &OutboxStorage{
+		db: collection,
+	}
+}
+
+func outboxCollection(cfg *config.Repo) string {
+	if cfg.OutboxCollection != "" {
+		return cfg.OutboxCollection
+	}
+	return defaultOutboxCollection
+}
+
+func (o *OutboxStorage) Add(ctx context.Context, event *models.OutboxEvent) error {
+	event.ID = primitive.NewObjectID()
+
+	_, err := o.db.InsertOne(ctx, event)
+	return err
+}
+
+func (o *OutboxStorage) Claim(ctx context.Context, now, until int64) (*models.OutboxEvent, error) {
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	result := o.db.FindOneAndUpdate(ctx, bson.M{"publishedAt": 0, "availableAt": bson.M{"$lte": now}}, bson.M{
+		"$set": bson.M{"availableAt": until},
+		"$inc": bson.M{"attempts": 1},
+	}, opts)
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrOutboxEmpty
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var event models.OutboxEvent
+	err := result.Decode(&event)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &event, nil
+}
+
+func (o *OutboxStorage) Retry(ctx context.Context, id, failure string, availableAt int64) error {
+	return o.update(ctx, id, bson.M{"availableAt": availableAt, "lastError": failure})
+}
+
+func (o *OutboxStorage) MarkPublished(ctx context.Context, id string, at int64) error {
+	return o.update(ctx, id, bson.M{"publishedAt": at})
+}
+
+func (o *OutboxStorage) MarkDispatched(ctx context.Context, id string) error {
+	return o.update(ctx, id, bson.M{"dispatched": true})
+}
+
+func (o *OutboxStorage) update(ctx context.Context, id string, set bson.M) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrOutboxEventNotFound
+	}
+
+	_, err = o.db.UpdateOne(ctx, bson.M{"_id": objectID, "publishedAt": 0}, bson.M{"$set": set})
+	return err
+}
+
+func (o *OutboxStorage) DeletePublished(ctx context.Context, before int64) (int64, error) {
+	result, err := o.db.DeleteMany(ctx, bson.M{"publishedAt": bson.M{"$gt": 0, "$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// TransactionsStorage runs callbacks in MongoDB transactions, repos called with the session context take part in them.
+// Transactions need a replica set, on an allowed standalone server writes are done one by one
+type TransactionsStorage struct {
+	client          *mongo.Client
+	allowStandalone bool
+
+	mu        sync.Mutex
+	checked   bool
+	supported bool
+}
+
+func newTransactions(mongo *mongodb.Mongo, allowStandalone bool) *TransactionsStorage {
+	return &TransactionsStorage{
+		client:          mongo.DB.Client(),
+		allowStandalone: allowStandalone,
+	}
+}
+
+// CheckTransactions returns ErrTransactionsUnsupported on a standalone server unless it is allowed by config
+func CheckTransactions(ctx context.Context, db *mongodb.Mongo, config *config.Repo) error {
+	_, err := newTransactions(db, config.AllowStandalone).isSupported(ctx)
+	return err
+}
+
+func (t *TransactionsStorage) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	supported, err := t.isSupported(ctx)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	// Transient errors like write conflicts run fn again
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+// isSupported checks once if the server is a replica set member or mongos, a standalone one is an error unless allowed
+func (t *TransactionsStorage) isSupported(ctx context.Context) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.checked {
+		return t.supported, nil
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := t.client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, err
+	}
+
+	supported := hello.SetName != "" || hello.Msg == "isdbgrid"
+	if !supported && !t.allowStandalone {
+		return false, fmt.Errorf("%w - standalone MongoDB, run a replica set or set MONGO_ALLOWSTANDALONE", models.ErrTransactionsUnsupported)
+	}
+
+	t.checked = true
+	t.supported = supported
+	if !t.supported {
+		log.Println("standalone MongoDB doesn't support transactions - outbox events are written after their changes")
+	}
+
+	return t.supported, nil
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at`
+
+type APIKeysStorage struct {
+	db *conn
+}
+
+func (a *APIKeysStorage) Create(ctx context.Context, key *models.APIKey) error {
+	key.ID = primitive.NewObjectID()
+
+	_, err := a.db.ExecContext(ctx, `INSERT INTO api_keys (`+apiKeyColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		key.ID.Hex(), key.UserID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.CreatedAt, key.ExpiresAt, key.LastUsedAt)
+	return err
+}
+
+func (a *APIKeysStorage) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	key, err := scanAPIKey(a.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (a *APIKeysStorage) ByUser(ctx context.Context, userId string) ([]models.APIKey, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *key)
+	}
+
+	return results, rows.Err()
+}
+
+func (a *APIKeysStorage) Touch(ctx context.Context, id string, at int64) error {
+	_, err := a.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+	return err
+}
+
+// Delete removes key only if it belongs to the user, so users can't revoke keys of others
+func (a *APIKeysStorage) Delete(ctx context.Context, userId, id string) error {
+	result, err := a.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userId)
+	return expectRow(result, err, models.ErrAPIKeyNotFound)
+}
+
+func (a *APIKeysStorage) DeleteByUser(ctx context.Context, userId string) error {
+	_, err := a.db.ExecContext(ctx, `DELETE FROM api_keys WHERE user_id = $1`, userId)
+	return err
+}
+
+func scanAPIKey(row scanner) (*models.APIKey, error) {
+	var (
+		key models.APIKey
+		id  string
+	)
+
+	err := row.Scan(&id, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Scopes),
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	key.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+)
+
+type AttemptsStorage struct {
+	db *conn
+}
+
+func (a *AttemptsStorage) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	attempts := models.LoginAttempts{Key: key}
+
+	err := a.db.QueryRowContext(ctx, `SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE key = $1`, key).
+		Scan(&attempts.Failures, &attempts.LastFailureAt, &attempts.LockedUntil)
+	if err == sql.ErrNoRows {
+		return &attempts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempts, nil
+}
+
+func (a *AttemptsStorage) AddFailure(ctx context.Context, key string, at int64) (*models.LoginAttempts, error) {
+	attempts := models.LoginAttempts{Key: key}
+
+	err := a.db.QueryRowContext(ctx, `INSERT INTO login_attempts (key, failures, last_failure_at) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET failures = login_attempts.failures + 1, last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures, last_failure_at, locked_until`, key, at).
+		Scan(&attempts.Failures, &attempts.LastFailureAt, &attempts.LockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempts, nil
+}
+
+func (a *AttemptsStorage) Lock(ctx context.Context, key string, until int64) error {
+	_, err := a.db.ExecContext(ctx, `UPDATE login_attempts SET locked_until = $2 WHERE key = $1`, key, until)
+	return err
+}
+
+func (a *AttemptsStorage) Reset(ctx context.Context, key string) error {
+	_, err := a.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE key = $1`, key)
+	return err
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const fileColumns = `file_id, filename, size, date, user_id, COALESCE(org_id, ''), url, status,
+	image_format, COALESCE(image_width, 0), COALESCE(image_height, 0), COALESCE(error, '')`
+
+type FilesStorage struct {
+	db *conn
+}
+
+func (f *FilesStorage) All(ctx context.Context) ([]models.FileOut, error) {
+	return f.query(ctx, `WHERE org_id IS NULL ORDER BY id`)
+}
+
+func (f *FilesStorage) AddLog(ctx context.Context, log *models.FileUploadLogInput) error {
+	var orgID sql.NullString
+	if log.OrgId != "" {
+		orgID = sql.NullString{String: log.OrgId, Valid: true}
+	}
+
+	log.ID = primitive.NewObjectID()
+
+	_, err := f.db.ExecContext(ctx, `INSERT INTO files (file_id, filename, size, date, user_id, org_id, url, status, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))`,
+		log.ID.Hex(), log.Filename, log.Size, log.UploadDate, log.UserId, orgID, log.Url, log.Status, log.Error)
+	return err
+}
+
+func (f *FilesStorage) ByUser(ctx context.Context, userId string) ([]models.FileOut, error) {
+	return f.query(ctx, `WHERE user_id = $1 AND org_id IS NULL ORDER BY id`, userId)
+}
+
+func (f *FilesStorage) ByOrg(ctx context.Context, orgId string) ([]models.FileOut, error) {
+	return f.query(ctx, `WHERE org_id = $1 ORDER BY id`, orgId)
+}
+
+func (f *FilesStorage) Stats(ctx context.Context, userId string) (*models.UserStats, error) {
+	stats := models.UserStats{UserID: userId}
+
+	err := f.db.QueryRowContext(ctx, `SELECT count(*), COALESCE(sum(size), 0), COALESCE(max(date), 0) FROM files
+		WHERE user_id = $1 AND org_id IS NULL`, userId).
+		Scan(&stats.FilesCount, &stats.TotalSize, &stats.LastUpload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// OrgUsage returns total size of organization files in bytes
+func (f *FilesStorage) OrgUsage(ctx context.Context, orgId string) (int64, error) {
+	var total int64
+	err := f.db.QueryRowContext(ctx, `SELECT COALESCE(sum(size), 0) FROM files WHERE org_id = $1`, orgId).Scan(&total)
+	return total, err
+}
+
+func (f *FilesStorage) DeleteByUser(ctx context.Context, userId string) error {
+	_, err := f.db.ExecContext(ctx, `DELETE FROM files WHERE user_id = $1 AND org_id IS NULL`, userId)
+	return err
+}
+
+func (f *FilesStorage) query(ctx context.Context, where string, args ...interface{}) ([]models.FileOut, error) {
+	rows, err := f.db.QueryContext(ctx, `SELECT `+fileColumns+` FROM files `+where, args...)
+	if err != nil {
+		return []models.FileOut{}, err
+	}
+	defer rows.Close()
+
+	results := []models.FileOut{}
+	for rows.Next() {
+		file, err := scanFile(rows)
+		if err != nil {
+			return []models.FileOut{}, err
+		}
+		results = append(results, *file)
+	}
+
+	return results, rows.Err()
+}
+
+func scanFile(row scanner) (*models.FileOut, error) {
+	var (
+		file   models.FileOut
+		id     string
+		format sql.NullString
+		image  models.ImageInfo
+	)
+
+	err := row.Scan(&id, &file.Filename, &file.Size, &file.Date, &file.UserId, &file.OrgId, &file.Url, &file.Status,
+		&format, &image.Width, &image.Height, &file.Error)
+	if err != nil {
+		return nil, err
+	}
+
+	file.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	if format.Valid {
+		image.Format = format.String
+		file.Image = &image
+	}
+
+	return &file, nil
+}
+
+func (f *FilesStorage) Exists(ctx context.Context, filename string) (bool, error) {
+	var exists bool
+	err := f.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM files WHERE filename = $1)`, filename).Scan(&exists)
+	return exists, err
+}
+
+func (f *FilesStorage) Get(ctx context.Context, id string) (*models.FileOut, error) {
+	file, err := scanFile(f.db.QueryRowContext(ctx, `SELECT `+fileColumns+` FROM files WHERE file_id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrFileNotFound
+	}
+
+	return file, err
+}
+
+func (f *FilesStorage) SetStatus(ctx context.Context, id string, update *models.FileStatusUpdate) error {
+	var (
+		format        sql.NullString
+		width, height sql.NullInt64
+		failure       sql.NullString
+	)
+	if update.Image != nil {
+		format = sql.NullString{String: update.Image.Format, Valid: true}
+		width = sql.NullInt64{Int64: int64(update.Image.Width), Valid: true}
+		height = sql.NullInt64{Int64: int64(update.Image.Height), Valid: true}
+	}
+	if update.Error != "" {
+		failure = sql.NullString{String: update.Error, Valid: true}
+	}
+
+	result, err := f.db.ExecContext(ctx, `UPDATE files SET status = $2, image_format = $3, image_width = $4, image_height = $5, error = $6
+		WHERE file_id = $1`, id, update.Status, format, width, height, failure)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrFileNotFound
+	}
+
+	return nil
+}
+
+func (f *FilesStorage) Delete(ctx context.Context, id string) error {
+	result, err := f.db.ExecContext(ctx, `DELETE FROM files WHERE file_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrFileNotFound
+	}
+
+	return nil
+}
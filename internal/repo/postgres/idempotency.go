@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+)
+
+type IdempotencyStorage struct {
+	db *conn
+}
+
+// Reserve drops expired records, then inserts the record or takes over an abandoned one.
+// No affected row means the key is held
+func (i *IdempotencyStorage) Reserve(ctx context.Context, record *models.IdempotencyRecord, now int64) (bool, error) {
+	_, err := i.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < $1`, now)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := i.db.ExecContext(ctx, `INSERT INTO idempotency_keys (scope, key, fingerprint, locked_until, expires_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, locked_until = EXCLUDED.locked_until,
+			expires_at = EXCLUDED.expires_at, status = NULL, content_type = NULL, body = NULL
+		WHERE idempotency_keys.status IS NULL AND idempotency_keys.locked_until < $6`,
+		record.Scope, record.Key, record.Fingerprint, record.LockedUntil, record.ExpiresAt, now)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (i *IdempotencyStorage) Get(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	var (
+		record      = models.IdempotencyRecord{Scope: scope, Key: key}
+		status      sql.NullInt64
+		contentType sql.NullString
+		body        []byte
+	)
+
+	err := i.db.QueryRowContext(ctx, `SELECT fingerprint, locked_until, expires_at, status, content_type, body FROM idempotency_keys
+		WHERE scope = $1 AND key = $2`, scope, key).
+		Scan(&record.Fingerprint, &record.LockedUntil, &record.ExpiresAt, &status, &contentType, &body)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if status.Valid {
+		record.Response = &models.IdempotentResponse{Status: int(status.Int64), ContentType: contentType.String, Body: body}
+	}
+
+	return &record, nil
+}
+
+func (i *IdempotencyStorage) Complete(ctx context.Context, scope, key string, response *models.IdempotentResponse) error {
+	result, err := i.db.ExecContext(ctx, `UPDATE idempotency_keys SET status = $3, content_type = $4, body = $5 WHERE scope = $1 AND key = $2`,
+		scope, key, response.Status, response.ContentType, response.Body)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrIdempotencyKeyNotFound
+	}
+
+	return nil
+}
+
+func (i *IdempotencyStorage) Release(ctx context.Context, scope, key string) error {
+	_, err := i.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key)
+	return err
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const jobColumns = `id, type, payload, state, attempts, run_at, COALESCE(leased_by, ''), COALESCE(leased_until, 0), COALESCE(last_error, ''), created_at`
+
+type JobsStorage struct {
+	db *conn
+}
+
+func (j *JobsStorage) Enqueue(ctx context.Context, job *models.Job) error {
+	job.ID = primitive.NewObjectID()
+
+	_, err := j.db.ExecContext(ctx, `INSERT INTO jobs (id, type, payload, state, attempts, run_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		job.ID.Hex(), job.Type, job.Payload, job.State, job.Attempts, job.RunAt, job.CreatedAt)
+	return err
+}
+
+// Lease skips rows locked by leases of other workers in progress, so they don't wait for each other
+func (j *JobsStorage) Lease(ctx context.Context, worker string, now, until int64) (*models.Job, error) {
+	job, err := scanJob(j.db.QueryRowContext(ctx, `UPDATE jobs SET state = $1, leased_by = $2, leased_until = $3, attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (state = $4 AND run_at <= $5) OR (state = $1 AND leased_until < $5)
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns, models.JobRunning, worker, until, models.JobPending, now))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrNoJobs
+	}
+
+	return job, err
+}
+
+func (j *JobsStorage) Complete(ctx context.Context, id, worker string) error {
+	result, err := j.db.ExecContext(ctx, `DELETE FROM jobs WHERE id = $1 AND state = $2 AND leased_by = $3`, id, models.JobRunning, worker)
+	return leaseResult(result, err)
+}
+
+func (j *JobsStorage) Retry(ctx context.Context, id, worker, failure string, runAt int64) error {
+	result, err := j.db.ExecContext(ctx, `UPDATE jobs SET state = $4, run_at = $5, last_error = $6, leased_by = NULL, leased_until = NULL
+		WHERE id = $1 AND state = $2 AND leased_by = $3`, id, models.JobRunning, worker, models.JobPending, runAt, failure)
+	return leaseResult(result, err)
+}
+
+func (j *JobsStorage) Bury(ctx context.Context, id, worker, failure string) error {
+	result, err := j.db.ExecContext(ctx, `UPDATE jobs SET state = $4, last_error = $5, leased_by = NULL, leased_until = NULL
+		WHERE id = $1 AND state = $2 AND leased_by = $3`, id, models.JobRunning, worker, models.JobDead, failure)
+	return leaseResult(result, err)
+}
+
+func leaseResult(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrJobLeaseLost
+	}
+
+	return nil
+}
+
+func (j *JobsStorage) Dead(ctx context.Context) ([]models.Job, error) {
+	rows, err := j.db.QueryContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE state = $1 ORDER BY created_at`, models.JobDead)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *job)
+	}
+
+	return results, rows.Err()
+}
+
+func (j *JobsStorage) Requeue(ctx context.Context, id string, runAt int64) error {
+	result, err := j.db.ExecContext(ctx, `UPDATE jobs SET state = $3, run_at = $4, attempts = 0 WHERE id = $1 AND state = $2`,
+		id, models.JobDead, models.JobPending, runAt)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrJobNotFound
+	}
+
+	return nil
+}
+
+func scanJob(row scanner) (*models.Job, error) {
+	var (
+		job models.Job
+		id  string
+	)
+
+	err := row.Scan(&id, &job.Type, &job.Payload, &job.State, &job.Attempts, &job.RunAt, &job.LeasedBy, &job.LeasedUntil, &job.LastError, &job.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	job.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,102 @@
+CREATE TABLE users (
+    id                  TEXT PRIMARY KEY,
+    email               TEXT NOT NULL UNIQUE,
+    password            TEXT NOT NULL DEFAULT '',
+    role                TEXT NOT NULL DEFAULT 'user',
+    disabled            BOOLEAN NOT NULL DEFAULT FALSE,
+    verified            BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at          BIGINT NOT NULL DEFAULT 0,
+    display_name        TEXT NOT NULL DEFAULT '',
+    avatar              TEXT NOT NULL DEFAULT '',
+    sessions_revoked_at BIGINT NOT NULL DEFAULT 0,
+    reset_token_hash    TEXT,
+    reset_expires_at    BIGINT,
+    mfa                 JSONB
+);
+
+CREATE INDEX users_reset_token_hash_idx ON users (reset_token_hash);
+
+CREATE TABLE user_identities (
+    user_id   TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider  TEXT NOT NULL,
+    subject   TEXT NOT NULL,
+    email     TEXT NOT NULL DEFAULT '',
+    linked_at BIGINT NOT NULL,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX user_identities_user_id_idx ON user_identities (user_id);
+
+CREATE TABLE sessions (
+    id           TEXT PRIMARY KEY,
+    user_id      TEXT NOT NULL,
+    user_agent   TEXT NOT NULL DEFAULT '',
+    ip           TEXT NOT NULL DEFAULT '',
+    created_at   BIGINT NOT NULL,
+    last_seen_at BIGINT NOT NULL,
+    expires_at   BIGINT NOT NULL
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id, last_seen_at DESC);
+
+CREATE TABLE files (
+    id       BIGSERIAL PRIMARY KEY,
+    filename TEXT NOT NULL,
+    size     BIGINT NOT NULL,
+    date     BIGINT NOT NULL,
+    user_id  TEXT NOT NULL,
+    org_id   TEXT,
+    url      TEXT NOT NULL
+);
+
+CREATE INDEX files_user_id_idx ON files (user_id, date DESC);
+CREATE INDEX files_org_id_idx ON files (org_id, date DESC);
+
+CREATE TABLE login_attempts (
+    key             TEXT PRIMARY KEY,
+    failures        INTEGER NOT NULL DEFAULT 0,
+    last_failure_at BIGINT NOT NULL DEFAULT 0,
+    locked_until    BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE api_keys (
+    id           TEXT PRIMARY KEY,
+    user_id      TEXT NOT NULL,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    key_hash     TEXT NOT NULL UNIQUE,
+    scopes       TEXT[] NOT NULL,
+    created_at   BIGINT NOT NULL,
+    expires_at   BIGINT NOT NULL DEFAULT 0,
+    last_used_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id, created_at DESC);
+
+CREATE TABLE organizations (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    quota      BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL
+);
+
+CREATE TABLE organization_members (
+    org_id    TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id   TEXT NOT NULL,
+    email     TEXT NOT NULL,
+    role      TEXT NOT NULL,
+    joined_at BIGINT NOT NULL,
+    PRIMARY KEY (org_id, user_id)
+);
+
+CREATE INDEX organization_members_user_id_idx ON organization_members (user_id);
+
+CREATE TABLE organization_invitations (
+    org_id     TEXT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    email      TEXT NOT NULL,
+    role       TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    invited_by TEXT NOT NULL,
+    expires_at BIGINT NOT NULL,
+    PRIMARY KEY (org_id, email)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE pending_uploads (
+    id         TEXT PRIMARY KEY,
+    filename   TEXT NOT NULL,
+    size       BIGINT NOT NULL,
+    user_id    TEXT NOT NULL,
+    org_id     TEXT,
+    created_at BIGINT NOT NULL
+);
+
+CREATE INDEX pending_uploads_created_at_idx ON pending_uploads (created_at);
+CREATE INDEX files_filename_idx ON files (filename);
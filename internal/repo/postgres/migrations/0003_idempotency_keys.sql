@@ -0,0 +1,13 @@
+CREATE TABLE idempotency_keys (
+    scope        TEXT NOT NULL,
+    key          TEXT NOT NULL,
+    fingerprint  TEXT NOT NULL,
+    locked_until BIGINT NOT NULL,
+    expires_at   BIGINT NOT NULL,
+    status       INTEGER,
+    content_type TEXT,
+    body         BYTEA,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,26 @@
+-- Files get ObjectID hex ids like in MongoDB, older rows are numbered by the serial id
+ALTER TABLE files ADD COLUMN file_id TEXT;
+UPDATE files SET file_id = lpad(to_hex(id), 24, '0');
+ALTER TABLE files ALTER COLUMN file_id SET NOT NULL;
+CREATE UNIQUE INDEX files_file_id_idx ON files (file_id);
+
+ALTER TABLE files ADD COLUMN status TEXT NOT NULL DEFAULT 'ready';
+ALTER TABLE files ADD COLUMN image_format TEXT;
+ALTER TABLE files ADD COLUMN image_width INTEGER;
+ALTER TABLE files ADD COLUMN image_height INTEGER;
+ALTER TABLE files ADD COLUMN error TEXT;
+
+CREATE TABLE jobs (
+    id           TEXT PRIMARY KEY,
+    type         TEXT NOT NULL,
+    payload      TEXT NOT NULL,
+    state        TEXT NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    run_at       BIGINT NOT NULL,
+    leased_by    TEXT,
+    leased_until BIGINT,
+    last_error   TEXT,
+    created_at   BIGINT NOT NULL
+);
+
+CREATE INDEX jobs_state_run_at_idx ON jobs (state, run_at);
@@ -0,0 +1,12 @@
+CREATE TABLE outbox (
+    id           TEXT PRIMARY KEY,
+    type         TEXT NOT NULL,
+    payload      TEXT NOT NULL,
+    available_at BIGINT NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    last_error   TEXT NOT NULL DEFAULT '',
+    published_at BIGINT NOT NULL DEFAULT 0,
+    created_at   BIGINT NOT NULL
+);
+
+CREATE INDEX outbox_published_at_created_at_idx ON outbox (published_at, created_at);
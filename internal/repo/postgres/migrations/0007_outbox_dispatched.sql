@@ -0,0 +1 @@
+ALTER TABLE outbox ADD COLUMN dispatched BOOLEAN NOT NULL DEFAULT FALSE;
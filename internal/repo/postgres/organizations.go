@@ -0,0 +1,274 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrganizationsStorage keeps members and invitations in own tables, they are loaded with the organization
+type OrganizationsStorage struct {
+	db *conn
+}
+
+func (o *OrganizationsStorage) Create(ctx context.Context, org *models.Organization) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	org.ID = primitive.NewObjectID()
+	if org.Members == nil {
+		org.Members = []models.Member{}
+	}
+	if org.Invitations == nil {
+		org.Invitations = []models.Invitation{}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO organizations (id, name, quota, created_at) VALUES ($1, $2, $3, $4)`,
+		org.ID.Hex(), org.Name, org.Quota, org.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range org.Members {
+		err = insertMember(ctx, tx, org.ID.Hex(), &member)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (o *OrganizationsStorage) Get(ctx context.Context, id string) (*models.Organization, error) {
+	return o.getOne(ctx, `WHERE id = $1`, id)
+}
+
+func (o *OrganizationsStorage) GetByInvitation(ctx context.Context, tokenHash string) (*models.Organization, error) {
+	return o.getOne(ctx, `WHERE id = (SELECT org_id FROM organization_invitations WHERE token_hash = $1)`, tokenHash)
+}
+
+func (o *OrganizationsStorage) ByMember(ctx context.Context, userId string) ([]models.Organization, error) {
+	orgs, err := o.query(ctx, `WHERE id IN (SELECT org_id FROM organization_members WHERE user_id = $1) ORDER BY name`, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.Organization, 0, len(orgs))
+	for _, org := range orgs {
+		results = append(results, *org)
+	}
+
+	return results, nil
+}
+
+func (o *OrganizationsStorage) AddMember(ctx context.Context, id string, member *models.Member) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockOrganization(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = insertMember(ctx, tx, id, member)
+	if isUniqueViolation(err) {
+		return models.ErrAlreadyMember
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM organization_invitations WHERE org_id = $1 AND email = $2`, id, member.Email)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (o *OrganizationsStorage) SetMemberRole(ctx context.Context, id, userId, role string) error {
+	result, err := o.db.ExecContext(ctx, `UPDATE organization_members SET role = $3 WHERE org_id = $1 AND user_id = $2`, id, userId, role)
+	return expectRow(result, err, models.ErrMemberNotFound)
+}
+
+func (o *OrganizationsStorage) RemoveMember(ctx context.Context, id, userId string) error {
+	result, err := o.db.ExecContext(ctx, `DELETE FROM organization_members WHERE org_id = $1 AND user_id = $2`, id, userId)
+	return expectRow(result, err, models.ErrMemberNotFound)
+}
+
+func (o *OrganizationsStorage) RemoveUser(ctx context.Context, userId string) error {
+	_, err := o.db.ExecContext(ctx, `DELETE FROM organization_members WHERE user_id = $1`, userId)
+	return err
+}
+
+// AddInvitation replaces pending invitation for the same email
+func (o *OrganizationsStorage) AddInvitation(ctx context.Context, id string, invitation *models.Invitation) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockOrganization(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO organization_invitations (org_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (org_id, email) DO UPDATE SET role = EXCLUDED.role, token_hash = EXCLUDED.token_hash,
+			invited_by = EXCLUDED.invited_by, expires_at = EXCLUDED.expires_at`,
+		id, invitation.Email, invitation.Role, invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (o *OrganizationsStorage) SetQuota(ctx context.Context, id string, quota int64) error {
+	result, err := o.db.ExecContext(ctx, `UPDATE organizations SET quota = $2 WHERE id = $1`, id, quota)
+	return expectRow(result, err, models.ErrOrganizationNotFound)
+}
+
+func (o *OrganizationsStorage) getOne(ctx context.Context, where string, args ...interface{}) (*models.Organization, error) {
+	orgs, err := o.query(ctx, where, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orgs) == 0 {
+		return nil, models.ErrOrganizationNotFound
+	}
+
+	return orgs[0], nil
+}
+
+// query loads organizations with their members and invitations
+func (o *OrganizationsStorage) query(ctx context.Context, where string, args ...interface{}) ([]*models.Organization, error) {
+	rows, err := o.db.QueryContext(ctx, `SELECT id, name, quota, created_at FROM organizations `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []*models.Organization{}
+	byID := map[string]*models.Organization{}
+	ids := []string{}
+	for rows.Next() {
+		var (
+			org models.Organization
+			id  string
+		)
+		err = rows.Scan(&id, &org.Name, &org.Quota, &org.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		org.ID, err = primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("decode error: %s", err.Error())
+		}
+		org.Members = []models.Member{}
+		org.Invitations = []models.Invitation{}
+
+		orgs = append(orgs, &org)
+		byID[id] = &org
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return orgs, nil
+	}
+
+	err = o.loadMembers(ctx, byID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.loadInvitations(ctx, byID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+func (o *OrganizationsStorage) loadMembers(ctx context.Context, byID map[string]*models.Organization, ids []string) error {
+	rows, err := o.db.QueryContext(ctx, `SELECT org_id, user_id, email, role, joined_at FROM organization_members
+		WHERE org_id = ANY($1) ORDER BY joined_at`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			orgID  string
+			member models.Member
+		)
+		err = rows.Scan(&orgID, &member.UserID, &member.Email, &member.Role, &member.JoinedAt)
+		if err != nil {
+			return err
+		}
+
+		org := byID[orgID]
+		org.Members = append(org.Members, member)
+	}
+
+	return rows.Err()
+}
+
+func (o *OrganizationsStorage) loadInvitations(ctx context.Context, byID map[string]*models.Organization, ids []string) error {
+	rows, err := o.db.QueryContext(ctx, `SELECT org_id, email, role, token_hash, invited_by, expires_at FROM organization_invitations
+		WHERE org_id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			orgID      string
+			invitation models.Invitation
+		)
+		err = rows.Scan(&orgID, &invitation.Email, &invitation.Role, &invitation.TokenHash, &invitation.InvitedBy, &invitation.ExpiresAt)
+		if err != nil {
+			return err
+		}
+
+		org := byID[orgID]
+		org.Invitations = append(org.Invitations, invitation)
+	}
+
+	return rows.Err()
+}
+
+// lockOrganization checks organization exists and serializes concurrent changes of it
+func lockOrganization(ctx context.Context, tx *storageTx, id string) error {
+	var locked string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM organizations WHERE id = $1 FOR UPDATE`, id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return models.ErrOrganizationNotFound
+	}
+	return err
+}
+
+func insertMember(ctx context.Context, tx *storageTx, orgID string, member *models.Member) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO organization_members (org_id, user_id, email, role, joined_at) VALUES ($1, $2, $3, $4, $5)`,
+		orgID, member.UserID, member.Email, member.Role, member.JoinedAt)
+	return err
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const outboxColumns = `id, type, payload, available_at, attempts, last_error, dispatched, published_at, created_at`
+
+type OutboxStorage struct {
+	db *conn
+}
+
+func (o *OutboxStorage) Add(ctx context.Context, event *models.OutboxEvent) error {
+	event.ID = primitive.NewObjectID()
+
+	_, err := o.db.ExecContext(ctx, `INSERT INTO outbox (`+outboxColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		event.ID.Hex(), event.Type, event.Payload, event.AvailableAt, event.Attempts, event.LastError, event.Dispatched, event.PublishedAt, event.CreatedAt)
+	return err
+}
+
+// Claim skips rows locked by claims of other relays in progress
+func (o *OutboxStorage) Claim(ctx context.Context, now, until int64) (*models.OutboxEvent, error) {
+	var (
+		event models.OutboxEvent
+		id    string
+	)
+
+	err := o.db.QueryRowContext(ctx, `UPDATE outbox SET available_at = $1, attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM outbox
+			WHERE published_at = 0 AND available_at <= $2
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+outboxColumns, until, now).
+		Scan(&id, &event.Type, &event.Payload, &event.AvailableAt, &event.Attempts, &event.LastError, &event.Dispatched, &event.PublishedAt, &event.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrOutboxEmpty
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &event, nil
+}
+
+func (o *OutboxStorage) Retry(ctx context.Context, id, failure string, availableAt int64) error {
+	return o.update(ctx, id, `available_at = $2, last_error = $3`, availableAt, failure)
+}
+
+func (o *OutboxStorage) MarkPublished(ctx context.Context, id string, at int64) error {
+	return o.update(ctx, id, `published_at = $2`, at)
+}
+
+func (o *OutboxStorage) MarkDispatched(ctx context.Context, id string) error {
+	return o.update(ctx, id, `dispatched = TRUE`)
+}
+
+// update changes unpublished event, set refers to args from $2
+func (o *OutboxStorage) update(ctx context.Context, id, set string, args ...interface{}) error {
+	if !primitive.IsValidObjectID(id) {
+		return models.ErrOutboxEventNotFound
+	}
+
+	_, err := o.db.ExecContext(ctx, `UPDATE outbox SET `+set+` WHERE id = $1 AND published_at = 0`, append([]interface{}{id}, args...)...)
+	return err
+}
+
+func (o *OutboxStorage) DeletePublished(ctx context.Context, before int64) (int64, error) {
+	result, err := o.db.ExecContext(ctx, `DELETE FROM outbox WHERE published_at > 0 AND published_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,172 @@
+// Package postgres implements repo interfaces on PostgreSQL.
+// IDs are ObjectID hex strings like in MongoDB, so services don't depend on the backend
+package postgres
+
+import (
+	"creatly-task/internal/config"
+	"creatly-task/internal/repo"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrationsLockID serializes migrations of replicas started at the same time
+const migrationsLockID = 7291846
+
+func Connect(config *config.Database) (*sql.DB, error) {
+	db, err := sql.Open("postgres", config.PostgresURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func New(db *sql.DB) *repo.Repo {
+	c := &conn{db: db}
+
+	return &repo.Repo{
+		Users:         &UsersStorage{db: c},
+		Sessions:      &SessionsStorage{db: c},
+		Files:         &FilesStorage{db: c},
+		Attempts:      &AttemptsStorage{db: c},
+		APIKeys:       &APIKeysStorage{db: c},
+		Organizations: &OrganizationsStorage{db: c},
+		Uploads:       &UploadsStorage{db: c},
+
+		IdempotencyKeys: &IdempotencyStorage{db: c},
+		Jobs:            &JobsStorage{db: c},
+
+		Webhooks:   &WebhooksStorage{db: c},
+		Deliveries: &DeliveriesStorage{db: c},
+
+		Outbox:       &OutboxStorage{db: c},
+		Transactions: &Transactions{db: db},
+	}
+}
+
+// Migrate applies migrations/NNNN_name.sql files which are not applied yet, each in own transaction
+func Migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	files, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			return fmt.Errorf("invalid migration name %s", name)
+		}
+
+		err = applyMigration(db, version, name)
+		if err != nil {
+			return fmt.Errorf("migration %s - %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(db *sql.DB, version int, name string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationsLockID)
+	if err != nil {
+		return err
+	}
+
+	var applied bool
+	err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	query, err := migrations.ReadFile("migrations/" + name)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(string(query))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)`, version, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// isUniqueViolation reports duplicate key error
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// expectRow returns notFound when update or delete matched no rows
+func expectRow(result sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// likePattern escapes LIKE wildcards of user search
+func likePattern(search string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(search) + "%"
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"creatly-task/internal/config"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/repotest"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Test_Contract runs against PostgreSQL from TEST_POSTGRES_URL, each subtest in own schema
+func Test_Contract(t *testing.T) {
+	postgresURL := os.Getenv("TEST_POSTGRES_URL")
+	if postgresURL == "" {
+		t.Skip("TEST_POSTGRES_URL is not set")
+	}
+
+	admin, err := Connect(&config.Database{PostgresURL: postgresURL})
+	if err != nil {
+		t.Fatalf("Connect error - %s", err.Error())
+	}
+	defer admin.Close()
+
+	repotest.Run(t, func(t *testing.T) *repo.Repo {
+		schema := "test_" + primitive.NewObjectID().Hex()
+
+		_, err := admin.Exec(fmt.Sprintf(`CREATE SCHEMA %s`, schema))
+		if err != nil {
+			t.Fatalf("create schema error - %s", err.Error())
+		}
+
+		db, err := Connect(&config.Database{PostgresURL: withSearchPath(t, postgresURL, schema)})
+		if err != nil {
+			t.Fatalf("Connect error - %s", err.Error())
+		}
+		t.Cleanup(func() {
+			db.Close()
+			admin.Exec(fmt.Sprintf(`DROP SCHEMA %s CASCADE`, schema))
+		})
+
+		err = Migrate(db)
+		if err != nil {
+			t.Fatalf("Migrate error - %s", err.Error())
+		}
+
+		return New(db)
+	})
+}
+
+func Test_Migrate(t *testing.T) {
+	postgresURL := os.Getenv("TEST_POSTGRES_URL")
+	if postgresURL == "" {
+		t.Skip("TEST_POSTGRES_URL is not set")
+	}
+
+	db, err := Connect(&config.Database{PostgresURL: postgresURL})
+	if err != nil {
+		t.Fatalf("Connect error - %s", err.Error())
+	}
+	defer db.Close()
+
+	// Applied migrations are skipped
+	for i := 0; i < 2; i++ {
+		err = Migrate(db)
+		if err != nil {
+			t.Fatalf("Migrate error - %s", err.Error())
+		}
+	}
+
+	var count int
+	err = db.QueryRow(`SELECT count(*) FROM schema_migrations`).Scan(&count)
+	if err != nil {
+		t.Fatalf("count error - %s", err.Error())
+	}
+	if count == 0 {
+		t.Fatalf("no migrations recorded")
+	}
+}
+
+func Test_likePattern(t *testing.T) {
+	tests := []struct {
+		search string
+		expect string
+	}{
+		{search: "user", expect: "%user%"},
+		{search: "50%_off", expect: `%50\%\_off%`},
+		{search: `a\b`, expect: `%a\\b%`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.search, func(t *testing.T) {
+			if pattern := likePattern(test.search); pattern != test.expect {
+				t.Fatalf("expected %s, got %s", test.expect, pattern)
+			}
+		})
+	}
+}
+
+// withSearchPath makes every connection of the pool use the schema
+func withSearchPath(t *testing.T, postgresURL, schema string) string {
+	u, err := url.Parse(postgresURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_POSTGRES_URL - %s", err.Error())
+	}
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_seen_at, expires_at`
+
+type SessionsStorage struct {
+	db *conn
+}
+
+func (s *SessionsStorage) Create(ctx context.Context, session *models.Session) error {
+	session.ID = primitive.NewObjectID()
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO sessions (`+sessionColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		session.ID.Hex(), session.UserID, session.UserAgent, session.IP, session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
+	return err
+}
+
+func (s *SessionsStorage) Get(ctx context.Context, id string) (*models.Session, error) {
+	session, err := scanSession(s.db.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *SessionsStorage) ByUser(ctx context.Context, userId string, now int64) ([]models.Session, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sessionColumns+` FROM sessions
+		WHERE user_id = $1 AND expires_at > $2 ORDER BY last_seen_at DESC`, userId, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *session)
+	}
+
+	return results, rows.Err()
+}
+
+func (s *SessionsStorage) Touch(ctx context.Context, id string, at int64) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE sessions SET last_seen_at = $2 WHERE id = $1`, id, at)
+	return err
+}
+
+func (s *SessionsStorage) Delete(ctx context.Context, userId, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1 AND user_id = $2`, id, userId)
+	return expectRow(result, err, models.ErrSessionNotFound)
+}
+
+func (s *SessionsStorage) DeleteByUser(ctx context.Context, userId string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1`, userId)
+	return err
+}
+
+func scanSession(row scanner) (*models.Session, error) {
+	var (
+		session models.Session
+		id      string
+	)
+
+	err := row.Scan(&id, &session.UserID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	session.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UploadsStorage struct {
+	db *conn
+}
+
+func (u *UploadsStorage) Create(ctx context.Context, upload *models.PendingUpload) error {
+	upload.ID = primitive.NewObjectID()
+
+	var orgID sql.NullString
+	if upload.OrgId != "" {
+		orgID = sql.NullString{String: upload.OrgId, Valid: true}
+	}
+
+	_, err := u.db.ExecContext(ctx, `INSERT INTO pending_uploads (id, filename, size, user_id, org_id, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		upload.ID.Hex(), upload.Filename, upload.Size, upload.UserId, orgID, upload.CreatedAt)
+	return err
+}
+
+func (u *UploadsStorage) Delete(ctx context.Context, id string) error {
+	_, err := u.db.ExecContext(ctx, `DELETE FROM pending_uploads WHERE id = $1`, id)
+	return err
+}
+
+func (u *UploadsStorage) Stale(ctx context.Context, before int64) ([]models.PendingUpload, error) {
+	rows, err := u.db.QueryContext(ctx, `SELECT id, filename, size, user_id, COALESCE(org_id, ''), created_at FROM pending_uploads
+		WHERE created_at < $1 ORDER BY created_at`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.PendingUpload{}
+	for rows.Next() {
+		var (
+			upload models.PendingUpload
+			id     string
+		)
+		err = rows.Scan(&id, &upload.Filename, &upload.Size, &upload.UserId, &upload.OrgId, &upload.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		upload.ID, err = primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("decode error: %s", err.Error())
+		}
+
+		results = append(results, upload)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,340 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const userColumns = `id, email, password, role, disabled, verified, created_at, display_name, avatar,
+	sessions_revoked_at, reset_token_hash, reset_expires_at, mfa`
+
+type UsersStorage struct {
+	db *conn
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (u *UsersStorage) CreateUser(ctx context.Context, input *models.UserSignUpInput) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	input.ID = primitive.NewObjectID()
+	id := input.ID.Hex()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO users (id, email, password, role, verified, created_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, input.Email, input.Password, input.Role, input.Verified, input.CreatedAt)
+	if isUniqueViolation(err) {
+		return models.ErrUserExists
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, identity := range input.Identities {
+		err = insertIdentity(ctx, tx, id, &identity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (u *UsersStorage) GetUserByCreds(ctx context.Context, email string) (*models.UserSignInOutput, error) {
+	var (
+		user models.UserSignInOutput
+		id   string
+		mfa  []byte
+	)
+
+	err := u.db.QueryRowContext(ctx, `SELECT id, email, password, disabled, mfa FROM users WHERE email = $1`, email).
+		Scan(&id, &user.Email, &user.Password, &user.Disabled, &mfa)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user.UserID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	user.MFA, err = decodeMFA(mfa)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (u *UsersStorage) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	return u.getUser(ctx, `WHERE id = $1`, id)
+}
+
+func (u *UsersStorage) GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	return u.getUser(ctx, `WHERE reset_token_hash = $1`, tokenHash)
+}
+
+func (u *UsersStorage) GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	return u.getUser(ctx, `WHERE id = (SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2)`, provider, subject)
+}
+
+func (u *UsersStorage) getUser(ctx context.Context, where string, args ...interface{}) (*models.User, error) {
+	user, err := scanUser(u.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users `+where, args...))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.loadIdentities(ctx, []*models.User{user})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (u *UsersStorage) List(ctx context.Context, filter *models.UsersFilter) ([]models.User, int64, error) {
+	where, args := "", []interface{}{}
+	if filter.Search != "" {
+		where = `WHERE email ILIKE $1`
+		args = append(args, likePattern(filter.Search))
+	}
+
+	var total int64
+	err := u.db.QueryRowContext(ctx, `SELECT count(*) FROM users `+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// ObjectID hex keeps creation order like _id sort in MongoDB. LIMIT NULL is no limit
+	query := fmt.Sprintf(`SELECT %s FROM users %s ORDER BY id OFFSET $%d LIMIT NULLIF($%d, 0)`,
+		userColumns, where, len(args)+1, len(args)+2)
+	offset := int64(0)
+	if filter.Page > 1 {
+		offset = (filter.Page - 1) * filter.Limit
+	}
+
+	rows, err := u.db.QueryContext(ctx, query, append(args, offset, filter.Limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	err = u.loadIdentities(ctx, users)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]models.User, 0, len(users))
+	for _, user := range users {
+		result = append(result, *user)
+	}
+
+	return result, total, nil
+}
+
+func (u *UsersStorage) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return u.update(ctx, `SET disabled = $2`, id, disabled)
+}
+
+// UpdatePassword sets new password, revokes all issued tokens and pending password reset
+func (u *UsersStorage) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	return u.update(ctx, `SET password = $2, sessions_revoked_at = $3, reset_token_hash = NULL, reset_expires_at = NULL`,
+		id, passwordHash, time.Now().Unix())
+}
+
+func (u *UsersStorage) SetVerified(ctx context.Context, email string) error {
+	result, err := u.db.ExecContext(ctx, `UPDATE users SET verified = TRUE WHERE email = $1`, email)
+	return expectRow(result, err, models.ErrUserNotFound)
+}
+
+func (u *UsersStorage) SetPasswordResetToken(ctx context.Context, id string, token *models.PasswordResetToken) error {
+	if token == nil {
+		return u.update(ctx, `SET reset_token_hash = NULL, reset_expires_at = NULL`, id)
+	}
+	return u.update(ctx, `SET reset_token_hash = $2, reset_expires_at = $3`, id, token.TokenHash, token.ExpiresAt)
+}
+
+// SetMFA replaces two-factor settings. Nil mfa removes them
+func (u *UsersStorage) SetMFA(ctx context.Context, id string, mfa *models.MFA) error {
+	if mfa == nil {
+		return u.update(ctx, `SET mfa = NULL`, id)
+	}
+
+	data, err := json.Marshal(mfa)
+	if err != nil {
+		return err
+	}
+
+	return u.update(ctx, `SET mfa = $2`, id, data)
+}
+
+func (u *UsersStorage) AddIdentity(ctx context.Context, id string, identity *models.Identity) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE users SET verified = TRUE WHERE id = $1`, id)
+	err = expectRow(result, err, models.ErrUserNotFound)
+	if err != nil {
+		return err
+	}
+
+	err = insertIdentity(ctx, tx, id, identity)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (u *UsersStorage) UpdateProfile(ctx context.Context, id string, input *models.ProfileUpdateInput) error {
+	sets, args := []string{}, []interface{}{id}
+	for column, value := range map[string]*string{"display_name": input.DisplayName, "avatar": input.Avatar} {
+		if value == nil {
+			continue
+		}
+		args = append(args, *value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if len(sets) == 0 {
+		return nil
+	}
+
+	return u.update(ctx, `SET `+strings.Join(sets, ", "), args...)
+}
+
+func (u *UsersStorage) Delete(ctx context.Context, id string) error {
+	result, err := u.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	return expectRow(result, err, models.ErrUserNotFound)
+}
+
+// update runs UPDATE users with set clause, $1 is the user id
+func (u *UsersStorage) update(ctx context.Context, set string, args ...interface{}) error {
+	result, err := u.db.ExecContext(ctx, `UPDATE users `+set+` WHERE id = $1`, args...)
+	return expectRow(result, err, models.ErrUserNotFound)
+}
+
+func (u *UsersStorage) loadIdentities(ctx context.Context, users []*models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*models.User, len(users))
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		byID[user.ID.Hex()] = user
+		ids = append(ids, user.ID.Hex())
+	}
+
+	rows, err := u.db.QueryContext(ctx, `SELECT user_id, provider, subject, email, linked_at FROM user_identities
+		WHERE user_id = ANY($1) ORDER BY linked_at`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			userID   string
+			identity models.Identity
+		)
+		err = rows.Scan(&userID, &identity.Provider, &identity.Subject, &identity.Email, &identity.LinkedAt)
+		if err != nil {
+			return err
+		}
+
+		user := byID[userID]
+		user.Identities = append(user.Identities, identity)
+	}
+
+	return rows.Err()
+}
+
+func insertIdentity(ctx context.Context, tx *storageTx, userID string, identity *models.Identity) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO user_identities (user_id, provider, subject, email, linked_at) VALUES ($1, $2, $3, $4, $5)`,
+		userID, identity.Provider, identity.Subject, identity.Email, identity.LinkedAt)
+	return err
+}
+
+func scanUser(row scanner) (*models.User, error) {
+	var (
+		user           models.User
+		id             string
+		resetTokenHash sql.NullString
+		resetExpiresAt sql.NullInt64
+		mfa            []byte
+	)
+
+	err := row.Scan(&id, &user.Email, &user.Password, &user.Role, &user.Disabled, &user.Verified, &user.CreatedAt,
+		&user.DisplayName, &user.Avatar, &user.SessionsRevokedAt, &resetTokenHash, &resetExpiresAt, &mfa)
+	if err != nil {
+		return nil, err
+	}
+
+	user.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	if resetTokenHash.Valid {
+		user.PasswordReset = &models.PasswordResetToken{
+			TokenHash: resetTokenHash.String,
+			ExpiresAt: resetExpiresAt.Int64,
+		}
+	}
+
+	user.MFA, err = decodeMFA(mfa)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func decodeMFA(data []byte) (*models.MFA, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var mfa models.MFA
+	err := json.Unmarshal(data, &mfa)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &mfa, nil
+}
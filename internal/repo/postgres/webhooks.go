@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	webhookColumns  = `id, url, secret, events, created_by, created_at`
+	deliveryColumns = `id, webhook_id, event, payload, status, attempts, created_at`
+)
+
+type WebhooksStorage struct {
+	db *conn
+}
+
+func (w *WebhooksStorage) Create(ctx context.Context, webhook *models.Webhook) error {
+	webhook.ID = primitive.NewObjectID()
+
+	_, err := w.db.ExecContext(ctx, `INSERT INTO webhooks (`+webhookColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		webhook.ID.Hex(), webhook.URL, webhook.Secret, pq.Array(webhook.Events), webhook.CreatedBy, webhook.CreatedAt)
+	return err
+}
+
+func (w *WebhooksStorage) Get(ctx context.Context, id string) (*models.Webhook, error) {
+	webhook, err := scanWebhook(w.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrWebhookNotFound
+	}
+
+	return webhook, err
+}
+
+func (w *WebhooksStorage) All(ctx context.Context) ([]models.Webhook, error) {
+	return w.query(ctx, `ORDER BY created_at, id`)
+}
+
+func (w *WebhooksStorage) ByEvent(ctx context.Context, event string) ([]models.Webhook, error) {
+	return w.query(ctx, `WHERE $1 = ANY(events) ORDER BY created_at, id`, event)
+}
+
+func (w *WebhooksStorage) query(ctx context.Context, where string, args ...interface{}) ([]models.Webhook, error) {
+	rows, err := w.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *webhook)
+	}
+
+	return results, rows.Err()
+}
+
+func (w *WebhooksStorage) Delete(ctx context.Context, id string) error {
+	result, err := w.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func scanWebhook(row scanner) (*models.Webhook, error) {
+	var (
+		webhook models.Webhook
+		id      string
+	)
+
+	err := row.Scan(&id, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events), &webhook.CreatedBy, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+type DeliveriesStorage struct {
+	db *conn
+}
+
+func (d *DeliveriesStorage) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.ID = primitive.NewObjectID()
+	if delivery.Attempts == nil {
+		delivery.Attempts = []models.DeliveryAttempt{}
+	}
+
+	attempts, err := json.Marshal(delivery.Attempts)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.ExecContext(ctx, `INSERT INTO webhook_deliveries (`+deliveryColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		delivery.ID.Hex(), delivery.WebhookID, delivery.Event, delivery.Payload, delivery.Status, attempts, delivery.CreatedAt)
+	return err
+}
+
+func (d *DeliveriesStorage) Get(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	delivery, err := scanDelivery(d.db.QueryRowContext(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, models.ErrDeliveryNotFound
+	}
+
+	return delivery, err
+}
+
+func (d *DeliveriesStorage) ByWebhook(ctx context.Context, webhookID string, limit int64) ([]models.WebhookDelivery, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE webhook_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *delivery)
+	}
+
+	return results, rows.Err()
+}
+
+func (d *DeliveriesStorage) AddAttempt(ctx context.Context, id string, attempt *models.DeliveryAttempt, status string) error {
+	data, err := json.Marshal([]*models.DeliveryAttempt{attempt})
+	if err != nil {
+		return err
+	}
+
+	return d.update(ctx, `UPDATE webhook_deliveries SET status = $2, attempts = attempts || $3::jsonb WHERE id = $1`, id, status, data)
+}
+
+func (d *DeliveriesStorage) SetStatus(ctx context.Context, id, status string) error {
+	return d.update(ctx, `UPDATE webhook_deliveries SET status = $2 WHERE id = $1`, id, status)
+}
+
+func (d *DeliveriesStorage) update(ctx context.Context, query, id string, args ...interface{}) error {
+	result, err := d.db.ExecContext(ctx, query, append([]interface{}{id}, args...)...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return models.ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+func (d *DeliveriesStorage) DeleteByWebhook(ctx context.Context, webhookID string) error {
+	_, err := d.db.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = $1`, webhookID)
+	return err
+}
+
+func scanDelivery(row scanner) (*models.WebhookDelivery, error) {
+	var (
+		delivery models.WebhookDelivery
+		id       string
+		attempts []byte
+	)
+
+	err := row.Scan(&id, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Status, &attempts, &delivery.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(attempts, &delivery.Attempts)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.ID, err = primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
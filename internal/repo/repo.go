@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"context"
 	"creatly-task/internal/config"
 	"creatly-task/internal/models"
 	"creatly-task/internal/mongodb"
@@ -9,29 +10,172 @@ import (
 //go:generate mockgen -source=repo.go -destination=mocks/mock.go
 
 type Users interface {
-	CreateUser(*models.UserSignUpInput) error
-	GetUserByCreds(email string) (*models.UserSignInOutput, error)
+	CreateUser(ctx context.Context, input *models.UserSignUpInput) error // Sets user ID
+	GetUserByCreds(ctx context.Context, email string) (*models.UserSignInOutput, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	List(ctx context.Context, filter *models.UsersFilter) ([]models.User, int64, error)
+	SetDisabled(ctx context.Context, id string, disabled bool) error
+	UpdatePassword(ctx context.Context, id, passwordHash string) error
+	SetVerified(ctx context.Context, email string) error
+	SetPasswordResetToken(ctx context.Context, id string, token *models.PasswordResetToken) error
+	GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error)
+	SetMFA(ctx context.Context, id string, mfa *models.MFA) error
+	GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error)
+	AddIdentity(ctx context.Context, id string, identity *models.Identity) error
+	UpdateProfile(ctx context.Context, id string, input *models.ProfileUpdateInput) error
+	Delete(ctx context.Context, id string) error
 }
 
-type Tokens interface {
-	GetUserIDByToken(token string) (int64, error) // Получение userID по токену
+type Sessions interface {
+	Create(ctx context.Context, session *models.Session) error // Sets session ID
+	Get(ctx context.Context, id string) (*models.Session, error)
+	ByUser(ctx context.Context, userId string, now int64) ([]models.Session, error) // Not expired sessions only
+	Touch(ctx context.Context, id string, at int64) error
+	Delete(ctx context.Context, userId, id string) error
+	DeleteByUser(ctx context.Context, userId string) error
 }
 
 type Files interface {
-	All() ([]models.FileOut, error)
-	AddLog(log *models.FileUploadLogInput) error
+	All(ctx context.Context) ([]models.FileOut, error)
+	AddLog(ctx context.Context, log *models.FileUploadLogInput) error    // Sets log ID
+	ByUser(ctx context.Context, userId string) ([]models.FileOut, error) // Personal files only, like All, Stats and DeleteByUser
+	Stats(ctx context.Context, userId string) (*models.UserStats, error)
+	DeleteByUser(ctx context.Context, userId string) error
+	ByOrg(ctx context.Context, orgId string) ([]models.FileOut, error)
+	OrgUsage(ctx context.Context, orgId string) (int64, error)
+	Exists(ctx context.Context, filename string) (bool, error) // Any record, personal or organization one
+	Get(ctx context.Context, id string) (*models.FileOut, error)
+	SetStatus(ctx context.Context, id string, update *models.FileStatusUpdate) error
+	Delete(ctx context.Context, id string) error
+}
+
+type Uploads interface {
+	Create(ctx context.Context, upload *models.PendingUpload) error          // Sets upload ID
+	Delete(ctx context.Context, id string) error                             // Missing upload is not an error
+	Stale(ctx context.Context, before int64) ([]models.PendingUpload, error) // Created before the time, oldest first
+}
+
+type IdempotencyKeys interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord, now int64) (bool, error) // False while the key is held by another unexpired record
+	Get(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope, key string, response *models.IdempotentResponse) error
+	Release(ctx context.Context, scope, key string) error // Missing key is not an error
+}
+
+type Jobs interface {
+	Enqueue(ctx context.Context, job *models.Job) error // Sets job ID
+	// Lease takes the oldest due pending job or running one with ended lease, ErrNoJobs when there is none
+	Lease(ctx context.Context, worker string, now, until int64) (*models.Job, error)
+	// Complete, Retry and Bury return ErrJobLeaseLost when the job was taken over by another worker
+	Complete(ctx context.Context, id, worker string) error // Drops the job
+	Retry(ctx context.Context, id, worker, failure string, runAt int64) error
+	Bury(ctx context.Context, id, worker, failure string) error // Moves the job to dead state
+	Dead(ctx context.Context) ([]models.Job, error)             // Oldest first
+	Requeue(ctx context.Context, id string, runAt int64) error  // Dead job is pending again with no attempts
+}
+
+type Webhooks interface {
+	Create(ctx context.Context, webhook *models.Webhook) error // Sets webhook ID
+	Get(ctx context.Context, id string) (*models.Webhook, error)
+	All(ctx context.Context) ([]models.Webhook, error)                   // Oldest first
+	ByEvent(ctx context.Context, event string) ([]models.Webhook, error) // Subscribed to the event
+	Delete(ctx context.Context, id string) error
+}
+
+type Deliveries interface {
+	Create(ctx context.Context, delivery *models.WebhookDelivery) error // Sets delivery ID
+	Get(ctx context.Context, id string) (*models.WebhookDelivery, error)
+	ByWebhook(ctx context.Context, webhookID string, limit int64) ([]models.WebhookDelivery, error) // Newest first
+	AddAttempt(ctx context.Context, id string, attempt *models.DeliveryAttempt, status string) error
+	SetStatus(ctx context.Context, id, status string) error
+	DeleteByWebhook(ctx context.Context, webhookID string) error
+}
+
+type Outbox interface {
+	Add(ctx context.Context, event *models.OutboxEvent) error // Sets event ID
+	// Claim takes the oldest unpublished event available at now and hides it until the time, ErrOutboxEmpty when there is none
+	Claim(ctx context.Context, now, until int64) (*models.OutboxEvent, error)
+	// Retry and marks of missing event are not errors, it was published and deleted by another relay.
+	// Invalid id is ErrOutboxEventNotFound
+	Retry(ctx context.Context, id, failure string, availableAt int64) error
+	MarkPublished(ctx context.Context, id string, at int64) error
+	MarkDispatched(ctx context.Context, id string) error              // Webhook deliveries of the event are queued
+	DeletePublished(ctx context.Context, before int64) (int64, error) // Returns deleted count
+}
+
+type Transactions interface {
+	// WithTransaction runs fn in one transaction, writes of repos called with its ctx are committed together
+	// when fn returns nil. Called inside a transaction fn joins it
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Attempts interface {
+	Get(ctx context.Context, key string) (*models.LoginAttempts, error)
+	AddFailure(ctx context.Context, key string, at int64) (*models.LoginAttempts, error)
+	Lock(ctx context.Context, key string, until int64) error
+	Reset(ctx context.Context, key string) error
+}
+
+type APIKeys interface {
+	Create(ctx context.Context, key *models.APIKey) error // Sets key ID
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ByUser(ctx context.Context, userId string) ([]models.APIKey, error)
+	Touch(ctx context.Context, id string, at int64) error
+	Delete(ctx context.Context, userId, id string) error
+	DeleteByUser(ctx context.Context, userId string) error
+}
+
+type Organizations interface {
+	Create(ctx context.Context, org *models.Organization) error // Sets org.ID
+	Get(ctx context.Context, id string) (*models.Organization, error)
+	ByMember(ctx context.Context, userId string) ([]models.Organization, error)
+	AddMember(ctx context.Context, id string, member *models.Member) error // Also drops invitations of member email
+	SetMemberRole(ctx context.Context, id, userId, role string) error
+	RemoveMember(ctx context.Context, id, userId string) error
+	RemoveUser(ctx context.Context, userId string) error // Membership in all organizations
+	AddInvitation(ctx context.Context, id string, invitation *models.Invitation) error
+	GetByInvitation(ctx context.Context, tokenHash string) (*models.Organization, error)
+	SetQuota(ctx context.Context, id string, quota int64) error
 }
 
 type Repo struct {
-	Users  Users
-	Tokens Tokens
-	Files  Files
+	Users    Users
+	Sessions Sessions
+	Files    Files
+	Attempts Attempts
+	APIKeys  APIKeys
+
+	Organizations Organizations
+	Uploads       Uploads
+
+	IdempotencyKeys IdempotencyKeys
+	Jobs            Jobs
+
+	Webhooks   Webhooks
+	Deliveries Deliveries
+
+	Outbox       Outbox
+	Transactions Transactions
 }
 
 func New(db *mongodb.Mongo, config *config.Repo) *Repo {
 	return &Repo{
-		Users:  newUsersRepo(db, config.UsersCollection),
-		Tokens: newTokensRepo(db, config.TokensCollection),
-		Files:  newFilesRepo(db, config.FilesCollection),
+		Users:    newUsersRepo(db, config.UsersCollection),
+		Sessions: newSessionsRepo(db, config.TokensCollection),
+		Files:    newFilesRepo(db, config.FilesCollection),
+		Attempts: newAttemptsRepo(db, config.AttemptsCollection),
+		APIKeys:  newAPIKeysRepo(db, config.APIKeysCollection),
+
+		Organizations: newOrganizationsRepo(db, config.OrganizationsCollection),
+		Uploads:       newUploadsRepo(db, uploadsCollection(config)),
+
+		IdempotencyKeys: newIdempotencyRepo(db, idempotencyCollection(config)),
+		Jobs:            newJobsRepo(db, jobsCollection(config)),
+
+		Webhooks:   newWebhooksRepo(db, webhooksCollection(config)),
+		Deliveries: newDeliveriesRepo(db, deliveriesCollection(config)),
+
+		Outbox:       newOutboxRepo(db, outboxCollection(config)),
+		Transactions: newTransactions(db, config.AllowStandalone),
 	}
 }
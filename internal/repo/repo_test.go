@@ -0,0 +1,195 @@
+package repo_test
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/repotest"
+	"errors"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestDB connects to MongoDB from TEST_MONGO_URL, every call gets own database
+func newTestDB(t *testing.T) (*mongodb.Mongo, *config.Repo) {
+	mongoURL := os.Getenv("TEST_MONGO_URL")
+	if mongoURL == "" {
+		t.Skip("TEST_MONGO_URL is not set")
+	}
+
+	parsed, err := url.Parse(mongoURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_MONGO_URL - %s", err.Error())
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = "27017"
+	}
+
+	cfg := &config.Repo{
+		Host:                    parsed.Hostname(),
+		Port:                    port,
+		DatabaseName:            "test_" + primitive.NewObjectID().Hex(),
+		UsersCollection:         "users",
+		FilesCollection:         "files",
+		TokensCollection:        "tokens",
+		AttemptsCollection:      "attempts",
+		APIKeysCollection:       "apikeys",
+		OrganizationsCollection: "organizations",
+		AllowStandalone:         true, // Contract tests run on a developer's standalone server too
+	}
+
+	db, err := mongodb.New(cfg)
+	if err != nil {
+		t.Fatalf("mongodb.New error - %s", err.Error())
+	}
+	t.Cleanup(func() {
+		db.DB.Drop(context.Background())
+		db.DB.Client().Disconnect(context.Background())
+	})
+
+	return db, cfg
+}
+
+func Test_Contract(t *testing.T) {
+	if os.Getenv("TEST_MONGO_URL") == "" {
+		t.Skip("TEST_MONGO_URL is not set")
+	}
+
+	repotest.Run(t, func(t *testing.T) *repo.Repo {
+		db, cfg := newTestDB(t)
+
+		err := repo.Migrate(context.Background(), db, cfg)
+		if err != nil {
+			t.Fatalf("Migrate error - %s", err.Error())
+		}
+
+		return repo.New(db, cfg)
+	})
+}
+
+func Test_CheckTransactions(t *testing.T) {
+	db, cfg := newTestDB(t)
+	ctx := context.Background()
+
+	var hello struct {
+		SetName string `bson:"setName"`
+	}
+	err := db.DB.Client().Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repo.CheckTransactions(ctx, db, cfg)
+	if err != nil {
+		t.Fatalf("allowed standalone server error - %s", err.Error())
+	}
+
+	cfg.AllowStandalone = false
+	err = repo.CheckTransactions(ctx, db, cfg)
+	if hello.SetName == "" && !errors.Is(err, models.ErrTransactionsUnsupported) {
+		t.Fatalf("expected standalone server to be rejected, got - %v", err)
+	}
+	if hello.SetName != "" && err != nil {
+		t.Fatalf("replica set error - %s", err.Error())
+	}
+}
+
+func Test_Migrate(t *testing.T) {
+	db, cfg := newTestDB(t)
+	ctx := context.Background()
+
+	// Replicas started together migrate once, applied migrations are skipped
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- repo.Migrate(ctx, db, cfg)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Migrate error - %s", err.Error())
+		}
+	}
+
+	statuses, err := repo.Migrations(ctx, db, cfg)
+	if err != nil {
+		t.Fatalf("Migrations error - %s", err.Error())
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == 0 {
+			t.Fatalf("migration %d is not applied", status.Version)
+		}
+	}
+
+	err = repo.Rollback(ctx, db, cfg, 0)
+	if err != nil {
+		t.Fatalf("Rollback error - %s", err.Error())
+	}
+
+	statuses, err = repo.Migrations(ctx, db, cfg)
+	if err != nil {
+		t.Fatalf("Migrations error - %s", err.Error())
+	}
+	for _, status := range statuses {
+		if status.AppliedAt != 0 {
+			t.Fatalf("migration %d is not reverted", status.Version)
+		}
+	}
+
+	// Reverted migrations apply again
+	err = repo.Migrate(ctx, db, cfg)
+	if err != nil {
+		t.Fatalf("Migrate error - %s", err.Error())
+	}
+}
+
+func Test_CreateUserConcurrent(t *testing.T) {
+	db, cfg := newTestDB(t)
+
+	err := repo.Migrate(context.Background(), db, cfg)
+	if err != nil {
+		t.Fatalf("Migrate error - %s", err.Error())
+	}
+	users := repo.New(db, cfg).Users
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- users.CreateUser(context.Background(), &models.UserSignUpInput{Email: "race@mail.com", Password: "hash"})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		if !errors.Is(err, models.ErrUserExists) {
+			t.Fatalf("CreateUser error - %s", err.Error())
+		}
+	}
+
+	if created != 1 {
+		t.Fatalf("created %d users with the same email", created)
+	}
+}
@@ -0,0 +1,813 @@
+// Package repotest contains contract tests every repo implementation has to pass
+package repotest
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ctx = context.Background()
+
+// Run runs the contract against repositories created by newRepo, each subtest gets an empty one
+func Run(t *testing.T, newRepo func(t *testing.T) *repo.Repo) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r *repo.Repo)
+	}{
+		{name: "Users", test: testUsers},
+		{name: "UsersList", test: testUsersList},
+		{name: "UsersIdentities", test: testUsersIdentities},
+		{name: "Sessions", test: testSessions},
+		{name: "Files", test: testFiles},
+		{name: "Uploads", test: testUploads},
+		{name: "IdempotencyKeys", test: testIdempotencyKeys},
+		{name: "Jobs", test: testJobs},
+		{name: "Webhooks", test: testWebhooks},
+		{name: "Deliveries", test: testDeliveries},
+		{name: "Attempts", test: testAttempts},
+		{name: "APIKeys", test: testAPIKeys},
+		{name: "Organizations", test: testOrganizations},
+		{name: "Outbox", test: testOutbox},
+		{name: "Transactions", test: testTransactions},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, newRepo(t))
+		})
+	}
+}
+
+func createUser(t *testing.T, r *repo.Repo, email string) *models.User {
+	input := &models.UserSignUpInput{Email: email, Password: "hash", Role: models.RoleUser, CreatedAt: 100}
+	err := r.Users.CreateUser(ctx, input)
+	if err != nil {
+		t.Fatalf("CreateUser error - %s", err.Error())
+	}
+
+	creds, err := r.Users.GetUserByCreds(ctx, email)
+	if err != nil {
+		t.Fatalf("GetUserByCreds error - %s", err.Error())
+	}
+
+	user, err := r.Users.GetUserByID(ctx, creds.UserID.Hex())
+	if err != nil {
+		t.Fatalf("GetUserByID error - %s", err.Error())
+	}
+	assert.Equal(t, user.ID, input.ID, "CreateUser sets ID")
+
+	return user
+}
+
+func expectError(t *testing.T, name string, err, expect error) {
+	t.Helper()
+	if !errors.Is(err, expect) {
+		t.Fatalf("%s: expected error %v, got %v", name, expect, err)
+	}
+}
+
+func noError(t *testing.T, name string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s error - %s", name, err.Error())
+	}
+}
+
+func testUsers(t *testing.T, r *repo.Repo) {
+	user := createUser(t, r, "user@mail.com")
+	id := user.ID.Hex()
+
+	assert.Equal(t, "user@mail.com", user.Email)
+	assert.Equal(t, models.RoleUser, user.Role)
+	assert.Equal(t, int64(100), user.CreatedAt)
+
+	err := r.Users.CreateUser(ctx, &models.UserSignUpInput{Email: "user@mail.com", Password: "hash"})
+	expectError(t, "CreateUser duplicate", err, models.ErrUserExists)
+
+	_, err = r.Users.GetUserByCreds(ctx, "missing@mail.com")
+	expectError(t, "GetUserByCreds", err, models.ErrUserNotFound)
+	_, err = r.Users.GetUserByID(ctx, primitive.NewObjectID().Hex())
+	expectError(t, "GetUserByID", err, models.ErrUserNotFound)
+	_, err = r.Users.GetUserByID(ctx, "invalid")
+	expectError(t, "GetUserByID invalid", err, models.ErrUserNotFound)
+	expectError(t, "SetDisabled", r.Users.SetDisabled(ctx, primitive.NewObjectID().Hex(), true), models.ErrUserNotFound)
+
+	noError(t, "SetDisabled", r.Users.SetDisabled(ctx, id, true))
+	noError(t, "SetVerified", r.Users.SetVerified(ctx, "user@mail.com"))
+	noError(t, "SetPasswordResetToken", r.Users.SetPasswordResetToken(ctx, id, &models.PasswordResetToken{TokenHash: "reset", ExpiresAt: 200}))
+
+	byToken, err := r.Users.GetUserByResetToken(ctx, "reset")
+	noError(t, "GetUserByResetToken", err)
+	assert.Equal(t, user.ID, byToken.ID)
+	assert.Equal(t, &models.PasswordResetToken{TokenHash: "reset", ExpiresAt: 200}, byToken.PasswordReset)
+
+	noError(t, "UpdatePassword", r.Users.UpdatePassword(ctx, id, "new hash"))
+	_, err = r.Users.GetUserByResetToken(ctx, "reset")
+	expectError(t, "GetUserByResetToken after UpdatePassword", err, models.ErrUserNotFound)
+
+	mfa := &models.MFA{Secret: "secret", Enabled: true, RecoveryCodes: []string{"a", "b"}, LastUsedStep: 7}
+	noError(t, "SetMFA", r.Users.SetMFA(ctx, id, mfa))
+
+	name, avatar := "User", "avatar.png"
+	noError(t, "UpdateProfile", r.Users.UpdateProfile(ctx, id, &models.ProfileUpdateInput{DisplayName: &name, Avatar: &avatar}))
+
+	user, err = r.Users.GetUserByID(ctx, id)
+	noError(t, "GetUserByID", err)
+	assert.True(t, user.Disabled)
+	assert.True(t, user.Verified)
+	assert.Equal(t, "new hash", user.Password)
+	assert.NotZero(t, user.SessionsRevokedAt)
+	assert.Nil(t, user.PasswordReset)
+	assert.Equal(t, mfa, user.MFA)
+	assert.Equal(t, "User", user.DisplayName)
+	assert.Equal(t, "avatar.png", user.Avatar)
+
+	creds, err := r.Users.GetUserByCreds(ctx, "user@mail.com")
+	noError(t, "GetUserByCreds", err)
+	assert.Equal(t, "new hash", creds.Password)
+	assert.True(t, creds.Disabled)
+	assert.Equal(t, mfa, creds.MFA)
+
+	empty := ""
+	noError(t, "UpdateProfile clear", r.Users.UpdateProfile(ctx, id, &models.ProfileUpdateInput{Avatar: &empty}))
+	noError(t, "SetMFA reset", r.Users.SetMFA(ctx, id, nil))
+
+	user, err = r.Users.GetUserByID(ctx, id)
+	noError(t, "GetUserByID", err)
+	assert.Equal(t, "User", user.DisplayName)
+	assert.Equal(t, "", user.Avatar)
+	assert.Nil(t, user.MFA)
+
+	noError(t, "Delete", r.Users.Delete(ctx, id))
+	expectError(t, "Delete again", r.Users.Delete(ctx, id), models.ErrUserNotFound)
+	_, err = r.Users.GetUserByID(ctx, id)
+	expectError(t, "GetUserByID deleted", err, models.ErrUserNotFound)
+}
+
+func testUsersList(t *testing.T, r *repo.Repo) {
+	for i := 0; i < 5; i++ {
+		createUser(t, r, fmt.Sprintf("user%d@mail.com", i))
+	}
+	createUser(t, r, "admin@site.com")
+
+	users, total, err := r.Users.List(ctx, &models.UsersFilter{Page: 1, Limit: 4})
+	noError(t, "List", err)
+	assert.Equal(t, int64(6), total)
+	assert.Len(t, users, 4)
+	assert.Equal(t, "user0@mail.com", users[0].Email)
+
+	users, total, err = r.Users.List(ctx, &models.UsersFilter{Page: 2, Limit: 4})
+	noError(t, "List second page", err)
+	assert.Equal(t, int64(6), total)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "admin@site.com", users[1].Email)
+
+	users, total, err = r.Users.List(ctx, &models.UsersFilter{Search: "MAIL.", Page: 1, Limit: 10})
+	noError(t, "List search", err)
+	assert.Equal(t, int64(5), total)
+	assert.Len(t, users, 5)
+
+	// Search is not a pattern
+	users, total, err = r.Users.List(ctx, &models.UsersFilter{Search: "user.", Page: 1, Limit: 10})
+	noError(t, "List search pattern", err)
+	assert.Equal(t, int64(0), total)
+	assert.Len(t, users, 0)
+}
+
+func testUsersIdentities(t *testing.T, r *repo.Repo) {
+	identity := models.Identity{Provider: "google", Subject: "1", Email: "user@gmail.com", LinkedAt: 100}
+	err := r.Users.CreateUser(ctx, &models.UserSignUpInput{Email: "user@mail.com", Verified: true, Identities: []models.Identity{identity}})
+	noError(t, "CreateUser", err)
+
+	user, err := r.Users.GetUserByIdentity(ctx, "google", "1")
+	noError(t, "GetUserByIdentity", err)
+	assert.Equal(t, "user@mail.com", user.Email)
+	assert.Equal(t, []models.Identity{identity}, user.Identities)
+
+	_, err = r.Users.GetUserByIdentity(ctx, "github", "1")
+	expectError(t, "GetUserByIdentity", err, models.ErrUserNotFound)
+
+	github := models.Identity{Provider: "github", Subject: "2", Email: "user@github.com", LinkedAt: 200}
+	noError(t, "AddIdentity", r.Users.AddIdentity(ctx, user.ID.Hex(), &github))
+	expectError(t, "AddIdentity", r.Users.AddIdentity(ctx, primitive.NewObjectID().Hex(), &github), models.ErrUserNotFound)
+
+	user, err = r.Users.GetUserByIdentity(ctx, "github", "2")
+	noError(t, "GetUserByIdentity", err)
+	assert.Equal(t, []models.Identity{identity, github}, user.Identities)
+}
+
+func testSessions(t *testing.T, r *repo.Repo) {
+	first := &models.Session{UserID: "1", UserAgent: "curl", IP: "127.0.0.1", CreatedAt: 100, LastSeenAt: 100, ExpiresAt: 1000}
+	second := &models.Session{UserID: "1", CreatedAt: 100, LastSeenAt: 200, ExpiresAt: 1000}
+	expired := &models.Session{UserID: "1", CreatedAt: 100, LastSeenAt: 300, ExpiresAt: 400}
+	other := &models.Session{UserID: "2", CreatedAt: 100, LastSeenAt: 100, ExpiresAt: 1000}
+
+	for _, session := range []*models.Session{first, second, expired, other} {
+		noError(t, "Create", r.Sessions.Create(ctx, session))
+		assert.False(t, session.ID.IsZero())
+	}
+
+	session, err := r.Sessions.Get(ctx, first.ID.Hex())
+	noError(t, "Get", err)
+	assert.Equal(t, first, session)
+
+	_, err = r.Sessions.Get(ctx, primitive.NewObjectID().Hex())
+	expectError(t, "Get", err, models.ErrSessionNotFound)
+
+	sessions, err := r.Sessions.ByUser(ctx, "1", 500)
+	noError(t, "ByUser", err)
+	assert.Equal(t, []models.Session{*second, *first}, sessions)
+
+	noError(t, "Touch", r.Sessions.Touch(ctx, first.ID.Hex(), 300))
+	sessions, err = r.Sessions.ByUser(ctx, "1", 500)
+	noError(t, "ByUser", err)
+	assert.Equal(t, first.ID, sessions[0].ID)
+	assert.Equal(t, int64(300), sessions[0].LastSeenAt)
+
+	expectError(t, "Delete of other user", r.Sessions.Delete(ctx, "2", first.ID.Hex()), models.ErrSessionNotFound)
+	noError(t, "Delete", r.Sessions.Delete(ctx, "1", first.ID.Hex()))
+	expectError(t, "Delete again", r.Sessions.Delete(ctx, "1", first.ID.Hex()), models.ErrSessionNotFound)
+
+	noError(t, "DeleteByUser", r.Sessions.DeleteByUser(ctx, "1"))
+	sessions, err = r.Sessions.ByUser(ctx, "1", 0)
+	noError(t, "ByUser", err)
+	assert.Len(t, sessions, 0)
+
+	_, err = r.Sessions.Get(ctx, other.ID.Hex())
+	noError(t, "Get of other user", err)
+}
+
+func testFiles(t *testing.T, r *repo.Repo) {
+	logs := []models.FileUploadLogInput{
+		{Filename: "a.png", Size: 10, UploadDate: 100, UserId: "1", Url: "url/a.png", Status: models.FileReady},
+		{Filename: "b.png", Size: 20, UploadDate: 200, UserId: "1", Url: "url/b.png", Status: models.FileProcessing},
+		{Filename: "c.png", Size: 30, UploadDate: 300, UserId: "2", Status: models.FileQuarantined, Error: "Eicar-Test-Signature"},
+		{Filename: "d.png", Size: 40, UploadDate: 400, UserId: "1", OrgId: "org", Url: "url/d.png"},
+		{Filename: "e.png", Size: 50, UploadDate: 500, UserId: "2", OrgId: "org", Url: "url/e.png"},
+	}
+	for i := range logs {
+		noError(t, "AddLog", r.Files.AddLog(ctx, &logs[i]))
+		assert.False(t, logs[i].ID.IsZero(), "AddLog sets ID")
+	}
+
+	files, err := r.Files.All(ctx)
+	noError(t, "All", err)
+	assert.Equal(t, []string{"a.png", "b.png", "c.png"}, filenames(files))
+
+	files, err = r.Files.ByUser(ctx, "1")
+	noError(t, "ByUser", err)
+	assert.Equal(t, []models.FileOut{
+		{ID: logs[0].ID, Filename: "a.png", Size: 10, Date: 100, UserId: "1", Url: "url/a.png", Status: models.FileReady},
+		{ID: logs[1].ID, Filename: "b.png", Size: 20, Date: 200, UserId: "1", Url: "url/b.png", Status: models.FileProcessing},
+	}, files)
+
+	file, err := r.Files.Get(ctx, logs[3].ID.Hex())
+	noError(t, "Get", err)
+	assert.Equal(t, &models.FileOut{ID: logs[3].ID, Filename: "d.png", Size: 40, Date: 400, UserId: "1", OrgId: "org", Url: "url/d.png"}, file)
+
+	file, err = r.Files.Get(ctx, logs[2].ID.Hex())
+	noError(t, "Get", err)
+	assert.Equal(t, &models.FileOut{ID: logs[2].ID, Filename: "c.png", Size: 30, Date: 300, UserId: "2", Status: models.FileQuarantined, Error: "Eicar-Test-Signature"}, file)
+
+	_, err = r.Files.Get(ctx, primitive.NewObjectID().Hex())
+	assert.True(t, errors.Is(err, models.ErrFileNotFound))
+
+	ready := &models.FileStatusUpdate{Status: models.FileReady, Image: &models.ImageInfo{Format: "png", Width: 640, Height: 480}}
+	noError(t, "SetStatus", r.Files.SetStatus(ctx, logs[1].ID.Hex(), ready))
+	file, err = r.Files.Get(ctx, logs[1].ID.Hex())
+	noError(t, "Get", err)
+	assert.Equal(t, models.FileReady, file.Status)
+	assert.Equal(t, ready.Image, file.Image)
+
+	noError(t, "SetStatus", r.Files.SetStatus(ctx, logs[1].ID.Hex(), &models.FileStatusUpdate{Status: models.FileFailed, Error: "broken"}))
+	file, err = r.Files.Get(ctx, logs[1].ID.Hex())
+	noError(t, "Get", err)
+	assert.Equal(t, models.FileFailed, file.Status)
+	assert.Equal(t, "broken", file.Error)
+	assert.Nil(t, file.Image)
+
+	err = r.Files.SetStatus(ctx, primitive.NewObjectID().Hex(), ready)
+	assert.True(t, errors.Is(err, models.ErrFileNotFound))
+
+	files, err = r.Files.ByOrg(ctx, "org")
+	noError(t, "ByOrg", err)
+	assert.Equal(t, []string{"d.png", "e.png"}, filenames(files))
+	assert.Equal(t, "org", files[0].OrgId)
+
+	stats, err := r.Files.Stats(ctx, "1")
+	noError(t, "Stats", err)
+	assert.Equal(t, &models.UserStats{UserID: "1", FilesCount: 2, TotalSize: 30, LastUpload: 200}, stats)
+
+	stats, err = r.Files.Stats(ctx, "3")
+	noError(t, "Stats without files", err)
+	assert.Equal(t, &models.UserStats{UserID: "3"}, stats)
+
+	usage, err := r.Files.OrgUsage(ctx, "org")
+	noError(t, "OrgUsage", err)
+	assert.Equal(t, int64(90), usage)
+
+	for filename, expect := range map[string]bool{"a.png": true, "e.png": true, "x.png": false} {
+		exists, err := r.Files.Exists(ctx, filename)
+		noError(t, "Exists", err)
+		assert.Equal(t, expect, exists, filename)
+	}
+
+	noError(t, "DeleteByUser", r.Files.DeleteByUser(ctx, "1"))
+	files, err = r.Files.All(ctx)
+	noError(t, "All", err)
+	assert.Equal(t, []string{"c.png"}, filenames(files))
+
+	// Organization files stay with the organization
+	files, err = r.Files.ByOrg(ctx, "org")
+	noError(t, "ByOrg", err)
+	assert.Len(t, files, 2)
+
+	noError(t, "Delete", r.Files.Delete(ctx, files[0].ID.Hex()))
+	_, err = r.Files.Get(ctx, files[0].ID.Hex())
+	expectError(t, "Get deleted", err, models.ErrFileNotFound)
+	expectError(t, "Delete missing", r.Files.Delete(ctx, files[0].ID.Hex()), models.ErrFileNotFound)
+	expectError(t, "Delete invalid", r.Files.Delete(ctx, "invalid"), models.ErrFileNotFound)
+
+	files, err = r.Files.ByOrg(ctx, "org")
+	noError(t, "ByOrg", err)
+	assert.Equal(t, []string{"e.png"}, filenames(files))
+}
+
+func testUploads(t *testing.T, r *repo.Repo) {
+	uploads := []models.PendingUpload{
+		{Filename: "b.png", Size: 20, UserId: "1", CreatedAt: 200},
+		{Filename: "a.png", Size: 10, UserId: "1", OrgId: "org", CreatedAt: 100},
+		{Filename: "c.png", Size: 30, UserId: "2", CreatedAt: 300},
+	}
+	for i := range uploads {
+		noError(t, "Create", r.Uploads.Create(ctx, &uploads[i]))
+		assert.False(t, uploads[i].ID.IsZero(), "Create sets ID")
+	}
+
+	stale, err := r.Uploads.Stale(ctx, 300)
+	noError(t, "Stale", err)
+	assert.Equal(t, []models.PendingUpload{uploads[1], uploads[0]}, stale)
+
+	noError(t, "Delete", r.Uploads.Delete(ctx, uploads[1].ID.Hex()))
+	noError(t, "Delete missing", r.Uploads.Delete(ctx, uploads[1].ID.Hex()))
+
+	stale, err = r.Uploads.Stale(ctx, 1000)
+	noError(t, "Stale", err)
+	assert.Equal(t, []models.PendingUpload{uploads[0], uploads[2]}, stale)
+}
+
+func testIdempotencyKeys(t *testing.T, r *repo.Repo) {
+	record := &models.IdempotencyRecord{Scope: "1", Key: "key", Fingerprint: "hash", LockedUntil: 200, ExpiresAt: 1000}
+
+	reserved, err := r.IdempotencyKeys.Reserve(ctx, record, 100)
+	noError(t, "Reserve", err)
+	assert.True(t, reserved)
+
+	reserved, err = r.IdempotencyKeys.Reserve(ctx, &models.IdempotencyRecord{Scope: "1", Key: "key", Fingerprint: "other", LockedUntil: 300, ExpiresAt: 1100}, 150)
+	noError(t, "Reserve", err)
+	assert.False(t, reserved, "key is held by request in progress")
+
+	reserved, err = r.IdempotencyKeys.Reserve(ctx, &models.IdempotencyRecord{Scope: "2", Key: "key", LockedUntil: 200, ExpiresAt: 1000}, 100)
+	noError(t, "Reserve", err)
+	assert.True(t, reserved, "keys of other users are separate")
+
+	stored, err := r.IdempotencyKeys.Get(ctx, "1", "key")
+	noError(t, "Get", err)
+	assert.Equal(t, record, stored)
+
+	response := &models.IdempotentResponse{Status: 200, ContentType: "application/json", Body: []byte(`{"message":"upload success"}`)}
+	noError(t, "Complete", r.IdempotencyKeys.Complete(ctx, "1", "key", response))
+
+	reserved, err = r.IdempotencyKeys.Reserve(ctx, &models.IdempotencyRecord{Scope: "1", Key: "key", LockedUntil: 600, ExpiresAt: 1500}, 500)
+	noError(t, "Reserve", err)
+	assert.False(t, reserved, "completed key is held until it expires")
+
+	stored, err = r.IdempotencyKeys.Get(ctx, "1", "key")
+	noError(t, "Get", err)
+	assert.Equal(t, response, stored.Response)
+
+	reserved, err = r.IdempotencyKeys.Reserve(ctx, &models.IdempotencyRecord{Scope: "2", Key: "key", Fingerprint: "retry", LockedUntil: 400, ExpiresAt: 1300}, 300)
+	noError(t, "Reserve", err)
+	assert.True(t, reserved, "abandoned request is taken over")
+
+	expired := &models.IdempotencyRecord{Scope: "1", Key: "key", Fingerprint: "new", LockedUntil: 1200, ExpiresAt: 2000}
+	reserved, err = r.IdempotencyKeys.Reserve(ctx, expired, 1100)
+	noError(t, "Reserve", err)
+	assert.True(t, reserved, "expired key is reserved again")
+
+	stored, err = r.IdempotencyKeys.Get(ctx, "1", "key")
+	noError(t, "Get", err)
+	assert.Equal(t, expired, stored)
+
+	noError(t, "Release", r.IdempotencyKeys.Release(ctx, "1", "key"))
+	noError(t, "Release missing", r.IdempotencyKeys.Release(ctx, "1", "key"))
+
+	_, err = r.IdempotencyKeys.Get(ctx, "1", "key")
+	assert.True(t, errors.Is(err, models.ErrIdempotencyKeyNotFound))
+
+	err = r.IdempotencyKeys.Complete(ctx, "1", "key", response)
+	assert.True(t, errors.Is(err, models.ErrIdempotencyKeyNotFound))
+}
+
+func testJobs(t *testing.T, r *repo.Repo) {
+	_, err := r.Jobs.Lease(ctx, "w1", 100, 160)
+	assert.True(t, errors.Is(err, models.ErrNoJobs))
+
+	jobs := []models.Job{
+		{Type: models.JobProcessImage, Payload: "late", State: models.JobPending, RunAt: 200, CreatedAt: 90},
+		{Type: models.JobProcessImage, Payload: "first", State: models.JobPending, RunAt: 100, CreatedAt: 100},
+	}
+	for i := range jobs {
+		noError(t, "Enqueue", r.Jobs.Enqueue(ctx, &jobs[i]))
+		assert.False(t, jobs[i].ID.IsZero(), "Enqueue sets ID")
+	}
+
+	job, err := r.Jobs.Lease(ctx, "w1", 150, 210)
+	noError(t, "Lease", err)
+	assert.Equal(t, &models.Job{ID: jobs[1].ID, Type: models.JobProcessImage, Payload: "first", State: models.JobRunning,
+		Attempts: 1, RunAt: 100, LeasedBy: "w1", LeasedUntil: 210, CreatedAt: 100}, job)
+
+	_, err = r.Jobs.Lease(ctx, "w2", 150, 210)
+	assert.True(t, errors.Is(err, models.ErrNoJobs), "job due later and leased one aren't taken")
+
+	noError(t, "Retry", r.Jobs.Retry(ctx, jobs[1].ID.Hex(), "w1", "timeout", 300))
+	assert.True(t, errors.Is(r.Jobs.Retry(ctx, jobs[1].ID.Hex(), "w1", "timeout", 300), models.ErrJobLeaseLost), "retried job isn't leased")
+
+	job, err = r.Jobs.Lease(ctx, "w2", 250, 310)
+	noError(t, "Lease", err)
+	assert.Equal(t, jobs[0].ID, job.ID)
+
+	// Lease of w2 ends at 310, w3 takes the job over
+	job, err = r.Jobs.Lease(ctx, "w3", 320, 380)
+	noError(t, "Lease", err)
+	assert.Equal(t, jobs[0].ID, job.ID)
+	assert.Equal(t, 2, job.Attempts)
+
+	assert.True(t, errors.Is(r.Jobs.Complete(ctx, jobs[0].ID.Hex(), "w2"), models.ErrJobLeaseLost))
+	noError(t, "Complete", r.Jobs.Complete(ctx, jobs[0].ID.Hex(), "w3"))
+
+	job, err = r.Jobs.Lease(ctx, "w1", 330, 390)
+	noError(t, "Lease", err)
+	assert.Equal(t, jobs[1].ID, job.ID)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "timeout", job.LastError)
+
+	noError(t, "Bury", r.Jobs.Bury(ctx, jobs[1].ID.Hex(), "w1", "broken"))
+
+	_, err = r.Jobs.Lease(ctx, "w1", 1000, 1060)
+	assert.True(t, errors.Is(err, models.ErrNoJobs), "dead jobs aren't leased")
+
+	dead, err := r.Jobs.Dead(ctx)
+	noError(t, "Dead", err)
+	assert.Equal(t, []models.Job{{ID: jobs[1].ID, Type: models.JobProcessImage, Payload: "first", State: models.JobDead,
+		Attempts: 2, RunAt: 300, LastError: "broken", CreatedAt: 100}}, dead)
+
+	noError(t, "Requeue", r.Jobs.Requeue(ctx, jobs[1].ID.Hex(), 1000))
+	assert.True(t, errors.Is(r.Jobs.Requeue(ctx, jobs[1].ID.Hex(), 1000), models.ErrJobNotFound), "only dead jobs are requeued")
+
+	job, err = r.Jobs.Lease(ctx, "w1", 1000, 1060)
+	noError(t, "Lease", err)
+	assert.Equal(t, jobs[1].ID, job.ID)
+	assert.Equal(t, 1, job.Attempts)
+
+	dead, err = r.Jobs.Dead(ctx)
+	noError(t, "Dead", err)
+	assert.Empty(t, dead)
+}
+
+func testOutbox(t *testing.T, r *repo.Repo) {
+	_, err := r.Outbox.Claim(ctx, 100, 130)
+	assert.True(t, errors.Is(err, models.ErrOutboxEmpty))
+
+	events := []models.OutboxEvent{
+		{Type: models.EventUserCreated, Payload: `{"id":"2"}`, AvailableAt: 100, CreatedAt: 110},
+		{Type: models.EventFileUploaded, Payload: `{"id":"1"}`, AvailableAt: 100, CreatedAt: 100},
+	}
+	for i := range events {
+		noError(t, "Add", r.Outbox.Add(ctx, &events[i]))
+		assert.False(t, events[i].ID.IsZero(), "Add sets ID")
+	}
+
+	event, err := r.Outbox.Claim(ctx, 150, 180)
+	noError(t, "Claim", err)
+	assert.Equal(t, &models.OutboxEvent{ID: events[1].ID, Type: models.EventFileUploaded, Payload: `{"id":"1"}`,
+		AvailableAt: 180, Attempts: 1, CreatedAt: 100}, event, "the oldest event is claimed first")
+
+	event, err = r.Outbox.Claim(ctx, 150, 180)
+	noError(t, "Claim", err)
+	assert.Equal(t, events[0].ID, event.ID, "claimed event is hidden")
+
+	_, err = r.Outbox.Claim(ctx, 150, 180)
+	assert.True(t, errors.Is(err, models.ErrOutboxEmpty))
+
+	noError(t, "Retry", r.Outbox.Retry(ctx, events[1].ID.Hex(), "status 500", 300))
+	noError(t, "MarkPublished", r.Outbox.MarkPublished(ctx, events[0].ID.Hex(), 160))
+
+	_, err = r.Outbox.Claim(ctx, 250, 280)
+	assert.True(t, errors.Is(err, models.ErrOutboxEmpty), "retried event waits, published one isn't claimed")
+
+	event, err = r.Outbox.Claim(ctx, 300, 330)
+	noError(t, "Claim", err)
+	assert.Equal(t, events[1].ID, event.ID)
+	assert.Equal(t, 2, event.Attempts)
+	assert.Equal(t, "status 500", event.LastError)
+
+	assert.False(t, event.Dispatched)
+
+	noError(t, "MarkDispatched", r.Outbox.MarkDispatched(ctx, events[1].ID.Hex()))
+	noError(t, "Retry", r.Outbox.Retry(ctx, events[1].ID.Hex(), "status 500", 300))
+
+	event, err = r.Outbox.Claim(ctx, 300, 330)
+	noError(t, "Claim", err)
+	assert.True(t, event.Dispatched, "dispatched event keeps the flag on retries")
+
+	noError(t, "MarkPublished", r.Outbox.MarkPublished(ctx, events[1].ID.Hex(), 310))
+	noError(t, "Retry", r.Outbox.Retry(ctx, events[1].ID.Hex(), "late", 400))
+
+	_, err = r.Outbox.Claim(ctx, 1000, 1030)
+	assert.True(t, errors.Is(err, models.ErrOutboxEmpty), "retry doesn't return published event")
+
+	deleted, err := r.Outbox.DeletePublished(ctx, 200)
+	noError(t, "DeletePublished", err)
+	assert.Equal(t, int64(1), deleted)
+
+	deleted, err = r.Outbox.DeletePublished(ctx, 1000)
+	noError(t, "DeletePublished", err)
+	assert.Equal(t, int64(1), deleted)
+
+	noError(t, "MarkPublished", r.Outbox.MarkPublished(ctx, events[1].ID.Hex(), 1000))
+
+	expectError(t, "Retry invalid id", r.Outbox.Retry(ctx, "invalid", "failure", 1000), models.ErrOutboxEventNotFound)
+	expectError(t, "MarkPublished invalid id", r.Outbox.MarkPublished(ctx, "invalid", 1000), models.ErrOutboxEventNotFound)
+	expectError(t, "MarkDispatched invalid id", r.Outbox.MarkDispatched(ctx, "invalid"), models.ErrOutboxEventNotFound)
+}
+
+func testTransactions(t *testing.T, r *repo.Repo) {
+	var event models.OutboxEvent
+	err := r.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		err := r.Users.CreateUser(ctx, &models.UserSignUpInput{Email: "tx@test.com", Password: "hash", Role: models.RoleUser, CreatedAt: 100})
+		if err != nil {
+			return err
+		}
+
+		// Nested call joins the outer transaction
+		return r.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+			event = models.OutboxEvent{Type: models.EventUserCreated, Payload: `{}`, CreatedAt: 100}
+			return r.Outbox.Add(ctx, &event)
+		})
+	})
+	noError(t, "WithTransaction", err)
+
+	_, err = r.Users.GetUserByCreds(ctx, "tx@test.com")
+	noError(t, "GetUserByCreds", err)
+
+	claimed, err := r.Outbox.Claim(ctx, 100, 130)
+	noError(t, "Claim", err)
+	assert.Equal(t, event.ID, claimed.ID)
+
+	failure := errors.New("failure")
+	err = r.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		return failure
+	})
+	assert.True(t, errors.Is(err, failure), "error of fn is returned")
+}
+
+func filenames(files []models.FileOut) []string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Filename)
+	}
+	return names
+}
+
+func testWebhooks(t *testing.T, r *repo.Repo) {
+	uploads := &models.Webhook{URL: "https://a.example.com", Secret: "s1", Events: []string{models.EventFileUploaded}, CreatedBy: "1", CreatedAt: 100}
+	all := &models.Webhook{URL: "https://b.example.com", Secret: "s2", Events: models.WebhookEvents, CreatedBy: "1", CreatedAt: 200}
+	noError(t, "Create", r.Webhooks.Create(ctx, uploads))
+	noError(t, "Create", r.Webhooks.Create(ctx, all))
+	assert.False(t, uploads.ID.IsZero(), "Create sets ID")
+
+	webhook, err := r.Webhooks.Get(ctx, uploads.ID.Hex())
+	noError(t, "Get", err)
+	assert.Equal(t, uploads, webhook)
+
+	_, err = r.Webhooks.Get(ctx, primitive.NewObjectID().Hex())
+	expectError(t, "Get missing", err, models.ErrWebhookNotFound)
+	_, err = r.Webhooks.Get(ctx, "invalid")
+	expectError(t, "Get invalid", err, models.ErrWebhookNotFound)
+
+	webhooks, err := r.Webhooks.All(ctx)
+	noError(t, "All", err)
+	assert.Equal(t, []models.Webhook{*uploads, *all}, webhooks)
+
+	webhooks, err = r.Webhooks.ByEvent(ctx, models.EventFileUploaded)
+	noError(t, "ByEvent", err)
+	assert.Len(t, webhooks, 2)
+
+	webhooks, err = r.Webhooks.ByEvent(ctx, models.EventUserCreated)
+	noError(t, "ByEvent", err)
+	assert.Equal(t, []models.Webhook{*all}, webhooks)
+
+	noError(t, "Delete", r.Webhooks.Delete(ctx, uploads.ID.Hex()))
+	expectError(t, "Delete missing", r.Webhooks.Delete(ctx, uploads.ID.Hex()), models.ErrWebhookNotFound)
+
+	webhooks, err = r.Webhooks.All(ctx)
+	noError(t, "All", err)
+	assert.Equal(t, []models.Webhook{*all}, webhooks)
+}
+
+func testDeliveries(t *testing.T, r *repo.Repo) {
+	webhook := &models.Webhook{URL: "https://a.example.com", Secret: "s1", Events: models.WebhookEvents, CreatedBy: "1", CreatedAt: 100}
+	noError(t, "Create webhook", r.Webhooks.Create(ctx, webhook))
+	other := &models.Webhook{URL: "https://b.example.com", Secret: "s2", Events: models.WebhookEvents, CreatedBy: "1", CreatedAt: 100}
+	noError(t, "Create webhook", r.Webhooks.Create(ctx, other))
+
+	deliveries := make([]*models.WebhookDelivery, 3)
+	for i := range deliveries {
+		deliveries[i] = &models.WebhookDelivery{
+			WebhookID: webhook.ID.Hex(),
+			Event:     models.EventFileUploaded,
+			Payload:   fmt.Sprintf(`{"n":%d}`, i),
+			Status:    models.DeliveryPending,
+			CreatedAt: int64(100 + i),
+		}
+		noError(t, "Create", r.Deliveries.Create(ctx, deliveries[i]))
+	}
+	noError(t, "Create", r.Deliveries.Create(ctx, &models.WebhookDelivery{WebhookID: other.ID.Hex(), Event: models.EventUserCreated, Payload: "{}", Status: models.DeliveryPending, CreatedAt: 200}))
+
+	id := deliveries[0].ID.Hex()
+	delivery, err := r.Deliveries.Get(ctx, id)
+	noError(t, "Get", err)
+	assert.Equal(t, deliveries[0], delivery)
+	assert.Empty(t, delivery.Attempts)
+
+	_, err = r.Deliveries.Get(ctx, primitive.NewObjectID().Hex())
+	expectError(t, "Get missing", err, models.ErrDeliveryNotFound)
+	_, err = r.Deliveries.Get(ctx, "invalid")
+	expectError(t, "Get invalid", err, models.ErrDeliveryNotFound)
+
+	failed := &models.DeliveryAttempt{At: 110, StatusCode: 500, DurationMs: 12}
+	delivered := &models.DeliveryAttempt{At: 120, StatusCode: 200, DurationMs: 5}
+	noError(t, "AddAttempt", r.Deliveries.AddAttempt(ctx, id, failed, models.DeliveryPending))
+	noError(t, "AddAttempt", r.Deliveries.AddAttempt(ctx, id, delivered, models.DeliveryDelivered))
+	expectError(t, "AddAttempt missing", r.Deliveries.AddAttempt(ctx, primitive.NewObjectID().Hex(), failed, models.DeliveryPending), models.ErrDeliveryNotFound)
+
+	delivery, err = r.Deliveries.Get(ctx, id)
+	noError(t, "Get", err)
+	assert.Equal(t, models.DeliveryDelivered, delivery.Status)
+	assert.Equal(t, []models.DeliveryAttempt{*failed, *delivered}, delivery.Attempts)
+
+	noError(t, "SetStatus", r.Deliveries.SetStatus(ctx, id, models.DeliveryFailed))
+	expectError(t, "SetStatus missing", r.Deliveries.SetStatus(ctx, primitive.NewObjectID().Hex(), models.DeliveryFailed), models.ErrDeliveryNotFound)
+	delivery, err = r.Deliveries.Get(ctx, id)
+	noError(t, "Get", err)
+	assert.Equal(t, models.DeliveryFailed, delivery.Status)
+	assert.Len(t, delivery.Attempts, 2)
+
+	list, err := r.Deliveries.ByWebhook(ctx, webhook.ID.Hex(), 2)
+	noError(t, "ByWebhook", err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, deliveries[2].ID, list[0].ID, "newest first")
+		assert.Equal(t, deliveries[1].ID, list[1].ID)
+	}
+
+	noError(t, "DeleteByWebhook", r.Deliveries.DeleteByWebhook(ctx, webhook.ID.Hex()))
+	list, err = r.Deliveries.ByWebhook(ctx, webhook.ID.Hex(), 10)
+	noError(t, "ByWebhook", err)
+	assert.Empty(t, list)
+
+	list, err = r.Deliveries.ByWebhook(ctx, other.ID.Hex(), 10)
+	noError(t, "ByWebhook", err)
+	assert.Len(t, list, 1, "deliveries of other webhooks stay")
+}
+
+func testAttempts(t *testing.T, r *repo.Repo) {
+	attempts, err := r.Attempts.Get(ctx, "key")
+	noError(t, "Get", err)
+	assert.Equal(t, &models.LoginAttempts{Key: "key"}, attempts)
+
+	_, err = r.Attempts.AddFailure(ctx, "key", 100)
+	noError(t, "AddFailure", err)
+	attempts, err = r.Attempts.AddFailure(ctx, "key", 200)
+	noError(t, "AddFailure", err)
+	assert.Equal(t, &models.LoginAttempts{Key: "key", Failures: 2, LastFailureAt: 200}, attempts)
+
+	noError(t, "Lock", r.Attempts.Lock(ctx, "key", 500))
+	attempts, err = r.Attempts.Get(ctx, "key")
+	noError(t, "Get", err)
+	assert.Equal(t, &models.LoginAttempts{Key: "key", Failures: 2, LastFailureAt: 200, LockedUntil: 500}, attempts)
+
+	noError(t, "Reset", r.Attempts.Reset(ctx, "key"))
+	attempts, err = r.Attempts.Get(ctx, "key")
+	noError(t, "Get", err)
+	assert.Equal(t, &models.LoginAttempts{Key: "key"}, attempts)
+}
+
+func testAPIKeys(t *testing.T, r *repo.Repo) {
+	key := &models.APIKey{UserID: "1", Name: "ci", Prefix: "ck_1", KeyHash: "hash1", Scopes: []string{models.ScopeFilesRead}, CreatedAt: 100}
+	newer := &models.APIKey{UserID: "1", Name: "cli", Prefix: "ck_2", KeyHash: "hash2", Scopes: []string{}, CreatedAt: 200, ExpiresAt: 1000}
+
+	noError(t, "Create", r.APIKeys.Create(ctx, key))
+	noError(t, "Create", r.APIKeys.Create(ctx, newer))
+	assert.False(t, key.ID.IsZero())
+
+	found, err := r.APIKeys.GetByHash(ctx, "hash1")
+	noError(t, "GetByHash", err)
+	assert.Equal(t, key, found)
+
+	_, err = r.APIKeys.GetByHash(ctx, "missing")
+	expectError(t, "GetByHash", err, models.ErrAPIKeyNotFound)
+
+	noError(t, "Touch", r.APIKeys.Touch(ctx, key.ID.Hex(), 300))
+	keys, err := r.APIKeys.ByUser(ctx, "1")
+	noError(t, "ByUser", err)
+	assert.Len(t, keys, 2)
+	assert.Equal(t, newer.ID, keys[0].ID)
+	assert.Equal(t, int64(300), keys[1].LastUsedAt)
+
+	expectError(t, "Delete of other user", r.APIKeys.Delete(ctx, "2", key.ID.Hex()), models.ErrAPIKeyNotFound)
+	noError(t, "Delete", r.APIKeys.Delete(ctx, "1", key.ID.Hex()))
+	expectError(t, "Delete again", r.APIKeys.Delete(ctx, "1", key.ID.Hex()), models.ErrAPIKeyNotFound)
+
+	noError(t, "DeleteByUser", r.APIKeys.DeleteByUser(ctx, "1"))
+	keys, err = r.APIKeys.ByUser(ctx, "1")
+	noError(t, "ByUser", err)
+	assert.Len(t, keys, 0)
+}
+
+func testOrganizations(t *testing.T, r *repo.Repo) {
+	owner := models.Member{UserID: "1", Email: "owner@mail.com", Role: models.OrgRoleOwner, JoinedAt: 100}
+	org := &models.Organization{Name: "Team", Quota: 1000, CreatedAt: 100, Members: []models.Member{owner}}
+	noError(t, "Create", r.Organizations.Create(ctx, org))
+	assert.False(t, org.ID.IsZero())
+	id := org.ID.Hex()
+
+	other := &models.Organization{Name: "Another", CreatedAt: 100, Members: []models.Member{owner}}
+	noError(t, "Create", r.Organizations.Create(ctx, other))
+
+	found, err := r.Organizations.Get(ctx, id)
+	noError(t, "Get", err)
+	assert.Equal(t, "Team", found.Name)
+	assert.Equal(t, int64(1000), found.Quota)
+	assert.Equal(t, []models.Member{owner}, found.Members)
+	assert.Len(t, found.Invitations, 0)
+
+	_, err = r.Organizations.Get(ctx, primitive.NewObjectID().Hex())
+	expectError(t, "Get", err, models.ErrOrganizationNotFound)
+
+	invitation := models.Invitation{Email: "editor@mail.com", Role: models.OrgRoleViewer, TokenHash: "first", InvitedBy: "1", ExpiresAt: 500}
+	noError(t, "AddInvitation", r.Organizations.AddInvitation(ctx, id, &invitation))
+	invitation.Role, invitation.TokenHash = models.OrgRoleEditor, "second"
+	noError(t, "AddInvitation again", r.Organizations.AddInvitation(ctx, id, &invitation))
+	expectError(t, "AddInvitation", r.Organizations.AddInvitation(ctx, primitive.NewObjectID().Hex(), &invitation), models.ErrOrganizationNotFound)
+
+	_, err = r.Organizations.GetByInvitation(ctx, "first")
+	expectError(t, "GetByInvitation replaced", err, models.ErrOrganizationNotFound)
+	found, err = r.Organizations.GetByInvitation(ctx, "second")
+	noError(t, "GetByInvitation", err)
+	assert.Equal(t, org.ID, found.ID)
+	assert.Equal(t, []models.Invitation{invitation}, found.Invitations)
+
+	editor := models.Member{UserID: "2", Email: "editor@mail.com", Role: models.OrgRoleEditor, JoinedAt: 200}
+	noError(t, "AddMember", r.Organizations.AddMember(ctx, id, &editor))
+	expectError(t, "AddMember", r.Organizations.AddMember(ctx, primitive.NewObjectID().Hex(), &editor), models.ErrOrganizationNotFound)
+
+	found, err = r.Organizations.Get(ctx, id)
+	noError(t, "Get", err)
+	assert.Equal(t, []models.Member{owner, editor}, found.Members)
+	assert.Len(t, found.Invitations, 0)
+
+	orgs, err := r.Organizations.ByMember(ctx, "1")
+	noError(t, "ByMember", err)
+	assert.Len(t, orgs, 2)
+	orgs, err = r.Organizations.ByMember(ctx, "2")
+	noError(t, "ByMember", err)
+	assert.Len(t, orgs, 1)
+
+	noError(t, "SetMemberRole", r.Organizations.SetMemberRole(ctx, id, "2", models.OrgRoleViewer))
+	expectError(t, "SetMemberRole", r.Organizations.SetMemberRole(ctx, id, "3", models.OrgRoleViewer), models.ErrMemberNotFound)
+	found, err = r.Organizations.Get(ctx, id)
+	noError(t, "Get", err)
+	assert.Equal(t, models.OrgRoleViewer, found.Member("2").Role)
+
+	noError(t, "RemoveMember", r.Organizations.RemoveMember(ctx, id, "2"))
+	expectError(t, "RemoveMember again", r.Organizations.RemoveMember(ctx, id, "2"), models.ErrMemberNotFound)
+
+	noError(t, "SetQuota", r.Organizations.SetQuota(ctx, id, 2000))
+	expectError(t, "SetQuota", r.Organizations.SetQuota(ctx, primitive.NewObjectID().Hex(), 2000), models.ErrOrganizationNotFound)
+	found, err = r.Organizations.Get(ctx, id)
+	noError(t, "Get", err)
+	assert.Equal(t, int64(2000), found.Quota)
+
+	noError(t, "RemoveUser", r.Organizations.RemoveUser(ctx, "1"))
+	orgs, err = r.Organizations.ByMember(ctx, "1")
+	noError(t, "ByMember", err)
+	assert.Len(t, orgs, 0)
+}
@@ -0,0 +1,721 @@
+package resilient
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"creatly-task/pkg/resilience"
+)
+
+// Calls which may be applied twice when repeated after a lost answer, like inserts with generated ID,
+// counters and deletes reporting not found, are created with idempotent false
+
+type users struct {
+	repo  repo.Users
+	guard *resilience.Guard
+}
+
+// Guard reports the database state
+func (u *users) Guard() *resilience.Guard {
+	return u.guard
+}
+
+func (u *users) CreateUser(ctx context.Context, input *models.UserSignUpInput) error {
+	return do(ctx, u.guard, false, func(ctx context.Context) error {
+		return u.repo.CreateUser(ctx, input)
+	})
+}
+
+func (u *users) GetUserByCreds(ctx context.Context, email string) (*models.UserSignInOutput, error) {
+	var result *models.UserSignInOutput
+	err := do(ctx, u.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = u.repo.GetUserByCreds(ctx, email)
+		return err
+	})
+	return result, err
+}
+
+func (u *users) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	var result *models.User
+	err := do(ctx, u.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = u.repo.GetUserByID(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (u *users) List(ctx context.Context, filter *models.UsersFilter) ([]models.User, int64, error) {
+	var (
+		users []models.User
+		total int64
+	)
+	err := do(ctx, u.guard, true, func(ctx context.Context) error {
+		var err error
+		users, total, err = u.repo.List(ctx, filter)
+		return err
+	})
+	return users, total, err
+}
+
+func (u *users) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return do(ctx, u.guard, true, func(ctx context.Context) error {
+		return u.repo.SetDisabled(ctx, id, disabled)
+	})
+}
+
+func (u *users) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	return do(ctx, u.guard, true, func(ctx context.Context) error {
+		return u.repo.UpdatePassword(ctx, id, passwordHash)
+	})
+}
+
+func (u *users) SetVerified(ctx context.Context, email string) error {
+	return do(ctx, u.guard, true, func(ctx context.Context) error {
+		return u.repo.SetVerified(ctx, email)
+	})
+}
+
+func (u *users) SetPasswordResetToken(ctx context.Context, id string, token *models.PasswordResetToken) error {
+	return do(ctx, u.guard, true, func(ctx context.Context) error {
+		return u.repo.SetPasswordResetToken(ctx, id, token)
+	})
+}
+
+func (u *users) GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	var result *models.User
+	err := do(ctx, u.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = u.repo.GetUserByResetToken(ctx, tokenHash)
+		return err
+	})
+	return result, err
+}
+
+func (u *users) SetMFA(ctx context.Context, id string, mfa *models.MFA) error {
+	return do(ctx, u.guard, true, func(ctx context.Context) error {
+		return u.repo.SetMFA(ctx, id, mfa)
+	})
+}
+
+func (u *users) GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	var result *models.User
+	err := do(ctx, u.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = u.repo.GetUserByIdentity(ctx, provider, subject)
+		return err
+	})
+	return result, err
+}
+
+func (u *users) AddIdentity(ctx context.Context, id string, identity *models.Identity) error {
+	return do(ctx, u.guard, false, func(ctx context.Context) error {
+		return u.repo.AddIdentity(ctx, id, identity)
+	})
+}
+
+func (u *users) UpdateProfile(ctx context.Context, id string, input *models.ProfileUpdateInput) error {
+	return do(ctx, u.guard, true, func(ctx context.Context) error {
+		return u.repo.UpdateProfile(ctx, id, input)
+	})
+}
+
+func (u *users) Delete(ctx context.Context, id string) error {
+	return do(ctx, u.guard, false, func(ctx context.Context) error {
+		return u.repo.Delete(ctx, id)
+	})
+}
+
+type sessions struct {
+	repo  repo.Sessions
+	guard *resilience.Guard
+}
+
+func (s *sessions) Create(ctx context.Context, session *models.Session) error {
+	return do(ctx, s.guard, false, func(ctx context.Context) error {
+		return s.repo.Create(ctx, session)
+	})
+}
+
+func (s *sessions) Get(ctx context.Context, id string) (*models.Session, error) {
+	var result *models.Session
+	err := do(ctx, s.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = s.repo.Get(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (s *sessions) ByUser(ctx context.Context, userId string, now int64) ([]models.Session, error) {
+	var result []models.Session
+	err := do(ctx, s.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = s.repo.ByUser(ctx, userId, now)
+		return err
+	})
+	return result, err
+}
+
+func (s *sessions) Touch(ctx context.Context, id string, at int64) error {
+	return do(ctx, s.guard, true, func(ctx context.Context) error {
+		return s.repo.Touch(ctx, id, at)
+	})
+}
+
+func (s *sessions) Delete(ctx context.Context, userId, id string) error {
+	return do(ctx, s.guard, false, func(ctx context.Context) error {
+		return s.repo.Delete(ctx, userId, id)
+	})
+}
+
+func (s *sessions) DeleteByUser(ctx context.Context, userId string) error {
+	return do(ctx, s.guard, true, func(ctx context.Context) error {
+		return s.repo.DeleteByUser(ctx, userId)
+	})
+}
+
+type files struct {
+	repo  repo.Files
+	guard *resilience.Guard
+}
+
+func (f *files) All(ctx context.Context) ([]models.FileOut, error) {
+	var result []models.FileOut
+	err := do(ctx, f.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = f.repo.All(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (f *files) AddLog(ctx context.Context, log *models.FileUploadLogInput) error {
+	return do(ctx, f.guard, false, func(ctx context.Context) error {
+		return f.repo.AddLog(ctx, log)
+	})
+}
+
+func (f *files) ByUser(ctx context.Context, userId string) ([]models.FileOut, error) {
+	var result []models.FileOut
+	err := do(ctx, f.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = f.repo.ByUser(ctx, userId)
+		return err
+	})
+	return result, err
+}
+
+func (f *files) Stats(ctx context.Context, userId string) (*models.UserStats, error) {
+	var result *models.UserStats
+	err := do(ctx, f.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = f.repo.Stats(ctx, userId)
+		return err
+	})
+	return result, err
+}
+
+func (f *files) DeleteByUser(ctx context.Context, userId string) error {
+	return do(ctx, f.guard, true, func(ctx context.Context) error {
+		return f.repo.DeleteByUser(ctx, userId)
+	})
+}
+
+func (f *files) ByOrg(ctx context.Context, orgId string) ([]models.FileOut, error) {
+	var result []models.FileOut
+	err := do(ctx, f.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = f.repo.ByOrg(ctx, orgId)
+		return err
+	})
+	return result, err
+}
+
+func (f *files) OrgUsage(ctx context.Context, orgId string) (int64, error) {
+	var result int64
+	err := do(ctx, f.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = f.repo.OrgUsage(ctx, orgId)
+		return err
+	})
+	return result, err
+}
+
+func (f *files) Exists(ctx context.Context, filename string) (bool, error) {
+	var result bool
+	err := do(ctx, f.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = f.repo.Exists(ctx, filename)
+		return err
+	})
+	return result, err
+}
+
+func (f *files) Get(ctx context.Context, id string) (*models.FileOut, error) {
+	var result *models.FileOut
+	err := do(ctx, f.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = f.repo.Get(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (f *files) SetStatus(ctx context.Context, id string, update *models.FileStatusUpdate) error {
+	return do(ctx, f.guard, true, func(ctx context.Context) error {
+		return f.repo.SetStatus(ctx, id, update)
+	})
+}
+
+// Delete isn't repeated, an applied delete would be reported as missing file
+func (f *files) Delete(ctx context.Context, id string) error {
+	return do(ctx, f.guard, false, func(ctx context.Context) error {
+		return f.repo.Delete(ctx, id)
+	})
+}
+
+type uploads struct {
+	repo  repo.Uploads
+	guard *resilience.Guard
+}
+
+func (u *uploads) Create(ctx context.Context, upload *models.PendingUpload) error {
+	return do(ctx, u.guard, false, func(ctx context.Context) error {
+		return u.repo.Create(ctx, upload)
+	})
+}
+
+func (u *uploads) Delete(ctx context.Context, id string) error {
+	return do(ctx, u.guard, true, func(ctx context.Context) error {
+		return u.repo.Delete(ctx, id)
+	})
+}
+
+func (u *uploads) Stale(ctx context.Context, before int64) ([]models.PendingUpload, error) {
+	var result []models.PendingUpload
+	err := do(ctx, u.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = u.repo.Stale(ctx, before)
+		return err
+	})
+	return result, err
+}
+
+type idempotencyKeys struct {
+	repo  repo.IdempotencyKeys
+	guard *resilience.Guard
+}
+
+// Reserve isn't repeated, the retry of an applied reservation would find the key held by itself
+func (i *idempotencyKeys) Reserve(ctx context.Context, record *models.IdempotencyRecord, now int64) (bool, error) {
+	var result bool
+	err := do(ctx, i.guard, false, func(ctx context.Context) error {
+		var err error
+		result, err = i.repo.Reserve(ctx, record, now)
+		return err
+	})
+	return result, err
+}
+
+func (i *idempotencyKeys) Get(ctx context.Context, scope, key string) (*models.IdempotencyRecord, error) {
+	var result *models.IdempotencyRecord
+	err := do(ctx, i.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = i.repo.Get(ctx, scope, key)
+		return err
+	})
+	return result, err
+}
+
+func (i *idempotencyKeys) Complete(ctx context.Context, scope, key string, response *models.IdempotentResponse) error {
+	return do(ctx, i.guard, true, func(ctx context.Context) error {
+		return i.repo.Complete(ctx, scope, key, response)
+	})
+}
+
+func (i *idempotencyKeys) Release(ctx context.Context, scope, key string) error {
+	return do(ctx, i.guard, true, func(ctx context.Context) error {
+		return i.repo.Release(ctx, scope, key)
+	})
+}
+
+type jobs struct {
+	repo  repo.Jobs
+	guard *resilience.Guard
+}
+
+func (j *jobs) Enqueue(ctx context.Context, job *models.Job) error {
+	return do(ctx, j.guard, false, func(ctx context.Context) error {
+		return j.repo.Enqueue(ctx, job)
+	})
+}
+
+// Lease isn't repeated, the job of an applied lease would wait for the lease end
+func (j *jobs) Lease(ctx context.Context, worker string, now, until int64) (*models.Job, error) {
+	var result *models.Job
+	err := do(ctx, j.guard, false, func(ctx context.Context) error {
+		var err error
+		result, err = j.repo.Lease(ctx, worker, now, until)
+		return err
+	})
+	return result, err
+}
+
+func (j *jobs) Complete(ctx context.Context, id, worker string) error {
+	return do(ctx, j.guard, false, func(ctx context.Context) error {
+		return j.repo.Complete(ctx, id, worker)
+	})
+}
+
+func (j *jobs) Retry(ctx context.Context, id, worker, failure string, runAt int64) error {
+	return do(ctx, j.guard, false, func(ctx context.Context) error {
+		return j.repo.Retry(ctx, id, worker, failure, runAt)
+	})
+}
+
+func (j *jobs) Bury(ctx context.Context, id, worker, failure string) error {
+	return do(ctx, j.guard, false, func(ctx context.Context) error {
+		return j.repo.Bury(ctx, id, worker, failure)
+	})
+}
+
+func (j *jobs) Dead(ctx context.Context) ([]models.Job, error) {
+	var result []models.Job
+	err := do(ctx, j.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = j.repo.Dead(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (j *jobs) Requeue(ctx context.Context, id string, runAt int64) error {
+	return do(ctx, j.guard, false, func(ctx context.Context) error {
+		return j.repo.Requeue(ctx, id, runAt)
+	})
+}
+
+type webhooks struct {
+	repo  repo.Webhooks
+	guard *resilience.Guard
+}
+
+func (w *webhooks) Create(ctx context.Context, webhook *models.Webhook) error {
+	return do(ctx, w.guard, false, func(ctx context.Context) error {
+		return w.repo.Create(ctx, webhook)
+	})
+}
+
+func (w *webhooks) Get(ctx context.Context, id string) (*models.Webhook, error) {
+	var result *models.Webhook
+	err := do(ctx, w.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = w.repo.Get(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (w *webhooks) All(ctx context.Context) ([]models.Webhook, error) {
+	var result []models.Webhook
+	err := do(ctx, w.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = w.repo.All(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (w *webhooks) ByEvent(ctx context.Context, event string) ([]models.Webhook, error) {
+	var result []models.Webhook
+	err := do(ctx, w.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = w.repo.ByEvent(ctx, event)
+		return err
+	})
+	return result, err
+}
+
+func (w *webhooks) Delete(ctx context.Context, id string) error {
+	return do(ctx, w.guard, false, func(ctx context.Context) error {
+		return w.repo.Delete(ctx, id)
+	})
+}
+
+type deliveries struct {
+	repo  repo.Deliveries
+	guard *resilience.Guard
+}
+
+func (d *deliveries) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return do(ctx, d.guard, false, func(ctx context.Context) error {
+		return d.repo.Create(ctx, delivery)
+	})
+}
+
+func (d *deliveries) Get(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	var result *models.WebhookDelivery
+	err := do(ctx, d.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = d.repo.Get(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (d *deliveries) ByWebhook(ctx context.Context, webhookID string, limit int64) ([]models.WebhookDelivery, error) {
+	var result []models.WebhookDelivery
+	err := do(ctx, d.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = d.repo.ByWebhook(ctx, webhookID, limit)
+		return err
+	})
+	return result, err
+}
+
+// AddAttempt isn't repeated, the attempt would be logged twice
+func (d *deliveries) AddAttempt(ctx context.Context, id string, attempt *models.DeliveryAttempt, status string) error {
+	return do(ctx, d.guard, false, func(ctx context.Context) error {
+		return d.repo.AddAttempt(ctx, id, attempt, status)
+	})
+}
+
+func (d *deliveries) SetStatus(ctx context.Context, id, status string) error {
+	return do(ctx, d.guard, true, func(ctx context.Context) error {
+		return d.repo.SetStatus(ctx, id, status)
+	})
+}
+
+func (d *deliveries) DeleteByWebhook(ctx context.Context, webhookID string) error {
+	return do(ctx, d.guard, true, func(ctx context.Context) error {
+		return d.repo.DeleteByWebhook(ctx, webhookID)
+	})
+}
+
+type attempts struct {
+	repo  repo.Attempts
+	guard *resilience.Guard
+}
+
+func (a *attempts) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	var result *models.LoginAttempts
+	err := do(ctx, a.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = a.repo.Get(ctx, key)
+		return err
+	})
+	return result, err
+}
+
+func (a *attempts) AddFailure(ctx context.Context, key string, at int64) (*models.LoginAttempts, error) {
+	var result *models.LoginAttempts
+	err := do(ctx, a.guard, false, func(ctx context.Context) error {
+		var err error
+		result, err = a.repo.AddFailure(ctx, key, at)
+		return err
+	})
+	return result, err
+}
+
+func (a *attempts) Lock(ctx context.Context, key string, until int64) error {
+	return do(ctx, a.guard, true, func(ctx context.Context) error {
+		return a.repo.Lock(ctx, key, until)
+	})
+}
+
+func (a *attempts) Reset(ctx context.Context, key string) error {
+	return do(ctx, a.guard, true, func(ctx context.Context) error {
+		return a.repo.Reset(ctx, key)
+	})
+}
+
+type apiKeys struct {
+	repo  repo.APIKeys
+	guard *resilience.Guard
+}
+
+func (a *apiKeys) Create(ctx context.Context, key *models.APIKey) error {
+	return do(ctx, a.guard, false, func(ctx context.Context) error {
+		return a.repo.Create(ctx, key)
+	})
+}
+
+func (a *apiKeys) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var result *models.APIKey
+	err := do(ctx, a.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = a.repo.GetByHash(ctx, keyHash)
+		return err
+	})
+	return result, err
+}
+
+func (a *apiKeys) ByUser(ctx context.Context, userId string) ([]models.APIKey, error) {
+	var result []models.APIKey
+	err := do(ctx, a.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = a.repo.ByUser(ctx, userId)
+		return err
+	})
+	return result, err
+}
+
+func (a *apiKeys) Touch(ctx context.Context, id string, at int64) error {
+	return do(ctx, a.guard, true, func(ctx context.Context) error {
+		return a.repo.Touch(ctx, id, at)
+	})
+}
+
+func (a *apiKeys) Delete(ctx context.Context, userId, id string) error {
+	return do(ctx, a.guard, false, func(ctx context.Context) error {
+		return a.repo.Delete(ctx, userId, id)
+	})
+}
+
+func (a *apiKeys) DeleteByUser(ctx context.Context, userId string) error {
+	return do(ctx, a.guard, true, func(ctx context.Context) error {
+		return a.repo.DeleteByUser(ctx, userId)
+	})
+}
+
+type organizations struct {
+	repo  repo.Organizations
+	guard *resilience.Guard
+}
+
+func (o *organizations) Create(ctx context.Context, org *models.Organization) error {
+	return do(ctx, o.guard, false, func(ctx context.Context) error {
+		return o.repo.Create(ctx, org)
+	})
+}
+
+func (o *organizations) Get(ctx context.Context, id string) (*models.Organization, error) {
+	var result *models.Organization
+	err := do(ctx, o.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = o.repo.Get(ctx, id)
+		return err
+	})
+	return result, err
+}
+
+func (o *organizations) ByMember(ctx context.Context, userId string) ([]models.Organization, error) {
+	var result []models.Organization
+	err := do(ctx, o.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = o.repo.ByMember(ctx, userId)
+		return err
+	})
+	return result, err
+}
+
+func (o *organizations) AddMember(ctx context.Context, id string, member *models.Member) error {
+	return do(ctx, o.guard, false, func(ctx context.Context) error {
+		return o.repo.AddMember(ctx, id, member)
+	})
+}
+
+func (o *organizations) SetMemberRole(ctx context.Context, id, userId, role string) error {
+	return do(ctx, o.guard, true, func(ctx context.Context) error {
+		return o.repo.SetMemberRole(ctx, id, userId, role)
+	})
+}
+
+func (o *organizations) RemoveMember(ctx context.Context, id, userId string) error {
+	return do(ctx, o.guard, false, func(ctx context.Context) error {
+		return o.repo.RemoveMember(ctx, id, userId)
+	})
+}
+
+func (o *organizations) RemoveUser(ctx context.Context, userId string) error {
+	return do(ctx, o.guard, true, func(ctx context.Context) error {
+		return o.repo.RemoveUser(ctx, userId)
+	})
+}
+
+func (o *organizations) AddInvitation(ctx context.Context, id string, invitation *models.Invitation) error {
+	return do(ctx, o.guard, true, func(ctx context.Context) error {
+		return o.repo.AddInvitation(ctx, id, invitation)
+	})
+}
+
+func (o *organizations) GetByInvitation(ctx context.Context, tokenHash string) (*models.Organization, error) {
+	var result *models.Organization
+	err := do(ctx, o.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = o.repo.GetByInvitation(ctx, tokenHash)
+		return err
+	})
+	return result, err
+}
+
+func (o *organizations) SetQuota(ctx context.Context, id string, quota int64) error {
+	return do(ctx, o.guard, true, func(ctx context.Context) error {
+		return o.repo.SetQuota(ctx, id, quota)
+	})
+}
+
+type outbox struct {
+	repo  repo.Outbox
+	guard *resilience.Guard
+}
+
+func (o *outbox) Add(ctx context.Context, event *models.OutboxEvent) error {
+	return do(ctx, o.guard, false, func(ctx context.Context) error {
+		return o.repo.Add(ctx, event)
+	})
+}
+
+// Claim isn't repeated, the event of an applied claim would wait for the claim end
+func (o *outbox) Claim(ctx context.Context, now, until int64) (*models.OutboxEvent, error) {
+	var result *models.OutboxEvent
+	err := do(ctx, o.guard, false, func(ctx context.Context) error {
+		var err error
+		result, err = o.repo.Claim(ctx, now, until)
+		return err
+	})
+	return result, err
+}
+
+func (o *outbox) Retry(ctx context.Context, id, failure string, availableAt int64) error {
+	return do(ctx, o.guard, true, func(ctx context.Context) error {
+		return o.repo.Retry(ctx, id, failure, availableAt)
+	})
+}
+
+func (o *outbox) MarkPublished(ctx context.Context, id string, at int64) error {
+	return do(ctx, o.guard, true, func(ctx context.Context) error {
+		return o.repo.MarkPublished(ctx, id, at)
+	})
+}
+
+func (o *outbox) MarkDispatched(ctx context.Context, id string) error {
+	return do(ctx, o.guard, true, func(ctx context.Context) error {
+		return o.repo.MarkDispatched(ctx, id)
+	})
+}
+
+func (o *outbox) DeletePublished(ctx context.Context, before int64) (int64, error) {
+	var result int64
+	err := do(ctx, o.guard, true, func(ctx context.Context) error {
+		var err error
+		result, err = o.repo.DeletePublished(ctx, before)
+		return err
+	})
+	return result, err
+}
+
+type transactions struct {
+	repo  repo.Transactions
+	guard *resilience.Guard
+}
+
+// WithTransaction is repeated only when it wasn't sent, MongoDB retries transient errors of committed transactions itself.
+// Calls inside fn aren't guarded, the transaction is guarded as a whole
+func (t *transactions) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return do(ctx, t.guard, false, func(ctx context.Context) error {
+		return t.repo.WithTransaction(context.WithValue(ctx, transactionKey{}, true), fn)
+	})
+}
@@ -0,0 +1,92 @@
+// Package resilient wraps repo interfaces with retries and circuit breaker of one database guard
+package resilient
+
+import (
+	"context"
+	"creatly-task/internal/repo"
+	"creatly-task/pkg/resilience"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"github.com/lib/pq"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+func New(r *repo.Repo, cfg *resilience.Config) *repo.Repo {
+	guard := resilience.New("database", cfg, Classify)
+
+	return &repo.Repo{
+		Users:         &users{repo: r.Users, guard: guard},
+		Sessions:      &sessions{repo: r.Sessions, guard: guard},
+		Files:         &files{repo: r.Files, guard: guard},
+		Attempts:      &attempts{repo: r.Attempts, guard: guard},
+		APIKeys:       &apiKeys{repo: r.APIKeys, guard: guard},
+		Organizations: &organizations{repo: r.Organizations, guard: guard},
+		Uploads:       &uploads{repo: r.Uploads, guard: guard},
+
+		IdempotencyKeys: &idempotencyKeys{repo: r.IdempotencyKeys, guard: guard},
+		Jobs:            &jobs{repo: r.Jobs, guard: guard},
+
+		Webhooks:   &webhooks{repo: r.Webhooks, guard: guard},
+		Deliveries: &deliveries{repo: r.Deliveries, guard: guard},
+
+		Outbox:       &outbox{repo: r.Outbox, guard: guard},
+		Transactions: &transactions{repo: r.Transactions, guard: guard},
+	}
+}
+
+type transactionKey struct{}
+
+// do runs op through the guard unless it is a call inside a transaction. Such call would take the probe
+// of a half-open circuit from the guarded transaction, and retrying it can't revive an aborted transaction
+func do(ctx context.Context, guard *resilience.Guard, idempotent bool, op func(ctx context.Context) error) error {
+	if ctx.Value(transactionKey{}) != nil || mongo.SessionFromContext(ctx) != nil {
+		return op(ctx)
+	}
+
+	return guard.Do(ctx, idempotent, op)
+}
+
+// Classify sorts MongoDB and PostgreSQL errors. Errors of the repo contract like not found are permanent
+func Classify(err error) resilience.Class {
+	var selection topology.ServerSelectionError
+	if errors.As(err, &selection) || errors.Is(err, driver.ErrBadConn) {
+		return resilience.Unsent // No server or connection, nothing was sent
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "40001", pqErr.Code == "40P01": // Serialization failure, deadlock - rolled back
+			return resilience.Unsent
+		case pqErr.Code == "57P01", pqErr.Code == "53300": // Admin shutdown, too many connections
+			return resilience.Unsent
+		case pqErr.Code.Class() == "08": // Connection exception
+			return resilience.Transient
+		}
+		return resilience.Permanent
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err) || mongo.IsNetworkError(err) {
+		return resilience.Transient
+	}
+
+	var labeled mongo.ServerError
+	if errors.As(err, &labeled) && (labeled.HasErrorLabel("RetryableWriteError") || labeled.HasErrorLabel("TransientTransactionError")) {
+		return resilience.Transient
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return resilience.Unsent
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return resilience.Transient
+	}
+
+	return resilience.Permanent
+}
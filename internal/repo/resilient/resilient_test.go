@@ -0,0 +1,183 @@
+package resilient
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/memory"
+	"creatly-task/internal/repo/repotest"
+	"creatly-task/pkg/resilience"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// Test_Contract checks wrappers pass calls and results through
+func Test_Contract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repo.Repo {
+		return New(memory.New(), nil)
+	})
+}
+
+var (
+	errReset   = &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	errRefused = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+)
+
+func Test_Classify(t *testing.T) {
+	testTable := []struct {
+		name   string
+		err    error
+		expect resilience.Class
+	}{
+		{name: "no server selected", err: topology.ServerSelectionError{Wrapped: context.DeadlineExceeded}, expect: resilience.Unsent},
+		{name: "bad connection", err: driver.ErrBadConn, expect: resilience.Unsent},
+		{name: "postgres deadlock", err: &pq.Error{Code: "40P01"}, expect: resilience.Unsent},
+		{name: "postgres connection failure", err: &pq.Error{Code: "08006"}, expect: resilience.Transient},
+		{name: "postgres unique violation", err: &pq.Error{Code: "23505"}, expect: resilience.Permanent},
+		{name: "mongo retryable write", err: mongo.CommandError{Code: 91, Labels: []string{"RetryableWriteError"}}, expect: resilience.Transient},
+		{name: "mongo duplicate key", err: mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}, expect: resilience.Permanent},
+		{name: "deadline", err: context.DeadlineExceeded, expect: resilience.Transient},
+		{name: "connection reset", err: errReset, expect: resilience.Transient},
+		{name: "connection refused", err: errRefused, expect: resilience.Unsent},
+		{name: "not found", err: models.ErrUserNotFound, expect: resilience.Permanent},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, Classify(test.err))
+		})
+	}
+}
+
+// faultyFiles fails calls with queued errors before passing them to the memory repo.
+// Failures after the call model answers lost on the way back
+type faultyFiles struct {
+	repo.Files
+	before []error
+	after  []error
+}
+
+func (f *faultyFiles) fault(queue *[]error) error {
+	if len(*queue) == 0 {
+		return nil
+	}
+	err := (*queue)[0]
+	*queue = (*queue)[1:]
+	return err
+}
+
+func (f *faultyFiles) AddLog(ctx context.Context, log *models.FileUploadLogInput) error {
+	if err := f.fault(&f.before); err != nil {
+		return err
+	}
+	err := f.Files.AddLog(ctx, log)
+	if err == nil {
+		err = f.fault(&f.after)
+	}
+	return err
+}
+
+func (f *faultyFiles) ByUser(ctx context.Context, userId string) ([]models.FileOut, error) {
+	if err := f.fault(&f.before); err != nil {
+		return nil, err
+	}
+	return f.Files.ByUser(ctx, userId)
+}
+
+func Test_Retries(t *testing.T) {
+	ctx := context.Background()
+	cfg := &resilience.Config{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, BreakerThreshold: 3}
+	log := &models.FileUploadLogInput{Filename: "a.png", UserId: "1"}
+
+	t.Run("reads are retried after transient failures", func(t *testing.T) {
+		files := &faultyFiles{Files: memory.NewFiles()}
+		r := New(&repo.Repo{Files: files}, cfg)
+
+		noError(t, r.Files.AddLog(ctx, log))
+		files.before = []error{errReset, errReset}
+		result, err := r.Files.ByUser(ctx, "1")
+		noError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("insert isn't repeated after lost answer", func(t *testing.T) {
+		files := &faultyFiles{Files: memory.NewFiles(), after: []error{errReset}}
+		r := New(&repo.Repo{Files: files}, cfg)
+
+		assert.Equal(t, errReset, r.Files.AddLog(ctx, log))
+		result, _ := r.Files.ByUser(ctx, "1")
+		assert.Len(t, result, 1, "upload is logged once")
+	})
+
+	t.Run("insert is repeated when it wasn't sent", func(t *testing.T) {
+		files := &faultyFiles{Files: memory.NewFiles(), before: []error{errRefused}}
+		r := New(&repo.Repo{Files: files}, cfg)
+
+		noError(t, r.Files.AddLog(ctx, log))
+		result, _ := r.Files.ByUser(ctx, "1")
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("database down opens the circuit for all repos", func(t *testing.T) {
+		files := &faultyFiles{Files: memory.NewFiles(), before: []error{errRefused, errRefused, errRefused}}
+		r := New(&repo.Repo{Files: files, Users: memory.NewUsers()}, cfg)
+
+		_, err := r.Files.ByUser(ctx, "1")
+		assert.Equal(t, errRefused, err)
+
+		_, err = r.Users.GetUserByID(ctx, "1")
+		assert.True(t, errors.Is(err, resilience.ErrCircuitOpen))
+		assert.Equal(t, resilience.Open, r.Users.(*users).Guard().State())
+	})
+}
+
+func Test_Transactions(t *testing.T) {
+	ctx := context.Background()
+	cfg := &resilience.Config{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, BreakerThreshold: 3, BreakerTimeout: 10 * time.Millisecond}
+	log := &models.FileUploadLogInput{Filename: "a.png", UserId: "1"}
+
+	t.Run("transaction is the probe of half-open circuit", func(t *testing.T) {
+		files := &faultyFiles{Files: memory.NewFiles(), before: []error{errRefused, errRefused, errRefused}}
+		r := New(&repo.Repo{Files: files, Transactions: memory.Transactions{}}, cfg)
+
+		_, err := r.Files.ByUser(ctx, "1")
+		assert.Equal(t, errRefused, err)
+		assert.Equal(t, resilience.Open, r.Transactions.(*transactions).guard.State())
+
+		time.Sleep(20 * time.Millisecond)
+		err = r.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+			return r.Files.AddLog(ctx, log)
+		})
+		noError(t, err)
+		assert.Equal(t, resilience.Closed, r.Transactions.(*transactions).guard.State(), "successful transaction closes the circuit")
+
+		result, _ := r.Files.ByUser(ctx, "1")
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("calls inside transaction aren't retried", func(t *testing.T) {
+		files := &faultyFiles{Files: memory.NewFiles(), before: []error{errReset}}
+		r := New(&repo.Repo{Files: files, Transactions: memory.Transactions{}}, cfg)
+
+		err := r.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+			_, err := r.Files.ByUser(ctx, "1")
+			return err
+		})
+		assert.Equal(t, errReset, err)
+	})
+}
+
+func noError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error - %s", err.Error())
+	}
+}
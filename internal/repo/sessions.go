@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SessionsStorage keeps sign-in sessions in the tokens collection
+type SessionsStorage struct {
+	db *mongo.Collection
+}
+
+// sessionDocument adds expireAt date for TTL index, MongoDB removes expired sessions by it
+type sessionDocument struct {
+	models.Session `bson:",inline"`
+	ExpireAt       time.Time `bson:"expireAt"`
+}
+
+func newSessionsRepo(mongo *mongodb.Mongo, collectionName string) *SessionsStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &SessionsStorage{
+		db: collection,
+	}
+}
+
+func (s *SessionsStorage) Create(ctx context.Context, session *models.Session) error {
+	session.ID = primitive.NewObjectID()
+
+	_, err := s.db.InsertOne(ctx, sessionDocument{
+		Session:  *session,
+		ExpireAt: time.Unix(session.ExpiresAt, 0),
+	})
+	return err
+}
+
+func (s *SessionsStorage) Get(ctx context.Context, id string) (*models.Session, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, models.ErrSessionNotFound
+	}
+
+	result := s.db.FindOne(ctx, bson.M{"_id": objectID})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrSessionNotFound
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var session models.Session
+	err = result.Decode(&session)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &session, nil
+}
+
+func (s *SessionsStorage) ByUser(ctx context.Context, userId string, now int64) ([]models.Session, error) {
+	opts := options.Find().SetSort(bson.M{"lastSeenAt": -1})
+
+	cursor, err := s.db.Find(ctx, bson.M{"userId": userId, "expiresAt": bson.M{"$gt": now}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.Session{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (s *SessionsStorage) Touch(ctx context.Context, id string, at int64) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrSessionNotFound
+	}
+
+	_, err = s.db.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"lastSeenAt": at}})
+	return err
+}
+
+func (s *SessionsStorage) Delete(ctx context.Context, userId, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrSessionNotFound
+	}
+
+	result, err := s.db.DeleteOne(ctx, bson.M{"_id": objectID, "userId": userId})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return models.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (s *SessionsStorage) DeleteByUser(ctx context.Context, userId string) error {
+	_, err := s.db.DeleteMany(ctx, bson.M{"userId": userId})
+	return err
+}
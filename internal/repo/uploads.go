@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultUploadsCollection = "pendingUploads"
+
+// UploadsStorage keeps uploads which are stored but not recorded yet
+type UploadsStorage struct {
+	db *mongo.Collection
+}
+
+func newUploadsRepo(mongo *mongodb.Mongo, collectionName string) *UploadsStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &UploadsStorage{
+		db: collection,
+	}
+}
+
+func uploadsCollection(cfg *config.Repo) string {
+	if cfg.UploadsCollection != "" {
+		return cfg.UploadsCollection
+	}
+	return defaultUploadsCollection
+}
+
+func (u *UploadsStorage) Create(ctx context.Context, upload *models.PendingUpload) error {
+	upload.ID = primitive.NewObjectID()
+
+	_, err := u.db.InsertOne(ctx, upload)
+	return err
+}
+
+func (u *UploadsStorage) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+
+	_, err = u.db.DeleteOne(ctx, bson.M{"_id": objectID})
+	return err
+}
+
+func (u *UploadsStorage) Stale(ctx context.Context, before int64) ([]models.PendingUpload, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": 1})
+
+	cursor, err := u.db.Find(ctx, bson.M{"createdAt": bson.M{"$lt": before}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.PendingUpload{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
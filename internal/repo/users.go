@@ -4,11 +4,14 @@ import (
 	"context"
 	"creatly-task/internal/models"
 	"creatly-task/internal/mongodb"
-	"errors"
 	"fmt"
+	"regexp"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserStorage struct {
@@ -22,54 +25,172 @@ func newUsersRepo(mongo *mongodb.Mongo, collectionName string) *UserStorage {
 	}
 }
 
-func (u *UserStorage) CreateUser(input *models.UserSignUpInput) error {
-	err := u.checkUserExists(input.Email)
-	if err != nil {
-		return err
+// CreateUser relies on the unique email index, concurrent sign-ups with the same email can't both succeed
+func (u *UserStorage) CreateUser(ctx context.Context, input *models.UserSignUpInput) error {
+	input.ID = primitive.NewObjectID()
+
+	_, err := u.db.InsertOne(ctx, input)
+	if mongo.IsDuplicateKeyError(err) {
+		return models.ErrUserExists
+	}
+
+	return err
+}
+
+func (u *UserStorage) GetUserByCreds(ctx context.Context, email string) (*models.UserSignInOutput, error) {
+	result := u.db.FindOne(ctx, bson.M{"email": email})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrUserNotFound
 	}
 
-	_, err = u.db.InsertOne(context.TODO(), input)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var user models.UserSignInOutput
+	err := result.Decode(&user)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("decode error: %s", err.Error())
 	}
 
-	return nil
+	return &user, nil
 }
 
-func (u *UserStorage) checkUserExists(email string) error {
-	result := u.db.FindOne(context.TODO(), bson.M{"email": email})
+func (u *UserStorage) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, models.ErrUserNotFound
+	}
+
+	result := u.db.FindOne(ctx, bson.M{"_id": objectID})
 
 	if result.Err() == mongo.ErrNoDocuments {
-		// User with this email not exists
-		return nil
+		return nil, models.ErrUserNotFound
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var user models.User
+	err = result.Decode(&user)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &user, nil
+}
+
+func (u *UserStorage) List(ctx context.Context, filter *models.UsersFilter) ([]models.User, int64, error) {
+	query := bson.M{}
+	if filter.Search != "" {
+		query["email"] = bson.M{"$regex": regexp.QuoteMeta(filter.Search), "$options": "i"}
 	}
 
-	var dbUser models.UserSignInInput
-	err := result.Decode(&dbUser)
+	total, err := u.db.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.M{"_id": 1}).
+		SetSkip((filter.Page - 1) * filter.Limit).
+		SetLimit(filter.Limit)
+
+	cursor, err := u.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users := []models.User{}
+	err = cursor.All(ctx, &users)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (u *UserStorage) SetDisabled(ctx context.Context, id string, disabled bool) error {
+	return u.updateByID(ctx, id, bson.M{"disabled": disabled})
+}
+
+// UpdatePassword sets new password, revokes all issued tokens and pending password reset
+func (u *UserStorage) UpdatePassword(ctx context.Context, id, passwordHash string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrUserNotFound
+	}
+
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$set":   bson.M{"password": passwordHash, "sessionsRevokedAt": time.Now().Unix()},
+		"$unset": bson.M{"passwordReset": ""},
+	})
 	if err != nil {
 		return err
 	}
 
-	if dbUser.Email != "" {
-		// User with this email exists
-		return errors.New("user exists")
+	if result.MatchedCount == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (u *UserStorage) SetPasswordResetToken(ctx context.Context, id string, token *models.PasswordResetToken) error {
+	return u.updateByID(ctx, id, bson.M{"passwordReset": token})
+}
+
+// SetMFA replaces two-factor settings. Nil mfa removes them
+func (u *UserStorage) SetMFA(ctx context.Context, id string, mfa *models.MFA) error {
+	if mfa == nil {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return models.ErrUserNotFound
+		}
+
+		_, err = u.db.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$unset": bson.M{"mfa": ""}})
+		return err
 	}
 
-	return errors.New("checkUserExists: unknown logic")
+	return u.updateByID(ctx, id, bson.M{"mfa": mfa})
 }
 
-func (u *UserStorage) GetUserByCreds(email string) (*models.UserSignInOutput, error) {
-	result := u.db.FindOne(context.TODO(), bson.M{"email": email})
+func (u *UserStorage) GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	result := u.db.FindOne(ctx, bson.M{"passwordReset.tokenHash": tokenHash})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrUserNotFound
+	}
 
 	if result.Err() != nil {
 		return nil, result.Err()
 	}
 
+	var user models.User
+	err := result.Decode(&user)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &user, nil
+}
+
+func (u *UserStorage) GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	result := u.db.FindOne(ctx, bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}},
+	})
+
 	if result.Err() == mongo.ErrNoDocuments {
-		return nil, errors.New("user not found")
+		return nil, models.ErrUserNotFound
 	}
 
-	var user models.UserSignInOutput
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var user models.User
 	err := result.Decode(&user)
 	if err != nil {
 		return nil, fmt.Errorf("decode error: %s", err.Error())
@@ -77,3 +198,113 @@ func (u *UserStorage) GetUserByCreds(email string) (*models.UserSignInOutput, er
 
 	return &user, nil
 }
+
+func (u *UserStorage) AddIdentity(ctx context.Context, id string, identity *models.Identity) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrUserNotFound
+	}
+
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
+		"$push": bson.M{"identities": identity},
+		"$set":  bson.M{"verified": true},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (u *UserStorage) UpdateProfile(ctx context.Context, id string, input *models.ProfileUpdateInput) error {
+	set, unset := bson.M{}, bson.M{}
+	for field, value := range map[string]*string{"displayName": input.DisplayName, "avatar": input.Avatar} {
+		switch {
+		case value == nil:
+		case *value == "":
+			unset[field] = ""
+		default:
+			set[field] = *value
+		}
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if len(update) == 0 {
+		return nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrUserNotFound
+	}
+
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (u *UserStorage) SetVerified(ctx context.Context, email string) error {
+	result, err := u.db.UpdateOne(ctx, bson.M{"email": email}, bson.M{"$set": bson.M{"verified": true}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (u *UserStorage) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrUserNotFound
+	}
+
+	result, err := u.db.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (u *UserStorage) updateByID(ctx context.Context, id string, fields bson.M) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrUserNotFound
+	}
+
+	result, err := u.db.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
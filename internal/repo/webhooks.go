@@ -0,0 +1,215 @@
+package repo
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/mongodb"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultWebhooksCollection   = "webhooks"
+	defaultDeliveriesCollection = "webhookDeliveries"
+)
+
+type WebhooksStorage struct {
+	db *mongo.Collection
+}
+
+func newWebhooksRepo(mongo *mongodb.Mongo, collectionName string) *WebhooksStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &WebhooksStorage{
+		db: collection,
+	}
+}
+
+func webhooksCollection(cfg *config.Repo) string {
+	if cfg.WebhooksCollection != "" {
+		return cfg.WebhooksCollection
+	}
+	return defaultWebhooksCollection
+}
+
+func (w *WebhooksStorage) Create(ctx context.Context, webhook *models.Webhook) error {
+	webhook.ID = primitive.NewObjectID()
+
+	_, err := w.db.InsertOne(ctx, webhook)
+	return err
+}
+
+func (w *WebhooksStorage) Get(ctx context.Context, id string) (*models.Webhook, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, models.ErrWebhookNotFound
+	}
+
+	result := w.db.FindOne(ctx, bson.M{"_id": objectID})
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrWebhookNotFound
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var webhook models.Webhook
+	err = result.Decode(&webhook)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &webhook, nil
+}
+
+func (w *WebhooksStorage) All(ctx context.Context) ([]models.Webhook, error) {
+	return w.find(ctx, bson.M{})
+}
+
+func (w *WebhooksStorage) ByEvent(ctx context.Context, event string) ([]models.Webhook, error) {
+	return w.find(ctx, bson.M{"events": event})
+}
+
+func (w *WebhooksStorage) find(ctx context.Context, filter bson.M) ([]models.Webhook, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": 1})
+
+	cursor, err := w.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.Webhook{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (w *WebhooksStorage) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrWebhookNotFound
+	}
+
+	result, err := w.db.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return models.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// DeliveriesStorage is the log of webhook deliveries
+type DeliveriesStorage struct {
+	db *mongo.Collection
+}
+
+func newDeliveriesRepo(mongo *mongodb.Mongo, collectionName string) *DeliveriesStorage {
+	collection := mongo.DB.Collection(collectionName)
+	return &DeliveriesStorage{
+		db: collection,
+	}
+}
+
+func deliveriesCollection(cfg *config.Repo) string {
+	if cfg.DeliveriesCollection != "" {
+		return cfg.DeliveriesCollection
+	}
+	return defaultDeliveriesCollection
+}
+
+func (d *DeliveriesStorage) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.ID = primitive.NewObjectID()
+	if delivery.Attempts == nil {
+		delivery.Attempts = []models.DeliveryAttempt{}
+	}
+
+	_, err := d.db.InsertOne(ctx, delivery)
+	return err
+}
+
+func (d *DeliveriesStorage) Get(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, models.ErrDeliveryNotFound
+	}
+
+	result := d.db.FindOne(ctx, bson.M{"_id": objectID})
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, models.ErrDeliveryNotFound
+	}
+
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var delivery models.WebhookDelivery
+	err = result.Decode(&delivery)
+	if err != nil {
+		return nil, fmt.Errorf("decode error: %s", err.Error())
+	}
+
+	return &delivery, nil
+}
+
+func (d *DeliveriesStorage) ByWebhook(ctx context.Context, webhookID string, limit int64) ([]models.WebhookDelivery, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(limit)
+
+	cursor, err := d.db.Find(ctx, bson.M{"webhookId": webhookID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.WebhookDelivery{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (d *DeliveriesStorage) AddAttempt(ctx context.Context, id string, attempt *models.DeliveryAttempt, status string) error {
+	return d.update(ctx, id, bson.M{
+		"$set":  bson.M{"status": status},
+		"$push": bson.M{"attempts": attempt},
+	})
+}
+
+func (d *DeliveriesStorage) SetStatus(ctx context.Context, id, status string) error {
+	return d.update(ctx, id, bson.M{"$set": bson.M{"status": status}})
+}
+
+func (d *DeliveriesStorage) update(ctx context.Context, id string, update bson.M) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return models.ErrDeliveryNotFound
+	}
+
+	result, err := d.db.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return models.ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+func (d *DeliveriesStorage) DeleteByWebhook(ctx context.Context, webhookID string) error {
+	_, err := d.db.DeleteMany(ctx, bson.M{"webhookId": webhookID})
+	return err
+}
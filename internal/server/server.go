@@ -1,8 +1,12 @@
 package server
 
 import (
+	"context"
 	"creatly-task/internal/config"
+	"creatly-task/internal/models"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,24 +22,177 @@ type Handlers interface {
 	SignIn(c *gin.Context)
 	AuthMiddleware(c *gin.Context)
 	Files(c *gin.Context)
+	File(c *gin.Context)
 	UploadFile(c *gin.Context)
+	DownloadFile(c *gin.Context)
+	DeleteFile(c *gin.Context)
+	FileEvents(c *gin.Context)
+	AdminMiddleware(c *gin.Context)
+	AdminUsers(c *gin.Context)
+	AdminUserStats(c *gin.Context)
+	AdminDisableUser(c *gin.Context)
+	AdminEnableUser(c *gin.Context)
+	AdminResetPassword(c *gin.Context)
+	AdminDeleteUser(c *gin.Context)
+	VerifyEmail(c *gin.Context)
+	ResendVerification(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPasswordForm(c *gin.Context)
+	ResetPassword(c *gin.Context)
+	ChangePassword(c *gin.Context)
+	EnrollMFA(c *gin.Context)
+	ConfirmMFA(c *gin.Context)
+	RegenerateRecoveryCodes(c *gin.Context)
+	DisableMFA(c *gin.Context)
+	SignInMFA(c *gin.Context)
+	JWKS(c *gin.Context)
+	RequireScope(scope string) gin.HandlerFunc
+	SessionOnlyMiddleware(c *gin.Context)
+	CreateAPIKey(c *gin.Context)
+	APIKeys(c *gin.Context)
+	RevokeAPIKey(c *gin.Context)
+	OIDCLogin(c *gin.Context)
+	OIDCCallback(c *gin.Context)
+	Sessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+	Profile(c *gin.Context)
+	UpdateProfile(c *gin.Context)
+	DeleteAccount(c *gin.Context)
+	ExportData(c *gin.Context)
+	CreateOrganization(c *gin.Context)
+	Organizations(c *gin.Context)
+	Organization(c *gin.Context)
+	InviteMember(c *gin.Context)
+	AcceptInvitation(c *gin.Context)
+	SetMemberRole(c *gin.Context)
+	RemoveMember(c *gin.Context)
+	SwitchOrganization(c *gin.Context)
+	AdminSetOrganizationQuota(c *gin.Context)
+	AdminReconcileUploads(c *gin.Context)
+	AdminReconcileStats(c *gin.Context)
+	Health(c *gin.Context)
+	Idempotency(c *gin.Context)
+	AdminDeadJobs(c *gin.Context)
+	AdminRequeueJob(c *gin.Context)
+	AdminCreateWebhook(c *gin.Context)
+	AdminWebhooks(c *gin.Context)
+	AdminDeleteWebhook(c *gin.Context)
+	AdminWebhookDeliveries(c *gin.Context)
+	AdminRedeliver(c *gin.Context)
 }
 
+const (
+	defaultRequestTimeout = 30 * time.Second
+
+	fileEventsPath = "/files/events"
+	exportPath     = "/me/export" // Streams every original image, which takes longer than other requests
+)
+
 func New(config *config.Server, handlers Handlers) *Server {
+	requestTimeout := config.RequestTimeout
+	if requestTimeout == 0 {
+		requestTimeout = defaultRequestTimeout
+	}
+
 	server := gin.Default()
 	server.MaxMultipartMemory = 8 << 20 // 8 MiB
+	server.Use(timeout(requestTimeout, fileEventsPath, exportPath))
+
+	server.GET("/.well-known/jwks.json", handlers.JWKS)
+	server.GET("/health", handlers.Health)
 
 	auth := server.Group("/")
 	{
-		auth.POST("/sign-up", handlers.SignUp)
+		auth.POST("/sign-up", handlers.Idempotency, handlers.SignUp)
 		auth.POST("/sign-in", handlers.SignIn)
+		auth.POST("/sign-in/mfa", handlers.SignInMFA)
+		auth.GET("/verify-email", handlers.VerifyEmail)
+		auth.POST("/password/forgot", handlers.ForgotPassword)
+		auth.GET("/password/reset", handlers.ResetPasswordForm)
+		auth.POST("/password/reset", handlers.ResetPassword)
+		auth.GET("/oidc/:provider/login", handlers.OIDCLogin)
+		auth.GET("/oidc/:provider/callback", handlers.OIDCCallback)
 	}
 
+	// Files routes accept API keys with the matching scope
 	files := server.Group("/")
 	{
 		files.Use(handlers.AuthMiddleware)
-		files.GET("/files", handlers.Files)
-		files.POST("/upload", handlers.UploadFile)
+		files.GET("/files", handlers.RequireScope(models.ScopeFilesRead), handlers.Files)
+		files.GET(fileEventsPath, handlers.RequireScope(models.ScopeFilesRead), handlers.FileEvents)
+		files.GET("/files/:id", handlers.RequireScope(models.ScopeFilesRead), handlers.File)
+		files.GET("/files/:id/download", handlers.RequireScope(models.ScopeFilesRead), handlers.DownloadFile)
+		files.DELETE("/files/:id", handlers.RequireScope(models.ScopeFilesDelete), handlers.DeleteFile)
+		files.POST("/upload", handlers.RequireScope(models.ScopeFilesUpload), handlers.Idempotency, handlers.UploadFile)
+	}
+
+	account := server.Group("/")
+	{
+		account.Use(handlers.AuthMiddleware, handlers.SessionOnlyMiddleware)
+		account.POST("/verify-email/resend", handlers.ResendVerification)
+		account.POST("/password/change", handlers.ChangePassword)
+	}
+
+	apiKeys := server.Group("/api-keys")
+	{
+		apiKeys.Use(handlers.AuthMiddleware, handlers.SessionOnlyMiddleware)
+		apiKeys.GET("", handlers.APIKeys)
+		apiKeys.POST("", handlers.CreateAPIKey)
+		apiKeys.DELETE("/:id", handlers.RevokeAPIKey)
+	}
+
+	me := server.Group("/me")
+	{
+		me.Use(handlers.AuthMiddleware, handlers.SessionOnlyMiddleware)
+		me.GET("", handlers.Profile)
+		me.PATCH("", handlers.UpdateProfile)
+		me.DELETE("", handlers.DeleteAccount)
+		me.GET("/export", handlers.ExportData)
+		me.POST("/organization", handlers.SwitchOrganization)
+		me.GET("/sessions", handlers.Sessions)
+		me.DELETE("/sessions/:id", handlers.RevokeSession)
+	}
+
+	orgs := server.Group("/orgs")
+	{
+		orgs.Use(handlers.AuthMiddleware, handlers.SessionOnlyMiddleware)
+		orgs.GET("", handlers.Organizations)
+		orgs.POST("", handlers.CreateOrganization)
+		orgs.GET("/:id", handlers.Organization)
+		orgs.POST("/:id/invitations", handlers.InviteMember)
+		orgs.PATCH("/:id/members/:userId", handlers.SetMemberRole)
+		orgs.DELETE("/:id/members/:userId", handlers.RemoveMember)
+	}
+	server.POST("/invitations/accept", handlers.AuthMiddleware, handlers.SessionOnlyMiddleware, handlers.AcceptInvitation)
+
+	mfa := server.Group("/2fa")
+	{
+		mfa.Use(handlers.AuthMiddleware, handlers.SessionOnlyMiddleware)
+		mfa.POST("/enroll", handlers.EnrollMFA)
+		mfa.POST("/confirm", handlers.ConfirmMFA)
+		mfa.POST("/recovery-codes", handlers.RegenerateRecoveryCodes)
+		mfa.POST("/disable", handlers.DisableMFA)
+	}
+
+	admin := server.Group("/admin")
+	{
+		admin.Use(handlers.AuthMiddleware, handlers.SessionOnlyMiddleware, handlers.AdminMiddleware)
+		admin.GET("/users", handlers.AdminUsers)
+		admin.GET("/users/:id/stats", handlers.AdminUserStats)
+		admin.POST("/users/:id/disable", handlers.AdminDisableUser)
+		admin.POST("/users/:id/enable", handlers.AdminEnableUser)
+		admin.POST("/users/:id/reset-password", handlers.AdminResetPassword)
+		admin.DELETE("/users/:id", handlers.AdminDeleteUser)
+		admin.PUT("/orgs/:id/quota", handlers.AdminSetOrganizationQuota)
+		admin.GET("/uploads/reconcile", handlers.AdminReconcileStats)
+		admin.POST("/uploads/reconcile", handlers.AdminReconcileUploads)
+		admin.GET("/jobs/dead", handlers.AdminDeadJobs)
+		admin.POST("/jobs/:id/requeue", handlers.AdminRequeueJob)
+		admin.POST("/webhooks", handlers.AdminCreateWebhook)
+		admin.GET("/webhooks", handlers.AdminWebhooks)
+		admin.DELETE("/webhooks/:id", handlers.AdminDeleteWebhook)
+		admin.GET("/webhooks/:id/deliveries", handlers.AdminWebhookDeliveries)
+		admin.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", handlers.AdminRedeliver)
 	}
 
 	return &Server{
@@ -45,6 +202,30 @@ func New(config *config.Server, handlers Handlers) *Server {
 	}
 }
 
+// timeout sets deadline to the request context, handlers pass it to database and storage calls.
+// The context is also cancelled when the client disconnects. Streams have no deadline and are open until the client disconnects
+func timeout(d time.Duration, streams ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, path := range streams {
+			if c.FullPath() == path {
+				c.Next()
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Handler returns routes of the server, used by end-to-end tests
+func (s *Server) Handler() http.Handler {
+	return s.httpServer
+}
+
 func (s *Server) Start() error {
 	err := s.httpServer.Run(fmt.Sprintf("%s:%s", s.host, s.port))
 	if err != nil {
@@ -0,0 +1,521 @@
+package server_test
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/handlers"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/memory"
+	"creatly-task/internal/server"
+	"creatly-task/internal/services"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/hasher"
+	"creatly-task/pkg/mailer"
+	"creatly-task/pkg/storage"
+	"creatly-task/pkg/webhook"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// testServer runs the whole application on in-memory repository and storage
+type testServer struct {
+	t        *testing.T
+	handler  http.Handler
+	mailer   *mailer.FileMailer
+	repo     *repo.Repo
+	services *services.Services
+}
+
+func newTestServer(t *testing.T) *testServer {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = ioutil.Discard
+	log.SetOutput(ioutil.Discard) // File mailer without dir logs messages
+
+	cfg := &config.Config{
+		Server:   &config.Server{PublicURL: "http://localhost:8000", RequestTimeout: time.Second},
+		Database: &config.Database{Driver: config.DriverMemory},
+		JWT:      &config.JWT{SigningKey: "secret", TokenTTL: 3600, TokenHeaderName: "Authorization"},
+		Auth:     &config.Auth{Salt: "salt", HeaderUserId: "userId", AdminEmails: []string{"admin@mail.com"}},
+		Files:    &config.File{Limit: 1 << 20},
+		Lockout:  &config.Lockout{},
+		Org:      &config.Org{},
+	}
+
+	tokener, err := jwtauth.New(cfg.JWT)
+	if err != nil {
+		t.Fatalf("jwt.New error - %s", err.Error())
+	}
+
+	repo := memory.New()
+	mailer := mailer.NewFile("", "noreply@mail.com")
+	services := services.New(repo, tokener, storage.NewMemory(), mailer, nil, cfg)
+	handlers := handlers.New(services, cfg.Files.Limit, hasher.New(cfg.Auth.Salt), cfg.JWT.TokenHeaderName, cfg.Auth.HeaderUserId)
+
+	return &testServer{
+		t:        t,
+		handler:  server.New(cfg.Server, handlers).Handler(),
+		mailer:   mailer,
+		repo:     repo,
+		services: services,
+	}
+}
+
+// do sends request and decodes JSON response into out when it is not nil
+func (s *testServer) do(method, path, token string, body interface{}, out interface{}) int {
+	s.t.Helper()
+
+	w := s.serve(s.newRequest(method, path, token, body))
+
+	if out != nil {
+		err := json.Unmarshal(w.Body.Bytes(), out)
+		if err != nil {
+			s.t.Fatalf("%s %s: decode error - %s, body %s", method, path, err.Error(), w.Body.String())
+		}
+	}
+
+	return w.Code
+}
+
+func (s *testServer) newRequest(method, path, token string, body interface{}) *http.Request {
+	s.t.Helper()
+
+	var reader *bytes.Reader
+	switch body := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case []byte:
+		reader = bytes.NewReader(body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("marshal error - %s", err.Error())
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if _, ok := body.([]byte); ok {
+		req.Header.Set("Content-Type", "image/png")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return req
+}
+
+func (s *testServer) serve(req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+	return w
+}
+
+// signUp creates an account and returns its token
+func (s *testServer) signUp(email string) string {
+	s.t.Helper()
+
+	creds := map[string]string{"email": email, "password": "password"}
+	if code := s.do(http.MethodPost, "/sign-up", "", creds, nil); code != http.StatusOK {
+		s.t.Fatalf("sign up %s: status %d", email, code)
+	}
+
+	var out map[string]string
+	if code := s.do(http.MethodPost, "/sign-in", "", creds, &out); code != http.StatusOK {
+		s.t.Fatalf("sign in %s: status %d", email, code)
+	}
+
+	return out["token"]
+}
+
+func Test_Files(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("user@mail.com")
+
+	creds := map[string]string{"email": "user@mail.com", "password": "password"}
+	assert.Equal(t, http.StatusInternalServerError, s.do(http.MethodPost, "/sign-up", "", creds, nil), "duplicate email")
+
+	wrong := map[string]string{"email": "user@mail.com", "password": "wrong"}
+	assert.Equal(t, http.StatusBadRequest, s.do(http.MethodPost, "/sign-in", "", wrong, nil))
+
+	assert.Equal(t, http.StatusUnauthorized, s.do(http.MethodGet, "/files", "", nil, nil))
+	assert.Equal(t, http.StatusAccepted, s.do(http.MethodPost, "/upload", token, []byte("\x89PNG image"), nil))
+
+	var files []models.FileOut
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/files", token, nil, &files))
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, 10, files[0].Size)
+		assert.True(t, strings.HasPrefix(files[0].Url, "memory://"))
+	}
+
+	var sessions []models.Session
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/me/sessions", token, nil, &sessions))
+	if assert.Len(t, sessions, 1) {
+		assert.True(t, sessions[0].Current)
+	}
+
+	assert.Equal(t, http.StatusNoContent, s.do(http.MethodDelete, "/me", token, nil, nil))
+	assert.Equal(t, http.StatusUnauthorized, s.do(http.MethodGet, "/me", token, nil, nil))
+}
+
+func Test_AdminUsers(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.signUp("admin@mail.com")
+	user := s.signUp("user0@mail.com")
+	for i := 1; i < 5; i++ {
+		s.signUp(fmt.Sprintf("user%d@mail.com", i))
+	}
+
+	assert.Equal(t, http.StatusForbidden, s.do(http.MethodGet, "/admin/users", user, nil, nil))
+
+	var page models.UsersPage
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/admin/users?page=2&limit=2", admin, nil, &page))
+	assert.Equal(t, int64(6), page.Total)
+	if assert.Len(t, page.Users, 2) {
+		assert.Equal(t, "user1@mail.com", page.Users[0].Email)
+		assert.Equal(t, "user2@mail.com", page.Users[1].Email)
+	}
+
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/admin/users?search=USER3", admin, nil, &page))
+	assert.Equal(t, int64(1), page.Total)
+}
+
+func Test_Organization(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.signUp("owner@mail.com")
+	editor := s.signUp("editor@mail.com")
+
+	var org models.OrganizationOut
+	assert.Equal(t, http.StatusCreated, s.do(http.MethodPost, "/orgs", owner, models.OrganizationInput{Name: "Team"}, &org))
+
+	invitation := models.InvitationInput{Email: "editor@mail.com", Role: models.OrgRoleEditor}
+	assert.Equal(t, http.StatusOK, s.do(http.MethodPost, "/orgs/"+org.ID+"/invitations", owner, invitation, nil))
+
+	sent := s.mailer.Sent()
+	body := sent[len(sent)-1].Body
+	inviteToken := body[strings.Index(body, "token=")+len("token="):]
+
+	var joined models.OrganizationOut
+	assert.Equal(t, http.StatusOK, s.do(http.MethodPost, "/invitations/accept", editor, models.InvitationAcceptInput{Token: inviteToken}, &joined))
+	assert.Equal(t, models.OrgRoleEditor, joined.Role)
+
+	var switched map[string]string
+	assert.Equal(t, http.StatusOK, s.do(http.MethodPost, "/me/organization", editor, models.OrgSwitchInput{OrgID: org.ID}, &switched))
+	orgToken := switched["token"]
+
+	assert.Equal(t, http.StatusAccepted, s.do(http.MethodPost, "/upload", orgToken, []byte("\x89PNG image"), nil))
+
+	var files []models.FileOut
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/files", orgToken, nil, &files))
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, org.ID, files[0].OrgId)
+	}
+
+	// Organization files are not listed in the personal space
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/files", editor, nil, &files))
+	assert.Len(t, files, 0)
+
+	var orgs []models.OrganizationOut
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/orgs", owner, nil, &orgs))
+	if assert.Len(t, orgs, 1) {
+		assert.Equal(t, int64(10), orgs[0].Used)
+	}
+}
+
+// hangingFiles never answers, like a database that stopped responding
+type hangingFiles struct {
+	repo.Files
+}
+
+func (f *hangingFiles) ByUser(ctx context.Context, userId string) ([]models.FileOut, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *hangingFiles) All(ctx context.Context) ([]models.FileOut, error) {
+	return f.ByUser(ctx, "")
+}
+
+func Test_RequestTimeout(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("user@mail.com")
+
+	s.repo.Files = &hangingFiles{Files: s.repo.Files}
+
+	assert.Equal(t, http.StatusGatewayTimeout, s.do(http.MethodGet, "/files", token, nil, nil))
+}
+
+// slowFiles answers after the request timeout unless the request is cancelled earlier
+type slowFiles struct {
+	repo.Files
+}
+
+func (f *slowFiles) ByUser(ctx context.Context, userId string) ([]models.FileOut, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(1500 * time.Millisecond):
+	}
+	return f.Files.ByUser(ctx, userId)
+}
+
+func Test_ExportOutlivesRequestTimeout(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("user@mail.com")
+	assert.Equal(t, http.StatusAccepted, s.do(http.MethodPost, "/upload", token, []byte("png"), nil))
+
+	s.repo.Files = &slowFiles{Files: s.repo.Files}
+
+	w := s.serve(s.newRequest(http.MethodGet, "/me/export", token, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("export is not a valid zip - %s", err.Error())
+	}
+	assert.Len(t, archive.File, 3, "profile, files metadata and the image")
+}
+
+// failingFiles fails the next AddLog calls, like a database write lost on the way
+type failingFiles struct {
+	repo.Files
+	failures int
+}
+
+func (f *failingFiles) AddLog(ctx context.Context, log *models.FileUploadLogInput) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("connection reset")
+	}
+	return f.Files.AddLog(ctx, log)
+}
+
+func Test_Idempotency(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("user@mail.com")
+	files := &failingFiles{Files: s.repo.Files, failures: 1}
+	s.repo.Files = files
+
+	upload := func(key string, body string) *httptest.ResponseRecorder {
+		req := s.newRequest(http.MethodPost, "/upload", token, []byte(body))
+		req.Header.Set("Idempotency-Key", key)
+		return s.serve(req)
+	}
+
+	w := upload("upload-1", "\x89PNG image")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = upload("upload-1", "\x89PNG image")
+	assert.Equal(t, http.StatusAccepted, w.Code, "failed request runs again")
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	first := w.Body.String()
+
+	w = upload("upload-1", "\x89PNG image")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first, w.Body.String())
+
+	w = upload("upload-1", "\x89PNG other")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var uploaded []models.FileOut
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/files", token, nil, &uploaded))
+	assert.Len(t, uploaded, 1, "retries don't upload again")
+
+	signUp := func() *httptest.ResponseRecorder {
+		req := s.newRequest(http.MethodPost, "/sign-up", "", map[string]string{"email": "new@mail.com", "password": "password"})
+		req.Header.Set("Idempotency-Key", "sign-up-1")
+		return s.serve(req)
+	}
+
+	assert.Equal(t, http.StatusOK, signUp().Code)
+	w = signUp()
+	assert.Equal(t, http.StatusOK, w.Code, "retry doesn't fail on existing email")
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+
+	// Another client with the same key isn't answered with the stored response
+	req := s.newRequest(http.MethodPost, "/sign-up", "", map[string]string{"email": "another@mail.com", "password": "password"})
+	req.Header.Set("Idempotency-Key", "sign-up-1")
+	w = s.serve(req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+}
+
+func Test_FileProcessing(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("user@mail.com")
+	other := s.signUp("other@mail.com")
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uploaded models.FileOut
+	assert.Equal(t, http.StatusAccepted, s.do(http.MethodPost, "/upload", token, buf.Bytes(), &uploaded))
+	assert.Equal(t, models.FileProcessing, uploaded.Status)
+
+	var broken models.FileOut
+	assert.Equal(t, http.StatusAccepted, s.do(http.MethodPost, "/upload", other, []byte("\x89PNG image"), &broken))
+
+	for s.services.RunNextJob(context.Background(), "worker") {
+	}
+
+	var file models.FileOut
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/files/"+uploaded.ID.Hex(), token, nil, &file))
+	assert.Equal(t, models.FileReady, file.Status)
+	assert.Equal(t, &models.ImageInfo{Format: "png", Width: 4, Height: 3}, file.Image)
+
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/files/"+broken.ID.Hex(), other, nil, &file))
+	assert.Equal(t, models.FileFailed, file.Status)
+	assert.Equal(t, "unsupported image format", file.Error)
+
+	assert.Equal(t, http.StatusNotFound, s.do(http.MethodGet, "/files/unknown", token, nil, nil))
+	assert.Equal(t, http.StatusForbidden, s.do(http.MethodGet, "/admin/jobs/dead", other, nil, nil))
+}
+
+func Test_Webhooks(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.signUp("admin@mail.com")
+	token := s.signUp("user@mail.com")
+	other := s.signUp("other@mail.com")
+
+	var received []models.WebhookEvent
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		err := webhook.Verify("secret", r.Header.Get(webhook.SignatureHeader), body, time.Minute, time.Now())
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var event models.WebhookEvent
+		_ = json.Unmarshal(body, &event)
+		received = append(received, event)
+	}))
+	defer receiver.Close()
+
+	input := models.WebhookInput{URL: receiver.URL, Secret: "secret", Events: []string{models.EventFileUploaded, models.EventFileDeleted}}
+	assert.Equal(t, http.StatusForbidden, s.do(http.MethodPost, "/admin/webhooks", token, input, nil))
+
+	var hook models.WebhookCreated
+	assert.Equal(t, http.StatusCreated, s.do(http.MethodPost, "/admin/webhooks", admin, input, &hook))
+	assert.Equal(t, "secret", hook.Secret)
+
+	var uploaded models.FileOut
+	assert.Equal(t, http.StatusAccepted, s.do(http.MethodPost, "/upload", token, []byte("png"), &uploaded))
+	for s.services.RelayNextEvent(context.Background()) {
+	}
+	for s.services.RunNextJob(context.Background(), "worker") {
+	}
+
+	var listed []models.FileOut
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/files", other, nil, &listed))
+	assert.Empty(t, listed)
+	assert.Equal(t, http.StatusNotFound, s.do(http.MethodGet, "/files/"+uploaded.ID.Hex(), other, nil, nil))
+	assert.Equal(t, http.StatusNotFound, s.do(http.MethodGet, "/files/"+uploaded.ID.Hex()+"/download", other, nil, nil))
+	assert.Equal(t, http.StatusNotFound, s.do(http.MethodDelete, "/files/"+uploaded.ID.Hex(), other, nil, nil))
+	assert.Equal(t, http.StatusNoContent, s.do(http.MethodDelete, "/files/"+uploaded.ID.Hex(), token, nil, nil))
+	assert.Equal(t, http.StatusNotFound, s.do(http.MethodDelete, "/files/"+uploaded.ID.Hex(), token, nil, nil))
+	for s.services.RelayNextEvent(context.Background()) {
+	}
+	for s.services.RunNextJob(context.Background(), "worker") {
+	}
+
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, models.EventFileUploaded, received[0].Type)
+		assert.Equal(t, models.EventFileDeleted, received[1].Type)
+	}
+
+	var deliveries []models.WebhookDelivery
+	assert.Equal(t, http.StatusOK, s.do(http.MethodGet, "/admin/webhooks/"+hook.ID.Hex()+"/deliveries", admin, nil, &deliveries))
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, models.DeliveryDelivered, deliveries[0].Status)
+		assert.Equal(t, http.StatusOK, deliveries[0].Attempts[0].StatusCode)
+
+		path := "/admin/webhooks/" + hook.ID.Hex() + "/deliveries/" + deliveries[0].ID.Hex() + "/redeliver"
+		assert.Equal(t, http.StatusAccepted, s.do(http.MethodPost, path, admin, nil, nil))
+		for s.services.RunNextJob(context.Background(), "worker") {
+		}
+		assert.Len(t, received, 3)
+	}
+
+	assert.Equal(t, http.StatusNoContent, s.do(http.MethodDelete, "/admin/webhooks/"+hook.ID.Hex(), admin, nil, nil))
+	assert.Equal(t, http.StatusNotFound, s.do(http.MethodGet, "/admin/webhooks/"+hook.ID.Hex()+"/deliveries", admin, nil, nil))
+}
+
+func Test_FileEvents(t *testing.T) {
+	s := newTestServer(t)
+	token := s.signUp("user@mail.com")
+	other := s.signUp("other@mail.com")
+
+	server := httptest.NewServer(s.handler)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/files/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "event:") {
+				lines <- strings.TrimPrefix(scanner.Text(), "event:")
+			}
+		}
+		close(lines)
+	}()
+	nextEvent := func() string {
+		select {
+		case event := <-lines:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("no event received")
+			return ""
+		}
+	}
+
+	var uploaded models.FileOut
+	assert.Equal(t, http.StatusAccepted, s.do(http.MethodPost, "/upload", other, []byte("png"), nil))
+	assert.Equal(t, http.StatusAccepted, s.do(http.MethodPost, "/upload", token, []byte("png"), &uploaded))
+	assert.Equal(t, models.FileEventCreated, nextEvent())
+
+	for s.services.RunNextJob(context.Background(), "worker") {
+	}
+	assert.Equal(t, models.FileEventUpdated, nextEvent())
+
+	// The stream outlives the request timeout
+	time.Sleep(1200 * time.Millisecond)
+	assert.Equal(t, http.StatusNoContent, s.do(http.MethodDelete, "/files/"+uploaded.ID.Hex(), token, nil, nil))
+	assert.Equal(t, models.FileEventDeleted, nextEvent())
+
+	assert.Equal(t, http.StatusUnauthorized, s.do(http.MethodGet, "/files/events", "", nil, nil))
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyPrefix       = "ck_"
+	apiKeyLength       = 32 // Random bytes count
+	apiKeyVisibleChars = 8  // Random chars kept in the visible prefix
+
+	apiKeyTouchInterval = time.Minute
+)
+
+func (s *Services) CreateAPIKey(ctx context.Context, userID string, input *models.APIKeyInput) (*models.APIKeyCreated, error) {
+	if len(input.Scopes) == 0 {
+		return nil, models.ErrInvalidScope
+	}
+
+	for _, scope := range input.Scopes {
+		if !isValidScope(scope) {
+			return nil, models.ErrInvalidScope
+		}
+	}
+
+	now := time.Now().Unix()
+	if input.ExpiresAt != 0 && input.ExpiresAt <= now {
+		return nil, models.ErrInvalidExpiry
+	}
+
+	secret, _, err := newSecretToken(apiKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	key := apiKeyPrefix + secret
+
+	apiKey := models.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(input.Name),
+		Prefix:    key[:len(apiKeyPrefix)+apiKeyVisibleChars],
+		KeyHash:   hashToken(key),
+		Scopes:    input.Scopes,
+		CreatedAt: now,
+		ExpiresAt: input.ExpiresAt,
+	}
+
+	err = s.db.APIKeys.Create(ctx, &apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.APIKeyCreated{APIKey: apiKey, Key: key}, nil
+}
+
+func (s *Services) APIKeys(ctx context.Context, userID string) ([]models.APIKey, error) {
+	return s.db.APIKeys.ByUser(ctx, userID)
+}
+
+func (s *Services) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	return s.db.APIKeys.Delete(ctx, userID, keyID)
+}
+
+// ParseAPIKey returns the key owner and the key scopes
+func (s *Services) ParseAPIKey(ctx context.Context, key string) (string, []string, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", nil, models.ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.db.APIKeys.GetByHash(ctx, hashToken(key))
+	if errors.Is(err, models.ErrAPIKeyNotFound) {
+		return "", nil, models.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now().Unix()
+	if apiKey.ExpiresAt != 0 && apiKey.ExpiresAt <= now {
+		return "", nil, models.ErrAPIKeyExpired
+	}
+
+	user, err := s.db.Users.GetUserByID(ctx, apiKey.UserID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if user.Disabled {
+		return "", nil, models.ErrUserDisabled
+	}
+
+	if now-apiKey.LastUsedAt >= int64(apiKeyTouchInterval/time.Second) {
+		// Last usage is informational, failed update shouldn't block the request
+		err = s.db.APIKeys.Touch(ctx, apiKey.ID.Hex(), now)
+		if err != nil {
+			log.Printf("error with updating api key %s usage - %s\n", apiKey.ID.Hex(), err.Error())
+		}
+	}
+
+	return apiKey.UserID, apiKey.Scopes, nil
+}
+
+func isValidScope(scope string) bool {
+	for _, known := range models.APIKeyScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_CreateAPIKey(t *testing.T) {
+	testTable := []struct {
+		name      string
+		input     *models.APIKeyInput
+		behavior  func(*mock_repo.MockAPIKeys)
+		wantError error
+	}{
+		{
+			name:  "OK",
+			input: &models.APIKeyInput{Name: "ci", Scopes: []string{models.ScopeFilesUpload}},
+			behavior: func(mk *mock_repo.MockAPIKeys) {
+				mk.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:      "ERROR: no scopes",
+			input:     &models.APIKeyInput{Name: "ci"},
+			behavior:  func(mk *mock_repo.MockAPIKeys) {},
+			wantError: models.ErrInvalidScope,
+		},
+		{
+			name:      "ERROR: unknown scope",
+			input:     &models.APIKeyInput{Name: "ci", Scopes: []string{"admin"}},
+			behavior:  func(mk *mock_repo.MockAPIKeys) {},
+			wantError: models.ErrInvalidScope,
+		},
+		{
+			name:      "ERROR: expiry in the past",
+			input:     &models.APIKeyInput{Name: "ci", Scopes: []string{models.ScopeFilesRead}, ExpiresAt: 1},
+			behavior:  func(mk *mock_repo.MockAPIKeys) {},
+			wantError: models.ErrInvalidExpiry,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			apiKeysRepo := mock_repo.NewMockAPIKeys(ctrl)
+			repo := &repo.Repo{APIKeys: apiKeysRepo}
+
+			test.behavior(apiKeysRepo)
+
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			output, err := services.CreateAPIKey(context.Background(), "1", test.input)
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !strings.HasPrefix(output.Key, output.Prefix) || output.KeyHash != hashToken(output.Key) || output.UserID != "1" {
+				t.Fatalf("unexpected key - %+v\n", output)
+			}
+		})
+	}
+}
+
+func Test_ParseAPIKey(t *testing.T) {
+	id := primitive.NewObjectID()
+	key := apiKeyPrefix + "0123456789abcdef"
+	scopes := []string{models.ScopeFilesRead}
+
+	testTable := []struct {
+		name      string
+		key       string
+		behavior  func(*mock_repo.MockAPIKeys, *mock_repo.MockUsers)
+		outUserID string
+		wantError error
+	}{
+		{
+			name: "OK",
+			key:  key,
+			behavior: func(mk *mock_repo.MockAPIKeys, mu *mock_repo.MockUsers) {
+				mk.EXPECT().GetByHash(gomock.Any(), hashToken(key)).Return(&models.APIKey{ID: id, UserID: "1", Scopes: scopes}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{}, nil)
+				mk.EXPECT().Touch(gomock.Any(), id.Hex(), gomock.Any()).Return(nil)
+			},
+			outUserID: "1",
+		},
+		{
+			name: "OK: usage update error is ignored",
+			key:  key,
+			behavior: func(mk *mock_repo.MockAPIKeys, mu *mock_repo.MockUsers) {
+				mk.EXPECT().GetByHash(gomock.Any(), hashToken(key)).Return(&models.APIKey{ID: id, UserID: "1", Scopes: scopes}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{}, nil)
+				mk.EXPECT().Touch(gomock.Any(), id.Hex(), gomock.Any()).Return(errors.New("db error"))
+			},
+			outUserID: "1",
+		},
+		{
+			name: "OK: recently used key isn't touched",
+			key:  key,
+			behavior: func(mk *mock_repo.MockAPIKeys, mu *mock_repo.MockUsers) {
+				mk.EXPECT().GetByHash(gomock.Any(), hashToken(key)).Return(&models.APIKey{ID: id, UserID: "1", Scopes: scopes, LastUsedAt: time.Now().Add(-time.Second * 10).Unix()}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{}, nil)
+			},
+			outUserID: "1",
+		},
+		{
+			name:      "ERROR: wrong prefix",
+			key:       "token",
+			behavior:  func(mk *mock_repo.MockAPIKeys, mu *mock_repo.MockUsers) {},
+			wantError: models.ErrInvalidAPIKey,
+		},
+		{
+			name: "ERROR: unknown key",
+			key:  key,
+			behavior: func(mk *mock_repo.MockAPIKeys, mu *mock_repo.MockUsers) {
+				mk.EXPECT().GetByHash(gomock.Any(), hashToken(key)).Return(nil, models.ErrAPIKeyNotFound)
+			},
+			wantError: models.ErrInvalidAPIKey,
+		},
+		{
+			name: "ERROR: unknown key, wrapped not found",
+			key:  key,
+			behavior: func(mk *mock_repo.MockAPIKeys, mu *mock_repo.MockUsers) {
+				mk.EXPECT().GetByHash(gomock.Any(), hashToken(key)).Return(nil, fmt.Errorf("lookup - %w", models.ErrAPIKeyNotFound))
+			},
+			wantError: models.ErrInvalidAPIKey,
+		},
+		{
+			name: "ERROR: expired",
+			key:  key,
+			behavior: func(mk *mock_repo.MockAPIKeys, mu *mock_repo.MockUsers) {
+				mk.EXPECT().GetByHash(gomock.Any(), hashToken(key)).Return(&models.APIKey{ID: id, UserID: "1", ExpiresAt: time.Now().Add(-time.Minute).Unix()}, nil)
+			},
+			wantError: models.ErrAPIKeyExpired,
+		},
+		{
+			name: "ERROR: user disabled",
+			key:  key,
+			behavior: func(mk *mock_repo.MockAPIKeys, mu *mock_repo.MockUsers) {
+				mk.EXPECT().GetByHash(gomock.Any(), hashToken(key)).Return(&models.APIKey{ID: id, UserID: "1"}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Disabled: true}, nil)
+			},
+			wantError: models.ErrUserDisabled,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			apiKeysRepo := mock_repo.NewMockAPIKeys(ctrl)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			repo := &repo.Repo{Users: usersRepo, APIKeys: apiKeysRepo}
+
+			test.behavior(apiKeysRepo, usersRepo)
+
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			userID, _, err := services.ParseAPIKey(context.Background(), test.key)
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+
+			if userID != test.outUserID {
+				t.Fatalf("unexpected user\nReceived - %s\nWant - %s\n", userID, test.outUserID)
+			}
+		})
+	}
+}
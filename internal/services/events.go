@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"encoding/json"
+	"log"
+)
+
+// fileTopic is topic of the file space, personal files are streamed only to their owner
+func fileTopic(userID, orgID string) string {
+	if orgID != "" {
+		return "files.org." + orgID
+	}
+	return "files.user." + userID
+}
+
+// SetEventBroker replaces the in-process bus, so events reach clients connected to other instances
+func (s *Services) SetEventBroker(broker EventBroker) {
+	s.events = broker
+}
+
+// publishFile notifies clients of the file space. The change is saved already, so failures are only logged
+func (s *Services) publishFile(ctx context.Context, eventType string, file *models.FileOut) {
+	data, err := json.Marshal(&models.FileEvent{Type: eventType, File: file})
+	if err == nil {
+		err = s.events.Publish(ctx, fileTopic(file.UserId, file.OrgId), data)
+	}
+	if err != nil {
+		log.Printf("%s event of file %s is not published - %s\n", eventType, file.ID.Hex(), err.Error())
+	}
+}
+
+// FileEvents streams events of files in the active space of the principal until ctx is done.
+// The channel is also closed when the broker drops a subscriber which doesn't keep up
+func (s *Services) FileEvents(ctx context.Context, principal *models.Principal) (<-chan models.FileEvent, error) {
+	if principal.OrgID != "" {
+		_, _, err := s.orgMember(ctx, principal.OrgID, principal.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	messages, err := s.events.Subscribe(ctx, fileTopic(principal.UserID, principal.OrgID))
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan models.FileEvent)
+	go func() {
+		defer close(events)
+
+		for data := range messages {
+			var event models.FileEvent
+			err := json.Unmarshal(data, &event)
+			if err != nil {
+				log.Printf("invalid file event - %s\n", err.Error())
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// setFileStatus saves the processing result of the file and notifies clients of its space
+func (s *Services) setFileStatus(ctx context.Context, file *models.FileOut, update *models.FileStatusUpdate) error {
+	err := s.db.Files.SetStatus(ctx, file.ID.Hex(), update)
+	if err != nil {
+		return err
+	}
+
+	updated := *file
+	updated.Status, updated.Image, updated.Error = update.Status, update.Image, update.Error
+	s.publishFile(ctx, models.FileEventUpdated, &updated)
+
+	return nil
+}
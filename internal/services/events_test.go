@@ -0,0 +1,102 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo/memory"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func nextEvent(t *testing.T, events <-chan models.FileEvent) models.FileEvent {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return models.FileEvent{}
+	}
+}
+
+func Test_FileEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cloud := mock_services.NewMockCloudStorage(ctrl)
+	cloud.EXPECT().UploadFile(gomock.Any(), gomock.Any(), int64(3), "file1.png").Return("url", nil)
+	cloud.EXPECT().DownloadFile(gomock.Any(), "file1.png").Return(io.NopCloser(bytes.NewReader(testPNG(t, 2, 2))), nil)
+	cloud.EXPECT().DeleteFile(gomock.Any(), "file1.png").Return(nil)
+
+	repo := memory.New()
+	services := New(repo, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	owner := &models.Principal{UserID: "1"}
+	events, err := services.FileEvents(ctx, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := services.FileEvents(ctx, &models.Principal{UserID: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := services.UploadFile(ctx, &models.FileUploadInput{Filename: "file1.png", Size: 3, UserId: "1", FileData: []byte("png")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := nextEvent(t, events)
+	if event.Type != models.FileEventCreated || event.File.ID != file.ID || event.File.Status != models.FileProcessing {
+		t.Fatalf("unexpected event - %+v\n", event)
+	}
+
+	services.RunNextJob(ctx, "worker")
+	event = nextEvent(t, events)
+	if event.Type != models.FileEventUpdated || event.File.Status != models.FileReady || event.File.Image == nil {
+		t.Fatalf("unexpected event - %+v\n", event)
+	}
+
+	err = services.DeleteFile(ctx, owner, file.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	event = nextEvent(t, events)
+	if event.Type != models.FileEventDeleted || event.File.ID != file.ID {
+		t.Fatalf("unexpected event - %+v\n", event)
+	}
+
+	select {
+	case event := <-other:
+		t.Fatalf("unexpected event of other user - %+v\n", event)
+	default:
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected stream to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream isn't closed after cancel")
+	}
+}
+
+func Test_FileEvents_NotMember(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := memory.New()
+	services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	_, err := services.FileEvents(context.Background(), &models.Principal{UserID: "1", OrgID: testOrgID.Hex()})
+	if !errors.Is(err, models.ErrNotOrgMember) {
+		t.Fatalf("expected not org member, got - %v\n", err)
+	}
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"creatly-task/internal/models"
+	"creatly-task/pkg/resilience"
+)
+
+// guarded is implemented by repo and storage wrappers which retry calls and trip a circuit breaker
+type guarded interface {
+	Guard() *resilience.Guard
+}
+
+// Health reports circuit states of the database and storage. Backends without a breaker aren't listed
+func (s *Services) Health() *models.Health {
+	health := &models.Health{Status: models.HealthOK, Backends: make(map[string]string)}
+
+	for _, backend := range []interface{}{s.db.Users, s.cloud} {
+		g, ok := backend.(guarded)
+		if !ok {
+			continue
+		}
+
+		guard := g.Guard()
+		health.Backends[guard.Name()] = string(guard.State())
+		if guard.State() != resilience.Closed {
+			health.Status = models.HealthDegraded
+		}
+	}
+
+	return health
+}
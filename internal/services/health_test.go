@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo/memory"
+	"creatly-task/internal/repo/resilient"
+	mock_services "creatly-task/internal/services/mocks"
+	"creatly-task/pkg/resilience"
+	"creatly-task/pkg/storage"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func Test_Health(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cloud := mock_services.NewMockCloudStorage(ctrl)
+	cloud.EXPECT().ListFiles(gomock.Any()).Return(nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+
+	cfg := &resilience.Config{Attempts: 1, BreakerThreshold: 1}
+	guarded := storage.NewResilient(cloud, cfg)
+	services := New(resilient.New(memory.New(), cfg), mock_services.NewMockTokener(ctrl), guarded, mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	expect := &models.Health{Status: models.HealthOK, Backends: map[string]string{"database": "closed", "storage": "closed"}}
+	if health := services.Health(); !reflect.DeepEqual(health, expect) {
+		t.Fatalf("unexpected health\nReceived - %+v\nWant - %+v\n", health, expect)
+	}
+
+	_, err := guarded.ListFiles(context.Background())
+	if err == nil {
+		t.Fatal("expected storage error")
+	}
+
+	expect = &models.Health{Status: models.HealthDegraded, Backends: map[string]string{"database": "closed", "storage": "open"}}
+	if health := services.Health(); !reflect.DeepEqual(health, expect) {
+		t.Fatalf("unexpected health\nReceived - %+v\nWant - %+v\n", health, expect)
+	}
+}
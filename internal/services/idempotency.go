@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"errors"
+	"time"
+)
+
+const (
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyWait        = 10 * time.Second
+	defaultIdempotencyLockTimeout = 2 * time.Minute
+
+	idempotencyPollInterval = 200 * time.Millisecond
+)
+
+type idempotency struct {
+	ttl         time.Duration
+	wait        time.Duration
+	lockTimeout time.Duration
+	poll        time.Duration
+}
+
+func newIdempotency(cfg *config.Idempotency) idempotency {
+	i := idempotency{
+		ttl:         defaultIdempotencyTTL,
+		wait:        defaultIdempotencyWait,
+		lockTimeout: defaultIdempotencyLockTimeout,
+		poll:        idempotencyPollInterval,
+	}
+
+	if cfg == nil {
+		return i
+	}
+
+	if cfg.TTL > 0 {
+		i.ttl = cfg.TTL
+	}
+	if cfg.Wait > 0 {
+		i.wait = cfg.Wait
+	}
+	if cfg.LockTimeout > 0 {
+		i.lockTimeout = cfg.LockTimeout
+	}
+
+	return i
+}
+
+// BeginIdempotent reserves the key of the request. It returns the stored response when the first request
+// with the key is completed and nil when the caller has to run the request and complete or release the key.
+// While the first request is in progress the retry waits for it up to the configured time
+func (s *Services) BeginIdempotent(ctx context.Context, scope, key, fingerprint string) (*models.IdempotentResponse, error) {
+	deadline := time.Now().Add(s.idempotency.wait)
+
+	for {
+		now := time.Now()
+		reserved, err := s.db.IdempotencyKeys.Reserve(ctx, &models.IdempotencyRecord{
+			Scope:       scope,
+			Key:         key,
+			Fingerprint: fingerprint,
+			LockedUntil: now.Add(s.idempotency.lockTimeout).Unix(),
+			ExpiresAt:   now.Add(s.idempotency.ttl).Unix(),
+		}, now.Unix())
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		record, err := s.db.IdempotencyKeys.Get(ctx, scope, key)
+		if errors.Is(err, models.ErrIdempotencyKeyNotFound) {
+			continue // Released meanwhile, reserve it again
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if record.Fingerprint != fingerprint {
+			return nil, models.ErrIdempotencyKeyReused
+		}
+		if record.Response != nil {
+			return record.Response, nil
+		}
+
+		if !time.Now().Before(deadline) {
+			return nil, models.ErrIdempotencyKeyInProgress
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.idempotency.poll):
+		}
+	}
+}
+
+// CompleteIdempotent stores the response replayed to retries with the key
+func (s *Services) CompleteIdempotent(ctx context.Context, scope, key string, response *models.IdempotentResponse) error {
+	return s.db.IdempotencyKeys.Complete(ctx, scope, key, response)
+}
+
+// ReleaseIdempotent drops the key of a failed request, so a retry runs it again
+func (s *Services) ReleaseIdempotent(ctx context.Context, scope, key string) error {
+	return s.db.IdempotencyKeys.Release(ctx, scope, key)
+}
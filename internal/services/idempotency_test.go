@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func Test_BeginIdempotent(t *testing.T) {
+	stored := &models.IdempotentResponse{Status: 200, ContentType: "application/json", Body: []byte(`{"message":"upload success"}`)}
+	inProgress := &models.IdempotencyRecord{Scope: "1", Key: "key", Fingerprint: "hash"}
+
+	testTable := []struct {
+		name      string
+		behavior  func(*mock_repo.MockIdempotencyKeys)
+		expect    *models.IdempotentResponse
+		wantError error
+	}{
+		{
+			name: "OK: first request",
+			behavior: func(mi *mock_repo.MockIdempotencyKeys) {
+				mi.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, record *models.IdempotencyRecord, now int64) (bool, error) {
+					if record.Scope != "1" || record.Key != "key" || record.Fingerprint != "hash" || record.LockedUntil <= now || record.ExpiresAt <= record.LockedUntil {
+						t.Fatalf("unexpected record - %+v\n", record)
+					}
+					return true, nil
+				})
+			},
+		},
+		{
+			name: "OK: completed request is replayed",
+			behavior: func(mi *mock_repo.MockIdempotencyKeys) {
+				mi.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+				mi.EXPECT().Get(gomock.Any(), "1", "key").Return(&models.IdempotencyRecord{Fingerprint: "hash", Response: stored}, nil)
+			},
+			expect: stored,
+		},
+		{
+			name: "OK: waits for request in progress",
+			behavior: func(mi *mock_repo.MockIdempotencyKeys) {
+				gomock.InOrder(
+					mi.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil),
+					mi.EXPECT().Get(gomock.Any(), "1", "key").Return(inProgress, nil),
+					mi.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil),
+					mi.EXPECT().Get(gomock.Any(), "1", "key").Return(&models.IdempotencyRecord{Fingerprint: "hash", Response: stored}, nil),
+				)
+			},
+			expect: stored,
+		},
+		{
+			name: "OK: released request runs again",
+			behavior: func(mi *mock_repo.MockIdempotencyKeys) {
+				gomock.InOrder(
+					mi.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil),
+					mi.EXPECT().Get(gomock.Any(), "1", "key").Return(nil, models.ErrIdempotencyKeyNotFound),
+					mi.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil),
+				)
+			},
+		},
+		{
+			name: "ERROR: in progress after wait",
+			behavior: func(mi *mock_repo.MockIdempotencyKeys) {
+				mi.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).MinTimes(1)
+				mi.EXPECT().Get(gomock.Any(), "1", "key").Return(inProgress, nil).MinTimes(1)
+			},
+			wantError: models.ErrIdempotencyKeyInProgress,
+		},
+		{
+			name: "ERROR: key used for another request",
+			behavior: func(mi *mock_repo.MockIdempotencyKeys) {
+				mi.EXPECT().Reserve(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+				mi.EXPECT().Get(gomock.Any(), "1", "key").Return(&models.IdempotencyRecord{Fingerprint: "other", Response: stored}, nil)
+			},
+			wantError: models.ErrIdempotencyKeyReused,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			keys := mock_repo.NewMockIdempotencyKeys(ctrl)
+			test.behavior(keys)
+
+			repo := &repo.Repo{IdempotencyKeys: keys}
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+			services.idempotency.wait = 20 * time.Millisecond
+			services.idempotency.poll = time.Millisecond
+
+			response, err := services.BeginIdempotent(context.Background(), "1", "key", "hash")
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+
+			if !reflect.DeepEqual(response, test.expect) {
+				t.Fatalf("unexpected response\nReceived - %+v\nWant - %+v\n", response, test.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Decoders of supported upload formats
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJobWorkers       = 4
+	defaultJobPollInterval  = time.Second
+	defaultJobLease         = time.Minute
+	defaultJobMaxAttempts   = 5
+	defaultJobRetryDelay    = 10 * time.Second
+	defaultJobMaxRetryDelay = 10 * time.Minute
+)
+
+// workers run background jobs from the queue shared by all replicas
+type workers struct {
+	count         int // Negative - disabled
+	pollInterval  time.Duration
+	lease         time.Duration
+	maxAttempts   int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+
+	instance string        // Prefix of lease owners of this process
+	wake     chan struct{} // Jobs enqueued by this instance are started without waiting for the poll
+}
+
+func newWorkers(cfg *config.Jobs) *workers {
+	host, _ := os.Hostname()
+	w := &workers{
+		count:         defaultJobWorkers,
+		pollInterval:  defaultJobPollInterval,
+		lease:         defaultJobLease,
+		maxAttempts:   defaultJobMaxAttempts,
+		retryDelay:    defaultJobRetryDelay,
+		maxRetryDelay: defaultJobMaxRetryDelay,
+		instance:      fmt.Sprintf("%s-%d", host, os.Getpid()),
+		wake:          make(chan struct{}, 1),
+	}
+
+	if cfg == nil {
+		return w
+	}
+
+	if cfg.Workers != 0 {
+		w.count = cfg.Workers
+	}
+	if cfg.PollInterval > 0 {
+		w.pollInterval = cfg.PollInterval
+	}
+	if cfg.Lease > 0 {
+		w.lease = cfg.Lease
+	}
+	if cfg.MaxAttempts > 0 {
+		w.maxAttempts = cfg.MaxAttempts
+	}
+	if cfg.RetryDelay > 0 {
+		w.retryDelay = cfg.RetryDelay
+	}
+	if cfg.MaxRetryDelay > 0 {
+		w.maxRetryDelay = cfg.MaxRetryDelay
+	}
+
+	return w
+}
+
+// retryAt returns when the job failed on the attempt runs again, the delay doubles with every attempt
+func (w *workers) retryAt(now time.Time, attempt int) time.Time {
+	delay := w.retryDelay
+	for i := 1; i < attempt && delay < w.maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > w.maxRetryDelay {
+		delay = w.maxRetryDelay
+	}
+	return now.Add(delay)
+}
+
+// permanentError fails the job without retries
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// RunWorkers runs the worker pool until ctx is done. Jobs cut off by shutdown are taken over when their lease ends
+func (s *Services) RunWorkers(ctx context.Context) {
+	if s.workers.count < 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= s.workers.count; i++ {
+		wg.Add(1)
+		go func(worker string) {
+			defer wg.Done()
+			s.work(ctx, worker)
+		}(fmt.Sprintf("%s/%d", s.workers.instance, i))
+	}
+	wg.Wait()
+}
+
+func (s *Services) work(ctx context.Context, worker string) {
+	for ctx.Err() == nil {
+		if s.RunNextJob(ctx, worker) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.workers.wake:
+		case <-time.After(s.workers.pollInterval):
+		}
+	}
+}
+
+// enqueue adds job which is due right away and wakes an idle worker
+func (s *Services) enqueue(ctx context.Context, jobType, payload string) error {
+	now := time.Now().Unix()
+	err := s.db.Jobs.Enqueue(ctx, &models.Job{
+		Type:      jobType,
+		Payload:   payload,
+		State:     models.JobPending,
+		RunAt:     now,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case s.workers.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// RunNextJob leases one due job and runs it, false when there was no job to run.
+// Failed job is retried with backoff until it runs out of attempts, then it is dead
+func (s *Services) RunNextJob(ctx context.Context, worker string) bool {
+	now := time.Now()
+	job, err := s.db.Jobs.Lease(ctx, worker, now.Unix(), now.Add(s.workers.lease).Unix())
+	if errors.Is(err, models.ErrNoJobs) {
+		return false
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("worker %s lease error - %s\n", worker, err.Error())
+		}
+		return false
+	}
+
+	if job.Attempts > s.workers.maxAttempts {
+		// Every run crashed the instance or outlived the lease
+		err = &permanentError{err: fmt.Errorf("no attempts left, last error: %s", job.LastError)}
+	} else {
+		runCtx, cancel := context.WithTimeout(ctx, s.workers.lease)
+		err = s.runJob(runCtx, job)
+		cancel()
+	}
+
+	s.finishJob(ctx, job, worker, err)
+	return true
+}
+
+func (s *Services) runJob(ctx context.Context, job *models.Job) error {
+	switch job.Type {
+	case models.JobProcessImage:
+		return s.processImage(ctx, job.Payload)
+	case models.JobDeliverWebhook:
+		return s.deliverWebhook(ctx, job.Payload)
+	}
+
+	return &permanentError{err: fmt.Errorf("unknown job type %q", job.Type)}
+}
+
+func (s *Services) finishJob(ctx context.Context, job *models.Job, worker string, runErr error) {
+	id := job.ID.Hex()
+
+	var (
+		permanent *permanentError
+		err       error
+	)
+	switch {
+	case runErr == nil:
+		err = s.db.Jobs.Complete(ctx, id, worker)
+	case errors.As(runErr, &permanent) || job.Attempts >= s.workers.maxAttempts:
+		log.Printf("job %s %s is dead - %s\n", job.Type, id, runErr.Error())
+		err = s.db.Jobs.Bury(ctx, id, worker, runErr.Error())
+		if err == nil {
+			s.abandonJob(ctx, job)
+		}
+	default:
+		err = s.db.Jobs.Retry(ctx, id, worker, runErr.Error(), s.workers.retryAt(time.Now(), job.Attempts).Unix())
+	}
+
+	if err != nil {
+		log.Printf("job %s %s is not finished by %s - %s\n", job.Type, id, worker, err.Error())
+	}
+}
+
+// abandonJob marks what the dead job was working on as failed
+func (s *Services) abandonJob(ctx context.Context, job *models.Job) {
+	var err error
+	switch job.Type {
+	case models.JobProcessImage:
+		var file *models.FileOut
+		file, err = s.db.Files.Get(ctx, job.Payload)
+		if err == nil {
+			err = s.setFileStatus(ctx, file, &models.FileStatusUpdate{Status: models.FileFailed, Error: "processing failed"})
+		}
+	case models.JobDeliverWebhook:
+		err = s.db.Deliveries.SetStatus(ctx, job.Payload, models.DeliveryFailed)
+	}
+
+	if err != nil && !errors.Is(err, models.ErrFileNotFound) && !errors.Is(err, models.ErrDeliveryNotFound) {
+		log.Printf("%s of job %s is not marked failed - %s\n", job.Payload, job.Type, err.Error())
+	}
+}
+
+// processImage reads format and dimensions of the uploaded image. File which isn't a supported image fails
+func (s *Services) processImage(ctx context.Context, fileID string) error {
+	file, err := s.db.Files.Get(ctx, fileID)
+	if errors.Is(err, models.ErrFileNotFound) {
+		return nil // Deleted before processing
+	}
+	if err != nil {
+		return err
+	}
+
+	if file.Status == models.FileReady {
+		return nil // Processed by a worker whose lease ended
+	}
+
+	body, err := s.cloud.DownloadFile(ctx, file.Filename)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	reader := &readErrorReader{reader: body}
+	config, format, err := image.DecodeConfig(reader)
+	if reader.err != nil {
+		return reader.err // Storage failed while reading, not the image
+	}
+	if err != nil {
+		return s.setFileStatus(ctx, file, &models.FileStatusUpdate{Status: models.FileFailed, Error: "unsupported image format"})
+	}
+
+	return s.setFileStatus(ctx, file, &models.FileStatusUpdate{
+		Status: models.FileReady,
+		Image:  &models.ImageInfo{Format: format, Width: config.Width, Height: config.Height},
+	})
+}
+
+// readErrorReader keeps the read error, so decode errors of broken images are told apart from storage errors
+type readErrorReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *readErrorReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF && !errors.Is(err, io.ErrUnexpectedEOF) {
+		r.err = err
+	}
+	return n, err
+}
+
+// DeadJobs lists jobs which failed every attempt
+func (s *Services) DeadJobs(ctx context.Context) ([]models.Job, error) {
+	return s.db.Jobs.Dead(ctx)
+}
+
+// RequeueJob runs the dead job again with all attempts
+func (s *Services) RequeueJob(ctx context.Context, id string) error {
+	err := s.db.Jobs.Requeue(ctx, id, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	select {
+	case s.workers.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo/memory"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func Test_RunNextJob(t *testing.T) {
+	image := testPNG(t, 3, 2)
+	download := func(data []byte) func(context.Context, string) (io.ReadCloser, error) {
+		return func(context.Context, string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+
+	testTable := []struct {
+		name        string
+		behavior    func(*mock_services.MockCloudStorage)
+		maxAttempts int
+		expect      models.FileOut
+		wantDead    bool
+	}{
+		{
+			name: "OK: image is ready",
+			behavior: func(mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().DownloadFile(gomock.Any(), "file1.png").DoAndReturn(download(image))
+			},
+			expect: models.FileOut{Status: models.FileReady, Image: &models.ImageInfo{Format: "png", Width: 3, Height: 2}},
+		},
+		{
+			name: "OK: unsupported format fails the file",
+			behavior: func(mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().DownloadFile(gomock.Any(), "file1.png").DoAndReturn(download([]byte("not an image")))
+			},
+			expect: models.FileOut{Status: models.FileFailed, Error: "unsupported image format"},
+		},
+		{
+			name: "ERROR: download error is retried",
+			behavior: func(mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().DownloadFile(gomock.Any(), "file1.png").Return(nil, errors.New("storage error"))
+			},
+			maxAttempts: 2,
+			expect:      models.FileOut{Status: models.FileProcessing},
+		},
+		{
+			name: "ERROR: job is dead after the last attempt",
+			behavior: func(mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().DownloadFile(gomock.Any(), "file1.png").Return(nil, errors.New("storage error"))
+			},
+			maxAttempts: 1,
+			expect:      models.FileOut{Status: models.FileFailed, Error: "processing failed"},
+			wantDead:    true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			cloud := mock_services.NewMockCloudStorage(ctrl)
+			test.behavior(cloud)
+
+			cfg := testConfig()
+			cfg.Jobs = &config.Jobs{MaxAttempts: test.maxAttempts}
+			repo := memory.New()
+			services := New(repo, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, cfg)
+
+			ctx := context.Background()
+			file := &models.FileUploadLogInput{Filename: "file1.png", UserId: "1", Status: models.FileProcessing}
+			err := repo.Files.AddLog(ctx, file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = services.enqueue(ctx, models.JobProcessImage, file.ID.Hex())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !services.RunNextJob(ctx, "worker") {
+				t.Fatal("expected job to run")
+			}
+			if services.RunNextJob(ctx, "worker") {
+				t.Fatal("expected no due jobs")
+			}
+
+			processed, err := repo.Files.Get(ctx, file.ID.Hex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			received := models.FileOut{Status: processed.Status, Image: processed.Image, Error: processed.Error}
+			if !reflect.DeepEqual(received, test.expect) {
+				t.Fatalf("unexpected file\nReceived - %+v\nWant - %+v\n", received, test.expect)
+			}
+
+			dead, err := services.DeadJobs(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (len(dead) == 1) != test.wantDead {
+				t.Fatalf("unexpected dead jobs - %+v\n", dead)
+			}
+		})
+	}
+}
+
+func Test_RequeueJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cloud := mock_services.NewMockCloudStorage(ctrl)
+	gomock.InOrder(
+		cloud.EXPECT().DownloadFile(gomock.Any(), "file1.png").Return(nil, errors.New("storage error")),
+		cloud.EXPECT().DownloadFile(gomock.Any(), "file1.png").Return(io.NopCloser(bytes.NewReader(testPNG(t, 1, 1))), nil),
+	)
+
+	cfg := testConfig()
+	cfg.Jobs = &config.Jobs{MaxAttempts: 1}
+	repo := memory.New()
+	services := New(repo, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, cfg)
+
+	ctx := context.Background()
+	file := &models.FileUploadLogInput{Filename: "file1.png", UserId: "1", Status: models.FileProcessing}
+	_ = repo.Files.AddLog(ctx, file)
+	_ = services.enqueue(ctx, models.JobProcessImage, file.ID.Hex())
+	services.RunNextJob(ctx, "worker")
+
+	dead, err := services.DeadJobs(ctx)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("expected dead job, got - %+v, %v\n", dead, err)
+	}
+
+	err = services.RequeueJob(ctx, dead[0].ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !services.RunNextJob(ctx, "worker") {
+		t.Fatal("expected requeued job to run")
+	}
+
+	processed, _ := repo.Files.Get(ctx, file.ID.Hex())
+	if processed.Status != models.FileReady {
+		t.Fatalf("unexpected file status - %s\n", processed.Status)
+	}
+
+	err = services.RequeueJob(ctx, dead[0].ID.Hex())
+	if !errors.Is(err, models.ErrJobNotFound) {
+		t.Fatalf("expected job not found, got - %v\n", err)
+	}
+}
+
+func Test_RetryAt(t *testing.T) {
+	w := newWorkers(&config.Jobs{RetryDelay: time.Second, MaxRetryDelay: 5 * time.Second})
+	now := time.Unix(0, 0)
+
+	for attempt, expect := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if received := w.retryAt(now, attempt).Sub(now); received != expect {
+			t.Fatalf("unexpected delay of attempt %d\nReceived - %s\nWant - %s\n", attempt, received, expect)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAccountThreshold = 5
+	defaultIPThreshold      = 20
+	defaultAttemptsWindow   = time.Minute * 15
+	defaultLockoutDuration  = time.Minute
+	defaultMaxLockout       = time.Hour
+)
+
+type lockout struct {
+	accountThreshold int
+	ipThreshold      int
+	window           time.Duration
+	baseDuration     time.Duration
+	maxDuration      time.Duration
+}
+
+func newLockout(cfg *config.Lockout) lockout {
+	l := lockout{
+		accountThreshold: defaultAccountThreshold,
+		ipThreshold:      defaultIPThreshold,
+		window:           defaultAttemptsWindow,
+		baseDuration:     defaultLockoutDuration,
+		maxDuration:      defaultMaxLockout,
+	}
+
+	if cfg == nil {
+		return l
+	}
+
+	if cfg.AccountThreshold > 0 {
+		l.accountThreshold = cfg.AccountThreshold
+	}
+	if cfg.IPThreshold > 0 {
+		l.ipThreshold = cfg.IPThreshold
+	}
+	if cfg.Window > 0 {
+		l.window = cfg.Window
+	}
+	if cfg.BaseDuration > 0 {
+		l.baseDuration = cfg.BaseDuration
+	}
+	if cfg.MaxDuration > 0 {
+		l.maxDuration = cfg.MaxDuration
+	}
+
+	return l
+}
+
+// duration returns lockout for given failures count. It doubles for every failure over threshold
+func (l lockout) duration(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+
+	duration := l.baseDuration
+	for i := threshold; i < failures && duration < l.maxDuration; i++ {
+		duration *= 2
+	}
+
+	if duration > l.maxDuration {
+		duration = l.maxDuration
+	}
+
+	return duration
+}
+
+type attemptKey struct {
+	key       string
+	threshold int
+}
+
+func (s *Services) attemptKeys(email, ip string) []attemptKey {
+	// Case and spaces variants of one email share the counter
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	keys := []attemptKey{{key: "account:" + email, threshold: s.lockout.accountThreshold}}
+	if ip != "" {
+		keys = append(keys, attemptKey{key: "ip:" + ip, threshold: s.lockout.ipThreshold})
+	}
+	return keys
+}
+
+// checkLocked returns LockedError if the account or IP is locked now
+func (s *Services) checkLocked(ctx context.Context, keys []attemptKey) error {
+	now := time.Now()
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		attempts, err := s.db.Attempts.Get(ctx, key.key)
+		if err != nil {
+			return err
+		}
+
+		lockedFor := time.Unix(attempts.LockedUntil, 0).Sub(now)
+		if lockedFor > retryAfter {
+			retryAfter = lockedFor
+		}
+	}
+
+	if retryAfter > 0 {
+		return &models.LockedError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+func (s *Services) registerFailure(ctx context.Context, keys []attemptKey) error {
+	now := time.Now()
+
+	for _, key := range keys {
+		attempts, err := s.db.Attempts.Get(ctx, key.key)
+		if err != nil {
+			return err
+		}
+
+		// Old failures are forgotten, start counting from scratch
+		if attempts.Failures > 0 && now.Sub(time.Unix(attempts.LastFailureAt, 0)) > s.lockout.window {
+			err = s.db.Attempts.Reset(ctx, key.key)
+			if err != nil {
+				return err
+			}
+		}
+
+		attempts, err = s.db.Attempts.AddFailure(ctx, key.key, now.Unix())
+		if err != nil {
+			return err
+		}
+
+		duration := s.lockout.duration(attempts.Failures, key.threshold)
+		if duration > 0 {
+			err = s.db.Attempts.Lock(ctx, key.key, now.Add(duration).Unix())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/memory"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_SignInLockout(t *testing.T) {
+	userID := primitive.NewObjectID()
+
+	type attempt struct {
+		email    string
+		password string
+		ip       string
+		want     error
+	}
+
+	testTable := []struct {
+		name     string
+		attempts []attempt
+	}{
+		{
+			name: "threshold locks the account",
+			attempts: []attempt{
+				{email: "some@mail.com", password: "wrong", ip: "192.0.2.1", want: models.ErrWrongPassword},
+				{email: "some@mail.com", password: "wrong", ip: "192.0.2.1", want: models.ErrWrongPassword},
+				{email: "some@mail.com", password: "wrong", ip: "192.0.2.1", want: models.ErrWrongPassword},
+				{email: "some@mail.com", password: "hash", ip: "192.0.2.2", want: &models.LockedError{}},
+			},
+		},
+		{
+			name: "case and spaces of email share the counter",
+			attempts: []attempt{
+				{email: "some@mail.com", password: "wrong", ip: "192.0.2.1", want: models.ErrWrongPassword},
+				{email: " Some@Mail.com", password: "wrong", ip: "192.0.2.2", want: models.ErrWrongPassword},
+				{email: "SOME@MAIL.COM ", password: "wrong", ip: "192.0.2.3", want: models.ErrWrongPassword},
+				{email: "some@mail.com", password: "hash", ip: "192.0.2.4", want: &models.LockedError{}},
+			},
+		},
+		{
+			name: "successful sign-in resets the counter",
+			attempts: []attempt{
+				{email: "some@mail.com", password: "wrong", ip: "192.0.2.1", want: models.ErrWrongPassword},
+				{email: "some@mail.com", password: "wrong", ip: "192.0.2.2", want: models.ErrWrongPassword},
+				{email: "some@mail.com", password: "hash", ip: "192.0.2.3"},
+				{email: "some@mail.com", password: "wrong", ip: "192.0.2.4", want: models.ErrWrongPassword},
+				{email: "some@mail.com", password: "wrong", ip: "192.0.2.5", want: models.ErrWrongPassword},
+				{email: "some@mail.com", password: "hash", ip: "192.0.2.6"},
+			},
+		},
+		{
+			name: "IP is counted apart from accounts",
+			attempts: []attempt{
+				{email: "a@mail.com", password: "wrong", ip: "192.0.2.1", want: models.ErrWrongPassword},
+				{email: "b@mail.com", password: "wrong", ip: "192.0.2.1", want: models.ErrWrongPassword},
+				{email: "c@mail.com", password: "wrong", ip: "192.0.2.1", want: models.ErrWrongPassword},
+				{email: "c@mail.com", password: "wrong", ip: "192.0.2.1", want: models.ErrWrongPassword},
+				{email: "some@mail.com", password: "hash", ip: "192.0.2.1", want: &models.LockedError{}},
+				{email: "some@mail.com", password: "hash", ip: "192.0.2.2"},
+			},
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
+			tokener := mock_services.NewMockTokener(ctrl)
+			repo := &repo.Repo{
+				Users:    usersRepo,
+				Sessions: sessionsRepo,
+				Files:    mock_repo.NewMockFiles(ctrl),
+				Attempts: memory.NewAttempts(),
+			}
+
+			usersRepo.EXPECT().GetUserByCreds(gomock.Any(), gomock.Any()).Return(&models.UserSignInOutput{
+				UserID:   userID,
+				Email:    "some@mail.com",
+				Password: "hash",
+			}, nil).AnyTimes()
+			expectSessions(sessionsRepo)
+			tokener.EXPECT().GenerateToken(userID.Hex(), testSessionID.Hex(), "").Return("token", nil).AnyTimes()
+
+			cfg := testConfig()
+			cfg.Lockout = &config.Lockout{AccountThreshold: 3, IPThreshold: 4}
+
+			services := New(repo, tokener, mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, cfg)
+
+			for i, attempt := range test.attempts {
+				_, err := services.SignIn(context.Background(), &models.UserSignInInput{
+					Email:        attempt.email,
+					PasswordHash: attempt.password,
+					IP:           attempt.ip,
+				})
+
+				var locked *models.LockedError
+				switch want := attempt.want.(type) {
+				case nil:
+					if err != nil {
+						t.Fatalf("attempt %d: Service SignIn error - %s\n", i, err.Error())
+					}
+				case *models.LockedError:
+					if !errors.As(err, &locked) {
+						t.Fatalf("attempt %d: unexpected error\nReceived - %v\nWant - %v\n", i, err, want)
+					}
+				default:
+					if !errors.Is(err, want) {
+						t.Fatalf("attempt %d: unexpected error\nReceived - %v\nWant - %v\n", i, err, want)
+					}
+				}
+			}
+		})
+	}
+}
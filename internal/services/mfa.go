@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/auth/totp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMFAIssuer = "Creatly"
+
+	mfaTokenTTL        = time.Minute * 5
+	mfaSkew            = 1 // Steps accepted before and after current one
+	recoveryCodesCount = 10
+	recoveryCodeLength = 5 // Random bytes count
+)
+
+// EnrollMFA generates new secret. Two-factor is enabled only after ConfirmMFA
+func (s *Services) EnrollMFA(ctx context.Context, userID string) (*models.MFAEnrollOutput, error) {
+	user, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFA != nil && user.MFA.Enabled {
+		return nil, models.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Users.SetMFA(ctx, userID, &models.MFA{Secret: secret})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollOutput{
+		Secret: secret,
+		URI:    totp.URI(secret, s.mfaIssuer, user.Email),
+	}, nil
+}
+
+// ConfirmMFA enables two-factor after the first valid code and returns recovery codes
+func (s *Services) ConfirmMFA(ctx context.Context, userID, code string) (*models.RecoveryCodesOutput, error) {
+	user, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFA == nil {
+		return nil, models.ErrMFANotEnabled
+	}
+
+	if user.MFA.Enabled {
+		return nil, models.ErrMFAAlreadyEnabled
+	}
+
+	err = s.verifyMFA(ctx, userID, user.MFA, code)
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFA.Enabled = true
+	return s.setRecoveryCodes(ctx, userID, user.MFA)
+}
+
+func (s *Services) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*models.RecoveryCodesOutput, error) {
+	mfa, err := s.enabledMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.verifyMFA(ctx, userID, mfa, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.setRecoveryCodes(ctx, userID, mfa)
+}
+
+func (s *Services) DisableMFA(ctx context.Context, userID, code string) error {
+	mfa, err := s.enabledMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.verifyMFA(ctx, userID, mfa, code)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Users.SetMFA(ctx, userID, nil)
+}
+
+// SignInMFA exchanges pending token from SignIn and valid code to access token
+func (s *Services) SignInMFA(ctx context.Context, mfaToken, code string, client *models.ClientInfo) (string, error) {
+	userID, err := s.tokener.ParsePurposeToken(mfaToken, jwtauth.PurposeMFA)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if user.Disabled {
+		return "", models.ErrUserDisabled
+	}
+
+	if user.MFA == nil || !user.MFA.Enabled {
+		return "", models.ErrMFANotEnabled
+	}
+
+	err = s.verifyMFA(ctx, userID, user.MFA, code)
+	if err != nil {
+		return "", err
+	}
+
+	return s.startSession(ctx, userID, client)
+}
+
+func (s *Services) enabledMFA(ctx context.Context, userID string) (*models.MFA, error) {
+	user, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFA == nil || !user.MFA.Enabled {
+		return nil, models.ErrMFANotEnabled
+	}
+
+	return user.MFA, nil
+}
+
+// verifyMFA accepts TOTP code or unused recovery code. Failures are counted
+// like sign-in failures, so codes can't be brute-forced
+func (s *Services) verifyMFA(ctx context.Context, userID string, mfa *models.MFA, code string) error {
+	keys := []attemptKey{{key: "mfa:" + userID, threshold: s.lockout.accountThreshold}}
+
+	err := s.checkLocked(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+
+	step, ok := totp.Validate(mfa.Secret, code, time.Now(), mfaSkew)
+	if ok && step > mfa.LastUsedStep {
+		mfa.LastUsedStep = step
+	} else if !s.useRecoveryCode(mfa, code) {
+		return s.failSignIn(ctx, keys, models.ErrInvalidMFACode)
+	}
+
+	err = s.db.Users.SetMFA(ctx, userID, mfa)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Attempts.Reset(ctx, keys[0].key)
+}
+
+func (s *Services) useRecoveryCode(mfa *models.MFA, code string) bool {
+	if code == "" {
+		return false
+	}
+
+	codeHash := hashToken(strings.ToLower(code))
+	for i, hash := range mfa.RecoveryCodes {
+		if hash == codeHash {
+			mfa.RecoveryCodes = append(mfa.RecoveryCodes[:i], mfa.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Services) setRecoveryCodes(ctx context.Context, userID string, mfa *models.MFA) (*models.RecoveryCodesOutput, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, hash, err := newSecretToken(recoveryCodeLength)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hash)
+	}
+
+	mfa.RecoveryCodes = hashes
+
+	err := s.db.Users.SetMFA(ctx, userID, mfa)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RecoveryCodesOutput{RecoveryCodes: codes}, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/auth/totp"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+const testMFASecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func Test_SignInMFA(t *testing.T) {
+	now := time.Now()
+	code, err := totp.Code(testMFASecret, now)
+	if err != nil {
+		t.Fatalf("totp code error - %s\n", err.Error())
+	}
+
+	testTable := []struct {
+		name      string
+		code      string
+		behavior  func(*mock_repo.MockUsers, *mock_services.MockTokener, *mock_repo.MockAttempts)
+		outToken  string
+		wantError error
+	}{
+		{
+			name: "OK: totp code",
+			code: code,
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, ma *mock_repo.MockAttempts) {
+				mt.EXPECT().ParsePurposeToken("pending", jwtauth.PurposeMFA).Return("1", nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{MFA: &models.MFA{Secret: testMFASecret, Enabled: true}}, nil)
+				ma.EXPECT().Get(gomock.Any(), "mfa:1").Return(&models.LoginAttempts{}, nil)
+				mu.EXPECT().SetMFA(gomock.Any(), "1", &models.MFA{Secret: testMFASecret, Enabled: true, LastUsedStep: totp.Step(now)}).Return(nil)
+				ma.EXPECT().Reset(gomock.Any(), "mfa:1").Return(nil)
+				mt.EXPECT().GenerateToken("1", testSessionID.Hex(), "").Return("token", nil)
+			},
+			outToken: "token",
+		},
+		{
+			name: "OK: recovery code is used once",
+			code: "abcdef0123",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, ma *mock_repo.MockAttempts) {
+				mt.EXPECT().ParsePurposeToken("pending", jwtauth.PurposeMFA).Return("1", nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{MFA: &models.MFA{
+					Secret:        testMFASecret,
+					Enabled:       true,
+					RecoveryCodes: []string{hashToken("0000000000"), hashToken("abcdef0123")},
+				}}, nil)
+				ma.EXPECT().Get(gomock.Any(), "mfa:1").Return(&models.LoginAttempts{}, nil)
+				mu.EXPECT().SetMFA(gomock.Any(), "1", &models.MFA{
+					Secret:        testMFASecret,
+					Enabled:       true,
+					RecoveryCodes: []string{hashToken("0000000000")},
+				}).Return(nil)
+				ma.EXPECT().Reset(gomock.Any(), "mfa:1").Return(nil)
+				mt.EXPECT().GenerateToken("1", testSessionID.Hex(), "").Return("token", nil)
+			},
+			outToken: "token",
+		},
+		{
+			name: "ERROR: code reuse",
+			code: code,
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, ma *mock_repo.MockAttempts) {
+				mt.EXPECT().ParsePurposeToken("pending", jwtauth.PurposeMFA).Return("1", nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{MFA: &models.MFA{
+					Secret:       testMFASecret,
+					Enabled:      true,
+					LastUsedStep: totp.Step(now) + 1,
+				}}, nil)
+				ma.EXPECT().Get(gomock.Any(), "mfa:1").Return(&models.LoginAttempts{}, nil).Times(2)
+				ma.EXPECT().AddFailure(gomock.Any(), "mfa:1", gomock.Any()).Return(&models.LoginAttempts{Failures: 1}, nil)
+			},
+			wantError: models.ErrInvalidMFACode,
+		},
+		{
+			name: "ERROR: second factor disabled",
+			code: code,
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, ma *mock_repo.MockAttempts) {
+				mt.EXPECT().ParsePurposeToken("pending", jwtauth.PurposeMFA).Return("1", nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{}, nil)
+			},
+			wantError: models.ErrMFANotEnabled,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			attemptsRepo := mock_repo.NewMockAttempts(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
+			repo := &repo.Repo{
+				Users:    usersRepo,
+				Sessions: sessionsRepo,
+				Files:    mock_repo.NewMockFiles(ctrl),
+				Attempts: attemptsRepo,
+			}
+			tokens := mock_services.NewMockTokener(ctrl)
+
+			expectSessions(sessionsRepo)
+			test.behavior(usersRepo, tokens, attemptsRepo)
+
+			services := New(repo, tokens, mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			token, err := services.SignInMFA(context.Background(), "pending", test.code, &models.ClientInfo{})
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+
+			if token != test.outToken {
+				t.Fatalf("unexpected token\nReceived - %s\nWant - %s\n", token, test.outToken)
+			}
+		})
+	}
+}
+
+func Test_ConfirmMFA(t *testing.T) {
+	code, err := totp.Code(testMFASecret, time.Now())
+	if err != nil {
+		t.Fatalf("totp code error - %s\n", err.Error())
+	}
+
+	ctrl := gomock.NewController(t)
+	usersRepo := mock_repo.NewMockUsers(ctrl)
+	attemptsRepo := mock_repo.NewMockAttempts(ctrl)
+	repo := &repo.Repo{
+		Users:    usersRepo,
+		Sessions: mock_repo.NewMockSessions(ctrl),
+		Files:    mock_repo.NewMockFiles(ctrl),
+		Attempts: attemptsRepo,
+	}
+
+	usersRepo.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{MFA: &models.MFA{Secret: testMFASecret}}, nil)
+	attemptsRepo.EXPECT().Get(gomock.Any(), "mfa:1").Return(&models.LoginAttempts{}, nil)
+	attemptsRepo.EXPECT().Reset(gomock.Any(), "mfa:1").Return(nil)
+
+	var saved *models.MFA
+	usersRepo.EXPECT().SetMFA(gomock.Any(), "1", gomock.Any()).DoAndReturn(func(_ context.Context, id string, mfa *models.MFA) error {
+		saved = mfa
+		return nil
+	}).Times(2)
+
+	services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	output, err := services.ConfirmMFA(context.Background(), "1", code)
+	if err != nil {
+		t.Fatalf("ConfirmMFA error - %s\n", err.Error())
+	}
+
+	if len(output.RecoveryCodes) != recoveryCodesCount || !saved.Enabled || len(saved.RecoveryCodes) != recoveryCodesCount {
+		t.Fatalf("unexpected result\nOutput - %+v\nSaved - %+v\n", output, saved)
+	}
+
+	if saved.RecoveryCodes[0] != hashToken(output.RecoveryCodes[0]) {
+		t.Fatal("recovery codes must be stored hashed")
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// policyModerator checks uploads against the declarative policy of config
+type policyModerator struct {
+	policy *config.Policy
+	users  repo.Users
+}
+
+// newModerator returns nil without policy, uploads aren't moderated then
+func newModerator(cfg *config.Moderation, users repo.Users) Moderator {
+	if cfg == nil || cfg.Policy == nil {
+		return nil
+	}
+
+	return &policyModerator{policy: cfg.Policy, users: users}
+}
+
+// SetModerator replaces the policy of config, nil disables moderation
+func (s *Services) SetModerator(moderator Moderator) {
+	s.moderator = moderator
+}
+
+// moderate decodes only the image header, so oversized images are rejected before their pixels are allocated
+func (s *Services) moderate(ctx context.Context, file *models.FileUploadInput) error {
+	if s.moderator == nil {
+		return nil
+	}
+
+	input := &models.ModerationInput{UserID: file.UserId, OrgID: file.OrgId}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(file.FileData))
+	if err == nil {
+		input.Format, input.Width, input.Height = format, cfg.Width, cfg.Height
+	}
+
+	violations, err := s.moderator.Moderate(ctx, input)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &models.PolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+func (m *policyModerator) Moderate(ctx context.Context, upload *models.ModerationInput) ([]models.Violation, error) {
+	if upload.Format == "" {
+		return []models.Violation{{Rule: "formats", Message: "content is not a supported image"}}, nil
+	}
+
+	rules := m.policy.RulesFor(upload.OrgID)
+	allowed, err := m.formats(ctx, &rules, upload.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []models.Violation
+	add := func(rule, format string, args ...interface{}) {
+		violations = append(violations, models.Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if allowed != nil && !contains(allowed, upload.Format) {
+		add("formats", "format %s is not allowed, allowed formats: %s", upload.Format, strings.Join(allowed, ", "))
+	}
+
+	width, height := upload.Width, upload.Height
+	if rules.MinWidth > 0 && width < rules.MinWidth {
+		add("minWidth", "width %d is under %d", width, rules.MinWidth)
+	}
+	if rules.MaxWidth > 0 && width > rules.MaxWidth {
+		add("maxWidth", "width %d is over %d", width, rules.MaxWidth)
+	}
+	if rules.MinHeight > 0 && height < rules.MinHeight {
+		add("minHeight", "height %d is under %d", height, rules.MinHeight)
+	}
+	if rules.MaxHeight > 0 && height > rules.MaxHeight {
+		add("maxHeight", "height %d is over %d", height, rules.MaxHeight)
+	}
+
+	if height > 0 {
+		ratio := float64(width) / float64(height)
+		if rules.MinAspectRatio > 0 && ratio < rules.MinAspectRatio {
+			add("minAspectRatio", "aspect ratio %.2f is under %g", ratio, rules.MinAspectRatio)
+		}
+		if rules.MaxAspectRatio > 0 && ratio > rules.MaxAspectRatio {
+			add("maxAspectRatio", "aspect ratio %.2f is over %g", ratio, rules.MaxAspectRatio)
+		}
+	}
+
+	pixels := int64(width) * int64(height)
+	if rules.MaxPixels > 0 && pixels > rules.MaxPixels {
+		add("maxPixels", "%d pixels are over %d", pixels, rules.MaxPixels)
+	}
+
+	return violations, nil
+}
+
+// formats returns formats allowed to the uploader, nil when any format is allowed.
+// The user is looked up only when the rules have lists of particular roles
+func (m *policyModerator) formats(ctx context.Context, rules *config.PolicyRules, userID string) ([]string, error) {
+	if len(rules.Formats) == 0 {
+		return nil, nil
+	}
+
+	role := ""
+	if _, ok := rules.Formats[config.AnyRole]; !ok || len(rules.Formats) > 1 {
+		user, err := m.users.GetUserByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		role = user.Role
+	}
+
+	if allowed, ok := rules.Formats[role]; ok {
+		return allowed, nil
+	}
+	if allowed, ok := rules.Formats[config.AnyRole]; ok {
+		return allowed, nil
+	}
+	return []string{}, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo/memory"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPolicy = &config.Policy{
+	PolicyRules: config.PolicyRules{
+		MaxWidth:       4096,
+		MaxHeight:      4096,
+		MinAspectRatio: 0.25,
+		MaxAspectRatio: 4,
+		MaxPixels:      8000000,
+		Formats:        map[string][]string{config.AnyRole: {"png", "jpeg"}, models.RoleAdmin: {"png", "jpeg", "gif"}},
+	},
+	Orgs: map[string]config.PolicyRules{
+		"org": {MinWidth: 512, MinHeight: 512, Formats: map[string][]string{config.AnyRole: {"png"}}},
+	},
+}
+
+func Test_Moderate(t *testing.T) {
+	testTable := []struct {
+		name   string
+		role   string
+		input  models.ModerationInput
+		expect []models.Violation
+	}{
+		{
+			name:  "OK",
+			role:  models.RoleUser,
+			input: models.ModerationInput{Format: "png", Width: 1920, Height: 1080},
+		},
+		{
+			name:  "OK: format of the role",
+			role:  models.RoleAdmin,
+			input: models.ModerationInput{Format: "gif", Width: 640, Height: 480},
+		},
+		{
+			name:   "ERROR: not an image",
+			input:  models.ModerationInput{},
+			expect: []models.Violation{{Rule: "formats", Message: "content is not a supported image"}},
+		},
+		{
+			name:   "ERROR: format of other role",
+			role:   models.RoleUser,
+			input:  models.ModerationInput{Format: "gif", Width: 640, Height: 480},
+			expect: []models.Violation{{Rule: "formats", Message: "format gif is not allowed, allowed formats: png, jpeg"}},
+		},
+		{
+			name:  "ERROR: dimensions and pixels",
+			role:  models.RoleUser,
+			input: models.ModerationInput{Format: "png", Width: 5000, Height: 2000},
+			expect: []models.Violation{
+				{Rule: "maxWidth", Message: "width 5000 is over 4096"},
+				{Rule: "maxPixels", Message: "10000000 pixels are over 8000000"},
+			},
+		},
+		{
+			name:   "ERROR: aspect ratio",
+			role:   models.RoleUser,
+			input:  models.ModerationInput{Format: "jpeg", Width: 100, Height: 1000},
+			expect: []models.Violation{{Rule: "minAspectRatio", Message: "aspect ratio 0.10 is under 0.25"}},
+		},
+		{
+			name:  "ERROR: organization overrides",
+			role:  models.RoleUser,
+			input: models.ModerationInput{OrgID: "org", Format: "jpeg", Width: 256, Height: 256},
+			expect: []models.Violation{
+				{Rule: "formats", Message: "format jpeg is not allowed, allowed formats: png"},
+				{Rule: "minWidth", Message: "width 256 is under 512"},
+				{Rule: "minHeight", Message: "height 256 is under 512"},
+			},
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := mock_repo.NewMockUsers(ctrl)
+			if test.role != "" {
+				users.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Role: test.role}, nil)
+			}
+
+			moderator := newModerator(&config.Moderation{Policy: testPolicy}, users)
+
+			test.input.UserID = "1"
+			violations, err := moderator.Moderate(context.Background(), &test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.expect, violations)
+		})
+	}
+}
+
+func Test_UploadFile_Policy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cloud := mock_services.NewMockCloudStorage(ctrl)
+	cloud.EXPECT().UploadFile(gomock.Any(), gomock.Any(), gomock.Any(), "small.png").Return("url", nil)
+
+	cfg := testConfig()
+	cfg.Moderation = &config.Moderation{Policy: &config.Policy{PolicyRules: config.PolicyRules{
+		MaxWidth: 100,
+		Formats:  map[string][]string{config.AnyRole: {"png"}},
+	}}}
+	db := memory.New()
+	services := New(db, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, cfg)
+
+	ctx := context.Background()
+	_, err := services.UploadFile(ctx, &models.FileUploadInput{Filename: "small.png", Size: 3, UserId: "1", FileData: testPNG(t, 100, 50)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = services.UploadFile(ctx, &models.FileUploadInput{Filename: "wide.png", Size: 3, UserId: "1", FileData: testPNG(t, 101, 50)})
+	var policyErr *models.PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected policy error, got - %v\n", err)
+	}
+	assert.Equal(t, []models.Violation{{Rule: "maxWidth", Message: "width 101 is over 100"}}, policyErr.Violations)
+
+	recorded, err := db.Files.ByUser(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, recorded, 1, "rejected upload isn't recorded")
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/auth/oidc"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+)
+
+const oidcStateTTL = time.Minute * 10
+
+// OIDCLogin returns provider login url. State, nonce and PKCE verifier are kept
+// in a signed purpose token, the handler stores it in a cookie until callback
+func (s *Services) OIDCLogin(ctx context.Context, providerName string) (*models.OIDCLoginOutput, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, models.ErrUnknownProvider
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		value, err := oidc.RandomString()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	url, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	signedState, err := s.tokener.GeneratePurposeToken(
+		strings.Join([]string{providerName, state, nonce, verifier}, " "),
+		jwtauth.PurposeOIDCState,
+		oidcStateTTL,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OIDCLoginOutput{URL: url, State: signedState}, nil
+}
+
+// OIDCCallback finishes login: the identity is matched by provider subject first,
+// then linked to account with the same email if provider verified it, otherwise new account is created
+func (s *Services) OIDCCallback(ctx context.Context, providerName, signedState, state, code string, client *models.ClientInfo) (*models.SignInResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, models.ErrUnknownProvider
+	}
+
+	subject, err := s.tokener.ParsePurposeToken(signedState, jwtauth.PurposeOIDCState)
+	if err != nil {
+		return nil, models.ErrInvalidOIDCState
+	}
+
+	parts := strings.Split(subject, " ")
+	if len(parts) != 4 || parts[0] != providerName || subtle.ConstantTimeCompare([]byte(parts[1]), []byte(state)) != 1 {
+		return nil, models.ErrInvalidOIDCState
+	}
+	nonce, verifier := parts[2], parts[3]
+
+	claims, err := provider.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.db.Users.GetUserByIdentity(ctx, providerName, claims.Subject)
+	if err == nil {
+		if user.Disabled {
+			return nil, models.ErrUserDisabled
+		}
+		return s.completeSignIn(ctx, user.ID.Hex(), user.MFA, client)
+	}
+	if !errors.Is(err, models.ErrUserNotFound) {
+		return nil, err
+	}
+
+	// Unverified email could belong to someone else, linking it would hand over the account
+	if !claims.EmailVerified || claims.Email == "" {
+		return nil, models.ErrExternalEmailNotVerified
+	}
+
+	identity := models.Identity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		LinkedAt: time.Now().Unix(),
+	}
+
+	existing, err := s.db.Users.GetUserByCreds(ctx, claims.Email)
+	if err == nil {
+		if existing.Disabled {
+			return nil, models.ErrUserDisabled
+		}
+
+		err = s.db.Users.AddIdentity(ctx, existing.UserID.Hex(), &identity)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.completeSignIn(ctx, existing.UserID.Hex(), existing.MFA, client)
+	}
+	if !errors.Is(err, models.ErrUserNotFound) {
+		return nil, err
+	}
+
+	// Accounts created by provider have no password, it can be set with password reset
+	input := &models.UserSignUpInput{
+		Email:      claims.Email,
+		Role:       s.roleFor(claims.Email),
+		Verified:   true,
+		CreatedAt:  time.Now().Unix(),
+		Identities: []models.Identity{identity},
+	}
+	err = s.db.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		err := s.db.Users.CreateUser(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		return s.addOutbox(ctx, models.EventUserCreated, &models.UserEvent{ID: input.ID.Hex(), Email: input.Email})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.db.Users.GetUserByCreds(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeSignIn(ctx, created.UserID.Hex(), nil, client)
+}
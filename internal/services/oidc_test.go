@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/memory"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/auth/oidc"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_OIDCCallback(t *testing.T) {
+	userID := primitive.NewObjectID()
+	verified := &oidc.Claims{Subject: "ext-1", Email: "user@company.com", EmailVerified: true}
+
+	testTable := []struct {
+		name      string
+		state     string
+		behavior  func(*mock_repo.MockUsers, *mock_services.MockTokener, *mock_services.MockOIDCProvider)
+		outResult *models.SignInResult
+		wantError error
+	}{
+		{
+			name:  "OK: linked identity",
+			state: "state",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, mp *mock_services.MockOIDCProvider) {
+				mp.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(verified, nil)
+				mu.EXPECT().GetUserByIdentity(gomock.Any(), "stub", "ext-1").Return(&models.User{ID: userID}, nil)
+				mt.EXPECT().GenerateToken(userID.Hex(), testSessionID.Hex(), "").Return("token", nil)
+			},
+			outResult: &models.SignInResult{Token: "token"},
+		},
+		{
+			name:  "OK: identity linked to account with same email",
+			state: "state",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, mp *mock_services.MockOIDCProvider) {
+				mp.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(verified, nil)
+				mu.EXPECT().GetUserByIdentity(gomock.Any(), "stub", "ext-1").Return(nil, models.ErrUserNotFound)
+				mu.EXPECT().GetUserByCreds(gomock.Any(), "user@company.com").Return(&models.UserSignInOutput{
+					UserID: userID,
+					MFA:    &models.MFA{Enabled: true},
+				}, nil)
+				mu.EXPECT().AddIdentity(gomock.Any(), userID.Hex(), gomock.Any()).Return(nil)
+				mt.EXPECT().GeneratePurposeToken(userID.Hex(), jwtauth.PurposeMFA, mfaTokenTTL).Return("pending", nil)
+			},
+			outResult: &models.SignInResult{MFARequired: true, MFAToken: "pending"},
+		},
+		{
+			name:  "OK: new account",
+			state: "state",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, mp *mock_services.MockOIDCProvider) {
+				mp.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(verified, nil)
+				mu.EXPECT().GetUserByIdentity(gomock.Any(), "stub", "ext-1").Return(nil, models.ErrUserNotFound)
+				gomock.InOrder(
+					mu.EXPECT().GetUserByCreds(gomock.Any(), "user@company.com").Return(nil, models.ErrUserNotFound),
+					mu.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil),
+					mu.EXPECT().GetUserByCreds(gomock.Any(), "user@company.com").Return(&models.UserSignInOutput{UserID: userID}, nil),
+				)
+				mt.EXPECT().GenerateToken(userID.Hex(), testSessionID.Hex(), "").Return("token", nil)
+			},
+			outResult: &models.SignInResult{Token: "token"},
+		},
+		{
+			name:  "ERROR: unverified email is not linked",
+			state: "state",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, mp *mock_services.MockOIDCProvider) {
+				mp.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(&oidc.Claims{Subject: "ext-1", Email: "user@company.com"}, nil)
+				mu.EXPECT().GetUserByIdentity(gomock.Any(), "stub", "ext-1").Return(nil, models.ErrUserNotFound)
+			},
+			wantError: models.ErrExternalEmailNotVerified,
+		},
+		{
+			name:  "ERROR: disabled user",
+			state: "state",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, mp *mock_services.MockOIDCProvider) {
+				mp.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(verified, nil)
+				mu.EXPECT().GetUserByIdentity(gomock.Any(), "stub", "ext-1").Return(&models.User{ID: userID, Disabled: true}, nil)
+			},
+			wantError: models.ErrUserDisabled,
+		},
+		{
+			name:      "ERROR: state mismatch",
+			state:     "forged",
+			behavior:  func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, mp *mock_services.MockOIDCProvider) {},
+			wantError: models.ErrInvalidOIDCState,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
+			repo := &repo.Repo{Users: usersRepo, Sessions: sessionsRepo, Webhooks: noWebhooks(ctrl), Outbox: memory.NewOutbox(), Transactions: memory.Transactions{}}
+			tokens := mock_services.NewMockTokener(ctrl)
+			provider := mock_services.NewMockOIDCProvider(ctrl)
+
+			expectSessions(sessionsRepo)
+			tokens.EXPECT().ParsePurposeToken("signed", jwtauth.PurposeOIDCState).Return("stub state nonce verifier", nil)
+			test.behavior(usersRepo, tokens, provider)
+
+			services := New(repo, tokens, mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl),
+				map[string]OIDCProvider{"stub": provider}, testConfig())
+
+			result, err := services.OIDCCallback(context.Background(), "stub", "signed", test.state, "code", &models.ClientInfo{})
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+
+			if test.outResult != nil && *result != *test.outResult {
+				t.Fatalf("unexpected result\nReceived - %+v\nWant - %+v\n", result, test.outResult)
+			}
+		})
+	}
+}
+
+func Test_OIDCUnknownProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	services := New(&repo.Repo{}, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	if _, err := services.OIDCLogin(context.Background(), "other"); !errors.Is(err, models.ErrUnknownProvider) {
+		t.Fatalf("unexpected error - %v\n", err)
+	}
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/pkg/mailer"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultInvitationTTL = time.Hour * 24 * 7
+
+	maxOrgNameLength      = 64 // Runes count
+	invitationTokenLength = 32 // Random bytes count
+)
+
+func (s *Services) CreateOrganization(ctx context.Context, userID string, input *models.OrganizationInput) (*models.OrganizationOut, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxOrgNameLength {
+		return nil, models.ErrInvalidOrgName
+	}
+
+	user, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	org := models.Organization{
+		Name:      name,
+		Quota:     s.orgQuota,
+		CreatedAt: now,
+		Members: []models.Member{{
+			UserID:   userID,
+			Email:    user.Email,
+			Role:     models.OrgRoleOwner,
+			JoinedAt: now,
+		}},
+	}
+
+	err = s.db.Organizations.Create(ctx, &org)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OrganizationOut{
+		ID:    org.ID.Hex(),
+		Name:  org.Name,
+		Role:  models.OrgRoleOwner,
+		Quota: org.Quota,
+	}, nil
+}
+
+// Organizations returns organizations of the user with the user's role and used storage
+func (s *Services) Organizations(ctx context.Context, userID string) ([]models.OrganizationOut, error) {
+	orgs, err := s.db.Organizations.ByMember(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.OrganizationOut, 0, len(orgs))
+	for _, org := range orgs {
+		used, err := s.db.Files.OrgUsage(ctx, org.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, models.OrganizationOut{
+			ID:    org.ID.Hex(),
+			Name:  org.Name,
+			Role:  org.Member(userID).Role,
+			Quota: org.Quota,
+			Used:  used,
+		})
+	}
+
+	return result, nil
+}
+
+// Organization returns organization with members, visible to members only
+func (s *Services) Organization(ctx context.Context, userID, orgID string) (*models.Organization, error) {
+	org, _, err := s.orgMember(ctx, orgID, userID)
+	return org, err
+}
+
+// InviteMember mails invitation link, invitation for the same email is replaced
+func (s *Services) InviteMember(ctx context.Context, userID, orgID string, input *models.InvitationInput) error {
+	org, err := s.orgRole(ctx, orgID, userID, models.OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if !isOrgRole(input.Role) {
+		return models.ErrInvalidOrgRole
+	}
+
+	email := strings.ToLower(input.Email)
+	for _, member := range org.Members {
+		if strings.ToLower(member.Email) == email {
+			return models.ErrAlreadyMember
+		}
+	}
+
+	token, tokenHash, err := newSecretToken(invitationTokenLength)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Organizations.AddInvitation(ctx, orgID, &models.Invitation{
+		Email:     email,
+		Role:      input.Role,
+		TokenHash: tokenHash,
+		InvitedBy: userID,
+		ExpiresAt: time.Now().Add(s.invitationTTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/invitations/accept?token=%s", s.publicURL, token)
+
+	return s.mailer.Send(&mailer.Message{
+		To:      email,
+		Subject: fmt.Sprintf("Invitation to %s", org.Name),
+		Body:    fmt.Sprintf("You are invited to join %s as %s. Sign in with this email and follow the link:\n%s", org.Name, input.Role, link),
+	})
+}
+
+// AcceptInvitation adds the user to organization if invitation was sent to the user's email
+func (s *Services) AcceptInvitation(ctx context.Context, userID, token string) (*models.OrganizationOut, error) {
+	org, err := s.db.Organizations.GetByInvitation(ctx, hashToken(token))
+	if errors.Is(err, models.ErrOrganizationNotFound) {
+		return nil, models.ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var invitation *models.Invitation
+	for i := range org.Invitations {
+		if org.Invitations[i].TokenHash == hashToken(token) {
+			invitation = &org.Invitations[i]
+		}
+	}
+
+	// Link forwarded to another account doesn't work
+	if invitation == nil || invitation.ExpiresAt < time.Now().Unix() || invitation.Email != strings.ToLower(user.Email) {
+		return nil, models.ErrInvalidInvitation
+	}
+
+	if org.Member(userID) != nil {
+		return nil, models.ErrAlreadyMember
+	}
+
+	err = s.db.Organizations.AddMember(ctx, org.ID.Hex(), &models.Member{
+		UserID:   userID,
+		Email:    user.Email,
+		Role:     invitation.Role,
+		JoinedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OrganizationOut{
+		ID:    org.ID.Hex(),
+		Name:  org.Name,
+		Role:  invitation.Role,
+		Quota: org.Quota,
+	}, nil
+}
+
+func (s *Services) SetMemberRole(ctx context.Context, userID, orgID, memberID, role string) error {
+	org, err := s.orgRole(ctx, orgID, userID, models.OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if !isOrgRole(role) {
+		return models.ErrInvalidOrgRole
+	}
+
+	member := org.Member(memberID)
+	if member == nil {
+		return models.ErrMemberNotFound
+	}
+
+	if member.Role == models.OrgRoleOwner && role != models.OrgRoleOwner && ownersCount(org) == 1 {
+		return models.ErrLastOwner
+	}
+
+	return s.db.Organizations.SetMemberRole(ctx, orgID, memberID, role)
+}
+
+// RemoveMember is allowed to owners and to members leaving the organization.
+// Files uploaded by the member stay in the organization
+func (s *Services) RemoveMember(ctx context.Context, userID, orgID, memberID string) error {
+	org, member, err := s.orgMember(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+
+	if memberID != userID && member.Role != models.OrgRoleOwner {
+		return models.ErrOrgForbidden
+	}
+
+	removed := org.Member(memberID)
+	if removed == nil {
+		return models.ErrMemberNotFound
+	}
+
+	if removed.Role == models.OrgRoleOwner && ownersCount(org) == 1 {
+		return models.ErrLastOwner
+	}
+
+	return s.db.Organizations.RemoveMember(ctx, orgID, memberID)
+}
+
+// SwitchOrganization issues token of the same session with another active organization
+func (s *Services) SwitchOrganization(ctx context.Context, principal *models.Principal, orgID string) (string, error) {
+	if orgID != "" {
+		_, _, err := s.orgMember(ctx, orgID, principal.UserID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return s.tokener.GenerateToken(principal.UserID, principal.SessionID, orgID)
+}
+
+func (s *Services) SetOrganizationQuota(ctx context.Context, orgID string, quota int64) error {
+	if quota < 0 {
+		return fmt.Errorf("negative quota %d", quota)
+	}
+	return s.db.Organizations.SetQuota(ctx, orgID, quota)
+}
+
+// orgMember returns organization and membership of the user, non-members get ErrNotOrgMember
+func (s *Services) orgMember(ctx context.Context, orgID, userID string) (*models.Organization, *models.Member, error) {
+	org, err := s.db.Organizations.Get(ctx, orgID)
+	if errors.Is(err, models.ErrOrganizationNotFound) {
+		// Existence of organizations isn't revealed to non-members
+		return nil, nil, models.ErrNotOrgMember
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	member := org.Member(userID)
+	if member == nil {
+		return nil, nil, models.ErrNotOrgMember
+	}
+
+	return org, member, nil
+}
+
+// orgRole returns organization if the user has one of the roles
+func (s *Services) orgRole(ctx context.Context, orgID, userID string, roles ...string) (*models.Organization, error) {
+	org, member, err := s.orgMember(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, role := range roles {
+		if member.Role == role {
+			return org, nil
+		}
+	}
+
+	return nil, models.ErrOrgForbidden
+}
+
+// checkQuota fails when upload of size bytes exceeds organization quota.
+// Concurrent uploads may overrun the quota by the size of the last file
+func (s *Services) checkQuota(ctx context.Context, org *models.Organization, size int64) error {
+	if org.Quota == 0 {
+		return nil
+	}
+
+	used, err := s.db.Files.OrgUsage(ctx, org.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	if used+size > org.Quota {
+		return models.ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+func isOrgRole(role string) bool {
+	for _, r := range models.OrgRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func ownersCount(org *models.Organization) int {
+	count := 0
+	for _, member := range org.Members {
+		if member.Role == models.OrgRoleOwner {
+			count++
+		}
+	}
+	return count
+}
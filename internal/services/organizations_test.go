@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/memory"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testOrgID = primitive.ObjectID{12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+
+func testOrg(quota int64, members ...models.Member) *models.Organization {
+	return &models.Organization{ID: testOrgID, Name: "Team", Quota: quota, Members: members}
+}
+
+func Test_UploadFileToOrganization(t *testing.T) {
+	testTable := []struct {
+		name      string
+		behavior  func(*mock_repo.MockOrganizations, *mock_repo.MockFiles, *mock_repo.MockUploads, *mock_repo.MockJobs, *mock_services.MockCloudStorage)
+		wantError error
+	}{
+		{
+			name: "OK: editor within quota",
+			behavior: func(mo *mock_repo.MockOrganizations, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mo.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(1000, models.Member{UserID: "1", Role: models.OrgRoleEditor}), nil)
+				mf.EXPECT().OrgUsage(gomock.Any(), testOrgID.Hex()).Return(int64(900), nil)
+				mu.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				mcs.EXPECT().UploadFile(gomock.Any(), []byte("png"), int64(100), "1-1.png").Return("url", nil)
+				mf.EXPECT().AddLog(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, log *models.FileUploadLogInput) error {
+					if log.OrgId != testOrgID.Hex() {
+						t.Fatalf("upload logged outside organization - %+v\n", log)
+					}
+					return nil
+				})
+				mu.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mj.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "ERROR: quota exceeded",
+			behavior: func(mo *mock_repo.MockOrganizations, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mo.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(1000, models.Member{UserID: "1", Role: models.OrgRoleOwner}), nil)
+				mf.EXPECT().OrgUsage(gomock.Any(), testOrgID.Hex()).Return(int64(901), nil)
+			},
+			wantError: models.ErrQuotaExceeded,
+		},
+		{
+			name: "ERROR: viewer can't upload",
+			behavior: func(mo *mock_repo.MockOrganizations, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mo.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(0, models.Member{UserID: "1", Role: models.OrgRoleViewer}), nil)
+			},
+			wantError: models.ErrOrgForbidden,
+		},
+		{
+			name: "ERROR: not a member",
+			behavior: func(mo *mock_repo.MockOrganizations, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mo.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(0, models.Member{UserID: "2", Role: models.OrgRoleOwner}), nil)
+			},
+			wantError: models.ErrNotOrgMember,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orgs := mock_repo.NewMockOrganizations(ctrl)
+			files := mock_repo.NewMockFiles(ctrl)
+			uploads := mock_repo.NewMockUploads(ctrl)
+			jobs := mock_repo.NewMockJobs(ctrl)
+			cloud := mock_services.NewMockCloudStorage(ctrl)
+			test.behavior(orgs, files, uploads, jobs, cloud)
+
+			repo := &repo.Repo{Organizations: orgs, Files: files, Uploads: uploads, Jobs: jobs, Webhooks: noWebhooks(ctrl), Outbox: memory.NewOutbox(), Transactions: memory.Transactions{}}
+			services := New(repo, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			_, err := services.UploadFile(context.Background(), &models.FileUploadInput{
+				Filename: "1-1.png",
+				Size:     100,
+				UserId:   "1",
+				OrgId:    testOrgID.Hex(),
+				FileData: []byte("png"),
+			})
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+		})
+	}
+}
+
+func Test_AcceptInvitation(t *testing.T) {
+	token := "invitation-token"
+	invitation := func(email string, expiresAt int64) []models.Invitation {
+		return []models.Invitation{{Email: email, Role: models.OrgRoleViewer, TokenHash: hashToken(token), ExpiresAt: expiresAt}}
+	}
+	future := time.Now().Add(time.Hour).Unix()
+
+	testTable := []struct {
+		name      string
+		behavior  func(*mock_repo.MockOrganizations, *mock_repo.MockUsers)
+		wantError error
+	}{
+		{
+			name: "OK",
+			behavior: func(mo *mock_repo.MockOrganizations, mu *mock_repo.MockUsers) {
+				org := testOrg(0)
+				org.Invitations = invitation("user@mail.com", future)
+				mo.EXPECT().GetByInvitation(gomock.Any(), hashToken(token)).Return(org, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "User@mail.com"}, nil)
+				mo.EXPECT().AddMember(gomock.Any(), testOrgID.Hex(), gomock.Any()).DoAndReturn(func(_ context.Context, id string, member *models.Member) error {
+					if member.UserID != "1" || member.Role != models.OrgRoleViewer {
+						t.Fatalf("unexpected member - %+v\n", member)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "ERROR: invitation for another email",
+			behavior: func(mo *mock_repo.MockOrganizations, mu *mock_repo.MockUsers) {
+				org := testOrg(0)
+				org.Invitations = invitation("other@mail.com", future)
+				mo.EXPECT().GetByInvitation(gomock.Any(), hashToken(token)).Return(org, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "user@mail.com"}, nil)
+			},
+			wantError: models.ErrInvalidInvitation,
+		},
+		{
+			name: "ERROR: expired",
+			behavior: func(mo *mock_repo.MockOrganizations, mu *mock_repo.MockUsers) {
+				org := testOrg(0)
+				org.Invitations = invitation("user@mail.com", time.Now().Add(-time.Hour).Unix())
+				mo.EXPECT().GetByInvitation(gomock.Any(), hashToken(token)).Return(org, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "user@mail.com"}, nil)
+			},
+			wantError: models.ErrInvalidInvitation,
+		},
+		{
+			name: "ERROR: unknown token",
+			behavior: func(mo *mock_repo.MockOrganizations, mu *mock_repo.MockUsers) {
+				mo.EXPECT().GetByInvitation(gomock.Any(), hashToken(token)).Return(nil, models.ErrOrganizationNotFound)
+			},
+			wantError: models.ErrInvalidInvitation,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orgs := mock_repo.NewMockOrganizations(ctrl)
+			users := mock_repo.NewMockUsers(ctrl)
+			test.behavior(orgs, users)
+
+			repo := &repo.Repo{Organizations: orgs, Users: users}
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			_, err := services.AcceptInvitation(context.Background(), "1", token)
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+		})
+	}
+}
+
+func Test_SetMemberRole(t *testing.T) {
+	testTable := []struct {
+		name      string
+		members   []models.Member
+		memberID  string
+		role      string
+		behavior  func(*mock_repo.MockOrganizations)
+		wantError error
+	}{
+		{
+			name:     "OK",
+			members:  []models.Member{{UserID: "1", Role: models.OrgRoleOwner}, {UserID: "2", Role: models.OrgRoleViewer}},
+			memberID: "2",
+			role:     models.OrgRoleEditor,
+			behavior: func(mo *mock_repo.MockOrganizations) {
+				mo.EXPECT().SetMemberRole(gomock.Any(), testOrgID.Hex(), "2", models.OrgRoleEditor).Return(nil)
+			},
+		},
+		{
+			name:      "ERROR: last owner",
+			members:   []models.Member{{UserID: "1", Role: models.OrgRoleOwner}, {UserID: "2", Role: models.OrgRoleViewer}},
+			memberID:  "1",
+			role:      models.OrgRoleEditor,
+			behavior:  func(mo *mock_repo.MockOrganizations) {},
+			wantError: models.ErrLastOwner,
+		},
+		{
+			name:      "ERROR: editor can't manage members",
+			members:   []models.Member{{UserID: "1", Role: models.OrgRoleEditor}, {UserID: "2", Role: models.OrgRoleViewer}},
+			memberID:  "2",
+			role:      models.OrgRoleEditor,
+			behavior:  func(mo *mock_repo.MockOrganizations) {},
+			wantError: models.ErrOrgForbidden,
+		},
+		{
+			name:      "ERROR: unknown role",
+			members:   []models.Member{{UserID: "1", Role: models.OrgRoleOwner}, {UserID: "2", Role: models.OrgRoleViewer}},
+			memberID:  "2",
+			role:      "admin",
+			behavior:  func(mo *mock_repo.MockOrganizations) {},
+			wantError: models.ErrInvalidOrgRole,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			orgs := mock_repo.NewMockOrganizations(ctrl)
+			orgs.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(0, test.members...), nil)
+			test.behavior(orgs)
+
+			repo := &repo.Repo{Organizations: orgs}
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			err := services.SetMemberRole(context.Background(), "1", testOrgID.Hex(), test.memberID, test.role)
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+		})
+	}
+}
+
+func Test_SwitchOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	orgs := mock_repo.NewMockOrganizations(ctrl)
+	tokens := mock_services.NewMockTokener(ctrl)
+
+	orgs.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(0, models.Member{UserID: "1", Role: models.OrgRoleViewer}), nil)
+	tokens.EXPECT().GenerateToken("1", "s1", testOrgID.Hex()).Return("org-token", nil)
+	orgs.EXPECT().Get(gomock.Any(), "other").Return(nil, models.ErrOrganizationNotFound)
+
+	repo := &repo.Repo{Organizations: orgs}
+	services := New(repo, tokens, mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	principal := &models.Principal{UserID: "1", SessionID: "s1"}
+
+	token, err := services.SwitchOrganization(context.Background(), principal, testOrgID.Hex())
+	if err != nil || token != "org-token" {
+		t.Fatalf("SwitchOrganization error - %v\n", err)
+	}
+
+	_, err = services.SwitchOrganization(context.Background(), principal, "other")
+	if !errors.Is(err, models.ErrNotOrgMember) {
+		t.Fatalf("unexpected error - %v\n", err)
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/pkg/outbox"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	defaultOutboxPollInterval  = time.Second
+	defaultOutboxLease         = time.Minute
+	defaultOutboxRetryDelay    = 10 * time.Second
+	defaultOutboxMaxRetryDelay = 10 * time.Minute
+	defaultOutboxRetention     = 24 * time.Hour
+
+	outboxCleanupInterval = time.Hour
+)
+
+// relay queues webhook deliveries of events saved to the outbox and publishes them, every replica may run one
+type relay struct {
+	publisher     EventPublisher // nil - events are only delivered to webhooks
+	pollInterval  time.Duration
+	lease         time.Duration
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	retention     time.Duration
+}
+
+func newRelay(cfg *config.Outbox) *relay {
+	r := &relay{
+		publisher:     outbox.NewLog(),
+		pollInterval:  defaultOutboxPollInterval,
+		lease:         defaultOutboxLease,
+		retryDelay:    defaultOutboxRetryDelay,
+		maxRetryDelay: defaultOutboxMaxRetryDelay,
+		retention:     defaultOutboxRetention,
+	}
+
+	if cfg == nil {
+		return r
+	}
+
+	switch cfg.Publisher {
+	case config.PublisherHTTP:
+		r.publisher = outbox.NewHTTP(cfg.URL, cfg.Timeout)
+	case config.PublisherMemory:
+		r.publisher = outbox.NewMemory()
+	case config.PublisherNone:
+		r.publisher = nil
+	}
+
+	if cfg.PollInterval > 0 {
+		r.pollInterval = cfg.PollInterval
+	}
+	if cfg.Lease > 0 {
+		r.lease = cfg.Lease
+	}
+	if cfg.RetryDelay > 0 {
+		r.retryDelay = cfg.RetryDelay
+	}
+	if cfg.MaxRetryDelay > 0 {
+		r.maxRetryDelay = cfg.MaxRetryDelay
+	}
+	if cfg.Retention > 0 {
+		r.retention = cfg.Retention
+	}
+
+	return r
+}
+
+// retryAt returns when the event failed on the attempt is published again, the delay doubles with every attempt
+func (r *relay) retryAt(now time.Time, attempt int) time.Time {
+	delay := r.retryDelay
+	for i := 1; i < attempt && delay < r.maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.maxRetryDelay {
+		delay = r.maxRetryDelay
+	}
+	return now.Add(delay)
+}
+
+// SetEventPublisher replaces the publisher chosen by config, nil disables publishing but not webhook deliveries
+func (s *Services) SetEventPublisher(publisher EventPublisher) {
+	s.relay.publisher = publisher
+}
+
+// addOutbox saves the event with the writes of the transaction in ctx, so it is relayed only when they are committed
+func (s *Services) addOutbox(ctx context.Context, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	return s.db.Outbox.Add(ctx, &models.OutboxEvent{
+		Type:        eventType,
+		Payload:     string(payload),
+		AvailableAt: now,
+		CreatedAt:   now,
+	})
+}
+
+// RunOutboxRelay relays outbox events and deletes published ones after retention until ctx is done
+func (s *Services) RunOutboxRelay(ctx context.Context) {
+	cleanup := time.NewTicker(outboxCleanupInterval)
+	defer cleanup.Stop()
+
+	for ctx.Err() == nil {
+		if s.RelayNextEvent(ctx) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-cleanup.C:
+			s.CleanupOutbox(ctx)
+		case <-time.After(s.relay.pollInterval):
+		}
+	}
+}
+
+// RelayNextEvent claims one due event, queues its webhook deliveries and publishes it, false when there was no event.
+// Relay which crashes after publishing publishes the event again once the claim ends
+func (s *Services) RelayNextEvent(ctx context.Context) bool {
+	now := time.Now()
+	event, err := s.db.Outbox.Claim(ctx, now.Unix(), now.Add(s.relay.lease).Unix())
+	if errors.Is(err, models.ErrOutboxEmpty) {
+		return false
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("outbox claim error - %s\n", err.Error())
+		}
+		return false
+	}
+
+	id := event.ID.Hex()
+
+	err = s.relayEvent(ctx, event)
+	if err != nil {
+		log.Printf("event %s is not published, attempt %d - %s\n", id, event.Attempts, err.Error())
+
+		err = s.db.Outbox.Retry(ctx, id, err.Error(), s.relay.retryAt(time.Now(), event.Attempts).Unix())
+		if err != nil {
+			log.Printf("event %s retry is not saved - %s\n", id, err.Error())
+		}
+		return true
+	}
+
+	// The event is published again when the claim ends
+	err = s.db.Outbox.MarkPublished(ctx, id, time.Now().Unix())
+	if err != nil {
+		log.Printf("event %s is not marked published - %s\n", id, err.Error())
+	}
+
+	return true
+}
+
+// relayEvent queues webhook deliveries once, retries of the event only publish it again
+func (s *Services) relayEvent(ctx context.Context, event *models.OutboxEvent) error {
+	if !event.Dispatched {
+		err := s.db.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+			err := s.queueDeliveries(ctx, event)
+			if err != nil {
+				return err
+			}
+
+			return s.db.Outbox.MarkDispatched(ctx, event.ID.Hex())
+		})
+		if err != nil {
+			return fmt.Errorf("webhook deliveries are not queued - %w", err)
+		}
+	}
+
+	if s.relay.publisher == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.relay.lease)
+	defer cancel()
+
+	return s.relay.publisher.Publish(ctx, event)
+}
+
+// CleanupOutbox deletes events published before the retention
+func (s *Services) CleanupOutbox(ctx context.Context) {
+	deleted, err := s.db.Outbox.DeletePublished(ctx, time.Now().Add(-s.relay.retention).Unix())
+	if err != nil {
+		log.Printf("outbox cleanup error - %s\n", err.Error())
+		return
+	}
+
+	if deleted > 0 {
+		log.Printf("outbox cleanup deleted %d published events\n", deleted)
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/memory"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	"creatly-task/pkg/outbox"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RelayNextEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tokener := mock_services.NewMockTokener(ctrl)
+	tokener.EXPECT().GeneratePurposeToken(gomock.Any(), gomock.Any(), gomock.Any()).Return("token", nil).AnyTimes()
+	mailer := mock_services.NewMockMailer(ctrl)
+	mailer.EXPECT().Send(gomock.Any()).Return(nil).AnyTimes()
+
+	db := memory.New()
+	services := New(db, tokener, mock_services.NewMockCloudStorage(ctrl), mailer, nil, testConfig())
+	publisher := outbox.NewMemory()
+	services.SetEventPublisher(publisher)
+
+	ctx := context.Background()
+	assert.False(t, services.RelayNextEvent(ctx), "outbox is empty")
+
+	user := &models.UserSignUpInput{Email: "some@mail.com", Password: "hash"}
+	err := services.SignUp(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, services.RelayNextEvent(ctx))
+	assert.False(t, services.RelayNextEvent(ctx), "published event isn't relayed again")
+
+	messages := publisher.Messages()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, models.EventUserCreated, messages[0].Type)
+		assert.JSONEq(t, `{"id":"`+user.ID.Hex()+`","email":"some@mail.com"}`, string(messages[0].Data))
+	}
+
+	services.relay.retention = -time.Minute
+	services.CleanupOutbox(ctx)
+	_, err = db.Outbox.Claim(ctx, time.Now().Add(time.Hour).Unix(), 0)
+	assert.True(t, errors.Is(err, models.ErrOutboxEmpty), "published event is deleted")
+}
+
+func Test_RelayNextEvent_Failed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := memory.New()
+	services := New(db, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	publisher := mock_services.NewMockEventPublisher(ctrl)
+	services.SetEventPublisher(publisher)
+
+	ctx := context.Background()
+	hook, err := services.CreateWebhook(ctx, "1", &models.WebhookInput{URL: "http://localhost:9000/hook", Events: []string{models.EventFileDeleted}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = services.addOutbox(ctx, models.EventFileDeleted, &models.FileOut{Filename: "file1.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publisher.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(errors.New("status 503"))
+	assert.True(t, services.RelayNextEvent(ctx))
+	assert.False(t, services.RelayNextEvent(ctx), "failed event waits for retry")
+
+	event, err := db.Outbox.Claim(ctx, services.relay.retryAt(time.Now(), 1).Unix(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "status 503", event.LastError)
+	assert.Equal(t, 2, event.Attempts)
+	assert.True(t, event.Dispatched)
+
+	publisher.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(nil)
+	assert.True(t, services.RelayNextEvent(ctx))
+
+	deliveries, err := services.WebhookDeliveries(ctx, hook.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, deliveries, 1, "retry of the event doesn't queue deliveries again")
+}
+
+func Test_SignUp_OutboxError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	users := mock_repo.NewMockUsers(ctrl)
+	users.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil)
+	events := mock_repo.NewMockOutbox(ctrl)
+	events.EXPECT().Add(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+
+	db := &repo.Repo{Users: users, Outbox: events, Transactions: memory.Transactions{}}
+	services := New(db, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	err := services.SignUp(context.Background(), &models.UserSignUpInput{Email: "some@mail.com"})
+	assert.Error(t, err, "user isn't created without its event")
+}
+
+func Test_newRelay(t *testing.T) {
+	testTable := []struct {
+		name      string
+		cfg       *config.Outbox
+		publisher EventPublisher
+	}{
+		{
+			name:      "OK: log by default",
+			publisher: outbox.NewLog(),
+		},
+		{
+			name:      "OK: http",
+			cfg:       &config.Outbox{Publisher: config.PublisherHTTP, URL: "http://localhost:9000/events", Timeout: time.Second},
+			publisher: outbox.NewHTTP("http://localhost:9000/events", time.Second),
+		},
+		{
+			name: "OK: disabled",
+			cfg:  &config.Outbox{Publisher: config.PublisherNone},
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.publisher, newRelay(test.cfg).publisher)
+		})
+	}
+}
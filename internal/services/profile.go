@@ -0,0 +1,134 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"creatly-task/internal/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const maxDisplayNameLength = 64 // Runes count
+
+func (s *Services) Profile(ctx context.Context, userID string) (*models.User, error) {
+	return s.db.Users.GetUserByID(ctx, userID)
+}
+
+// UpdateProfile sets display name and avatar, avatar must be one of the user's uploaded files
+func (s *Services) UpdateProfile(ctx context.Context, userID string, input *models.ProfileUpdateInput) (*models.User, error) {
+	if input.DisplayName != nil {
+		displayName := strings.TrimSpace(*input.DisplayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength || strings.ContainsAny(displayName, "\r\n\t") {
+			return nil, models.ErrInvalidDisplayName
+		}
+		input.DisplayName = &displayName
+	}
+
+	if input.Avatar != nil && *input.Avatar != "" {
+		files, err := s.db.Files.ByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasFile(files, *input.Avatar) {
+			return nil, models.ErrAvatarNotFound
+		}
+	}
+
+	err := s.db.Users.UpdateProfile(ctx, userID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.Users.GetUserByID(ctx, userID)
+}
+
+func hasFile(files []models.FileOut, filename string) bool {
+	for _, file := range files {
+		if file.Filename == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportData writes ZIP archive with profile, file metadata and original images.
+// Nothing is written to w when user or files metadata can't be loaded
+func (s *Services) ExportData(ctx context.Context, userID string, w io.Writer) error {
+	user, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	files, err := s.db.Files.ByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+
+	err = writeJSON(archive, "profile.json", user)
+	if err != nil {
+		return err
+	}
+
+	err = writeJSON(archive, "files.json", files)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		// Infected uploads aren't stored, their metadata is exported only
+		if file.Status == models.FileQuarantined {
+			continue
+		}
+
+		err = s.exportFile(ctx, archive, &file)
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// exportFile streams object from storage, images are not loaded into memory
+func (s *Services) exportFile(ctx context.Context, archive *zip.Writer, file *models.FileOut) error {
+	object, err := s.cloud.DownloadFile(ctx, file.Filename)
+	if err != nil {
+		return fmt.Errorf("error with download file %s - %w", file.Filename, err)
+	}
+	defer object.Close()
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     "images/" + path.Base(file.Filename),
+		Method:   zip.Store, // Images are compressed already
+		Modified: time.Unix(file.Date, 0),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entry, object)
+	if err != nil {
+		return fmt.Errorf("error with export file %s - %s", file.Filename, err.Error())
+	}
+
+	return nil
+}
+
+func writeJSON(archive *zip.Writer, name string, v interface{}) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func Test_UpdateProfile(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	testTable := []struct {
+		name      string
+		input     *models.ProfileUpdateInput
+		behavior  func(*mock_repo.MockUsers, *mock_repo.MockFiles)
+		wantError error
+	}{
+		{
+			name:  "OK",
+			input: &models.ProfileUpdateInput{DisplayName: str("  Jane  "), Avatar: str("1-1.png")},
+			behavior: func(mu *mock_repo.MockUsers, mf *mock_repo.MockFiles) {
+				mf.EXPECT().ByUser(gomock.Any(), "1").Return([]models.FileOut{{Filename: "1-1.png"}}, nil)
+				mu.EXPECT().UpdateProfile(gomock.Any(), "1", &models.ProfileUpdateInput{DisplayName: str("Jane"), Avatar: str("1-1.png")}).Return(nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{DisplayName: "Jane", Avatar: "1-1.png"}, nil)
+			},
+		},
+		{
+			name:  "OK: clear avatar",
+			input: &models.ProfileUpdateInput{Avatar: str("")},
+			behavior: func(mu *mock_repo.MockUsers, mf *mock_repo.MockFiles) {
+				mu.EXPECT().UpdateProfile(gomock.Any(), "1", &models.ProfileUpdateInput{Avatar: str("")}).Return(nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{}, nil)
+			},
+		},
+		{
+			name:      "ERROR: display name too long",
+			input:     &models.ProfileUpdateInput{DisplayName: str(strings.Repeat("a", maxDisplayNameLength+1))},
+			behavior:  func(mu *mock_repo.MockUsers, mf *mock_repo.MockFiles) {},
+			wantError: models.ErrInvalidDisplayName,
+		},
+		{
+			name:  "ERROR: avatar of another user",
+			input: &models.ProfileUpdateInput{Avatar: str("2-1.png")},
+			behavior: func(mu *mock_repo.MockUsers, mf *mock_repo.MockFiles) {
+				mf.EXPECT().ByUser(gomock.Any(), "1").Return([]models.FileOut{{Filename: "1-1.png"}}, nil)
+			},
+			wantError: models.ErrAvatarNotFound,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			users := mock_repo.NewMockUsers(ctrl)
+			files := mock_repo.NewMockFiles(ctrl)
+			test.behavior(users, files)
+
+			repo := &repo.Repo{Users: users, Files: files}
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			_, err := services.UpdateProfile(context.Background(), "1", test.input)
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+		})
+	}
+}
+
+func Test_ExportData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	users := mock_repo.NewMockUsers(ctrl)
+	files := mock_repo.NewMockFiles(ctrl)
+	cloud := mock_services.NewMockCloudStorage(ctrl)
+
+	users.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "user@mail.com", Password: "hash"}, nil)
+	files.EXPECT().ByUser(gomock.Any(), "1").Return([]models.FileOut{
+		{Filename: "1-1.png", Size: 3},
+		{Filename: "1-2.png", Size: 3, Status: models.FileQuarantined}, // Has no object to download
+	}, nil)
+	cloud.EXPECT().DownloadFile(gomock.Any(), "1-1.png").Return(ioutil.NopCloser(strings.NewReader("png")), nil)
+
+	repo := &repo.Repo{Users: users, Files: files}
+	services := New(repo, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	var buf bytes.Buffer
+	err := services.ExportData(context.Background(), "1", &buf)
+	if err != nil {
+		t.Fatalf("Service ExportData error - %s\n", err.Error())
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("invalid archive - %s\n", err.Error())
+	}
+
+	entries := map[string]string{}
+	for _, file := range archive.File {
+		reader, _ := file.Open()
+		content, _ := ioutil.ReadAll(reader)
+		reader.Close()
+		entries[file.Name] = string(content)
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if strings.Join(names, ",") != "files.json,images/1-1.png,profile.json" {
+		t.Fatalf("unexpected archive entries - %v\n", names)
+	}
+
+	if !strings.Contains(entries["files.json"], "1-2.png") {
+		t.Fatalf("quarantined file is missing in metadata - %s\n", entries["files.json"])
+	}
+
+	if entries["images/1-1.png"] != "png" {
+		t.Fatalf("unexpected image content - %q\n", entries["images/1-1.png"])
+	}
+
+	if !strings.Contains(entries["profile.json"], "user@mail.com") || strings.Contains(entries["profile.json"], "hash") {
+		t.Fatalf("unexpected profile - %s\n", entries["profile.json"])
+	}
+}
+
+func Test_ExportDataUserNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	users := mock_repo.NewMockUsers(ctrl)
+	users.EXPECT().GetUserByID(gomock.Any(), "1").Return(nil, models.ErrUserNotFound)
+
+	repo := &repo.Repo{Users: users}
+	services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	var buf bytes.Buffer
+	err := services.ExportData(context.Background(), "1", &buf)
+	if !errors.Is(err, models.ErrUserNotFound) || buf.Len() != 0 {
+		t.Fatalf("unexpected result - %v, %d bytes written\n", err, buf.Len())
+	}
+}
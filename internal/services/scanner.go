@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/pkg/scanner"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// contentScanner decides what happens to uploads the scanner flags or can't check
+type contentScanner struct {
+	scanner  Scanner // nil - uploads aren't scanned
+	failOpen bool
+}
+
+func newContentScanner(cfg *config.Scanner) *contentScanner {
+	if cfg == nil || cfg.Address == "" {
+		return &contentScanner{}
+	}
+
+	return &contentScanner{
+		scanner:  scanner.NewClamd(cfg.Address, cfg.Timeout),
+		failOpen: cfg.FailMode == config.ScanFailOpen,
+	}
+}
+
+// SetScanner replaces the scanner chosen by config, nil disables scanning
+func (s *Services) SetScanner(scanner Scanner) {
+	s.scanner.scanner = scanner
+}
+
+// scanUpload checks the upload before it is stored. Infected upload is recorded as quarantined and ErrFileQuarantined is returned.
+// When the scanner fails the upload is rejected with ErrScannerUnavailable, or stored unscanned in fail open mode
+func (s *Services) scanUpload(ctx context.Context, file *models.FileUploadInput) error {
+	if s.scanner.scanner == nil {
+		return nil
+	}
+
+	result, err := s.scanner.scanner.Scan(ctx, bytes.NewReader(file.FileData))
+	if err != nil {
+		if s.scanner.failOpen {
+			log.Printf("file %s of user %s is stored unscanned - %s\n", file.Filename, file.UserId, err.Error())
+			return nil
+		}
+
+		log.Printf("file %s of user %s is rejected unscanned - %s\n", file.Filename, file.UserId, err.Error())
+		return models.ErrScannerUnavailable
+	}
+
+	if !result.Infected {
+		return nil
+	}
+
+	// The content never reaches the bucket, the record is kept for review
+	err = s.db.Files.AddLog(ctx, &models.FileUploadLogInput{
+		Size:       file.Size,
+		UploadDate: time.Now().Unix(),
+		Filename:   file.Filename,
+		UserId:     file.UserId,
+		OrgId:      file.OrgId,
+		Status:     models.FileQuarantined,
+		Error:      result.Threat,
+	})
+	if err != nil {
+		return fmt.Errorf("error with log quarantined file - %w", err)
+	}
+
+	log.Printf("file %s of user %s is quarantined, threat %s\n", file.Filename, file.UserId, result.Threat)
+	return fmt.Errorf("%w: %s found", models.ErrFileQuarantined, result.Threat)
+}
+
+// DownloadFile returns content of the file of the active space, quarantined files aren't downloaded
+func (s *Services) DownloadFile(ctx context.Context, principal *models.Principal, id string) (*models.FileOut, io.ReadCloser, error) {
+	file, err := s.File(ctx, principal, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if file.Status == models.FileQuarantined {
+		return nil, nil, models.ErrFileQuarantined
+	}
+
+	content, err := s.cloud.DownloadFile(ctx, file.Filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, content, nil
+}
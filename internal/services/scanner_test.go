@@ -0,0 +1,139 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo/memory"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UploadFile_Scan(t *testing.T) {
+	testTable := []struct {
+		name       string
+		failMode   string
+		behavior   func(*mock_services.MockScanner, *mock_services.MockCloudStorage)
+		wantError  error
+		wantStatus string // Status of the file recorded by the upload, empty - no record
+		wantListed bool
+	}{
+		{
+			name: "OK: clean file is stored",
+			behavior: func(ms *mock_services.MockScanner, mcs *mock_services.MockCloudStorage) {
+				ms.EXPECT().Scan(gomock.Any(), bytes.NewReader([]byte("png"))).Return(&models.ScanResult{}, nil)
+				mcs.EXPECT().UploadFile(gomock.Any(), []byte("png"), int64(3), "file1.png").Return("url", nil)
+			},
+			wantStatus: models.FileProcessing,
+			wantListed: true,
+		},
+		{
+			name: "OK: infected file is quarantined without storing",
+			behavior: func(ms *mock_services.MockScanner, mcs *mock_services.MockCloudStorage) {
+				ms.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(&models.ScanResult{Infected: true, Threat: "Eicar-Test-Signature"}, nil)
+			},
+			wantError:  models.ErrFileQuarantined,
+			wantStatus: models.FileQuarantined,
+		},
+		{
+			name: "ERROR: scanner unavailable, fail closed",
+			behavior: func(ms *mock_services.MockScanner, mcs *mock_services.MockCloudStorage) {
+				ms.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			wantError: models.ErrScannerUnavailable,
+		},
+		{
+			name:     "OK: scanner unavailable, fail open",
+			failMode: config.ScanFailOpen,
+			behavior: func(ms *mock_services.MockScanner, mcs *mock_services.MockCloudStorage) {
+				ms.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+				mcs.EXPECT().UploadFile(gomock.Any(), []byte("png"), int64(3), "file1.png").Return("url", nil)
+			},
+			wantStatus: models.FileProcessing,
+			wantListed: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			scanner := mock_services.NewMockScanner(ctrl)
+			cloud := mock_services.NewMockCloudStorage(ctrl)
+			test.behavior(scanner, cloud)
+
+			cfg := testConfig()
+			cfg.Scanner = &config.Scanner{Address: "localhost:3310", FailMode: test.failMode}
+			db := memory.New()
+			services := New(db, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, cfg)
+			services.SetScanner(scanner)
+
+			ctx := context.Background()
+			_, err := services.UploadFile(ctx, &models.FileUploadInput{Filename: "file1.png", Size: 3, UserId: "1", FileData: []byte("png")})
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("expected error %v, got - %v\n", test.wantError, err)
+			}
+
+			recorded, err := db.Files.ByUser(ctx, "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.wantStatus == "" {
+				assert.Empty(t, recorded)
+			} else if assert.Len(t, recorded, 1) {
+				assert.Equal(t, test.wantStatus, recorded[0].Status)
+			}
+
+			listed, err := services.Files(ctx, &models.Principal{UserID: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.wantListed, len(listed) == 1)
+		})
+	}
+}
+
+func Test_DownloadFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cloud := mock_services.NewMockCloudStorage(ctrl)
+	cloud.EXPECT().DownloadFile(gomock.Any(), "ready.png").Return(ioutil.NopCloser(bytes.NewBufferString("png")), nil)
+
+	db := memory.New()
+	ctx := context.Background()
+	files := []models.FileUploadLogInput{
+		{Filename: "ready.png", Size: 3, UserId: "1", Status: models.FileReady},
+		{Filename: "infected.png", Size: 3, UserId: "1", Status: models.FileQuarantined, Error: "Eicar-Test-Signature"},
+	}
+	for i := range files {
+		err := db.Files.AddLog(ctx, &files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	services := New(db, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, testConfig())
+	principal := &models.Principal{UserID: "1"}
+
+	file, content, err := services.DownloadFile(ctx, principal, files[0].ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer content.Close()
+	data, _ := ioutil.ReadAll(content)
+	assert.Equal(t, "ready.png", file.Filename)
+	assert.Equal(t, "png", string(data))
+
+	_, _, err = services.DownloadFile(ctx, principal, files[1].ID.Hex())
+	assert.True(t, errors.Is(err, models.ErrFileQuarantined))
+
+	// Personal file of another user isn't found, the storage isn't touched
+	_, _, err = services.DownloadFile(ctx, &models.Principal{UserID: "2"}, files[0].ID.Hex())
+	assert.True(t, errors.Is(err, models.ErrFileNotFound))
+}
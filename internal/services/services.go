@@ -1,93 +1,781 @@
 package services
 
 import (
+	"context"
+	"creatly-task/internal/config"
 	"creatly-task/internal/models"
 	"creatly-task/internal/repo"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/auth/oidc"
+	"creatly-task/pkg/events"
+	"creatly-task/pkg/mailer"
+	"creatly-task/pkg/webhook"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
 	"time"
 )
 
+const (
+	defaultUsersPageLimit = 20
+	maxUsersPageLimit     = 100
+
+	defaultVerificationTTL = time.Hour * 24
+	defaultResetTokenTTL   = time.Hour
+
+	resetTokenLength = 32 // Random bytes count
+)
+
 //go:generate mockgen -source=services.go -destination=mocks/mock.go
 
 type Tokener interface {
-	GenerateToken(userId string) (string, error)
-	ParseToken(token string) (string, error)
+	GenerateToken(userId, sessionID, orgID string) (string, error)
+	ParseToken(token string) (*jwtauth.Claims, error)
+	GeneratePurposeToken(subject, purpose string, ttl time.Duration) (string, error)
+	ParsePurposeToken(token, purpose string) (string, error)
+	JWKS() *jwtauth.JWKS
 }
 
 type CloudStorage interface {
-	UploadFile(file []byte, filesize int64, filename string) (string, error)
+	UploadFile(ctx context.Context, file []byte, filesize int64, filename string) (string, error)
+	DeleteFile(ctx context.Context, filename string) error
+	DownloadFile(ctx context.Context, filename string) (io.ReadCloser, error)
+	ListFiles(ctx context.Context) ([]models.StoredObject, error)
+}
+
+type Mailer interface {
+	Send(message *mailer.Message) error
+}
+
+// EventBroker delivers messages published by topic to its subscribers.
+// Subscribe channel is closed when ctx is done or the subscriber is dropped
+type EventBroker interface {
+	Publish(ctx context.Context, topic string, data []byte) error
+	Subscribe(ctx context.Context, topic string) (<-chan []byte, error)
+}
+
+// EventPublisher sends outbox events out of the service, the same event may be sent again after failures
+type EventPublisher interface {
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// Scanner checks content for malware, Infected result carries the threat name
+type Scanner interface {
+	Scan(ctx context.Context, data io.Reader) (*models.ScanResult, error)
+}
+
+// Moderator checks an upload against content rules, broken rules are returned as violations
+type Moderator interface {
+	Moderate(ctx context.Context, upload *models.ModerationInput) ([]models.Violation, error)
+}
+
+type OIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.Claims, error)
 }
 
 type Services struct {
-	db      *repo.Repo
-	tokener Tokener
-	cloud   CloudStorage
+	db          *repo.Repo
+	tokener     Tokener
+	cloud       CloudStorage
+	mailer      Mailer
+	providers   map[string]OIDCProvider
+	adminEmails map[string]struct{}
+
+	publicURL       string
+	resetURL        string
+	requireVerified bool
+	verificationTTL time.Duration
+	resetTokenTTL   time.Duration
+	mfaIssuer       string
+
+	lockout    lockout
+	sessionTTL time.Duration
+
+	orgQuota      int64
+	invitationTTL time.Duration
+
+	reconciler  *reconciler
+	idempotency idempotency
+	workers     *workers
+	webhooks    *webhook.Client
+	events      EventBroker
+	relay       *relay
+	scanner     *contentScanner
+	moderator   Moderator
 }
 
-func New(repo *repo.Repo, tokener Tokener, cloud CloudStorage) *Services {
+func New(repo *repo.Repo, tokener Tokener, cloud CloudStorage, mailer Mailer, providers map[string]OIDCProvider, config *config.Config) *Services {
+	adminEmails := make(map[string]struct{}, len(config.Auth.AdminEmails))
+	for _, email := range config.Auth.AdminEmails {
+		adminEmails[strings.ToLower(strings.TrimSpace(email))] = struct{}{}
+	}
+
+	verificationTTL := config.Auth.VerificationTTL
+	if verificationTTL == 0 {
+		verificationTTL = defaultVerificationTTL
+	}
+
+	resetTokenTTL := config.Auth.ResetTokenTTL
+	if resetTokenTTL == 0 {
+		resetTokenTTL = defaultResetTokenTTL
+	}
+
+	publicURL := strings.TrimSuffix(config.Server.PublicURL, "/")
+	resetURL := config.Auth.ResetURL
+	if resetURL == "" {
+		resetURL = publicURL + "/password/reset"
+	}
+
+	sessionTTL := defaultSessionTTL
+	if config.JWT != nil && config.JWT.TokenTTL > 0 {
+		sessionTTL = time.Second * time.Duration(config.JWT.TokenTTL)
+	}
+
+	var orgQuota int64
+	invitationTTL := defaultInvitationTTL
+	if config.Org != nil {
+		orgQuota = config.Org.DefaultQuota
+		if config.Org.InvitationTTL > 0 {
+			invitationTTL = config.Org.InvitationTTL
+		}
+	}
+
+	mfaIssuer := config.Auth.MFAIssuer
+	if mfaIssuer == "" {
+		mfaIssuer = defaultMFAIssuer
+	}
+
 	return &Services{
-		db:      repo,
-		tokener: tokener,
-		cloud:   cloud,
+		db:          repo,
+		tokener:     tokener,
+		cloud:       cloud,
+		mailer:      mailer,
+		providers:   providers,
+		adminEmails: adminEmails,
+
+		publicURL:       publicURL,
+		resetURL:        resetURL,
+		requireVerified: config.Auth.RequireVerified,
+		verificationTTL: verificationTTL,
+		resetTokenTTL:   resetTokenTTL,
+		mfaIssuer:       mfaIssuer,
+
+		lockout:    newLockout(config.Lockout),
+		sessionTTL: sessionTTL,
+
+		orgQuota:      orgQuota,
+		invitationTTL: invitationTTL,
+
+		reconciler:  newReconciler(config.Files),
+		idempotency: newIdempotency(config.Idempotency),
+		workers:     newWorkers(config.Jobs),
+		webhooks:    newWebhookClient(config.Webhooks),
+		events:      events.NewBus(0),
+		relay:       newRelay(config.Outbox),
+		scanner:     newContentScanner(config.Scanner),
+		moderator:   newModerator(config.Moderation, repo.Users),
+	}
+}
+
+func (s *Services) SignUp(ctx context.Context, user *models.UserSignUpInput) error {
+	user.Role = s.roleFor(user.Email)
+	user.Verified = false
+	user.CreatedAt = time.Now().Unix()
+
+	err := s.db.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		err := s.db.Users.CreateUser(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return s.addOutbox(ctx, models.EventUserCreated, &models.UserEvent{ID: user.ID.Hex(), Email: user.Email})
+	})
+	if err != nil {
+		return err
 	}
+
+	// Account is created already, user can request the mail again
+	err = s.sendVerification(user.Email)
+	if err != nil {
+		log.Printf("verification mail for %s not sent - %s\n", user.Email, err.Error())
+	}
+
+	return nil
 }
 
-func (s *Services) SignUp(user *models.UserSignUpInput) error {
-	return s.db.Users.CreateUser(user)
+func (s *Services) roleFor(email string) string {
+	if _, ok := s.adminEmails[strings.ToLower(email)]; ok {
+		return models.RoleAdmin
+	}
+	return models.RoleUser
 }
 
-func (s *Services) SignIn(user *models.UserSignInInput) (string, error) {
-	userFromDB, err := s.db.Users.GetUserByCreds(user.Email)
+func (s *Services) VerifyEmail(ctx context.Context, token string) error {
+	email, err := s.tokener.ParsePurposeToken(token, jwtauth.PurposeEmailVerification)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	return s.db.Users.SetVerified(ctx, email)
+}
+
+func (s *Services) ResendVerification(ctx context.Context, userID string) error {
+	user, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Verified {
+		return nil
+	}
+
+	return s.sendVerification(user.Email)
+}
+
+func (s *Services) sendVerification(email string) error {
+	token, err := s.tokener.GeneratePurposeToken(email, jwtauth.PurposeEmailVerification, s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", s.publicURL, url.QueryEscape(token))
+
+	return s.mailer.Send(&mailer.Message{
+		To:      email,
+		Subject: "Confirm your email",
+		Body:    fmt.Sprintf("Follow the link to confirm your email:\n%s", link),
+	})
+}
+
+// SignIn checks credentials. After too many failed attempts for the account or IP
+// sign-in is locked and LockedError is returned. With enabled two-factor
+// only pending token is returned, see SignInMFA
+func (s *Services) SignIn(ctx context.Context, user *models.UserSignInInput) (*models.SignInResult, error) {
+	keys := s.attemptKeys(user.Email, user.IP)
+
+	err := s.checkLocked(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	userFromDB, err := s.db.Users.GetUserByCreds(ctx, user.Email)
+	if errors.Is(err, models.ErrUserNotFound) {
+		// Count failures for unknown emails too, so attempts don't reveal existing accounts
+		return nil, s.failSignIn(ctx, keys, err)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	if userFromDB.Password != user.PasswordHash {
-		fmt.Printf("want password - %s\naccepted - %s\n", userFromDB.Password, user.PasswordHash)
-		return "", errors.New("wrong password")
+		return nil, s.failSignIn(ctx, keys, models.ErrWrongPassword)
+	}
+
+	if userFromDB.Disabled {
+		return nil, models.ErrUserDisabled
+	}
+
+	err = s.db.Attempts.Reset(ctx, keys[0].key)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeSignIn(ctx, userFromDB.UserID.Hex(), userFromDB.MFA, &models.ClientInfo{
+		IP:        user.IP,
+		UserAgent: user.UserAgent,
+	})
+}
+
+// completeSignIn starts session, or issues pending token when second factor is required
+func (s *Services) completeSignIn(ctx context.Context, userID string, mfa *models.MFA, client *models.ClientInfo) (*models.SignInResult, error) {
+	if mfa != nil && mfa.Enabled {
+		mfaToken, err := s.tokener.GeneratePurposeToken(userID, jwtauth.PurposeMFA, mfaTokenTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.SignInResult{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	token, err := s.startSession(ctx, userID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SignInResult{Token: token}, nil
+}
+
+func (s *Services) failSignIn(ctx context.Context, keys []attemptKey, reason error) error {
+	err := s.registerFailure(ctx, keys)
+	if err != nil {
+		return err
+	}
+	return reason
+}
+
+// ForgotPassword mails single-use reset link. Unknown emails are ignored silently
+// so the endpoint can't be used to check which accounts exist
+func (s *Services) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.db.Users.GetUserByCreds(ctx, email)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	token, err := s.tokener.GenerateToken(userFromDB.UserID.String())
+	if user.Disabled {
+		return nil
+	}
+
+	token, tokenHash, err := newSecretToken(resetTokenLength)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Users.SetPasswordResetToken(ctx, user.UserID.Hex(), &models.PasswordResetToken{
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(s.resetTokenTTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	separator := "?"
+	if strings.Contains(s.resetURL, "?") {
+		separator = "&"
+	}
+	link := fmt.Sprintf("%s%stoken=%s", s.resetURL, separator, url.QueryEscape(token))
+
+	return s.mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body:    fmt.Sprintf("Follow the link to set a new password:\n%s\n\nIgnore this mail if you didn't request it.", link),
+	})
+}
+
+func (s *Services) ResetPassword(ctx context.Context, token, passwordHash string) error {
+	user, err := s.db.Users.GetUserByResetToken(ctx, hashToken(token))
+	if errors.Is(err, models.ErrUserNotFound) {
+		return models.ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.PasswordReset == nil || user.PasswordReset.ExpiresAt < time.Now().Unix() {
+		return models.ErrInvalidResetToken
+	}
+
+	return s.setPassword(ctx, user.ID.Hex(), passwordHash)
+}
+
+// ChangePassword revokes all sessions and returns token of new session for the current client
+func (s *Services) ChangePassword(ctx context.Context, userID, currentPasswordHash, newPasswordHash string, client *models.ClientInfo) (string, error) {
+	user, err := s.db.Users.GetUserByID(ctx, userID)
 	if err != nil {
 		return "", err
 	}
 
-	return token, nil
+	if user.Password != currentPasswordHash {
+		return "", models.ErrWrongPassword
+	}
+
+	err = s.setPassword(ctx, userID, newPasswordHash)
+	if err != nil {
+		return "", err
+	}
+
+	return s.startSession(ctx, userID, client)
+}
+
+// setPassword updates password and signs out all devices
+func (s *Services) setPassword(ctx context.Context, userID, passwordHash string) error {
+	err := s.db.Users.UpdatePassword(ctx, userID, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Sessions.DeleteByUser(ctx, userID)
 }
 
-func (s *Services) Files() ([]models.FileOut, error) {
-	files, err := s.db.Files.All()
+// Files lists files of the active organization, or personal files of the user when it's not set
+func (s *Services) Files(ctx context.Context, principal *models.Principal) ([]models.FileOut, error) {
+	if principal.OrgID != "" {
+		_, _, err := s.orgMember(ctx, principal.OrgID, principal.UserID)
+		if err != nil {
+			return []models.FileOut{}, err
+		}
+		files, err := s.db.Files.ByOrg(ctx, principal.OrgID)
+		if err != nil {
+			return []models.FileOut{}, err
+		}
+		return listed(files), nil
+	}
+
+	files, err := s.db.Files.ByUser(ctx, principal.UserID)
 	if err != nil {
 		return []models.FileOut{}, err
 	}
-	return files, nil
+	return listed(files), nil
 }
 
-func (s *Services) UploadFile(file *models.FileUploadInput) error {
-	url, err := s.cloud.UploadFile(file.FileData, file.Size, file.Filename)
+// listed drops quarantined files, they are shown only by id
+func listed(files []models.FileOut) []models.FileOut {
+	result := make([]models.FileOut, 0, len(files))
+	for _, file := range files {
+		if file.Status != models.FileQuarantined {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// File returns file with its processing status. Files of other spaces than the active one
+// and personal files of other users aren't found
+func (s *Services) File(ctx context.Context, principal *models.Principal, id string) (*models.FileOut, error) {
+	if principal.OrgID != "" {
+		_, _, err := s.orgMember(ctx, principal.OrgID, principal.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := s.db.Files.Get(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if file.OrgId != principal.OrgID {
+		return nil, models.ErrFileNotFound
+	}
+
+	if file.OrgId == "" && file.UserId != principal.UserID {
+		return nil, models.ErrFileNotFound
+	}
+
+	return file, nil
+}
+
+// UploadFile stores file in personal space, or in organization when file.OrgId is set.
+// Organization uploads need owner or editor role and fit into organization quota.
+// Content breaking the moderation policy is rejected with PolicyError before anything is stored.
+// The file is processed in background, it is returned in processing status
+func (s *Services) UploadFile(ctx context.Context, file *models.FileUploadInput) (*models.FileOut, error) {
+	err := s.moderate(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.OrgId != "" {
+		org, err := s.orgRole(ctx, file.OrgId, file.UserId, models.OrgRoleOwner, models.OrgRoleEditor)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.checkQuota(ctx, org, file.Size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.requireVerified {
+		user, err := s.db.Users.GetUserByID(ctx, file.UserId)
+		if err != nil {
+			return nil, err
+		}
+
+		if !user.Verified {
+			return nil, models.ErrUserNotVerified
+		}
+	}
+
+	err = s.scanUpload(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &models.PendingUpload{
+		Filename:  file.Filename,
+		Size:      file.Size,
+		UserId:    file.UserId,
+		OrgId:     file.OrgId,
+		CreatedAt: time.Now().Unix(),
+	}
+	err = s.db.Uploads.Create(ctx, upload)
+	if err != nil {
+		return nil, fmt.Errorf("error with pending upload - %w", err)
 	}
 
-	err = s.db.Files.AddLog(&models.FileUploadLogInput{
+	url, err := s.cloud.UploadFile(ctx, file.FileData, file.Size, file.Filename)
+	if err != nil {
+		s.discardUpload(ctx, upload)
+		return nil, err
+	}
+
+	uploaded := &models.FileUploadLogInput{
 		Size:       file.Size,
 		UploadDate: time.Now().Unix(),
 		Filename:   file.Filename,
 		UserId:     file.UserId,
+		OrgId:      file.OrgId,
 		Url:        url,
+		Status:     models.FileProcessing,
+	}
+	err = s.addFile(ctx, uploaded)
+	if err != nil {
+		s.discardUpload(ctx, upload)
+		return nil, fmt.Errorf("error with log uploaded file - %w", err)
+	}
+
+	// The upload is recorded already, the reconciler drops pending record left here
+	err = s.db.Uploads.Delete(ctx, upload.ID.Hex())
+	if err != nil {
+		log.Printf("pending upload %s is not finalized - %s\n", upload.ID.Hex(), err.Error())
+	}
+
+	out := newFileOut(uploaded)
+	s.publishFile(ctx, models.FileEventCreated, out)
+
+	return out, nil
+}
+
+// addFile records metadata of stored object together with its upload event and processing job,
+// so a file isn't left processing without a job
+func (s *Services) addFile(ctx context.Context, uploaded *models.FileUploadLogInput) error {
+	return s.db.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		err := s.db.Files.AddLog(ctx, uploaded)
+		if err != nil {
+			return err
+		}
+
+		err = s.enqueue(ctx, models.JobProcessImage, uploaded.ID.Hex())
+		if err != nil {
+			return err
+		}
+
+		return s.addOutbox(ctx, models.EventFileUploaded, newFileOut(uploaded))
 	})
+}
+
+func newFileOut(file *models.FileUploadLogInput) *models.FileOut {
+	return &models.FileOut{
+		ID:       file.ID,
+		Filename: file.Filename,
+		Size:     int(file.Size),
+		Date:     file.UploadDate,
+		UserId:   file.UserId,
+		OrgId:    file.OrgId,
+		Url:      file.Url,
+		Status:   file.Status,
+	}
+}
+
+// DeleteFile removes the file of the active space. Personal files are deleted by their owner,
+// organization files by owners and editors or by the uploader
+func (s *Services) DeleteFile(ctx context.Context, principal *models.Principal, id string) error {
+	file, err := s.File(ctx, principal, id)
 	if err != nil {
-		return fmt.Errorf("error with log uploaded file - %s", err.Error())
+		return err
 	}
 
+	// Personal files of other users aren't found, organization files need a writing role
+	if file.UserId != principal.UserID {
+		_, err = s.orgRole(ctx, file.OrgId, principal.UserID, models.OrgRoleOwner, models.OrgRoleEditor)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.db.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		err := s.db.Files.Delete(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return s.addOutbox(ctx, models.EventFileDeleted, file)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The record is gone, the reconciler removes the object if this fails. Quarantined files have no object
+	if file.Status != models.FileQuarantined {
+		err = s.cloud.DeleteFile(ctx, file.Filename)
+		if err != nil {
+			log.Printf("object %s of deleted file is not removed - %s\n", file.Filename, err.Error())
+		}
+	}
+
+	s.publishFile(ctx, models.FileEventDeleted, file)
+
 	return nil
 }
 
-func (s *Services) ParseToken(token string) (string, error) {
-	userID, err := s.tokener.ParseToken(token)
+// ParseToken returns user, session and active organization of valid access token
+func (s *Services) ParseToken(ctx context.Context, token string) (*models.Principal, error) {
+	claims, err := s.tokener.ParseToken(token)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	user, err := s.db.Users.GetUserByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Disabled {
+		return nil, models.ErrUserDisabled
 	}
-	return userID, nil
+
+	if claims.IssuedAt < user.SessionsRevokedAt {
+		return nil, models.ErrSessionRevoked
+	}
+
+	err = s.checkSession(ctx, claims.Subject, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Principal{
+		UserID:    claims.Subject,
+		SessionID: claims.SessionID,
+		OrgID:     claims.OrgID,
+	}, nil
+}
+
+// JWKS returns public keys which other services use to verify our tokens
+func (s *Services) JWKS() *jwtauth.JWKS {
+	return s.tokener.JWKS()
+}
+
+func (s *Services) IsAdmin(ctx context.Context, userID string) (bool, error) {
+	user, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.Role == models.RoleAdmin, nil
+}
+
+func (s *Services) Users(ctx context.Context, filter *models.UsersFilter) (*models.UsersPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+
+	if filter.Limit < 1 {
+		filter.Limit = defaultUsersPageLimit
+	}
+
+	if filter.Limit > maxUsersPageLimit {
+		filter.Limit = maxUsersPageLimit
+	}
+
+	users, total, err := s.db.Users.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UsersPage{
+		Users: users,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	}, nil
+}
+
+func (s *Services) UserStats(ctx context.Context, userID string) (*models.UserStats, error) {
+	_, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.Files.Stats(ctx, userID)
+}
+
+func (s *Services) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	return s.db.Users.SetDisabled(ctx, userID, disabled)
+}
+
+func (s *Services) ResetUserPassword(ctx context.Context, userID, passwordHash string) error {
+	return s.setPassword(ctx, userID, passwordHash)
+}
+
+// DeleteUser removes the user with all personal files from cloud storage and files collection
+func (s *Services) DeleteUser(ctx context.Context, userID string) error {
+	_, err := s.db.Users.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	orgs, err := s.db.Organizations.ByMember(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, org := range orgs {
+		// Members of the organization would be left without anyone to manage it
+		if org.Member(userID).Role == models.OrgRoleOwner && ownersCount(&org) == 1 && len(org.Members) > 1 {
+			return fmt.Errorf("%w - %s", models.ErrLastOwner, org.Name)
+		}
+	}
+
+	files, err := s.db.Files.ByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.Status == models.FileQuarantined {
+			continue
+		}
+
+		err = s.cloud.DeleteFile(ctx, file.Filename)
+		if err != nil {
+			return fmt.Errorf("error with delete file %s - %w", file.Filename, err)
+		}
+	}
+
+	err = s.db.Transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		err := s.db.Files.DeleteByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		for i := range files {
+			err = s.addOutbox(ctx, models.EventFileDeleted, &files[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range files {
+		s.publishFile(ctx, models.FileEventDeleted, &files[i])
+	}
+
+	err = s.db.APIKeys.DeleteByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Sessions.DeleteByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Organization files uploaded by the user stay in organizations
+	err = s.db.Organizations.RemoveUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Users.Delete(ctx, userID)
 }
@@ -1,12 +1,19 @@
 package services
 
 import (
+	"context"
+	"creatly-task/internal/config"
 	"creatly-task/internal/models"
 	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/memory"
 	mock_repo "creatly-task/internal/repo/mocks"
 	mock_services "creatly-task/internal/services/mocks"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"creatly-task/pkg/mailer"
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,12 +25,63 @@ import (
 // 	return services
 // }
 
+func testConfig() *config.Config {
+	return &config.Config{
+		Server: &config.Server{PublicURL: "http://localhost:8000"},
+		Auth:   &config.Auth{},
+	}
+}
+
+var testSessionID = primitive.ObjectID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+
+// expectSessions makes sessions repo assign testSessionID to every created session
+func expectSessions(sessions *mock_repo.MockSessions) {
+	sessions.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, session *models.Session) error {
+		session.ID = testSessionID
+		return nil
+	}).AnyTimes()
+}
+
+// noWebhooks is webhooks repo without subscriptions, events are dropped
+func noWebhooks(ctrl *gomock.Controller) *mock_repo.MockWebhooks {
+	webhooks := mock_repo.NewMockWebhooks(ctrl)
+	webhooks.EXPECT().ByEvent(gomock.Any(), gomock.Any()).Return([]models.Webhook{}, nil).AnyTimes()
+	return webhooks
+}
+
+// stamped matches a pointer to struct equal to want, except for the timestamp
+// field which has to be within a few seconds of now
+type stamped struct {
+	want  interface{}
+	field string
+}
+
+func (m stamped) Matches(x interface{}) bool {
+	got, want := reflect.ValueOf(x), reflect.ValueOf(m.want)
+	if got.Type() != want.Type() || got.IsNil() {
+		return false
+	}
+	stamp := got.Elem().FieldByName(m.field).Int()
+	if d := time.Now().Unix() - stamp; d < 0 || d > 5 {
+		return false
+	}
+
+	copied := reflect.New(got.Elem().Type())
+	copied.Elem().Set(got.Elem())
+	copied.Elem().FieldByName(m.field).SetInt(want.Elem().FieldByName(m.field).Int())
+	return reflect.DeepEqual(copied.Interface(), m.want)
+}
+
+func (m stamped) String() string {
+	return fmt.Sprintf("is equal to %+v with %s close to now", m.want, m.field)
+}
+
 func Test_SignUp(t *testing.T) {
 	testTable := []struct {
 		name      string
 		expect    error
 		input     models.UserSignUpInput
-		behavior  func(*mock_repo.MockUsers)
+		behavior  func(*mock_repo.MockUsers, *mock_services.MockTokener, *mock_services.MockMailer)
 		wantError bool
 	}{
 		{
@@ -33,14 +91,35 @@ func Test_SignUp(t *testing.T) {
 				Email:    "some@mail.com",
 				Password: "SuperStrongPassword",
 			},
-			behavior: func(mu *mock_repo.MockUsers) {
-				mu.EXPECT().CreateUser(&models.UserSignUpInput{
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, mm *mock_services.MockMailer) {
+				mu.EXPECT().CreateUser(gomock.Any(), stamped{&models.UserSignUpInput{
 					Email:    "some@mail.com",
 					Password: "SuperStrongPassword",
+					Role:     models.RoleUser,
+				}, "CreatedAt"}).Return(nil)
+				mt.EXPECT().GeneratePurposeToken("some@mail.com", jwtauth.PurposeEmailVerification, time.Hour*24).Return("token", nil)
+				mm.EXPECT().Send(&mailer.Message{
+					To:      "some@mail.com",
+					Subject: "Confirm your email",
+					Body:    "Follow the link to confirm your email:\nhttp://localhost:8000/verify-email?token=token",
 				}).Return(nil)
 			},
 			wantError: false,
 		},
+		{
+			name:   "OK: mail error doesn't fail sign-up",
+			expect: nil,
+			input: models.UserSignUpInput{
+				Email:    "some@mail.com",
+				Password: "SuperStrongPassword",
+			},
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, mm *mock_services.MockMailer) {
+				mu.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(nil)
+				mt.EXPECT().GeneratePurposeToken("some@mail.com", jwtauth.PurposeEmailVerification, time.Hour*24).Return("token", nil)
+				mm.EXPECT().Send(gomock.Any()).Return(errors.New("smtp error"))
+			},
+			wantError: false,
+		},
 		{
 			name:   "ERROR: user not created, error in db",
 			expect: errors.New("database error"),
@@ -48,11 +127,12 @@ func Test_SignUp(t *testing.T) {
 				Email:    "some@mail.com",
 				Password: "SuperStrongPassword",
 			},
-			behavior: func(mu *mock_repo.MockUsers) {
-				mu.EXPECT().CreateUser(&models.UserSignUpInput{
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, mm *mock_services.MockMailer) {
+				mu.EXPECT().CreateUser(gomock.Any(), stamped{&models.UserSignUpInput{
 					Email:    "some@mail.com",
 					Password: "SuperStrongPassword",
-				}).Return(errors.New("database error"))
+					Role:     models.RoleUser,
+				}, "CreatedAt"}).Return(errors.New("database error"))
 			},
 			wantError: true,
 		},
@@ -62,24 +142,32 @@ func Test_SignUp(t *testing.T) {
 
 		ctrl := gomock.NewController(t)
 		usersRepo := mock_repo.NewMockUsers(ctrl)
-		tokenRepo := mock_repo.NewMockTokens(ctrl)
+		sessionsRepo := mock_repo.NewMockSessions(ctrl)
 		filesRepo := mock_repo.NewMockFiles(ctrl)
 		repo := &repo.Repo{
-			Users:  usersRepo,
-			Tokens: tokenRepo,
-			Files:  filesRepo,
+			Users:        usersRepo,
+			Sessions:     sessionsRepo,
+			Files:        filesRepo,
+			Webhooks:     noWebhooks(ctrl),
+			Outbox:       memory.NewOutbox(),
+			Transactions: memory.Transactions{},
 		}
 		tokens := mock_services.NewMockTokener(ctrl)
 		cloud := mock_services.NewMockCloudStorage(ctrl)
+		mailer := mock_services.NewMockMailer(ctrl)
 
-		test.behavior(usersRepo)
+		test.behavior(usersRepo, tokens, mailer)
 
-		services := New(repo, tokens, cloud)
+		services := New(repo, tokens, cloud, mailer, nil, testConfig())
 
-		err := services.SignUp(&test.input)
+		err := services.SignUp(context.Background(), &test.input)
 		if err != nil && err != test.expect && !test.wantError {
 			t.Fatalf("error service SignUp - %s\n", err.Error())
 		}
+
+		if err == nil && test.wantError {
+			t.Fatalf("%s: expected error\n", test.name)
+		}
 	}
 
 }
@@ -88,7 +176,7 @@ func Test_SignIn(t *testing.T) {
 	testTable := []struct {
 		name      string
 		input     models.UserSignInInput
-		behavior  func(*mock_repo.MockUsers, *mock_services.MockTokener)
+		behavior  func(*mock_repo.MockUsers, *mock_services.MockTokener, *mock_repo.MockAttempts)
 		wantError bool
 		outToken  string
 	}{
@@ -97,14 +185,18 @@ func Test_SignIn(t *testing.T) {
 			input: models.UserSignInInput{
 				Email:        "some@mail.com",
 				PasswordHash: "wd781bpi2du08237f82v",
+				IP:           "192.0.2.1",
 			},
-			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener) {
-				mu.EXPECT().GetUserByCreds("some@mail.com").Return(&models.UserSignInOutput{
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, ma *mock_repo.MockAttempts) {
+				ma.EXPECT().Get(gomock.Any(), "account:some@mail.com").Return(&models.LoginAttempts{Key: "account:some@mail.com"}, nil)
+				ma.EXPECT().Get(gomock.Any(), "ip:192.0.2.1").Return(&models.LoginAttempts{Key: "ip:192.0.2.1"}, nil)
+				mu.EXPECT().GetUserByCreds(gomock.Any(), "some@mail.com").Return(&models.UserSignInOutput{
 					UserID:   primitive.ObjectID{53, 50, 51, 52, 53, 54, 50, 56, 57, 58, 49},
 					Email:    "some@mail.com",
 					Password: "wd781bpi2du08237f82v",
 				}, nil)
-				mt.EXPECT().GenerateToken(primitive.ObjectID{53, 50, 51, 52, 53, 54, 50, 56, 57, 58, 49}.String()).Return("token", nil)
+				ma.EXPECT().Reset(gomock.Any(), "account:some@mail.com").Return(nil)
+				mt.EXPECT().GenerateToken(primitive.ObjectID{53, 50, 51, 52, 53, 54, 50, 56, 57, 58, 49}.Hex(), testSessionID.Hex(), "").Return("token", nil)
 			},
 			wantError: false,
 			outToken:  "token",
@@ -114,44 +206,85 @@ func Test_SignIn(t *testing.T) {
 			input: models.UserSignInInput{
 				Email:        "some@mail.com",
 				PasswordHash: "wd781bpi2du08237f82v",
+				IP:           "192.0.2.1",
 			},
-			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener) {
-				mu.EXPECT().GetUserByCreds("some@mail.com").Return(&models.UserSignInOutput{
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, ma *mock_repo.MockAttempts) {
+				ma.EXPECT().Get(gomock.Any(), "account:some@mail.com").Return(&models.LoginAttempts{Key: "account:some@mail.com"}, nil)
+				ma.EXPECT().Get(gomock.Any(), "ip:192.0.2.1").Return(&models.LoginAttempts{Key: "ip:192.0.2.1"}, nil)
+				mu.EXPECT().GetUserByCreds(gomock.Any(), "some@mail.com").Return(&models.UserSignInOutput{
 					UserID:   primitive.ObjectID{53, 50, 51, 52, 53, 54, 50, 56, 57, 58, 49},
 					Email:    "some@mail.com",
 					Password: "wd781bpi2du08237f82v",
 				}, nil)
-				mt.EXPECT().GenerateToken(primitive.ObjectID{53, 50, 51, 52, 53, 54, 50, 56, 57, 58, 49}.String()).Return("", nil) // Here error
+				ma.EXPECT().Reset(gomock.Any(), "account:some@mail.com").Return(nil)
+				mt.EXPECT().GenerateToken(primitive.ObjectID{53, 50, 51, 52, 53, 54, 50, 56, 57, 58, 49}.Hex(), testSessionID.Hex(), "").Return("", nil) // Here error
 			},
 			wantError: true,
 			outToken:  "token",
 		},
+		{
+			name: "ERROR: wrong password registers failure and locks account",
+			input: models.UserSignInInput{
+				Email:        "some@mail.com",
+				PasswordHash: "wrong",
+				IP:           "192.0.2.1",
+			},
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, ma *mock_repo.MockAttempts) {
+				ma.EXPECT().Get(gomock.Any(), "account:some@mail.com").Return(&models.LoginAttempts{Key: "account:some@mail.com"}, nil).Times(2)
+				ma.EXPECT().Get(gomock.Any(), "ip:192.0.2.1").Return(&models.LoginAttempts{Key: "ip:192.0.2.1"}, nil).Times(2)
+				mu.EXPECT().GetUserByCreds(gomock.Any(), "some@mail.com").Return(&models.UserSignInOutput{
+					Email:    "some@mail.com",
+					Password: "wd781bpi2du08237f82v",
+				}, nil)
+				ma.EXPECT().AddFailure(gomock.Any(), "account:some@mail.com", gomock.Any()).Return(&models.LoginAttempts{Failures: 5}, nil)
+				ma.EXPECT().Lock(gomock.Any(), "account:some@mail.com", gomock.Any()).Return(nil)
+				ma.EXPECT().AddFailure(gomock.Any(), "ip:192.0.2.1", gomock.Any()).Return(&models.LoginAttempts{Failures: 5}, nil)
+			},
+			wantError: true,
+		},
+		{
+			name: "ERROR: locked account",
+			input: models.UserSignInInput{
+				Email:        "some@mail.com",
+				PasswordHash: "wd781bpi2du08237f82v",
+				IP:           "192.0.2.1",
+			},
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener, ma *mock_repo.MockAttempts) {
+				ma.EXPECT().Get(gomock.Any(), "account:some@mail.com").Return(&models.LoginAttempts{LockedUntil: time.Now().Add(time.Minute).Unix()}, nil)
+				ma.EXPECT().Get(gomock.Any(), "ip:192.0.2.1").Return(&models.LoginAttempts{}, nil)
+			},
+			wantError: true,
+		},
 	}
 
 	for _, test := range testTable {
 		t.Run(test.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			usersRepo := mock_repo.NewMockUsers(ctrl)
-			tokenRepo := mock_repo.NewMockTokens(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
 			filesRepo := mock_repo.NewMockFiles(ctrl)
+			attemptsRepo := mock_repo.NewMockAttempts(ctrl)
 			repo := &repo.Repo{
-				Users:  usersRepo,
-				Tokens: tokenRepo,
-				Files:  filesRepo,
+				Users:    usersRepo,
+				Sessions: sessionsRepo,
+				Files:    filesRepo,
+				Attempts: attemptsRepo,
 			}
 			tokens := mock_services.NewMockTokener(ctrl)
 			cloud := mock_services.NewMockCloudStorage(ctrl)
+			mailer := mock_services.NewMockMailer(ctrl)
 
-			test.behavior(usersRepo, tokens)
+			expectSessions(sessionsRepo)
+			test.behavior(usersRepo, tokens, attemptsRepo)
 
-			services := New(repo, tokens, cloud)
+			services := New(repo, tokens, cloud, mailer, nil, testConfig())
 
-			token, err := services.SignIn(&test.input)
+			result, err := services.SignIn(context.Background(), &test.input)
 			if err != nil && !test.wantError {
 				t.Fatalf("SignIn error - %s\n", err.Error())
 			}
 
-			if test.outToken != token && !test.wantError {
+			if !test.wantError && (result == nil || test.outToken != result.Token) {
 				t.Fatal("unexpected token")
 			}
 
@@ -159,6 +292,29 @@ func Test_SignIn(t *testing.T) {
 	}
 }
 
+func Test_lockoutDuration(t *testing.T) {
+	l := newLockout(&config.Lockout{BaseDuration: time.Minute, MaxDuration: time.Minute * 10})
+
+	testTable := []struct {
+		failures int
+		expect   time.Duration
+	}{
+		{failures: 4, expect: 0},
+		{failures: 5, expect: time.Minute},
+		{failures: 6, expect: time.Minute * 2},
+		{failures: 8, expect: time.Minute * 8},
+		{failures: 9, expect: time.Minute * 10},
+		{failures: 100, expect: time.Minute * 10},
+	}
+
+	for _, test := range testTable {
+		duration := l.duration(test.failures, 5)
+		if duration != test.expect {
+			t.Fatalf("failures %d\nReceived - %s\nWant - %s\n", test.failures, duration, test.expect)
+		}
+	}
+}
+
 func Test_Files(t *testing.T) {
 	testTable := []struct {
 		name      string
@@ -169,7 +325,7 @@ func Test_Files(t *testing.T) {
 		{
 			name: "OK",
 			behavior: func(mf *mock_repo.MockFiles) {
-				mf.EXPECT().All().Return([]models.FileOut{
+				mf.EXPECT().ByUser(gomock.Any(), "1").Return([]models.FileOut{
 					{
 						Filename: "file 1",
 						Size:     100,
@@ -191,9 +347,9 @@ func Test_Files(t *testing.T) {
 			},
 		},
 		{
-			name: "ERROR: error in Files.All()",
+			name: "ERROR: error in Files.ByUser()",
 			behavior: func(mf *mock_repo.MockFiles) {
-				mf.EXPECT().All().Return([]models.FileOut{}, errors.New("some error"))
+				mf.EXPECT().ByUser(gomock.Any(), "1").Return([]models.FileOut{}, errors.New("some error"))
 			},
 			wantError: true,
 			outFiles:  []models.FileOut{},
@@ -204,21 +360,22 @@ func Test_Files(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			usersRepo := mock_repo.NewMockUsers(ctrl)
-			tokenRepo := mock_repo.NewMockTokens(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
 			filesRepo := mock_repo.NewMockFiles(ctrl)
 			repo := &repo.Repo{
-				Users:  usersRepo,
-				Tokens: tokenRepo,
-				Files:  filesRepo,
+				Users:    usersRepo,
+				Sessions: sessionsRepo,
+				Files:    filesRepo,
 			}
 			tokens := mock_services.NewMockTokener(ctrl)
 			cloud := mock_services.NewMockCloudStorage(ctrl)
+			mailer := mock_services.NewMockMailer(ctrl)
 
 			test.behavior(filesRepo)
 
-			services := New(repo, tokens, cloud)
+			services := New(repo, tokens, cloud, mailer, nil, testConfig())
 
-			files, err := services.Files()
+			files, err := services.Files(context.Background(), &models.Principal{UserID: "1"})
 
 			if err != nil && !test.wantError {
 				t.Fatalf("Service Files error - %s\n", err.Error())
@@ -232,26 +389,280 @@ func Test_Files(t *testing.T) {
 	}
 }
 
+func Test_File(t *testing.T) {
+	personal := &models.FileOut{Filename: "file 1", UserId: "1", Status: models.FileReady}
+	ofOrg := &models.FileOut{Filename: "file 2", UserId: "2", OrgId: testOrgID.Hex(), Status: models.FileProcessing}
+
+	testTable := []struct {
+		name      string
+		principal models.Principal
+		behavior  func(*mock_repo.MockFiles, *mock_repo.MockOrganizations)
+		expect    *models.FileOut
+		wantError error
+	}{
+		{
+			name:      "OK: personal file",
+			principal: models.Principal{UserID: "1"},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations) {
+				mf.EXPECT().Get(gomock.Any(), "id").Return(personal, nil)
+			},
+			expect: personal,
+		},
+		{
+			name:      "OK: file of active organization",
+			principal: models.Principal{UserID: "1", OrgID: testOrgID.Hex()},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations) {
+				mo.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(0, models.Member{UserID: "1", Role: models.OrgRoleViewer}), nil)
+				mf.EXPECT().Get(gomock.Any(), "id").Return(ofOrg, nil)
+			},
+			expect: ofOrg,
+		},
+		{
+			name:      "ERROR: file of other space",
+			principal: models.Principal{UserID: "1"},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations) {
+				mf.EXPECT().Get(gomock.Any(), "id").Return(ofOrg, nil)
+			},
+			wantError: models.ErrFileNotFound,
+		},
+		{
+			name:      "ERROR: personal file of other user",
+			principal: models.Principal{UserID: "2"},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations) {
+				mf.EXPECT().Get(gomock.Any(), "id").Return(personal, nil)
+			},
+			wantError: models.ErrFileNotFound,
+		},
+		{
+			name:      "ERROR: not a member",
+			principal: models.Principal{UserID: "1", OrgID: testOrgID.Hex()},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations) {
+				mo.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(0, models.Member{UserID: "2", Role: models.OrgRoleOwner}), nil)
+			},
+			wantError: models.ErrNotOrgMember,
+		},
+		{
+			name:      "ERROR: not found",
+			principal: models.Principal{UserID: "1"},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations) {
+				mf.EXPECT().Get(gomock.Any(), "id").Return(nil, models.ErrFileNotFound)
+			},
+			wantError: models.ErrFileNotFound,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			filesRepo := mock_repo.NewMockFiles(ctrl)
+			orgsRepo := mock_repo.NewMockOrganizations(ctrl)
+			test.behavior(filesRepo, orgsRepo)
+
+			repo := &repo.Repo{Files: filesRepo, Organizations: orgsRepo}
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			file, err := services.File(context.Background(), &test.principal, "id")
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+			if !reflect.DeepEqual(file, test.expect) {
+				t.Fatalf("unexpected file\nReceived - %+v\nWant - %+v\n", file, test.expect)
+			}
+		})
+	}
+}
+
+func Test_DeleteFile(t *testing.T) {
+	personal := &models.FileOut{Filename: "file 1", UserId: "1"}
+	ofOrg := &models.FileOut{Filename: "file 2", UserId: "2", OrgId: testOrgID.Hex()}
+
+	testTable := []struct {
+		name      string
+		principal models.Principal
+		behavior  func(*mock_repo.MockFiles, *mock_repo.MockOrganizations, *mock_services.MockCloudStorage)
+		wantError error
+	}{
+		{
+			name:      "OK: own file",
+			principal: models.Principal{UserID: "1"},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations, mcs *mock_services.MockCloudStorage) {
+				mf.EXPECT().Get(gomock.Any(), "id").Return(personal, nil)
+				mf.EXPECT().Delete(gomock.Any(), "id").Return(nil)
+				mcs.EXPECT().DeleteFile(gomock.Any(), "file 1").Return(nil)
+			},
+		},
+		{
+			name:      "OK: storage error is left to reconciler",
+			principal: models.Principal{UserID: "1"},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations, mcs *mock_services.MockCloudStorage) {
+				mf.EXPECT().Get(gomock.Any(), "id").Return(personal, nil)
+				mf.EXPECT().Delete(gomock.Any(), "id").Return(nil)
+				mcs.EXPECT().DeleteFile(gomock.Any(), "file 1").Return(errors.New("storage error"))
+			},
+		},
+		{
+			name:      "OK: editor deletes file of organization",
+			principal: models.Principal{UserID: "1", OrgID: testOrgID.Hex()},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations, mcs *mock_services.MockCloudStorage) {
+				mo.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(0, models.Member{UserID: "1", Role: models.OrgRoleEditor}), nil).Times(2)
+				mf.EXPECT().Get(gomock.Any(), "id").Return(ofOrg, nil)
+				mf.EXPECT().Delete(gomock.Any(), "id").Return(nil)
+				mcs.EXPECT().DeleteFile(gomock.Any(), "file 2").Return(nil)
+			},
+		},
+		{
+			name:      "ERROR: personal file of other user",
+			principal: models.Principal{UserID: "2"},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations, mcs *mock_services.MockCloudStorage) {
+				mf.EXPECT().Get(gomock.Any(), "id").Return(personal, nil)
+			},
+			wantError: models.ErrFileNotFound,
+		},
+		{
+			name:      "ERROR: viewer of organization",
+			principal: models.Principal{UserID: "1", OrgID: testOrgID.Hex()},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations, mcs *mock_services.MockCloudStorage) {
+				mo.EXPECT().Get(gomock.Any(), testOrgID.Hex()).Return(testOrg(0, models.Member{UserID: "1", Role: models.OrgRoleViewer}), nil).Times(2)
+				mf.EXPECT().Get(gomock.Any(), "id").Return(ofOrg, nil)
+			},
+			wantError: models.ErrOrgForbidden,
+		},
+		{
+			name:      "ERROR: not found",
+			principal: models.Principal{UserID: "1"},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations, mcs *mock_services.MockCloudStorage) {
+				mf.EXPECT().Get(gomock.Any(), "id").Return(nil, models.ErrFileNotFound)
+			},
+			wantError: models.ErrFileNotFound,
+		},
+		{
+			name:      "ERROR: record is not deleted",
+			principal: models.Principal{UserID: "1"},
+			behavior: func(mf *mock_repo.MockFiles, mo *mock_repo.MockOrganizations, mcs *mock_services.MockCloudStorage) {
+				mf.EXPECT().Get(gomock.Any(), "id").Return(personal, nil)
+				mf.EXPECT().Delete(gomock.Any(), "id").Return(errors.New("db error"))
+			},
+			wantError: errors.New("db error"),
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			filesRepo := mock_repo.NewMockFiles(ctrl)
+			orgsRepo := mock_repo.NewMockOrganizations(ctrl)
+			cloud := mock_services.NewMockCloudStorage(ctrl)
+			test.behavior(filesRepo, orgsRepo, cloud)
+
+			repo := &repo.Repo{Files: filesRepo, Organizations: orgsRepo, Webhooks: noWebhooks(ctrl), Outbox: memory.NewOutbox(), Transactions: memory.Transactions{}}
+			services := New(repo, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			err := services.DeleteFile(context.Background(), &test.principal, "id")
+			if !reflect.DeepEqual(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+		})
+	}
+}
+
 func Test_UploadFile(t *testing.T) {
+	uploadID := primitive.NewObjectID()
+	fileID := primitive.NewObjectID()
+	createUpload := func(mu *mock_repo.MockUploads, size int64) {
+		mu.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, upload *models.PendingUpload) error {
+			if upload.Filename != "file1.png" || upload.Size != size || upload.UserId != "1" {
+				t.Fatalf("unexpected pending upload - %+v\n", upload)
+			}
+			upload.ID = uploadID
+			return nil
+		})
+	}
+
 	testTable := []struct {
 		name        string
-		behavior    func(*mock_services.MockCloudStorage, *mock_repo.MockFiles)
+		behavior    func(*mock_services.MockCloudStorage, *mock_repo.MockFiles, *mock_repo.MockUploads, *mock_repo.MockJobs)
 		wantError   bool
+		wantStatus  string
 		inputUpload models.FileUploadInput
 	}{
 		{
 			name: "OK",
-			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles) {
-				mcs.EXPECT().UploadFile([]byte{}, int64(10000), "file1.png").Return("https://s3.storage.com/1", nil)
-				mf.EXPECT().AddLog(&models.FileUploadLogInput{
-					Size:       10000,
-					UploadDate: time.Now().Unix(),
-					Filename:   "file1.png",
-					UserId:     "1",
-					Url:        "https://s3.storage.com/1",
-				}).Return(nil)
+			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs) {
+				createUpload(mu, 10000)
+				mcs.EXPECT().UploadFile(gomock.Any(), []byte{}, int64(10000), "file1.png").Return("https://s3.storage.com/1", nil)
+				mf.EXPECT().AddLog(gomock.Any(), stamped{&models.FileUploadLogInput{
+					Size:     10000,
+					Filename: "file1.png",
+					UserId:   "1",
+					Url:      "https://s3.storage.com/1",
+					Status:   models.FileProcessing,
+				}, "UploadDate"}).DoAndReturn(func(_ context.Context, log *models.FileUploadLogInput) error {
+					log.ID = fileID
+					return nil
+				})
+				mu.EXPECT().Delete(gomock.Any(), uploadID.Hex()).Return(nil)
+				mj.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *models.Job) error {
+					if job.Type != models.JobProcessImage || job.Payload != fileID.Hex() || job.State != models.JobPending {
+						t.Fatalf("unexpected job - %+v\n", job)
+					}
+					return nil
+				})
 			},
-			wantError: false,
+			wantStatus: models.FileProcessing,
+			inputUpload: models.FileUploadInput{
+				FileData: []byte{},
+				Size:     10000,
+				Filename: "file1.png",
+				UserId:   "1",
+			},
+		},
+		{
+			name: "ERROR: processing is not queued",
+			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs) {
+				createUpload(mu, 10000)
+				mcs.EXPECT().UploadFile(gomock.Any(), []byte{}, int64(10000), "file1.png").Return("https://s3.storage.com/1", nil)
+				mf.EXPECT().AddLog(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, log *models.FileUploadLogInput) error {
+					log.ID = fileID
+					return nil
+				})
+				mj.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+				mcs.EXPECT().DeleteFile(gomock.Any(), "file1.png").Return(nil)
+				mu.EXPECT().Delete(gomock.Any(), uploadID.Hex()).Return(nil)
+			},
+			wantError: true,
+			inputUpload: models.FileUploadInput{
+				FileData: []byte{},
+				Size:     10000,
+				Filename: "file1.png",
+				UserId:   "1",
+			},
+		},
+		{
+			name: "OK: pending upload is left to reconciler",
+			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs) {
+				createUpload(mu, 10000)
+				mcs.EXPECT().UploadFile(gomock.Any(), []byte{}, int64(10000), "file1.png").Return("https://s3.storage.com/1", nil)
+				mf.EXPECT().AddLog(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, log *models.FileUploadLogInput) error {
+					log.ID = fileID
+					return nil
+				})
+				mu.EXPECT().Delete(gomock.Any(), uploadID.Hex()).Return(errors.New("db error"))
+				mj.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus: models.FileProcessing,
+			inputUpload: models.FileUploadInput{
+				FileData: []byte{},
+				Size:     10000,
+				Filename: "file1.png",
+				UserId:   "1",
+			},
+		},
+		{
+			name: "ERROR: pending upload error",
+			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs) {
+				mu.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			wantError: true,
 			inputUpload: models.FileUploadInput{
 				FileData: []byte{},
 				Size:     10000,
@@ -261,8 +672,11 @@ func Test_UploadFile(t *testing.T) {
 		},
 		{
 			name: "ERROR: upload error",
-			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles) {
-				mcs.EXPECT().UploadFile([]byte{}, int64(60000000), "file1.png").Return("", errors.New("uploading error"))
+			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs) {
+				createUpload(mu, 60000000)
+				mcs.EXPECT().UploadFile(gomock.Any(), []byte{}, int64(60000000), "file1.png").Return("", errors.New("uploading error"))
+				mcs.EXPECT().DeleteFile(gomock.Any(), "file1.png").Return(nil)
+				mu.EXPECT().Delete(gomock.Any(), uploadID.Hex()).Return(nil)
 			},
 			wantError: true,
 			inputUpload: models.FileUploadInput{
@@ -274,15 +688,18 @@ func Test_UploadFile(t *testing.T) {
 		},
 		{
 			name: "ERROR: add log error",
-			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles) {
-				mcs.EXPECT().UploadFile([]byte{}, int64(60000000), "file1.png").Return("https://s3.storage.com/1", nil)
-				mf.EXPECT().AddLog(&models.FileUploadLogInput{
-					Size:       60000000,
-					UploadDate: time.Now().Unix(),
-					Filename:   "file1.png",
-					UserId:     "1",
-					Url:        "https://s3.storage.com/1",
-				}).Return(errors.New("add log error"))
+			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs) {
+				createUpload(mu, 60000000)
+				mcs.EXPECT().UploadFile(gomock.Any(), []byte{}, int64(60000000), "file1.png").Return("https://s3.storage.com/1", nil)
+				mf.EXPECT().AddLog(gomock.Any(), stamped{&models.FileUploadLogInput{
+					Size:     60000000,
+					Filename: "file1.png",
+					UserId:   "1",
+					Url:      "https://s3.storage.com/1",
+					Status:   models.FileProcessing,
+				}, "UploadDate"}).Return(errors.New("add log error"))
+				mcs.EXPECT().DeleteFile(gomock.Any(), "file1.png").Return(nil)
+				mu.EXPECT().Delete(gomock.Any(), uploadID.Hex()).Return(nil)
 			},
 			wantError: true,
 			inputUpload: models.FileUploadInput{
@@ -292,31 +709,61 @@ func Test_UploadFile(t *testing.T) {
 				UserId:   "1",
 			},
 		},
+		{
+			name: "ERROR: add log and cleanup errors",
+			behavior: func(mcs *mock_services.MockCloudStorage, mf *mock_repo.MockFiles, mu *mock_repo.MockUploads, mj *mock_repo.MockJobs) {
+				createUpload(mu, 100)
+				mcs.EXPECT().UploadFile(gomock.Any(), []byte{}, int64(100), "file1.png").Return("https://s3.storage.com/1", nil)
+				mf.EXPECT().AddLog(gomock.Any(), gomock.Any()).Return(errors.New("add log error"))
+				mcs.EXPECT().DeleteFile(gomock.Any(), "file1.png").Return(errors.New("storage error"))
+			},
+			wantError: true,
+			inputUpload: models.FileUploadInput{
+				FileData: []byte{},
+				Size:     100,
+				Filename: "file1.png",
+				UserId:   "1",
+			},
+		},
 	}
 
 	for _, test := range testTable {
 		t.Run(test.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			usersRepo := mock_repo.NewMockUsers(ctrl)
-			tokenRepo := mock_repo.NewMockTokens(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
 			filesRepo := mock_repo.NewMockFiles(ctrl)
+			uploadsRepo := mock_repo.NewMockUploads(ctrl)
+			jobsRepo := mock_repo.NewMockJobs(ctrl)
 			repo := &repo.Repo{
-				Users:  usersRepo,
-				Tokens: tokenRepo,
-				Files:  filesRepo,
+				Users:        usersRepo,
+				Sessions:     sessionsRepo,
+				Files:        filesRepo,
+				Uploads:      uploadsRepo,
+				Jobs:         jobsRepo,
+				Webhooks:     noWebhooks(ctrl),
+				Outbox:       memory.NewOutbox(),
+				Transactions: memory.Transactions{},
 			}
 			tokens := mock_services.NewMockTokener(ctrl)
 			cloud := mock_services.NewMockCloudStorage(ctrl)
+			mailer := mock_services.NewMockMailer(ctrl)
 
-			test.behavior(cloud, filesRepo)
+			test.behavior(cloud, filesRepo, uploadsRepo, jobsRepo)
 
-			services := New(repo, tokens, cloud)
+			services := New(repo, tokens, cloud, mailer, nil, testConfig())
 
-			err := services.UploadFile(&test.inputUpload)
+			file, err := services.UploadFile(context.Background(), &test.inputUpload)
 
 			if err != nil && !test.wantError {
 				t.Fatalf("Service UploadFile error - %s\n", err.Error())
 			}
+			if err == nil && test.wantError {
+				t.Fatalf("Service UploadFile expected error\n")
+			}
+			if err == nil && (file.ID != fileID || file.Status != test.wantStatus) {
+				t.Fatalf("unexpected uploaded file - %+v\n", file)
+			}
 		})
 	}
 }
@@ -324,29 +771,95 @@ func Test_UploadFile(t *testing.T) {
 func Test_ParseToken(t *testing.T) {
 	testTable := []struct {
 		name       string
-		behavior   func(*mock_services.MockTokener)
+		behavior   func(*mock_services.MockTokener, *mock_repo.MockUsers, *mock_repo.MockSessions)
 		inputToken string
 		outUserId  string
 		wantError  bool
 	}{
 		{
 			name: "OK",
-			behavior: func(mt *mock_services.MockTokener) {
-				mt.EXPECT().ParseToken("293o89bcuwp8yb0823peob2pf9u829p").Return("1", nil)
+			behavior: func(mt *mock_services.MockTokener, mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mt.EXPECT().ParseToken("293o89bcuwp8yb0823peob2pf9u829p").Return(&jwtauth.Claims{Subject: "1", SessionID: "s1", IssuedAt: 1000}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "some@mail.com"}, nil)
+				ms.EXPECT().Get(gomock.Any(), "s1").Return(&models.Session{UserID: "1", LastSeenAt: time.Now().Unix()}, nil)
 			},
 			inputToken: "293o89bcuwp8yb0823peob2pf9u829p",
 			outUserId:  "1",
 			wantError:  false,
 		},
+		{
+			name: "OK: stale last-seen is updated",
+			behavior: func(mt *mock_services.MockTokener, mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mt.EXPECT().ParseToken("293o89bcuwp8yb0823peob2pf9u829p").Return(&jwtauth.Claims{Subject: "1", SessionID: "s1", IssuedAt: 1000}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "some@mail.com"}, nil)
+				ms.EXPECT().Get(gomock.Any(), "s1").Return(&models.Session{UserID: "1", LastSeenAt: 1000}, nil)
+				ms.EXPECT().Touch(gomock.Any(), "s1", gomock.Any()).Return(nil)
+			},
+			inputToken: "293o89bcuwp8yb0823peob2pf9u829p",
+			outUserId:  "1",
+			wantError:  false,
+		},
+		{
+			name: "ERROR: session revoked",
+			behavior: func(mt *mock_services.MockTokener, mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mt.EXPECT().ParseToken("293o89bcuwp8yb0823peob2pf9u829p").Return(&jwtauth.Claims{Subject: "1", SessionID: "s1", IssuedAt: 1000}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "some@mail.com"}, nil)
+				ms.EXPECT().Get(gomock.Any(), "s1").Return(nil, models.ErrSessionNotFound)
+			},
+			inputToken: "293o89bcuwp8yb0823peob2pf9u829p",
+			outUserId:  "",
+			wantError:  true,
+		},
+		{
+			name: "ERROR: session of another user",
+			behavior: func(mt *mock_services.MockTokener, mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mt.EXPECT().ParseToken("293o89bcuwp8yb0823peob2pf9u829p").Return(&jwtauth.Claims{Subject: "1", SessionID: "s1", IssuedAt: 1000}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "some@mail.com"}, nil)
+				ms.EXPECT().Get(gomock.Any(), "s1").Return(&models.Session{UserID: "2"}, nil)
+			},
+			inputToken: "293o89bcuwp8yb0823peob2pf9u829p",
+			outUserId:  "",
+			wantError:  true,
+		},
+		{
+			name: "ERROR: token without session",
+			behavior: func(mt *mock_services.MockTokener, mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mt.EXPECT().ParseToken("293o89bcuwp8yb0823peob2pf9u829p").Return(&jwtauth.Claims{Subject: "1", IssuedAt: 1000}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "some@mail.com"}, nil)
+			},
+			inputToken: "293o89bcuwp8yb0823peob2pf9u829p",
+			outUserId:  "",
+			wantError:  true,
+		},
 		{
 			name: "ERROR: parse error",
-			behavior: func(mt *mock_services.MockTokener) {
-				mt.EXPECT().ParseToken("whooohooo").Return("", errors.New("isn't token"))
+			behavior: func(mt *mock_services.MockTokener, mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mt.EXPECT().ParseToken("whooohooo").Return(nil, errors.New("isn't token"))
 			},
 			inputToken: "whooohooo",
 			outUserId:  "",
 			wantError:  true,
 		},
+		{
+			name: "ERROR: user disabled",
+			behavior: func(mt *mock_services.MockTokener, mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mt.EXPECT().ParseToken("293o89bcuwp8yb0823peob2pf9u829p").Return(&jwtauth.Claims{Subject: "1", IssuedAt: 1000}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "some@mail.com", Disabled: true}, nil)
+			},
+			inputToken: "293o89bcuwp8yb0823peob2pf9u829p",
+			outUserId:  "",
+			wantError:  true,
+		},
+		{
+			name: "ERROR: token issued before password change",
+			behavior: func(mt *mock_services.MockTokener, mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mt.EXPECT().ParseToken("293o89bcuwp8yb0823peob2pf9u829p").Return(&jwtauth.Claims{Subject: "1", IssuedAt: 1000}, nil)
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Email: "some@mail.com", SessionsRevokedAt: 1001}, nil)
+			},
+			inputToken: "293o89bcuwp8yb0823peob2pf9u829p",
+			outUserId:  "",
+			wantError:  true,
+		},
 	}
 
 	for _, test := range testTable {
@@ -354,29 +867,487 @@ func Test_ParseToken(t *testing.T) {
 			ctrl := gomock.NewController(t)
 
 			usersRepo := mock_repo.NewMockUsers(ctrl)
-			tokenRepo := mock_repo.NewMockTokens(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
 			filesRepo := mock_repo.NewMockFiles(ctrl)
 
 			repo := &repo.Repo{
-				Users:  usersRepo,
-				Tokens: tokenRepo,
-				Files:  filesRepo,
+				Users:        usersRepo,
+				Sessions:     sessionsRepo,
+				Files:        filesRepo,
+				Webhooks:     noWebhooks(ctrl),
+				Outbox:       memory.NewOutbox(),
+				Transactions: memory.Transactions{},
 			}
 
 			tokens := mock_services.NewMockTokener(ctrl)
 			cloud := mock_services.NewMockCloudStorage(ctrl)
+			mailer := mock_services.NewMockMailer(ctrl)
 
-			test.behavior(tokens)
+			test.behavior(tokens, usersRepo, sessionsRepo)
 
-			services := New(repo, tokens, cloud)
+			services := New(repo, tokens, cloud, mailer, nil, testConfig())
 
-			userID, err := services.ParseToken(test.inputToken)
+			principal, err := services.ParseToken(context.Background(), test.inputToken)
 			if err != nil && !test.wantError {
 				t.Fatalf("Service ParseToken error - %s\n", err.Error())
 			}
 
-			if userID != test.outUserId && !test.wantError {
-				t.Fatalf("Invalid userID\nReceived - %s\nWant - %s\n", userID, test.outUserId)
+			if err == nil && test.wantError {
+				t.Fatal("expected error")
+			}
+
+			if !test.wantError && principal.UserID != test.outUserId {
+				t.Fatalf("Invalid userID\nReceived - %s\nWant - %s\n", principal.UserID, test.outUserId)
+			}
+		})
+	}
+}
+
+func Test_SignUpAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	usersRepo := mock_repo.NewMockUsers(ctrl)
+	repo := &repo.Repo{
+		Users:        usersRepo,
+		Sessions:     mock_repo.NewMockSessions(ctrl),
+		Files:        mock_repo.NewMockFiles(ctrl),
+		Webhooks:     noWebhooks(ctrl),
+		Outbox:       memory.NewOutbox(),
+		Transactions: memory.Transactions{},
+	}
+
+	usersRepo.EXPECT().CreateUser(gomock.Any(), stamped{&models.UserSignUpInput{
+		Email:    "Admin@Mail.com",
+		Password: "SuperStrongPassword",
+		Role:     models.RoleAdmin,
+	}, "CreatedAt"}).Return(nil)
+
+	tokens := mock_services.NewMockTokener(ctrl)
+	tokens.EXPECT().GeneratePurposeToken("Admin@Mail.com", jwtauth.PurposeEmailVerification, gomock.Any()).Return("token", nil)
+	mailer := mock_services.NewMockMailer(ctrl)
+	mailer.EXPECT().Send(gomock.Any()).Return(nil)
+
+	config := testConfig()
+	config.Auth.AdminEmails = []string{" admin@mail.com"}
+
+	services := New(repo, tokens, mock_services.NewMockCloudStorage(ctrl), mailer, nil, config)
+
+	err := services.SignUp(context.Background(), &models.UserSignUpInput{
+		Email:    "Admin@Mail.com",
+		Password: "SuperStrongPassword",
+	})
+	if err != nil {
+		t.Fatalf("SignUp error - %s\n", err.Error())
+	}
+}
+
+func Test_VerifyEmail(t *testing.T) {
+	testTable := []struct {
+		name      string
+		behavior  func(*mock_repo.MockUsers, *mock_services.MockTokener)
+		wantError bool
+	}{
+		{
+			name: "OK",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener) {
+				mt.EXPECT().ParsePurposeToken("token", jwtauth.PurposeEmailVerification).Return("some@mail.com", nil)
+				mu.EXPECT().SetVerified(gomock.Any(), "some@mail.com").Return(nil)
+			},
+		},
+		{
+			name: "ERROR: invalid token",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener) {
+				mt.EXPECT().ParsePurposeToken("token", jwtauth.PurposeEmailVerification).Return("", errors.New("token is expired"))
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			repo := &repo.Repo{
+				Users:    usersRepo,
+				Sessions: mock_repo.NewMockSessions(ctrl),
+				Files:    mock_repo.NewMockFiles(ctrl),
+			}
+			tokens := mock_services.NewMockTokener(ctrl)
+
+			test.behavior(usersRepo, tokens)
+
+			services := New(repo, tokens, mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			err := services.VerifyEmail(context.Background(), "token")
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected VerifyEmail result - %v\n", err)
+			}
+		})
+	}
+}
+
+func Test_UploadFileRequireVerified(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	usersRepo := mock_repo.NewMockUsers(ctrl)
+	repo := &repo.Repo{
+		Users:    usersRepo,
+		Sessions: mock_repo.NewMockSessions(ctrl),
+		Files:    mock_repo.NewMockFiles(ctrl),
+	}
+
+	usersRepo.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Verified: false}, nil)
+
+	config := testConfig()
+	config.Auth.RequireVerified = true
+
+	services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, config)
+
+	_, err := services.UploadFile(context.Background(), &models.FileUploadInput{Filename: "file1.png", UserId: "1"})
+	if !errors.Is(err, models.ErrUserNotVerified) {
+		t.Fatalf("expected not verified error, got - %v\n", err)
+	}
+}
+
+func Test_ResetPassword(t *testing.T) {
+	userID := primitive.NewObjectID()
+
+	testTable := []struct {
+		name      string
+		behavior  func(*mock_repo.MockUsers, *mock_repo.MockSessions)
+		expect    error
+		wantError bool
+	}{
+		{
+			name: "OK",
+			behavior: func(mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mu.EXPECT().GetUserByResetToken(gomock.Any(), hashToken("token")).Return(&models.User{
+					ID:            userID,
+					PasswordReset: &models.PasswordResetToken{TokenHash: hashToken("token"), ExpiresAt: time.Now().Add(time.Hour).Unix()},
+				}, nil)
+				mu.EXPECT().UpdatePassword(gomock.Any(), userID.Hex(), "hash").Return(nil)
+				ms.EXPECT().DeleteByUser(gomock.Any(), userID.Hex()).Return(nil)
+			},
+		},
+		{
+			name: "ERROR: unknown token",
+			behavior: func(mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mu.EXPECT().GetUserByResetToken(gomock.Any(), hashToken("token")).Return(nil, models.ErrUserNotFound)
+			},
+			expect:    models.ErrInvalidResetToken,
+			wantError: true,
+		},
+		{
+			name: "ERROR: expired token",
+			behavior: func(mu *mock_repo.MockUsers, ms *mock_repo.MockSessions) {
+				mu.EXPECT().GetUserByResetToken(gomock.Any(), hashToken("token")).Return(&models.User{
+					ID:            userID,
+					PasswordReset: &models.PasswordResetToken{TokenHash: hashToken("token"), ExpiresAt: time.Now().Add(-time.Minute).Unix()},
+				}, nil)
+			},
+			expect:    models.ErrInvalidResetToken,
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
+			repo := &repo.Repo{
+				Users:    usersRepo,
+				Sessions: sessionsRepo,
+				Files:    mock_repo.NewMockFiles(ctrl),
+			}
+
+			test.behavior(usersRepo, sessionsRepo)
+
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			err := services.ResetPassword(context.Background(), "token", "hash")
+			if err != nil && !test.wantError {
+				t.Fatalf("Service ResetPassword error - %s\n", err.Error())
+			}
+
+			if test.wantError && !errors.Is(err, test.expect) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.expect)
+			}
+		})
+	}
+}
+
+func Test_ForgotPassword(t *testing.T) {
+	userID := primitive.NewObjectID()
+
+	testTable := []struct {
+		name     string
+		resetURL string
+		wantLink string
+	}{
+		{
+			name:     "OK: built-in form",
+			wantLink: "http://localhost:8000/password/reset?token=",
+		},
+		{
+			name:     "OK: frontend page",
+			resetURL: "https://app.example.com/reset",
+			wantLink: "https://app.example.com/reset?token=",
+		},
+		{
+			name:     "OK: frontend page with query",
+			resetURL: "https://app.example.com/reset?lang=en",
+			wantLink: "https://app.example.com/reset?lang=en&token=",
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			mailerMock := mock_services.NewMockMailer(ctrl)
+			repo := &repo.Repo{
+				Users:    usersRepo,
+				Sessions: mock_repo.NewMockSessions(ctrl),
+				Files:    mock_repo.NewMockFiles(ctrl),
+			}
+
+			usersRepo.EXPECT().GetUserByCreds(gomock.Any(), "some@mail.com").Return(&models.UserSignInOutput{UserID: userID, Email: "some@mail.com"}, nil)
+			usersRepo.EXPECT().SetPasswordResetToken(gomock.Any(), userID.Hex(), gomock.Any()).Return(nil)
+			mailerMock.EXPECT().Send(gomock.Any()).DoAndReturn(func(message *mailer.Message) error {
+				if !strings.Contains(message.Body, "\n"+test.wantLink) {
+					t.Fatalf("unexpected reset link\nReceived - %s\nWant - %s\n", message.Body, test.wantLink)
+				}
+				return nil
+			})
+
+			config := testConfig()
+			config.Auth.ResetURL = test.resetURL
+
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mailerMock, nil, config)
+
+			err := services.ForgotPassword(context.Background(), "some@mail.com")
+			if err != nil {
+				t.Fatalf("Service ForgotPassword error - %s\n", err.Error())
+			}
+		})
+	}
+}
+
+func Test_ChangePassword(t *testing.T) {
+	testTable := []struct {
+		name      string
+		behavior  func(*mock_repo.MockUsers, *mock_services.MockTokener)
+		outToken  string
+		wantError bool
+	}{
+		{
+			name: "OK",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener) {
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Password: "old"}, nil)
+				mu.EXPECT().UpdatePassword(gomock.Any(), "1", "new").Return(nil)
+				mt.EXPECT().GenerateToken("1", testSessionID.Hex(), "").Return("token", nil)
+			},
+			outToken: "token",
+		},
+		{
+			name: "ERROR: wrong current password",
+			behavior: func(mu *mock_repo.MockUsers, mt *mock_services.MockTokener) {
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{Password: "other"}, nil)
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
+			repo := &repo.Repo{
+				Users:    usersRepo,
+				Sessions: sessionsRepo,
+				Files:    mock_repo.NewMockFiles(ctrl),
+			}
+			tokens := mock_services.NewMockTokener(ctrl)
+
+			expectSessions(sessionsRepo)
+			if !test.wantError {
+				sessionsRepo.EXPECT().DeleteByUser(gomock.Any(), "1").Return(nil)
+			}
+			test.behavior(usersRepo, tokens)
+
+			services := New(repo, tokens, mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			token, err := services.ChangePassword(context.Background(), "1", "old", "new", &models.ClientInfo{IP: "192.0.2.1"})
+			if err != nil && !test.wantError {
+				t.Fatalf("Service ChangePassword error - %s\n", err.Error())
+			}
+
+			if err == nil && test.wantError {
+				t.Fatal("expected error")
+			}
+
+			if token != test.outToken {
+				t.Fatalf("unexpected token\nReceived - %s\nWant - %s\n", token, test.outToken)
+			}
+		})
+	}
+}
+
+func Test_Users(t *testing.T) {
+	testTable := []struct {
+		name      string
+		input     models.UsersFilter
+		behavior  func(*mock_repo.MockUsers)
+		outPage   *models.UsersPage
+		wantError bool
+	}{
+		{
+			name:  "OK: default pagination",
+			input: models.UsersFilter{Search: "mail"},
+			behavior: func(mu *mock_repo.MockUsers) {
+				mu.EXPECT().List(gomock.Any(), &models.UsersFilter{Search: "mail", Page: 1, Limit: 20}).
+					Return([]models.User{{Email: "some@mail.com"}}, int64(1), nil)
+			},
+			outPage: &models.UsersPage{
+				Users: []models.User{{Email: "some@mail.com"}},
+				Total: 1,
+				Page:  1,
+				Limit: 20,
+			},
+		},
+		{
+			name:  "OK: limit capped",
+			input: models.UsersFilter{Page: 3, Limit: 1000},
+			behavior: func(mu *mock_repo.MockUsers) {
+				mu.EXPECT().List(gomock.Any(), &models.UsersFilter{Page: 3, Limit: 100}).
+					Return([]models.User{}, int64(0), nil)
+			},
+			outPage: &models.UsersPage{
+				Users: []models.User{},
+				Total: 0,
+				Page:  3,
+				Limit: 100,
+			},
+		},
+		{
+			name:  "ERROR: repo error",
+			input: models.UsersFilter{},
+			behavior: func(mu *mock_repo.MockUsers) {
+				mu.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, int64(0), errors.New("database error"))
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			repo := &repo.Repo{
+				Users:    usersRepo,
+				Sessions: mock_repo.NewMockSessions(ctrl),
+				Files:    mock_repo.NewMockFiles(ctrl),
+			}
+
+			test.behavior(usersRepo)
+
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			page, err := services.Users(context.Background(), &test.input)
+			if err != nil && !test.wantError {
+				t.Fatalf("Service Users error - %s\n", err.Error())
+			}
+
+			if !reflect.DeepEqual(page, test.outPage) && !test.wantError {
+				t.Fatalf("pages not equals\nReceived - %+v\nWant - %+v\n", page, test.outPage)
+			}
+		})
+	}
+}
+
+func Test_DeleteUser(t *testing.T) {
+	testTable := []struct {
+		name      string
+		behavior  func(*mock_repo.MockUsers, *mock_repo.MockOrganizations, *mock_repo.MockFiles, *mock_repo.MockAPIKeys, *mock_repo.MockSessions, *mock_services.MockCloudStorage)
+		wantError bool
+	}{
+		{
+			name: "OK",
+			behavior: func(mu *mock_repo.MockUsers, mo *mock_repo.MockOrganizations, mf *mock_repo.MockFiles, mk *mock_repo.MockAPIKeys, ms *mock_repo.MockSessions, mcs *mock_services.MockCloudStorage) {
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{}, nil)
+				mo.EXPECT().ByMember(gomock.Any(), "1").Return([]models.Organization{{Members: []models.Member{{UserID: "1", Role: models.OrgRoleEditor}}}}, nil)
+				mf.EXPECT().ByUser(gomock.Any(), "1").Return([]models.FileOut{{Filename: "1-1.png"}, {Filename: "1-2.png"}}, nil)
+				mcs.EXPECT().DeleteFile(gomock.Any(), "1-1.png").Return(nil)
+				mcs.EXPECT().DeleteFile(gomock.Any(), "1-2.png").Return(nil)
+				mf.EXPECT().DeleteByUser(gomock.Any(), "1").Return(nil)
+				mk.EXPECT().DeleteByUser(gomock.Any(), "1").Return(nil)
+				ms.EXPECT().DeleteByUser(gomock.Any(), "1").Return(nil)
+				mo.EXPECT().RemoveUser(gomock.Any(), "1").Return(nil)
+				mu.EXPECT().Delete(gomock.Any(), "1").Return(nil)
+			},
+		},
+		{
+			name: "ERROR: last owner of organization with members",
+			behavior: func(mu *mock_repo.MockUsers, mo *mock_repo.MockOrganizations, mf *mock_repo.MockFiles, mk *mock_repo.MockAPIKeys, ms *mock_repo.MockSessions, mcs *mock_services.MockCloudStorage) {
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{}, nil)
+				mo.EXPECT().ByMember(gomock.Any(), "1").Return([]models.Organization{{Members: []models.Member{
+					{UserID: "1", Role: models.OrgRoleOwner},
+					{UserID: "2", Role: models.OrgRoleEditor},
+				}}}, nil)
+			},
+			wantError: true,
+		},
+		{
+			name: "ERROR: user not found",
+			behavior: func(mu *mock_repo.MockUsers, mo *mock_repo.MockOrganizations, mf *mock_repo.MockFiles, mk *mock_repo.MockAPIKeys, ms *mock_repo.MockSessions, mcs *mock_services.MockCloudStorage) {
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(nil, models.ErrUserNotFound)
+			},
+			wantError: true,
+		},
+		{
+			name: "ERROR: storage error keeps metadata",
+			behavior: func(mu *mock_repo.MockUsers, mo *mock_repo.MockOrganizations, mf *mock_repo.MockFiles, mk *mock_repo.MockAPIKeys, ms *mock_repo.MockSessions, mcs *mock_services.MockCloudStorage) {
+				mu.EXPECT().GetUserByID(gomock.Any(), "1").Return(&models.User{}, nil)
+				mo.EXPECT().ByMember(gomock.Any(), "1").Return([]models.Organization{}, nil)
+				mf.EXPECT().ByUser(gomock.Any(), "1").Return([]models.FileOut{{Filename: "1-1.png"}}, nil)
+				mcs.EXPECT().DeleteFile(gomock.Any(), "1-1.png").Return(errors.New("storage error"))
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usersRepo := mock_repo.NewMockUsers(ctrl)
+			filesRepo := mock_repo.NewMockFiles(ctrl)
+			apiKeysRepo := mock_repo.NewMockAPIKeys(ctrl)
+			sessionsRepo := mock_repo.NewMockSessions(ctrl)
+			orgsRepo := mock_repo.NewMockOrganizations(ctrl)
+			repo := &repo.Repo{
+				Users:         usersRepo,
+				Sessions:      sessionsRepo,
+				Files:         filesRepo,
+				APIKeys:       apiKeysRepo,
+				Organizations: orgsRepo,
+				Webhooks:      noWebhooks(ctrl),
+				Outbox:        memory.NewOutbox(),
+				Transactions:  memory.Transactions{},
+			}
+			cloud := mock_services.NewMockCloudStorage(ctrl)
+			mailer := mock_services.NewMockMailer(ctrl)
+
+			test.behavior(usersRepo, orgsRepo, filesRepo, apiKeysRepo, sessionsRepo, cloud)
+
+			services := New(repo, mock_services.NewMockTokener(ctrl), cloud, mailer, nil, testConfig())
+
+			err := services.DeleteUser(context.Background(), "1")
+			if err != nil && !test.wantError {
+				t.Fatalf("Service DeleteUser error - %s\n", err.Error())
+			}
+
+			if err == nil && test.wantError {
+				t.Fatal("expected error")
 			}
 		})
 	}
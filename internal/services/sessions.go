@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	defaultSessionTTL = time.Hour * 24
+
+	// Last-seen is updated not on every request to avoid a write per request
+	sessionTouchInterval = time.Minute
+
+	maxUserAgentLength = 256
+)
+
+// startSession creates session for the client and returns access token bound to it
+func (s *Services) startSession(ctx context.Context, userID string, client *models.ClientInfo) (string, error) {
+	if client == nil {
+		client = &models.ClientInfo{}
+	}
+
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         client.IP,
+		CreatedAt:  now.Unix(),
+		LastSeenAt: now.Unix(),
+		ExpiresAt:  now.Add(s.sessionTTL).Unix(),
+	}
+
+	err := s.db.Sessions.Create(ctx, &session)
+	if err != nil {
+		return "", err
+	}
+
+	return s.tokener.GenerateToken(userID, session.ID.Hex(), "")
+}
+
+func (s *Services) checkSession(ctx context.Context, userID, sessionID string) error {
+	if sessionID == "" {
+		return models.ErrSessionRevoked
+	}
+
+	session, err := s.db.Sessions.Get(ctx, sessionID)
+	if errors.Is(err, models.ErrSessionNotFound) {
+		return models.ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != userID {
+		return models.ErrSessionRevoked
+	}
+
+	now := time.Now().Unix()
+	if now-session.LastSeenAt >= int64(sessionTouchInterval/time.Second) {
+		// Last-seen is informational, failed update shouldn't block the request
+		err = s.db.Sessions.Touch(ctx, sessionID, now)
+		if err != nil {
+			log.Printf("error with updating session %s last-seen - %s\n", sessionID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Sessions returns active sessions of the user, the current one is marked
+func (s *Services) Sessions(ctx context.Context, userID, currentSessionID string) ([]models.Session, error) {
+	sessions, err := s.db.Sessions.ByUser(ctx, userID, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.Hex() == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+func (s *Services) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return s.db.Sessions.Delete(ctx, userID, sessionID)
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_Sessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sessionsRepo := mock_repo.NewMockSessions(ctrl)
+	repo := &repo.Repo{Sessions: sessionsRepo}
+
+	current, other := primitive.NewObjectID(), primitive.NewObjectID()
+	sessionsRepo.EXPECT().ByUser(gomock.Any(), "1", gomock.Any()).Return([]models.Session{{ID: other}, {ID: current}}, nil)
+
+	services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+	sessions, err := services.Sessions(context.Background(), "1", current.Hex())
+	if err != nil {
+		t.Fatalf("Service Sessions error - %s\n", err.Error())
+	}
+
+	if sessions[0].Current || !sessions[1].Current {
+		t.Fatalf("unexpected current session - %+v\n", sessions)
+	}
+}
+
+func Test_StartSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sessionsRepo := mock_repo.NewMockSessions(ctrl)
+	repo := &repo.Repo{Sessions: sessionsRepo}
+	tokens := mock_services.NewMockTokener(ctrl)
+
+	cfg := testConfig()
+	cfg.JWT = &config.JWT{TokenTTL: 900}
+
+	sessionsRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, session *models.Session) error {
+		if session.UserID != "1" || session.IP != "192.0.2.1" || session.UserAgent != "curl/8.0" || session.ExpiresAt-session.CreatedAt != 900 {
+			t.Fatalf("unexpected session - %+v\n", session)
+		}
+		session.ID = testSessionID
+		return nil
+	})
+	tokens.EXPECT().GenerateToken("1", testSessionID.Hex(), "").Return("token", nil)
+
+	services := New(repo, tokens, mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, cfg)
+
+	token, err := services.startSession(context.Background(), "1", &models.ClientInfo{IP: "192.0.2.1", UserAgent: "curl/8.0"})
+	if err != nil || token != "token" {
+		t.Fatalf("startSession error - %v\n", err)
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReconcileInterval = 15 * time.Minute
+	defaultReconcileGrace    = time.Hour
+)
+
+// reconciler fixes uploads interrupted between storage and metadata and keeps totals of what it fixed
+type reconciler struct {
+	interval time.Duration // Negative - disabled
+	grace    time.Duration
+	remove   bool // Objects are removed, otherwise only counted
+
+	mu    sync.Mutex
+	stats models.ReconcileStats
+}
+
+func newReconciler(cfg *config.File) *reconciler {
+	r := &reconciler{
+		interval: defaultReconcileInterval,
+		grace:    defaultReconcileGrace,
+	}
+
+	if cfg == nil {
+		return r
+	}
+
+	if cfg.ReconcileInterval != 0 {
+		r.interval = cfg.ReconcileInterval
+	}
+	if cfg.ReconcileGrace > 0 {
+		r.grace = cfg.ReconcileGrace
+	}
+	r.remove = cfg.ReconcileDelete
+
+	return r
+}
+
+func (r *reconciler) record(report *models.ReconcileReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats.Runs++
+	r.stats.Completed += report.Completed
+	r.stats.Recorded += report.Recorded
+	r.stats.Cleaned += report.Cleaned
+	r.stats.Orphans += report.Orphans
+	r.stats.Failed += report.Failed
+	r.stats.Last = report
+}
+
+// RunReconciler reconciles uploads every interval until ctx is done.
+// Replicas may run it at the same time, every fix can be repeated
+func (s *Services) RunReconciler(ctx context.Context) {
+	if s.reconciler.interval < 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.reconciler.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := s.ReconcileUploads(ctx)
+		if err != nil {
+			log.Printf("uploads reconcile error - %s\n", err.Error())
+		}
+		if report.Completed+report.Recorded+report.Cleaned+report.Orphans+report.Failed > 0 {
+			log.Printf("uploads reconciled (dry run %t): %d completed, %d recorded, %d cleaned, %d orphans, %d failed\n",
+				report.DryRun, report.Completed, report.Recorded, report.Cleaned, report.Orphans, report.Failed)
+		}
+	}
+}
+
+// ReconcileUploads resolves pending uploads older than the grace period and removes stored objects
+// which have no file record. Younger ones may belong to uploads in progress. Only objects listed by
+// the storage are touched, and in dry run nothing is removed, the report counts what would be.
+// The report is returned with the error as well, it counts fixes made before it
+func (s *Services) ReconcileUploads(ctx context.Context) (*models.ReconcileReport, error) {
+	report := &models.ReconcileReport{StartedAt: time.Now().Unix(), DryRun: !s.reconciler.remove}
+	defer func() {
+		report.FinishedAt = time.Now().Unix()
+		s.reconciler.record(report)
+	}()
+
+	before := time.Now().Add(-s.reconciler.grace).Unix()
+
+	// Objects are listed first, interrupted uploads with complete object are recorded by them
+	objects, err := s.cloud.ListFiles(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	stored := make(map[string]models.StoredObject, len(objects))
+	for _, object := range objects {
+		stored[object.Key] = object
+	}
+
+	uploads, err := s.db.Uploads.Stale(ctx, before)
+	if err != nil {
+		return report, err
+	}
+
+	resolved := make(map[string]struct{}, len(uploads))
+	for i := range uploads {
+		resolved[uploads[i].Filename] = struct{}{}
+
+		outcome, err := s.resolveUpload(ctx, &uploads[i], stored)
+		switch {
+		case err != nil:
+			report.Failed++
+			log.Printf("pending upload %s is not resolved - %s\n", uploads[i].ID.Hex(), err.Error())
+		case outcome == uploadCompleted:
+			report.Completed++
+		case outcome == uploadRecorded:
+			report.Recorded++
+		default:
+			report.Cleaned++
+		}
+	}
+
+	for _, object := range objects {
+		if _, ok := resolved[object.Key]; ok || object.LastModified >= before {
+			continue
+		}
+
+		exists, err := s.db.Files.Exists(ctx, object.Key)
+		if err == nil && exists {
+			continue
+		}
+		if err == nil && s.reconciler.remove {
+			err = s.cloud.DeleteFile(ctx, object.Key)
+		}
+		if err != nil {
+			report.Failed++
+			log.Printf("orphaned object %s is not removed - %s\n", object.Key, err.Error())
+			continue
+		}
+
+		report.Orphans++
+	}
+
+	return report, nil
+}
+
+// ReconcileStats returns totals of reconciler runs since start
+func (s *Services) ReconcileStats() *models.ReconcileStats {
+	s.reconciler.mu.Lock()
+	defer s.reconciler.mu.Unlock()
+
+	stats := s.reconciler.stats
+	return &stats
+}
+
+type uploadOutcome int
+
+const (
+	uploadCompleted uploadOutcome = iota
+	uploadRecorded
+	uploadCleaned
+)
+
+// resolveUpload finishes pending upload: recorded one is complete, complete stored object gets
+// its metadata recorded, otherwise the object is removed. In dry run only the outcome is returned
+func (s *Services) resolveUpload(ctx context.Context, upload *models.PendingUpload, stored map[string]models.StoredObject) (uploadOutcome, error) {
+	exists, err := s.db.Files.Exists(ctx, upload.Filename)
+	if err != nil {
+		return 0, err
+	}
+
+	if exists {
+		return uploadCompleted, s.db.Uploads.Delete(ctx, upload.ID.Hex())
+	}
+
+	if object, ok := stored[upload.Filename]; ok && object.Size == upload.Size {
+		return uploadRecorded, s.recordUpload(ctx, upload, &object)
+	}
+
+	if !s.reconciler.remove {
+		return uploadCleaned, nil
+	}
+
+	return uploadCleaned, s.removeUpload(ctx, upload)
+}
+
+// recordUpload records metadata of upload interrupted after its object was stored,
+// the file is processed like a new upload
+func (s *Services) recordUpload(ctx context.Context, upload *models.PendingUpload, object *models.StoredObject) error {
+	uploaded := &models.FileUploadLogInput{
+		Size:       upload.Size,
+		UploadDate: upload.CreatedAt,
+		Filename:   upload.Filename,
+		UserId:     upload.UserId,
+		OrgId:      upload.OrgId,
+		Url:        object.URL,
+		Status:     models.FileProcessing,
+	}
+	err := s.addFile(ctx, uploaded)
+	if err != nil {
+		return err
+	}
+
+	// The next run completes the upload when this fails
+	err = s.db.Uploads.Delete(ctx, upload.ID.Hex())
+	if err != nil {
+		log.Printf("pending upload %s is not finalized - %s\n", upload.ID.Hex(), err.Error())
+	}
+
+	s.publishFile(ctx, models.FileEventCreated, newFileOut(uploaded))
+
+	return nil
+}
+
+// removeUpload removes the object of pending upload with the pending record, every upload has own object
+func (s *Services) removeUpload(ctx context.Context, upload *models.PendingUpload) error {
+	err := s.cloud.DeleteFile(ctx, upload.Filename)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Uploads.Delete(ctx, upload.ID.Hex())
+}
+
+// discardUpload cleans up after failed upload right away, on error the reconciler does it later
+func (s *Services) discardUpload(ctx context.Context, upload *models.PendingUpload) {
+	err := s.removeUpload(ctx, upload)
+	if err != nil {
+		log.Printf("failed upload %s is left to reconciler - %s\n", upload.ID.Hex(), err.Error())
+	}
+}
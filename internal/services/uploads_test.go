@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo"
+	"creatly-task/internal/repo/memory"
+	mock_repo "creatly-task/internal/repo/mocks"
+	mock_services "creatly-task/internal/services/mocks"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_ReconcileUploads(t *testing.T) {
+	old := time.Now().Add(-2 * time.Hour).Unix()
+	fresh := time.Now().Unix()
+	recorded := models.PendingUpload{ID: primitive.NewObjectID(), Filename: "recorded.png", CreatedAt: old}
+	interrupted := models.PendingUpload{ID: primitive.NewObjectID(), Filename: "interrupted.png", Size: 100, CreatedAt: old}
+	stored := models.PendingUpload{ID: primitive.NewObjectID(), Filename: "stored.png", Size: 100, UserId: "1", CreatedAt: old}
+	fileID := primitive.NewObjectID()
+
+	testTable := []struct {
+		name      string
+		remove    bool
+		behavior  func(*mock_repo.MockUploads, *mock_repo.MockFiles, *mock_repo.MockJobs, *mock_services.MockCloudStorage)
+		expect    models.ReconcileReport
+		wantError bool
+	}{
+		{
+			name:   "OK",
+			remove: true,
+			behavior: func(mu *mock_repo.MockUploads, mf *mock_repo.MockFiles, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().ListFiles(gomock.Any()).Return([]models.StoredObject{
+					{Key: "recorded.png", LastModified: old},
+					{Key: "interrupted.png", Size: 50, LastModified: old},
+					{Key: "orphan.png", LastModified: old},
+					{Key: "in-progress.png", LastModified: fresh},
+				}, nil)
+
+				mu.EXPECT().Stale(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, before int64) ([]models.PendingUpload, error) {
+					if before > time.Now().Add(-time.Hour).Unix() {
+						t.Fatalf("pending uploads younger than grace are reconciled\n")
+					}
+					return []models.PendingUpload{recorded, interrupted}, nil
+				})
+				mf.EXPECT().Exists(gomock.Any(), "recorded.png").Return(true, nil)
+				mu.EXPECT().Delete(gomock.Any(), recorded.ID.Hex()).Return(nil)
+				mf.EXPECT().Exists(gomock.Any(), "interrupted.png").Return(false, nil)
+				mcs.EXPECT().DeleteFile(gomock.Any(), "interrupted.png").Return(nil)
+				mu.EXPECT().Delete(gomock.Any(), interrupted.ID.Hex()).Return(nil)
+
+				mf.EXPECT().Exists(gomock.Any(), "orphan.png").Return(false, nil)
+				mcs.EXPECT().DeleteFile(gomock.Any(), "orphan.png").Return(nil)
+			},
+			expect: models.ReconcileReport{Completed: 1, Cleaned: 1, Orphans: 1},
+		},
+		{
+			name: "OK: dry run removes nothing",
+			behavior: func(mu *mock_repo.MockUploads, mf *mock_repo.MockFiles, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().ListFiles(gomock.Any()).Return([]models.StoredObject{
+					{Key: "interrupted.png", Size: 50, LastModified: old},
+					{Key: "orphan.png", LastModified: old},
+				}, nil)
+
+				mu.EXPECT().Stale(gomock.Any(), gomock.Any()).Return([]models.PendingUpload{recorded, interrupted}, nil)
+				mf.EXPECT().Exists(gomock.Any(), "recorded.png").Return(true, nil)
+				mu.EXPECT().Delete(gomock.Any(), recorded.ID.Hex()).Return(nil)
+				mf.EXPECT().Exists(gomock.Any(), "interrupted.png").Return(false, nil)
+				mf.EXPECT().Exists(gomock.Any(), "orphan.png").Return(false, nil)
+			},
+			expect: models.ReconcileReport{DryRun: true, Completed: 1, Cleaned: 1, Orphans: 1},
+		},
+		{
+			name: "OK: complete stored object is recorded",
+			behavior: func(mu *mock_repo.MockUploads, mf *mock_repo.MockFiles, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().ListFiles(gomock.Any()).Return([]models.StoredObject{
+					{Key: "stored.png", Size: 100, LastModified: old, URL: "https://s3.storage.com/stored.png"},
+				}, nil)
+
+				mu.EXPECT().Stale(gomock.Any(), gomock.Any()).Return([]models.PendingUpload{stored}, nil)
+				mf.EXPECT().Exists(gomock.Any(), "stored.png").Return(false, nil)
+				mf.EXPECT().AddLog(gomock.Any(), &models.FileUploadLogInput{
+					Size:       100,
+					UploadDate: old,
+					Filename:   "stored.png",
+					UserId:     "1",
+					Url:        "https://s3.storage.com/stored.png",
+					Status:     models.FileProcessing,
+				}).DoAndReturn(func(_ context.Context, log *models.FileUploadLogInput) error {
+					log.ID = fileID
+					return nil
+				})
+				mu.EXPECT().Delete(gomock.Any(), stored.ID.Hex()).Return(nil)
+				mj.EXPECT().Enqueue(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, job *models.Job) error {
+					if job.Type != models.JobProcessImage || job.Payload != fileID.Hex() {
+						t.Fatalf("unexpected job - %+v\n", job)
+					}
+					return nil
+				})
+			},
+			expect: models.ReconcileReport{DryRun: true, Recorded: 1},
+		},
+		{
+			name:   "OK: failed fixes are counted",
+			remove: true,
+			behavior: func(mu *mock_repo.MockUploads, mf *mock_repo.MockFiles, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().ListFiles(gomock.Any()).Return([]models.StoredObject{{Key: "orphan.png", LastModified: old}}, nil)
+
+				mu.EXPECT().Stale(gomock.Any(), gomock.Any()).Return([]models.PendingUpload{interrupted}, nil)
+				mf.EXPECT().Exists(gomock.Any(), "interrupted.png").Return(false, nil)
+				mcs.EXPECT().DeleteFile(gomock.Any(), "interrupted.png").Return(errors.New("storage error"))
+
+				mf.EXPECT().Exists(gomock.Any(), "orphan.png").Return(false, errors.New("db error"))
+			},
+			expect: models.ReconcileReport{Failed: 2},
+		},
+		{
+			name: "ERROR: list error",
+			behavior: func(mu *mock_repo.MockUploads, mf *mock_repo.MockFiles, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().ListFiles(gomock.Any()).Return(nil, errors.New("storage error"))
+			},
+			expect:    models.ReconcileReport{DryRun: true},
+			wantError: true,
+		},
+		{
+			name: "ERROR: stale uploads error",
+			behavior: func(mu *mock_repo.MockUploads, mf *mock_repo.MockFiles, mj *mock_repo.MockJobs, mcs *mock_services.MockCloudStorage) {
+				mcs.EXPECT().ListFiles(gomock.Any()).Return([]models.StoredObject{}, nil)
+				mu.EXPECT().Stale(gomock.Any(), gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			expect:    models.ReconcileReport{DryRun: true},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			uploads := mock_repo.NewMockUploads(ctrl)
+			files := mock_repo.NewMockFiles(ctrl)
+			jobs := mock_repo.NewMockJobs(ctrl)
+			cloud := mock_services.NewMockCloudStorage(ctrl)
+			test.behavior(uploads, files, jobs, cloud)
+
+			repo := &repo.Repo{
+				Uploads:      uploads,
+				Files:        files,
+				Jobs:         jobs,
+				Webhooks:     noWebhooks(ctrl),
+				Outbox:       memory.NewOutbox(),
+				Transactions: memory.Transactions{},
+			}
+			cfg := testConfig()
+			cfg.Files = &config.File{ReconcileDelete: test.remove}
+			services := New(repo, mock_services.NewMockTokener(ctrl), cloud, mock_services.NewMockMailer(ctrl), nil, cfg)
+
+			report, err := services.ReconcileUploads(context.Background())
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+
+			assert.Equal(t, test.expect.DryRun, report.DryRun)
+			assert.Equal(t, test.expect.Completed, report.Completed)
+			assert.Equal(t, test.expect.Recorded, report.Recorded)
+			assert.Equal(t, test.expect.Cleaned, report.Cleaned)
+			assert.Equal(t, test.expect.Orphans, report.Orphans)
+			assert.Equal(t, test.expect.Failed, report.Failed)
+
+			stats := services.ReconcileStats()
+			assert.Equal(t, 1, stats.Runs)
+			assert.Equal(t, report, stats.Last)
+		})
+	}
+}
+
+func Test_ReconcileStats(t *testing.T) {
+	r := newReconciler(nil)
+	r.record(&models.ReconcileReport{Completed: 1, Recorded: 4, Cleaned: 2})
+	r.record(&models.ReconcileReport{Orphans: 3, Failed: 1})
+
+	services := &Services{reconciler: r}
+	assert.Equal(t, &models.ReconcileStats{
+		Runs:      2,
+		Completed: 1,
+		Recorded:  4,
+		Cleaned:   2,
+		Orphans:   3,
+		Failed:    1,
+		Last:      &models.ReconcileReport{Orphans: 3, Failed: 1},
+	}, services.ReconcileStats())
+}
+
+func Test_newReconciler(t *testing.T) {
+	r := newReconciler(&config.File{ReconcileInterval: -1})
+	assert.Equal(t, time.Duration(-1), r.interval)
+	assert.Equal(t, defaultReconcileGrace, r.grace)
+
+	assert.False(t, r.remove, "removal is opt-in")
+
+	r = newReconciler(&config.File{ReconcileInterval: time.Minute, ReconcileGrace: 2 * time.Hour, ReconcileDelete: true})
+	assert.Equal(t, time.Minute, r.interval)
+	assert.Equal(t, 2*time.Hour, r.grace)
+	assert.True(t, r.remove)
+
+	// Disabled reconciler returns right away
+	services := &Services{reconciler: newReconciler(&config.File{ReconcileInterval: -1})}
+	services.RunReconciler(context.Background())
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/pkg/webhook"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookSecretLength = 32 // Random bytes count
+
+	deliveriesLimit = 50 // Deliveries listed for a webhook
+)
+
+func newWebhookClient(cfg *config.Webhooks) *webhook.Client {
+	if cfg == nil {
+		return webhook.New(0)
+	}
+	return webhook.New(cfg.Timeout)
+}
+
+// CreateWebhook subscribes the URL to events of all users, the secret is generated when it isn't set
+func (s *Services) CreateWebhook(ctx context.Context, userID string, input *models.WebhookInput) (*models.WebhookCreated, error) {
+	target, err := url.Parse(input.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, models.ErrInvalidWebhookURL
+	}
+
+	if len(input.Events) == 0 {
+		return nil, models.ErrInvalidWebhookEvents
+	}
+	for _, event := range input.Events {
+		if !isWebhookEvent(event) {
+			return nil, models.ErrInvalidWebhookEvents
+		}
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		token, _, err := newSecretToken(webhookSecretLength)
+		if err != nil {
+			return nil, err
+		}
+		secret = webhookSecretPrefix + token
+	}
+
+	hook := models.Webhook{
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    input.Events,
+		CreatedBy: userID,
+		CreatedAt: time.Now().Unix(),
+	}
+
+	err = s.db.Webhooks.Create(ctx, &hook)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookCreated{Webhook: hook, Secret: secret}, nil
+}
+
+func isWebhookEvent(event string) bool {
+	for _, known := range models.WebhookEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Services) Webhooks(ctx context.Context) ([]models.Webhook, error) {
+	return s.db.Webhooks.All(ctx)
+}
+
+// DeleteWebhook drops the webhook with its delivery log, queued deliveries are skipped
+func (s *Services) DeleteWebhook(ctx context.Context, id string) error {
+	err := s.db.Webhooks.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Deliveries.DeleteByWebhook(ctx, id)
+}
+
+// WebhookDeliveries lists the latest deliveries of the webhook with their attempts
+func (s *Services) WebhookDeliveries(ctx context.Context, webhookID string) ([]models.WebhookDelivery, error) {
+	_, err := s.db.Webhooks.Get(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.Deliveries.ByWebhook(ctx, webhookID, deliveriesLimit)
+}
+
+// Redeliver sends the delivery again with the same payload, attempts are added to its log
+func (s *Services) Redeliver(ctx context.Context, webhookID, deliveryID string) error {
+	delivery, err := s.db.Deliveries.Get(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	if delivery.WebhookID != webhookID {
+		return models.ErrDeliveryNotFound
+	}
+
+	err = s.db.Deliveries.SetStatus(ctx, deliveryID, models.DeliveryPending)
+	if err != nil {
+		return err
+	}
+
+	return s.enqueue(ctx, models.JobDeliverWebhook, deliveryID)
+}
+
+// queueDeliveries queues deliveries of the outbox event to subscribed webhooks, the event ID is sent as its ID
+func (s *Services) queueDeliveries(ctx context.Context, event *models.OutboxEvent) error {
+	hooks, err := s.db.Webhooks.ByEvent(ctx, event.Type)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(&models.WebhookEvent{
+		ID:        event.ID.Hex(),
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	for _, hook := range hooks {
+		delivery := models.WebhookDelivery{
+			WebhookID: hook.ID.Hex(),
+			Event:     event.Type,
+			Payload:   string(payload),
+			Status:    models.DeliveryPending,
+			CreatedAt: now,
+		}
+
+		err = s.db.Deliveries.Create(ctx, &delivery)
+		if err != nil {
+			return err
+		}
+
+		err = s.enqueue(ctx, models.JobDeliverWebhook, delivery.ID.Hex())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliverWebhook sends the delivery once, every attempt is logged. Responses other than 2xx are retried by the job
+func (s *Services) deliverWebhook(ctx context.Context, deliveryID string) error {
+	delivery, err := s.db.Deliveries.Get(ctx, deliveryID)
+	if errors.Is(err, models.ErrDeliveryNotFound) {
+		return nil // Webhook was deleted
+	}
+	if err != nil {
+		return err
+	}
+
+	if delivery.Status == models.DeliveryDelivered {
+		return nil // Sent by a worker whose lease ended
+	}
+
+	hook, err := s.db.Webhooks.Get(ctx, delivery.WebhookID)
+	if errors.Is(err, models.ErrWebhookNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	code, sendErr := s.webhooks.Send(ctx, hook.URL, hook.Secret, delivery.Event, deliveryID, []byte(delivery.Payload))
+
+	attempt := models.DeliveryAttempt{
+		At:         started.Unix(),
+		StatusCode: code,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	status := models.DeliveryPending
+	switch {
+	case sendErr != nil:
+		attempt.Error = sendErr.Error()
+	case code < 200 || code > 299:
+		sendErr = fmt.Errorf("webhook responded with status %d", code)
+	default:
+		status = models.DeliveryDelivered
+	}
+
+	err = s.db.Deliveries.AddAttempt(ctx, deliveryID, &attempt, status)
+	if err != nil {
+		log.Printf("attempt of delivery %s is not logged - %s\n", deliveryID, err.Error())
+	}
+
+	return sendErr
+}
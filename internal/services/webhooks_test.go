@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"creatly-task/internal/config"
+	"creatly-task/internal/models"
+	"creatly-task/internal/repo/memory"
+	mock_services "creatly-task/internal/services/mocks"
+	"creatly-task/pkg/webhook"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+// receiver is webhook endpoint answering with the queued status codes, signatures are verified
+type receiver struct {
+	t      *testing.T
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	events   []models.WebhookEvent
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.t.Error(err)
+		return
+	}
+
+	err = webhook.Verify(r.secret, req.Header.Get(webhook.SignatureHeader), body, time.Minute, time.Now())
+	if err != nil {
+		r.t.Errorf("unexpected signature error - %v\n", err)
+	}
+
+	var event models.WebhookEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		r.t.Error(err)
+	}
+	if event.Type != req.Header.Get(webhook.EventHeader) {
+		r.t.Errorf("unexpected event header - %s\n", req.Header.Get(webhook.EventHeader))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func Test_CreateWebhook(t *testing.T) {
+	testTable := []struct {
+		name      string
+		input     models.WebhookInput
+		wantError error
+	}{
+		{
+			name:  "OK",
+			input: models.WebhookInput{URL: "https://example.com/hook", Events: []string{models.EventFileUploaded, models.EventUserCreated}},
+		},
+		{
+			name:  "OK: secret is set",
+			input: models.WebhookInput{URL: "http://example.com/hook", Secret: "secret", Events: []string{models.EventFileDeleted}},
+		},
+		{
+			name:      "ERROR: invalid URL",
+			input:     models.WebhookInput{URL: "example.com/hook", Events: []string{models.EventFileUploaded}},
+			wantError: models.ErrInvalidWebhookURL,
+		},
+		{
+			name:      "ERROR: no events",
+			input:     models.WebhookInput{URL: "https://example.com/hook"},
+			wantError: models.ErrInvalidWebhookEvents,
+		},
+		{
+			name:      "ERROR: unknown event",
+			input:     models.WebhookInput{URL: "https://example.com/hook", Events: []string{"file.renamed"}},
+			wantError: models.ErrInvalidWebhookEvents,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := memory.New()
+			services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, testConfig())
+
+			created, err := services.CreateWebhook(context.Background(), "1", &test.input)
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.wantError)
+			}
+			if test.wantError != nil {
+				return
+			}
+
+			if test.input.Secret != "" && created.Secret != test.input.Secret {
+				t.Fatalf("unexpected secret - %s\n", created.Secret)
+			}
+			if test.input.Secret == "" && !strings.HasPrefix(created.Secret, webhookSecretPrefix) {
+				t.Fatalf("unexpected generated secret - %s\n", created.Secret)
+			}
+
+			stored, err := repo.Webhooks.Get(context.Background(), created.ID.Hex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Secret != created.Secret || stored.CreatedBy != "1" {
+				t.Fatalf("unexpected stored webhook - %+v\n", stored)
+			}
+		})
+	}
+}
+
+func Test_DeliverWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	hook := &receiver{t: t, secret: "secret", statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(hook)
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.Jobs = &config.Jobs{RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond}
+	repo := memory.New()
+	services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, cfg)
+
+	ctx := context.Background()
+	created, err := services.CreateWebhook(ctx, "1", &models.WebhookInput{URL: server.URL, Secret: "secret", Events: []string{models.EventUserCreated}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhookID := created.ID.Hex()
+
+	// Deliveries are queued by the relay without a publisher too
+	services.SetEventPublisher(nil)
+	relayOutbox(t, services, models.EventFileUploaded, &models.FileOut{})
+	relayOutbox(t, services, models.EventUserCreated, &models.UserEvent{ID: "1", Email: "some@mail.com"})
+
+	// First attempt is answered with 500 and retried
+	if !services.RunNextJob(ctx, "worker") {
+		t.Fatal("expected delivery job to run")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if !services.RunNextJob(ctx, "worker") {
+		t.Fatal("expected delivery to be retried")
+	}
+	if services.RunNextJob(ctx, "worker") {
+		t.Fatal("expected no due jobs")
+	}
+
+	deliveries, err := services.WebhookDeliveries(ctx, webhookID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("unexpected deliveries - %+v\n", deliveries)
+	}
+	delivery := deliveries[0]
+	if delivery.Status != models.DeliveryDelivered || len(delivery.Attempts) != 2 ||
+		delivery.Attempts[0].StatusCode != http.StatusInternalServerError || delivery.Attempts[1].StatusCode != http.StatusOK {
+		t.Fatalf("unexpected delivery log - %+v\n", delivery)
+	}
+
+	if len(hook.events) != 2 || hook.events[0].Type != models.EventUserCreated || hook.events[0].ID != hook.events[1].ID {
+		t.Fatalf("unexpected received events - %+v\n", hook.events)
+	}
+
+	err = services.Redeliver(ctx, webhookID, delivery.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !services.RunNextJob(ctx, "worker") {
+		t.Fatal("expected redelivery job to run")
+	}
+
+	redelivered, err := repo.Deliveries.Get(ctx, delivery.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivered.Status != models.DeliveryDelivered || len(redelivered.Attempts) != 3 || len(hook.events) != 3 {
+		t.Fatalf("unexpected redelivery - %+v\n", redelivered)
+	}
+
+	err = services.Redeliver(ctx, "other", delivery.ID.Hex())
+	if !errors.Is(err, models.ErrDeliveryNotFound) {
+		t.Fatalf("expected delivery not found, got - %v\n", err)
+	}
+}
+
+func Test_DeliverWebhook_Failed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	hook := &receiver{t: t, secret: "secret", statuses: []int{http.StatusBadGateway}}
+	server := httptest.NewServer(hook)
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.Jobs = &config.Jobs{MaxAttempts: 1}
+	repo := memory.New()
+	services := New(repo, mock_services.NewMockTokener(ctrl), mock_services.NewMockCloudStorage(ctrl), mock_services.NewMockMailer(ctrl), nil, cfg)
+
+	ctx := context.Background()
+	created, err := services.CreateWebhook(ctx, "1", &models.WebhookInput{URL: server.URL, Secret: "secret", Events: []string{models.EventFileDeleted}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relayOutbox(t, services, models.EventFileDeleted, &models.FileOut{Filename: "file 1"})
+	services.RunNextJob(ctx, "worker")
+
+	deliveries, err := services.WebhookDeliveries(ctx, created.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != models.DeliveryFailed || len(deliveries[0].Attempts) != 1 ||
+		deliveries[0].Attempts[0].StatusCode != http.StatusBadGateway {
+		t.Fatalf("unexpected delivery log - %+v\n", deliveries)
+	}
+
+	err = services.DeleteWebhook(ctx, created.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = services.WebhookDeliveries(ctx, created.ID.Hex())
+	if !errors.Is(err, models.ErrWebhookNotFound) {
+		t.Fatalf("expected webhook not found, got - %v\n", err)
+	}
+	_, err = repo.Deliveries.Get(ctx, deliveries[0].ID.Hex())
+	if !errors.Is(err, models.ErrDeliveryNotFound) {
+		t.Fatalf("expected delivery log to be deleted, got - %v\n", err)
+	}
+}
+
+// relayOutbox saves the event like a committed change and relays it
+func relayOutbox(t *testing.T, services *Services, eventType string, data interface{}) {
+	ctx := context.Background()
+	err := services.addOutbox(ctx, eventType, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !services.RelayNextEvent(ctx) {
+		t.Fatal("expected event to be relayed")
+	}
+}
@@ -2,33 +2,134 @@ package jwtauth
 
 import (
 	"creatly-task/internal/config"
-	"errors"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
+const (
+	PurposeEmailVerification = "email-verification"
+	PurposeMFA               = "mfa-pending"
+	PurposeOIDCState         = "oidc-state"
+)
+
+// Claims contains access token data used by the application
+type Claims struct {
+	ID        string
+	Subject   string
+	SessionID string
+	OrgID     string // Active organization, empty for personal space
+	IssuedAt  int64
+}
+
+// JWTTokener signs tokens with the active key and verifies them with any configured key.
+// For rotation add a new key, make it active and keep the previous one until its tokens expire
 type JWTTokener struct {
-	signinKey []byte
+	activeKey *signingKey
+	keys      map[string]*signingKey
 	tokenTTL  time.Duration
+
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
 }
 
-func New(config *config.JWT) *JWTTokener {
-	return &JWTTokener{
-		signinKey: []byte(config.SigningKey),
-		tokenTTL:  time.Second * time.Duration(config.TokenTTL),
+func New(config *config.JWT) (*JWTTokener, error) {
+	tokener := &JWTTokener{
+		keys:     make(map[string]*signingKey),
+		tokenTTL: time.Second * time.Duration(config.TokenTTL),
+		issuer:   config.Issuer,
+		audience: config.Audience,
+		leeway:   config.Leeway,
+		now:      time.Now,
+	}
+
+	if config.Algorithm == "" || config.Algorithm == AlgorithmHS256 {
+		key := &signingKey{
+			id:      config.ActiveKeyID,
+			method:  jwt.SigningMethodHS256,
+			private: []byte(config.SigningKey),
+			public:  []byte(config.SigningKey),
+		}
+		tokener.activeKey = key
+		tokener.keys[key.id] = key
+		return tokener, nil
+	}
+
+	for id, filename := range config.Keys {
+		key, err := loadKey(id, config.Algorithm, filename)
+		if err != nil {
+			return nil, err
+		}
+		tokener.keys[id] = key
 	}
+
+	activeKey, ok := tokener.keys[config.ActiveKeyID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found", config.ActiveKeyID)
+	}
+
+	if !activeKey.canSign() {
+		return nil, fmt.Errorf("active key %q has no private key", config.ActiveKeyID)
+	}
+
+	tokener.activeKey = activeKey
+
+	return tokener, nil
 }
 
-func (j *JWTTokener) GenerateToken(userId string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
-		ExpiresAt: time.Now().Add(time.Second * j.tokenTTL).Unix(),
-		IssuedAt:  time.Now().Unix(),
-		Subject:   userId,
+// GenerateToken issues access token bound to the session, revoked session invalidates it
+func (j *JWTTokener) GenerateToken(userId, sessionID, orgID string) (string, error) {
+	claims, err := j.standardClaims(userId, j.audience, j.tokenTTL)
+	if err != nil {
+		return "", err
+	}
+
+	return j.sign(tokenClaims{
+		StandardClaims: claims,
+		SessionID:      sessionID,
+		OrgID:          orgID,
 	})
+}
+
+func (j *JWTTokener) standardClaims(subject, audience string, ttl time.Duration) (jwt.StandardClaims, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return jwt.StandardClaims{}, fmt.Errorf("error with generating token id - %s", err.Error())
+	}
+
+	now := j.now()
+
+	return jwt.StandardClaims{
+		Id:        id,
+		Subject:   subject,
+		Issuer:    j.issuer,
+		Audience:  audience,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}, nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
 
-	tokenString, err := token.SignedString(j.signinKey)
+func (j *JWTTokener) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(j.activeKey.method, claims)
+	if j.activeKey.id != "" {
+		token.Header["kid"] = j.activeKey.id
+	}
+
+	tokenString, err := token.SignedString(j.activeKey.private)
 	if err != nil {
 		return "", fmt.Errorf("error with signing token - %s", err.Error())
 	}
@@ -36,32 +137,124 @@ func (j *JWTTokener) GenerateToken(userId string) (string, error) {
 	return tokenString, nil
 }
 
-func (j *JWTTokener) ParseToken(token string) (string, error) {
-	acceptedToken, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.signinKey, nil
-	})
+// keyFunc selects verification key by "kid" header. Tokens without kid
+// are accepted only for HMAC, they were issued before key ids were added
+func (j *JWTTokener) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key: %q", kid)
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.public, nil
+}
 
+// parse verifies signature and standard claims, "aud" is checked when audience isn't empty. Time checks
+// use the tokener clock with leeway instead of the jwt package ones, which don't allow skew
+func (j *JWTTokener) parse(token, audience string, claims *tokenClaims) error {
+	parser := jwt.Parser{SkipClaimsValidation: true}
+
+	acceptedToken, err := parser.ParseWithClaims(token, claims, j.keyFunc)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("%w - %s", ErrInvalidToken, err.Error())
 	}
 
 	if !acceptedToken.Valid {
-		return "", errors.New("invalid token")
+		return ErrInvalidToken
 	}
 
-	claims, ok := acceptedToken.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", errors.New("invalid claims")
+	return j.validate(&claims.StandardClaims, audience)
+}
+
+func (j *JWTTokener) validate(claims *jwt.StandardClaims, audience string) error {
+	now := j.now().Unix()
+	leeway := int64(j.leeway / time.Second)
+
+	if claims.ExpiresAt == 0 || now > claims.ExpiresAt+leeway {
+		return ErrTokenExpired
 	}
 
-	subject, ok := claims["sub"].(string)
-	if !ok {
-		return "", errors.New("invalid claims - subject")
+	if now+leeway < claims.NotBefore || now+leeway < claims.IssuedAt {
+		return ErrTokenNotValidYet
 	}
 
-	return subject, nil
+	if j.issuer != "" && claims.Issuer != j.issuer {
+		return ErrInvalidIssuer
+	}
+
+	if audience != "" && claims.Audience != audience {
+		return ErrInvalidAudience
+	}
+
+	if claims.Subject == "" {
+		return fmt.Errorf("%w - subject", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+type tokenClaims struct {
+	jwt.StandardClaims
+	SessionID string `json:"sid,omitempty"`
+	OrgID     string `json:"org,omitempty"`
+	Purpose   string `json:"purpose,omitempty"`
+}
+
+func (j *JWTTokener) ParseToken(token string) (*Claims, error) {
+	var claims tokenClaims
+	if err := j.parse(token, j.audience, &claims); err != nil {
+		return nil, err
+	}
+
+	// Purpose tokens (email verification etc.) can't be used for access
+	if claims.Purpose != "" {
+		return nil, fmt.Errorf("%w - purpose", ErrInvalidToken)
+	}
+
+	return &Claims{
+		ID:        claims.Id,
+		Subject:   claims.Subject,
+		SessionID: claims.SessionID,
+		OrgID:     claims.OrgID,
+		IssuedAt:  claims.IssuedAt,
+	}, nil
+}
+
+// GeneratePurposeToken signs short-lived token which is valid only for given purpose
+func (j *JWTTokener) GeneratePurposeToken(subject, purpose string, ttl time.Duration) (string, error) {
+	claims, err := j.standardClaims(subject, j.purposeAudience(purpose), ttl)
+	if err != nil {
+		return "", err
+	}
+
+	return j.sign(tokenClaims{
+		StandardClaims: claims,
+		Purpose:        purpose,
+	})
+}
+
+func (j *JWTTokener) ParsePurposeToken(token, purpose string) (string, error) {
+	var claims tokenClaims
+	if err := j.parse(token, j.purposeAudience(purpose), &claims); err != nil {
+		return "", err
+	}
+
+	if claims.Purpose != purpose {
+		return "", fmt.Errorf("%w - purpose", ErrInvalidToken)
+	}
+
+	return claims.Subject, nil
+}
+
+// purposeAudience differs from the access token one, so verifiers checking "aud" with the published keys reject purpose tokens
+func (j *JWTTokener) purposeAudience(purpose string) string {
+	if j.audience == "" {
+		return purpose
+	}
+	return j.audience + ":" + purpose
 }
@@ -0,0 +1,406 @@
+package jwtauth
+
+import (
+	"creatly-task/internal/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePrivateKey(t *testing.T, dir, name string, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal private key error - %s\n", err.Error())
+	}
+	return writePEM(t, dir, name, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, dir, name string, key interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("marshal public key error - %s\n", err.Error())
+	}
+	return writePEM(t, dir, name, "PUBLIC KEY", der)
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	filename := filepath.Join(dir, name)
+	err := ioutil.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("write key error - %s\n", err.Error())
+	}
+	return filename
+}
+
+func Test_RotationRS256(t *testing.T) {
+	dir := t.TempDir()
+
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key error - %s\n", err.Error())
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key error - %s\n", err.Error())
+	}
+
+	before, err := New(&config.JWT{
+		TokenTTL:    5,
+		Algorithm:   AlgorithmRS256,
+		Keys:        map[string]string{"old": writePrivateKey(t, dir, "old.pem", oldKey)},
+		ActiveKeyID: "old",
+	})
+	if err != nil {
+		t.Fatalf("init tokener error - %s\n", err.Error())
+	}
+
+	oldToken, err := before.GenerateToken("1", "s1", "")
+	if err != nil {
+		t.Fatalf("generate token error - %s\n", err.Error())
+	}
+
+	// Rotated: the new key signs, the old one is kept only as public key
+	after, err := New(&config.JWT{
+		TokenTTL:  5,
+		Algorithm: AlgorithmRS256,
+		Keys: map[string]string{
+			"old": writePublicKey(t, dir, "old.pub.pem", &oldKey.PublicKey),
+			"new": writePrivateKey(t, dir, "new.pem", newKey),
+		},
+		ActiveKeyID: "new",
+	})
+	if err != nil {
+		t.Fatalf("init tokener error - %s\n", err.Error())
+	}
+
+	claims, err := after.ParseToken(oldToken)
+	if err != nil || claims.Subject != "1" {
+		t.Fatalf("token of rotated key must be valid - %v\n", err)
+	}
+
+	newToken, err := after.GenerateToken("2", "s2", "")
+	if err != nil {
+		t.Fatalf("generate token error - %s\n", err.Error())
+	}
+
+	if _, err := before.ParseToken(newToken); err == nil {
+		t.Fatal("token of unknown key must be rejected")
+	}
+
+	jwks := after.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kid != "new" || jwks.Keys[1].Kid != "old" || jwks.Keys[0].Kty != "RSA" {
+		t.Fatalf("unexpected jwks - %+v\n", jwks)
+	}
+}
+
+func Test_EdDSA(t *testing.T) {
+	dir := t.TempDir()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key error - %s\n", err.Error())
+	}
+
+	tokener, err := New(&config.JWT{
+		TokenTTL:    5,
+		Algorithm:   AlgorithmEdDSA,
+		Keys:        map[string]string{"ed": writePrivateKey(t, dir, "ed.pem", private)},
+		ActiveKeyID: "ed",
+	})
+	if err != nil {
+		t.Fatalf("init tokener error - %s\n", err.Error())
+	}
+
+	token, err := tokener.GenerateToken("1", "s1", "org1")
+	if err != nil {
+		t.Fatalf("generate token error - %s\n", err.Error())
+	}
+
+	claims, err := tokener.ParseToken(token)
+	if err != nil || claims.Subject != "1" || claims.OrgID != "org1" {
+		t.Fatalf("parse token error - %v\n", err)
+	}
+
+	jwks := tokener.JWKS()
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Kty != "OKP" || jwks.Keys[0].Crv != "Ed25519" || jwks.Keys[0].X == "" {
+		t.Fatalf("unexpected jwks - %+v\n", jwks)
+	}
+}
+
+func Test_New(t *testing.T) {
+	dir := t.TempDir()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key error - %s\n", err.Error())
+	}
+	publicOnly := writePublicKey(t, dir, "pub.pem", &key.PublicKey)
+
+	testTable := []struct {
+		name      string
+		config    *config.JWT
+		wantError bool
+	}{
+		{
+			name:   "OK: HMAC by default",
+			config: &config.JWT{SigningKey: "secret"},
+		},
+		{
+			name:      "ERROR: active key not found",
+			config:    &config.JWT{Algorithm: AlgorithmRS256, Keys: map[string]string{"a": publicOnly}, ActiveKeyID: "b"},
+			wantError: true,
+		},
+		{
+			name:      "ERROR: active key without private part",
+			config:    &config.JWT{Algorithm: AlgorithmRS256, Keys: map[string]string{"a": publicOnly}, ActiveKeyID: "a"},
+			wantError: true,
+		},
+		{
+			name:      "ERROR: missing file",
+			config:    &config.JWT{Algorithm: AlgorithmRS256, Keys: map[string]string{"a": filepath.Join(dir, "none.pem")}, ActiveKeyID: "a"},
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.config)
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+		})
+	}
+}
+
+func Test_PurposeTokenIsNotAccessToken(t *testing.T) {
+	tokener, err := New(&config.JWT{SigningKey: "secret", TokenTTL: 5})
+	if err != nil {
+		t.Fatalf("init tokener error - %s\n", err.Error())
+	}
+
+	token, err := tokener.GeneratePurposeToken("1", PurposeMFA, time.Minute)
+	if err != nil {
+		t.Fatalf("generate token error - %s\n", err.Error())
+	}
+
+	if _, err := tokener.ParseToken(token); err == nil {
+		t.Fatal("purpose token accepted as access token")
+	}
+
+	if _, err := tokener.ParsePurposeToken(token, PurposeEmailVerification); err == nil {
+		t.Fatal("purpose token accepted for another purpose")
+	}
+
+	subject, err := tokener.ParsePurposeToken(token, PurposeMFA)
+	if err != nil || subject != "1" {
+		t.Fatalf("parse purpose token error - %v\n", err)
+	}
+}
+
+func Test_PurposeTokenAudience(t *testing.T) {
+	tokener, err := New(&config.JWT{SigningKey: "secret", TokenTTL: 5, Issuer: "creatly", Audience: "creatly-api"})
+	if err != nil {
+		t.Fatalf("init tokener error - %s\n", err.Error())
+	}
+
+	token, err := tokener.GeneratePurposeToken("1", PurposeMFA, time.Minute)
+	if err != nil {
+		t.Fatalf("generate token error - %s\n", err.Error())
+	}
+
+	var claims tokenClaims
+	if err := tokener.parse(token, "", &claims); err != nil {
+		t.Fatalf("parse token error - %s\n", err.Error())
+	}
+	if claims.Audience != "creatly-api:"+PurposeMFA {
+		t.Fatalf("unexpected audience - %s\n", claims.Audience)
+	}
+
+	// Verifiers which check only signature, issuer and audience reject it too
+	if _, err := tokener.ParseToken(token); !errors.Is(err, ErrInvalidAudience) {
+		t.Fatalf("expected invalid audience, got - %v\n", err)
+	}
+
+	if _, err := tokener.ParsePurposeToken(token, PurposeMFA); err != nil {
+		t.Fatalf("parse purpose token error - %s\n", err.Error())
+	}
+}
+
+func Test_StandardClaims(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	issuer, err := New(&config.JWT{
+		SigningKey: "secret",
+		TokenTTL:   900,
+		Issuer:     "creatly",
+		Audience:   "creatly-api",
+	})
+	if err != nil {
+		t.Fatalf("init tokener error - %s\n", err.Error())
+	}
+	issuer.now = func() time.Time { return issuedAt }
+
+	token, err := issuer.GenerateToken("1", "s1", "")
+	if err != nil {
+		t.Fatalf("generate token error - %s\n", err.Error())
+	}
+
+	testTable := []struct {
+		name      string
+		config    *config.JWT
+		now       time.Time
+		wantError error
+	}{
+		{
+			name:   "OK",
+			config: &config.JWT{SigningKey: "secret", Issuer: "creatly", Audience: "creatly-api"},
+			now:    issuedAt.Add(time.Minute),
+		},
+		{
+			name:   "OK: issuer and audience not checked",
+			config: &config.JWT{SigningKey: "secret"},
+			now:    issuedAt,
+		},
+		{
+			name:      "ERROR: expired",
+			config:    &config.JWT{SigningKey: "secret"},
+			now:       issuedAt.Add(901 * time.Second),
+			wantError: ErrTokenExpired,
+		},
+		{
+			name:   "OK: expired within leeway",
+			config: &config.JWT{SigningKey: "secret", Leeway: 30 * time.Second},
+			now:    issuedAt.Add(910 * time.Second),
+		},
+		{
+			name:      "ERROR: not valid yet",
+			config:    &config.JWT{SigningKey: "secret"},
+			now:       issuedAt.Add(-time.Minute),
+			wantError: ErrTokenNotValidYet,
+		},
+		{
+			name:   "OK: clock skew within leeway",
+			config: &config.JWT{SigningKey: "secret", Leeway: time.Minute},
+			now:    issuedAt.Add(-30 * time.Second),
+		},
+		{
+			name:      "ERROR: bad issuer",
+			config:    &config.JWT{SigningKey: "secret", Issuer: "other"},
+			now:       issuedAt,
+			wantError: ErrInvalidIssuer,
+		},
+		{
+			name:      "ERROR: bad audience",
+			config:    &config.JWT{SigningKey: "secret", Audience: "other-api"},
+			now:       issuedAt,
+			wantError: ErrInvalidAudience,
+		},
+		{
+			name:      "ERROR: bad signature",
+			config:    &config.JWT{SigningKey: "other"},
+			now:       issuedAt,
+			wantError: ErrInvalidToken,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			tokener, err := New(test.config)
+			if err != nil {
+				t.Fatalf("init tokener error - %s\n", err.Error())
+			}
+			tokener.now = func() time.Time { return test.now }
+
+			claims, err := tokener.ParseToken(token)
+			if !errors.Is(err, test.wantError) {
+				t.Fatalf("unexpected error - %v, want %v\n", err, test.wantError)
+			}
+
+			if test.wantError == nil && (claims.Subject != "1" || claims.SessionID != "s1" || claims.ID == "" || claims.IssuedAt != issuedAt.Unix()) {
+				t.Fatalf("unexpected claims - %+v\n", claims)
+			}
+		})
+	}
+}
+
+func Test_TokenTTL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	testTable := []struct {
+		name     string
+		tokenTTL int64
+		generate func(*JWTTokener) (string, error)
+		wantTTL  time.Duration
+	}{
+		{
+			name:     "access token",
+			tokenTTL: 900,
+			generate: func(j *JWTTokener) (string, error) {
+				return j.GenerateToken("1", "s1", "")
+			},
+			wantTTL: time.Minute * 15,
+		},
+		{
+			name:     "access token with other ttl",
+			tokenTTL: 3600,
+			generate: func(j *JWTTokener) (string, error) {
+				return j.GenerateToken("1", "s1", "org1")
+			},
+			wantTTL: time.Hour,
+		},
+		{
+			name:     "purpose token",
+			tokenTTL: 900,
+			generate: func(j *JWTTokener) (string, error) {
+				return j.GeneratePurposeToken("1", PurposeEmailVerification, time.Hour*24)
+			},
+			wantTTL: time.Hour * 24,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			tokener, err := New(&config.JWT{SigningKey: "secret", TokenTTL: test.tokenTTL})
+			if err != nil {
+				t.Fatalf("init tokener error - %s\n", err.Error())
+			}
+			tokener.now = func() time.Time { return now }
+
+			first, err := test.generate(tokener)
+			if err != nil {
+				t.Fatalf("generate token error - %s\n", err.Error())
+			}
+
+			second, err := test.generate(tokener)
+			if err != nil {
+				t.Fatalf("generate token error - %s\n", err.Error())
+			}
+
+			var firstClaims, secondClaims tokenClaims
+			if err := tokener.parse(first, "", &firstClaims); err != nil {
+				t.Fatalf("fresh token must be valid - %s\n", err.Error())
+			}
+			if err := tokener.parse(second, "", &secondClaims); err != nil {
+				t.Fatalf("fresh token must be valid - %s\n", err.Error())
+			}
+
+			if firstClaims.IssuedAt != now.Unix() {
+				t.Fatalf("unexpected iat\nReceived - %d\nWant - %d\n", firstClaims.IssuedAt, now.Unix())
+			}
+			if ttl := time.Duration(firstClaims.ExpiresAt-firstClaims.IssuedAt) * time.Second; ttl != test.wantTTL {
+				t.Fatalf("unexpected exp - iat\nReceived - %s\nWant - %s\n", ttl, test.wantTTL)
+			}
+
+			if firstClaims.Id == secondClaims.Id {
+				t.Fatal("token ids must be unique")
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// signingKey is one of configured keys. Keys without private part
+// only verify tokens signed before rotation
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+func (k *signingKey) canSign() bool {
+	return k.private != nil
+}
+
+// loadKey reads PEM file with private or public key for the algorithm
+func loadKey(id, algorithm, filename string) (*signingKey, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error with read key %s - %s", id, err.Error())
+	}
+
+	switch algorithm {
+	case AlgorithmRS256:
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err == nil {
+			return &signingKey{id: id, method: jwt.SigningMethodRS256, private: private, public: &private.PublicKey}, nil
+		}
+
+		public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("error with parse RSA key %s - %s", id, err.Error())
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodRS256, public: public}, nil
+
+	case AlgorithmEdDSA:
+		private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err == nil {
+			edPrivate, ok := private.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("key %s is not ed25519 key", id)
+			}
+			return &signingKey{id: id, method: jwt.SigningMethodEdDSA, private: edPrivate, public: edPrivate.Public()}, nil
+		}
+
+		public, err := jwt.ParseEdPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("error with parse ed25519 key %s - %s", id, err.Error())
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodEdDSA, public: public}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm for key files - %s", algorithm)
+	}
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public parts of all asymmetric keys. HMAC secrets are never published
+func (j *JWTTokener) JWKS() *JWKS {
+	jwks := &JWKS{Keys: []JWK{}}
+
+	ids := make([]string, 0, len(j.keys))
+	for id := range j.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		jwk, err := toJWK(j.keys[id])
+		if err != nil {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, *jwk)
+	}
+
+	return jwks
+}
+
+func toJWK(key *signingKey) (*JWK, error) {
+	encoding := base64.RawURLEncoding
+
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		return &JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Kid: key.id,
+			Alg: key.method.Alg(),
+			N:   encoding.EncodeToString(public.N.Bytes()),
+			E:   encoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return &JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Kid: key.id,
+			Alg: key.method.Alg(),
+			Crv: "Ed25519",
+			X:   encoding.EncodeToString(public),
+		}, nil
+	default:
+		return nil, errors.New("key can't be published")
+	}
+}
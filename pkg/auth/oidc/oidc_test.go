@@ -0,0 +1,224 @@
+package oidc
+
+import (
+	"context"
+	jwtauth "creatly-task/pkg/auth/jwt"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// stubProvider is a minimal identity provider: it issues id token for code "code"
+// when code_verifier matches the challenge sent to the authorization endpoint
+type stubProvider struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key error - %s\n", err.Error())
+	}
+
+	stub := &stubProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Discovery{
+			Issuer:                stub.server.URL,
+			AuthorizationEndpoint: stub.server.URL + "/authorize",
+			TokenEndpoint:         stub.server.URL + "/token",
+			JWKSURI:               stub.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwtauth.JWKS{Keys: []jwtauth.JWK{{
+			Kty: "RSA",
+			Kid: "stub",
+			Alg: "RS256",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "client" || clientSecret != "secret" || r.FormValue("code") != "code" ||
+			challenge(r.FormValue("code_verifier")) != stub.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":            stub.server.URL,
+			"aud":            []string{"client"},
+			"sub":            "external-1",
+			"email":          "User@Company.com",
+			"email_verified": true,
+			"nonce":          stub.nonce,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		}
+		for k, v := range stub.claims {
+			claims[k] = v
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "stub"
+		idToken, _ := token.SignedString(key)
+
+		json.NewEncoder(w).Encode(tokenResponse{IDToken: idToken})
+	})
+
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+// authorize emulates user login on provider page
+func (s *stubProvider) authorize(t *testing.T, authURL string) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse auth url error - %s\n", err.Error())
+	}
+
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "client" {
+		t.Fatalf("unexpected auth url - %s\n", authURL)
+	}
+
+	s.challenge = query.Get("code_challenge")
+	s.nonce = query.Get("nonce")
+}
+
+func Test_Flow(t *testing.T) {
+	testTable := []struct {
+		name       string
+		claims     jwt.MapClaims
+		code       string
+		verifier   string
+		nonce      string
+		wantError  bool
+		errorIs    error
+		wantClaims *Claims
+	}{
+		{
+			name:       "OK",
+			code:       "code",
+			wantClaims: &Claims{Subject: "external-1", Email: "user@company.com", EmailVerified: true},
+		},
+		{
+			name:      "ERROR: wrong code",
+			code:      "other",
+			wantError: true,
+		},
+		{
+			name:      "ERROR: wrong verifier",
+			code:      "code",
+			verifier:  "other",
+			wantError: true,
+		},
+		{
+			name:      "ERROR: nonce mismatch",
+			code:      "code",
+			nonce:     "other",
+			wantError: true,
+			errorIs:   ErrNonceMismatch,
+		},
+		{
+			name:      "ERROR: another audience",
+			code:      "code",
+			claims:    jwt.MapClaims{"aud": "other-client"},
+			wantError: true,
+			errorIs:   ErrInvalidIDToken,
+		},
+		{
+			name:      "ERROR: expired",
+			code:      "code",
+			claims:    jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()},
+			wantError: true,
+			errorIs:   ErrInvalidIDToken,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newStubProvider(t)
+			stub.claims = test.claims
+
+			provider := NewProvider(&Config{
+				Issuer:       stub.server.URL,
+				ClientID:     "client",
+				ClientSecret: "secret",
+				RedirectURL:  "http://localhost:8000/oidc/stub/callback",
+			}, stub.server.Client())
+
+			verifier, _ := RandomString()
+			authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", verifier)
+			if err != nil {
+				t.Fatalf("auth url error - %s\n", err.Error())
+			}
+			stub.authorize(t, authURL)
+
+			if test.verifier != "" {
+				verifier = test.verifier
+			}
+			nonce := "nonce"
+			if test.nonce != "" {
+				nonce = test.nonce
+			}
+
+			claims, err := provider.Exchange(context.Background(), test.code, verifier, nonce)
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+
+			if test.errorIs != nil && !errors.Is(err, test.errorIs) {
+				t.Fatalf("unexpected error\nReceived - %v\nWant - %v\n", err, test.errorIs)
+			}
+
+			if test.wantClaims != nil && *claims != *test.wantClaims {
+				t.Fatalf("unexpected claims - %+v\n", claims)
+			}
+		})
+	}
+}
+
+func Test_DiscoveryIssuerMismatch(t *testing.T) {
+	stub := newStubProvider(t)
+
+	provider := NewProvider(&Config{Issuer: stub.server.URL + "/other"}, stub.server.Client())
+
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func Test_CanceledContext(t *testing.T) {
+	stub := newStubProvider(t)
+
+	provider := NewProvider(&Config{Issuer: stub.server.URL}, stub.server.Client())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := provider.AuthCodeURL(ctx, "state", "nonce", "verifier"); err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Fatalf("expected canceled request, got - %v\n", err)
+	}
+}
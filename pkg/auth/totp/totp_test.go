@@ -0,0 +1,90 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test vectors from RFC 6238 Appendix B (SHA1)
+func Test_generate(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	testTable := []struct {
+		unix   int64
+		expect string
+	}{
+		{unix: 59, expect: "94287082"},
+		{unix: 1111111109, expect: "07081804"},
+		{unix: 1111111111, expect: "14050471"},
+		{unix: 1234567890, expect: "89005924"},
+		{unix: 2000000000, expect: "69279037"},
+		{unix: 20000000000, expect: "65353130"},
+	}
+
+	for _, test := range testTable {
+		code := generate(key, uint64(test.unix/Period), 8)
+		if code != test.expect {
+			t.Fatalf("time %d\nReceived - %s\nWant - %s\n", test.unix, code, test.expect)
+		}
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatalf("code error - %s\n", err.Error())
+	}
+
+	if code != "050471" {
+		t.Fatalf("unexpected code - %s\n", code)
+	}
+
+	testTable := []struct {
+		name   string
+		secret string
+		code   string
+		at     time.Time
+		expect bool
+	}{
+		{name: "OK: current step", secret: secret, code: code, at: now, expect: true},
+		{name: "OK: previous step", secret: secret, code: code, at: now.Add(time.Second * Period), expect: true},
+		{name: "OK: lowercase secret", secret: strings.ToLower(secret), code: code, at: now, expect: true},
+		{name: "ERROR: too old", secret: secret, code: code, at: now.Add(time.Second * Period * 2), expect: false},
+		{name: "ERROR: wrong code", secret: secret, code: "000000", at: now, expect: false},
+		{name: "ERROR: wrong length", secret: secret, code: "50471", at: now, expect: false},
+		{name: "ERROR: invalid secret", secret: "!!!", code: code, at: now, expect: false},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			_, ok := Validate(test.secret, test.code, test.at, 1)
+			if ok != test.expect {
+				t.Fatalf("Received - %v\nWant - %v\n", ok, test.expect)
+			}
+		})
+	}
+}
+
+func Test_URI(t *testing.T) {
+	uri := URI("JBSWY3DPEHPK3PXP", "Creatly", "some@mail.com")
+
+	expect := "otpauth://totp/Creatly:some@mail.com?algorithm=SHA1&digits=6&issuer=Creatly&period=30&secret=JBSWY3DPEHPK3PXP"
+	if uri != expect {
+		t.Fatalf("Received - %s\nWant - %s\n", uri, expect)
+	}
+}
+
+func Test_GenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate error - %s\n", err.Error())
+	}
+
+	if _, err := decodeSecret(secret); err != nil {
+		t.Fatalf("secret not decodable - %s\n", err.Error())
+	}
+}
@@ -0,0 +1,88 @@
+// Package events fans out messages published by topic to subscribers of this process.
+// Brokers shared by several instances implement the same Publish and Subscribe methods
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultBuffer = 64 // Messages kept for a subscriber which doesn't keep up
+
+// Bus is in-process broker, subscribers get only messages published by this instance
+type Bus struct {
+	mu     sync.Mutex
+	topics map[string]map[*subscriber]struct{}
+	buffer int
+}
+
+type subscriber struct {
+	messages chan []byte
+}
+
+// NewBus returns bus keeping buffer messages per subscriber, 64 when buffer is 0
+func NewBus(buffer int) *Bus {
+	if buffer <= 0 {
+		buffer = defaultBuffer
+	}
+
+	return &Bus{
+		topics: make(map[string]map[*subscriber]struct{}),
+		buffer: buffer,
+	}
+}
+
+// Publish never blocks. A subscriber with full buffer is dropped, its channel is closed so it can resubscribe and catch up
+func (b *Bus) Publish(ctx context.Context, topic string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.topics[topic] {
+		select {
+		case sub.messages <- data:
+		default:
+			b.remove(topic, sub)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe returns messages of the topic published from now on. The channel is closed when ctx is done
+func (b *Bus) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	sub := &subscriber{messages: make(chan []byte, b.buffer)}
+
+	b.mu.Lock()
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[*subscriber]struct{})
+	}
+	b.topics[topic][sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(topic, sub)
+	}()
+
+	return sub.messages, nil
+}
+
+// remove closes the subscriber once, b.mu is held by the caller
+func (b *Bus) remove(topic string, sub *subscriber) {
+	subs, ok := b.topics[topic]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	close(sub.messages)
+	if len(subs) == 0 {
+		delete(b.topics, topic)
+	}
+}
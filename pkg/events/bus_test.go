@@ -0,0 +1,72 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, messages <-chan []byte) ([]byte, bool) {
+	t.Helper()
+
+	select {
+	case data, ok := <-messages:
+		return data, ok
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return nil, false
+	}
+}
+
+func Test_Bus(t *testing.T) {
+	bus := NewBus(0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	first, _ := bus.Subscribe(ctx, "files.user.1")
+	second, _ := bus.Subscribe(context.Background(), "files.user.1")
+	other, _ := bus.Subscribe(context.Background(), "files.user.2")
+
+	_ = bus.Publish(context.Background(), "files.user.1", []byte("created"))
+
+	for _, messages := range []<-chan []byte{first, second} {
+		data, ok := receive(t, messages)
+		if !ok || string(data) != "created" {
+			t.Fatalf("unexpected message - %q, %v\n", data, ok)
+		}
+	}
+	select {
+	case data := <-other:
+		t.Fatalf("unexpected message of other topic - %q\n", data)
+	default:
+	}
+
+	cancel()
+	if _, ok := receive(t, first); ok {
+		t.Fatal("expected channel to be closed after cancel")
+	}
+
+	_ = bus.Publish(context.Background(), "files.user.1", []byte("deleted"))
+	if data, ok := receive(t, second); !ok || string(data) != "deleted" {
+		t.Fatalf("unexpected message - %q, %v\n", data, ok)
+	}
+}
+
+func Test_Bus_SlowSubscriber(t *testing.T) {
+	bus := NewBus(2)
+	messages, _ := bus.Subscribe(context.Background(), "topic")
+
+	for _, data := range []string{"1", "2", "3", "4"} {
+		err := bus.Publish(context.Background(), "topic", []byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var received []string
+	for data := range messages {
+		received = append(received, string(data))
+	}
+	if len(received) != 2 || received[0] != "1" || received[1] != "2" {
+		t.Fatalf("unexpected messages of dropped subscriber - %v\n", received)
+	}
+}
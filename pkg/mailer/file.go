@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer writes every message into a separate .eml file in dir.
+// With empty dir messages are written to the log. Used for local development and tests
+type FileMailer struct {
+	dir  string
+	from string
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+func NewFile(dir, from string) *FileMailer {
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *FileMailer) Send(message *Message) error {
+	m.mu.Lock()
+	m.sent = append(m.sent, *message)
+	count := len(m.sent)
+	m.mu.Unlock()
+
+	if m.dir == "" {
+		log.Printf("mail to %s - %s\n%s\n", message.To, message.Subject, message.Body)
+		return nil
+	}
+
+	filename := filepath.Join(m.dir, fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), count))
+	err := ioutil.WriteFile(filename, message.format(m.from), 0644)
+	if err != nil {
+		return fmt.Errorf("error with write mail - %s", err.Error())
+	}
+
+	return nil
+}
+
+// Sent returns all messages sent by this mailer
+func (m *FileMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sent := make([]Message, len(m.sent))
+	copy(sent, m.sent)
+	return sent
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"creatly-task/internal/config"
+	"fmt"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(message *Message) error
+}
+
+// New returns mailer selected by config driver. File mailer is used by default
+func New(cfg *config.Mail) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTP(cfg), nil
+	case DriverFile, "":
+		return NewFile(cfg.Dir, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver - %s", cfg.Driver)
+	}
+}
+
+func (m *Message) format(from string) []byte {
+	return []byte(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=\"utf-8\"\r\n\r\n%s\r\n",
+		from, m.To, m.Subject, m.Body))
+}
@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"creatly-task/internal/config"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func Test_New(t *testing.T) {
+	testTable := []struct {
+		name      string
+		driver    string
+		wantError bool
+	}{
+		{name: "OK: default", driver: ""},
+		{name: "OK: file", driver: "file"},
+		{name: "OK: smtp", driver: "smtp"},
+		{name: "ERROR: unknown driver", driver: "pigeon", wantError: true},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(&config.Mail{Driver: test.driver})
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+		})
+	}
+}
+
+func Test_FileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := NewFile(dir, "noreply@creatly.me")
+
+	err := mailer.Send(&Message{To: "some@mail.com", Subject: "Hello", Body: "World"})
+	if err != nil {
+		t.Fatalf("send error - %s\n", err.Error())
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one mail file, got %d - %v\n", len(files), err)
+	}
+
+	data, err := ioutil.ReadFile(dir + "/" + files[0].Name())
+	if err != nil {
+		t.Fatalf("read error - %s\n", err.Error())
+	}
+
+	if !strings.Contains(string(data), "To: some@mail.com") || !strings.Contains(string(data), "World") {
+		t.Fatalf("unexpected mail content\n%s\n", data)
+	}
+
+	if len(mailer.Sent()) != 1 {
+		t.Fatal("sent message not recorded")
+	}
+}
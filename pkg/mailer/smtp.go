@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"creatly-task/internal/config"
+	"fmt"
+	"net/smtp"
+)
+
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTP(cfg *config.Mail) *SMTPMailer {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	return &SMTPMailer{
+		addr: fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
+		from: cfg.From,
+		auth: auth,
+	}
+}
+
+func (m *SMTPMailer) Send(message *Message) error {
+	err := smtp.SendMail(m.addr, m.auth, m.from, []string{message.To}, message.format(m.from))
+	if err != nil {
+		return fmt.Errorf("error with send mail - %s", err.Error())
+	}
+	return nil
+}
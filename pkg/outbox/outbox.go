@@ -0,0 +1,120 @@
+// Package outbox contains publishers of domain events relayed from the outbox.
+// Every event may be published more than once, consumers drop duplicates by event ID
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"creatly-task/internal/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	EventHeader = "X-Event-ID"
+
+	defaultTimeout = 10 * time.Second
+)
+
+// Message is the published form of an event, its data is the event payload as is
+type Message struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt int64           `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func newMessage(event *models.OutboxEvent) *Message {
+	return &Message{
+		ID:        event.ID.Hex(),
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      json.RawMessage(event.Payload),
+	}
+}
+
+// Log writes events to the standard logger
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (l *Log) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	log.Printf("event %s %s - %s\n", event.Type, event.ID.Hex(), event.Payload)
+	return nil
+}
+
+// HTTP posts every event as JSON message, responses other than 2xx are failures
+type HTTP struct {
+	url  string
+	http *http.Client
+}
+
+// NewHTTP returns publisher with time limit of one request, 10s when timeout is 0
+func NewHTTP(url string, timeout time.Duration) *HTTP {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &HTTP{
+		url:  url,
+		http: &http.Client{Timeout: timeout},
+	}
+}
+
+func (h *HTTP) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	body, err := json.Marshal(newMessage(event))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event.ID.Hex())
+
+	resp, err := h.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("event %s is rejected with status %d", event.ID.Hex(), resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Memory keeps published messages in process, for tests
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, *newMessage(event))
+	return nil
+}
+
+// Messages returns copy of messages published so far
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
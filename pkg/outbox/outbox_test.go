@@ -0,0 +1,65 @@
+package outbox
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_HTTP(t *testing.T) {
+	event := &models.OutboxEvent{ID: primitive.NewObjectID(), Type: models.EventFileUploaded, Payload: `{"filename":"file1.png"}`, CreatedAt: 100}
+
+	testTable := []struct {
+		name      string
+		status    int
+		wantError bool
+	}{
+		{
+			name:   "OK",
+			status: http.StatusAccepted,
+		},
+		{
+			name:      "ERROR: rejected",
+			status:    http.StatusInternalServerError,
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			var body, id string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := ioutil.ReadAll(r.Body)
+				body, id = string(data), r.Header.Get(EventHeader)
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			err := NewHTTP(server.URL, 0).Publish(context.Background(), event)
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+
+			assert.Equal(t, event.ID.Hex(), id)
+			assert.Equal(t, `{"id":"`+event.ID.Hex()+`","type":"file.uploaded","createdAt":100,"data":{"filename":"file1.png"}}`, body)
+		})
+	}
+}
+
+func Test_Memory(t *testing.T) {
+	memory := NewMemory()
+	event := &models.OutboxEvent{ID: primitive.NewObjectID(), Type: models.EventUserCreated, Payload: `{}`, CreatedAt: 100}
+
+	_ = memory.Publish(context.Background(), event)
+	_ = memory.Publish(context.Background(), event)
+
+	messages := memory.Messages()
+	assert.Len(t, messages, 2)
+	assert.Equal(t, Message{ID: event.ID.Hex(), Type: models.EventUserCreated, CreatedAt: 100, Data: []byte(`{}`)}, messages[0])
+}
@@ -0,0 +1,39 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Breaker(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(3, time.Minute)
+	b.now = func() time.Time { return now }
+
+	// Success resets consecutive failures
+	for _, failed := range []bool{true, true, false, true, true} {
+		assert.NoError(t, b.Allow())
+		b.Record(failed)
+	}
+	assert.Equal(t, Closed, b.State())
+
+	assert.NoError(t, b.Allow())
+	b.Record(true)
+	assert.Equal(t, Open, b.State())
+	assert.Equal(t, ErrCircuitOpen, b.Allow())
+
+	// Only one probe goes through in half-open state
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Allow())
+	assert.Equal(t, HalfOpen, b.State())
+	assert.Equal(t, ErrCircuitOpen, b.Allow())
+
+	// Released probe lets the next one through
+	b.Release()
+	assert.NoError(t, b.Allow())
+	b.Record(false)
+	assert.Equal(t, Closed, b.State())
+	assert.NoError(t, b.Allow())
+}
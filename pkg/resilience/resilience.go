@@ -0,0 +1,170 @@
+// Package resilience retries failed backend calls with jittered exponential backoff
+// and stops calling a backend which is down with a circuit breaker
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultAttempts         = 3
+	defaultBaseDelay        = 100 * time.Millisecond
+	defaultMaxDelay         = 2 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerTimeout   = 30 * time.Second
+)
+
+// Class tells whether the failed call may be repeated
+type Class int
+
+const (
+	Permanent Class = iota // Error of the call itself like not found, the backend works
+	Transient              // Backend failure, the call may have been applied
+	Unsent                 // Backend failure before the call reached it, any call can be repeated
+)
+
+// Classifier sorts backend errors, unknown ones should be Permanent
+type Classifier func(err error) Class
+
+type Config struct {
+	Attempts  int           // Calls including the first one, 1 - no retries
+	BaseDelay time.Duration // Delay before the first retry, doubled for every next one
+	MaxDelay  time.Duration
+
+	BreakerThreshold int           // Consecutive failures opening the circuit
+	BreakerTimeout   time.Duration // Open circuit lets a probe call through after it
+}
+
+// Guard runs calls of one backend. All calls share its circuit breaker
+type Guard struct {
+	name      string
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	classify  Classifier
+	breaker   *Breaker
+
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter func(d time.Duration) time.Duration
+}
+
+// New creates guard of the named backend, zero config fields get defaults
+func New(name string, cfg *Config, classify Classifier) *Guard {
+	g := &Guard{
+		name:      name,
+		attempts:  defaultAttempts,
+		baseDelay: defaultBaseDelay,
+		maxDelay:  defaultMaxDelay,
+		classify:  classify,
+		sleep:     sleep,
+		jitter:    jitter,
+	}
+
+	threshold, timeout := defaultBreakerThreshold, defaultBreakerTimeout
+	if cfg != nil {
+		if cfg.Attempts > 0 {
+			g.attempts = cfg.Attempts
+		}
+		if cfg.BaseDelay > 0 {
+			g.baseDelay = cfg.BaseDelay
+		}
+		if cfg.MaxDelay > 0 {
+			g.maxDelay = cfg.MaxDelay
+		}
+		if cfg.BreakerThreshold > 0 {
+			threshold = cfg.BreakerThreshold
+		}
+		if cfg.BreakerTimeout > 0 {
+			timeout = cfg.BreakerTimeout
+		}
+	}
+	g.breaker = NewBreaker(threshold, timeout)
+
+	return g
+}
+
+func (g *Guard) Name() string {
+	return g.name
+}
+
+// State of the backend circuit
+func (g *Guard) State() State {
+	return g.breaker.State()
+}
+
+// Do calls op until it succeeds, fails permanently or attempts run out.
+// Not idempotent op is repeated only after Unsent failures, repeating it after others may apply it twice.
+// While the circuit is open it fails fast with ErrCircuitOpen
+func (g *Guard) Do(ctx context.Context, idempotent bool, op func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := g.breaker.Allow()
+		if err != nil {
+			return fmt.Errorf("%s: %w", g.name, err)
+		}
+
+		err = op(ctx)
+		if err == nil {
+			g.breaker.Record(false)
+			return nil
+		}
+
+		// Cancelled caller tells nothing about the backend
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+			g.breaker.Release()
+			return err
+		}
+
+		class := g.classify(err)
+		if ctx.Err() != nil && class == Permanent {
+			class = Transient // The backend didn't answer before the deadline
+		}
+		g.breaker.Record(class != Permanent)
+
+		if class == Permanent || (class == Transient && !idempotent) || attempt >= g.attempts || ctx.Err() != nil {
+			return err
+		}
+
+		err = g.sleep(ctx, g.jitter(g.backoff(attempt)))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// backoff doubles base delay for every attempt up to max delay
+func (g *Guard) backoff(attempt int) time.Duration {
+	delay := g.baseDelay
+	for i := 1; i < attempt && delay < g.maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > g.maxDelay {
+		delay = g.maxDelay
+	}
+
+	return delay
+}
+
+// jitter spreads retries of concurrent calls, so they don't hit recovering backend at once
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,196 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errPermanent = errors.New("not found")
+	errTransient = errors.New("connection reset")
+	errUnsent    = errors.New("connection refused")
+)
+
+func testClassify(err error) Class {
+	switch err {
+	case errTransient:
+		return Transient
+	case errUnsent:
+		return Unsent
+	}
+	return Permanent
+}
+
+// faultyOp fails with given errors one by one, then succeeds
+type faultyOp struct {
+	errs  []error
+	calls int
+}
+
+func (f *faultyOp) call(ctx context.Context) error {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return f.errs[f.calls-1]
+	}
+	return nil
+}
+
+// newTestGuard records delays instead of sleeping
+func newTestGuard(cfg *Config) (*Guard, *[]time.Duration) {
+	delays := []time.Duration{}
+	g := New("db", cfg, testClassify)
+	g.jitter = func(d time.Duration) time.Duration { return d }
+	g.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return g, &delays
+}
+
+func Test_GuardDo(t *testing.T) {
+	testTable := []struct {
+		name       string
+		errs       []error
+		idempotent bool
+		expect     error
+		calls      int
+		delays     []time.Duration
+	}{
+		{
+			name:       "OK: first call",
+			idempotent: true,
+			calls:      1,
+			delays:     []time.Duration{},
+		},
+		{
+			name:       "OK: transient failures are retried with backoff",
+			errs:       []error{errTransient, errTransient},
+			idempotent: true,
+			calls:      3,
+			delays:     []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			name:   "OK: not idempotent call is retried when it wasn't sent",
+			errs:   []error{errUnsent},
+			calls:  2,
+			delays: []time.Duration{100 * time.Millisecond},
+		},
+		{
+			name:   "ERROR: not idempotent call isn't retried after transient failure",
+			errs:   []error{errTransient},
+			expect: errTransient,
+			calls:  1,
+			delays: []time.Duration{},
+		},
+		{
+			name:       "ERROR: permanent failure isn't retried",
+			errs:       []error{errPermanent},
+			idempotent: true,
+			expect:     errPermanent,
+			calls:      1,
+			delays:     []time.Duration{},
+		},
+		{
+			name:       "ERROR: attempts run out",
+			errs:       []error{errTransient, errUnsent, errTransient, errTransient},
+			idempotent: true,
+			expect:     errTransient,
+			calls:      3,
+			delays:     []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			g, delays := newTestGuard(nil)
+			op := &faultyOp{errs: test.errs}
+
+			err := g.Do(context.Background(), test.idempotent, op.call)
+
+			assert.Equal(t, test.expect, err)
+			assert.Equal(t, test.calls, op.calls)
+			assert.Equal(t, test.delays, *delays)
+		})
+	}
+}
+
+func Test_GuardCircuit(t *testing.T) {
+	g, _ := newTestGuard(&Config{Attempts: 1, BreakerThreshold: 2, BreakerTimeout: time.Minute})
+	now := time.Now()
+	g.breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Permanent failures don't open the circuit
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, errPermanent, g.Do(ctx, true, (&faultyOp{errs: []error{errPermanent}}).call))
+	}
+	assert.Equal(t, Closed, g.State())
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, errTransient, g.Do(ctx, true, (&faultyOp{errs: []error{errTransient}}).call))
+	}
+	assert.Equal(t, Open, g.State())
+
+	// Open circuit fails fast without calling the backend
+	op := &faultyOp{}
+	err := g.Do(ctx, true, op.call)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, "db: circuit breaker is open", err.Error())
+	assert.Equal(t, 0, op.calls)
+
+	// Failed probe opens it again
+	now = now.Add(time.Minute)
+	assert.Equal(t, errTransient, g.Do(ctx, true, (&faultyOp{errs: []error{errTransient}}).call))
+	assert.Equal(t, Open, g.State())
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, g.Do(ctx, true, op.call))
+	assert.Equal(t, Closed, g.State())
+}
+
+func Test_GuardContext(t *testing.T) {
+	t.Run("cancelled call isn't counted", func(t *testing.T) {
+		g, _ := newTestGuard(&Config{BreakerThreshold: 1})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := g.Do(ctx, true, func(ctx context.Context) error { return ctx.Err() })
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, Closed, g.State())
+	})
+
+	t.Run("deadline stops retries and counts as failure", func(t *testing.T) {
+		g, delays := newTestGuard(&Config{BreakerThreshold: 1})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+
+		op := &faultyOp{errs: []error{errors.New("wrapped deadline")}}
+		err := g.Do(ctx, true, func(ctx context.Context) error {
+			<-ctx.Done()
+			return op.call(ctx)
+		})
+		assert.EqualError(t, err, "wrapped deadline")
+		assert.Equal(t, 1, op.calls)
+		assert.Empty(t, *delays)
+		assert.Equal(t, Open, g.State())
+	})
+}
+
+func Test_backoff(t *testing.T) {
+	g := New("db", &Config{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, testClassify)
+
+	delays := []time.Duration{}
+	for attempt := 1; attempt <= 5; attempt++ {
+		delays = append(delays, g.backoff(attempt))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second)
+		assert.True(t, d >= time.Second/2 && d < time.Second, d)
+	}
+}
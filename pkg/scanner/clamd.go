@@ -0,0 +1,120 @@
+// Package scanner checks uploaded content with ClamAV daemon using INSTREAM command over TCP
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"creatly-task/internal/models"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	chunkSize      = 64 * 1024 // Must not exceed StreamMaxLength of clamd
+
+	foundSuffix = " FOUND"
+)
+
+var ErrUnexpectedReply = errors.New("unexpected clamd reply")
+
+type Clamd struct {
+	address string
+	timeout time.Duration
+	dialer  net.Dialer
+}
+
+// NewClamd returns scanner of clamd listening on host:port address, a scan is limited by timeout, 30s when it is 0
+func NewClamd(address string, timeout time.Duration) *Clamd {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Clamd{
+		address: address,
+		timeout: timeout,
+	}
+}
+
+// Scan streams data to clamd. Connection failures, timeouts and clamd errors like size limit are returned as errors
+func (c *Clamd) Scan(ctx context.Context, data io.Reader) (*models.ScanResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	// clamd replies and closes the connection when the stream is over its limit, the reply explains failed write
+	werr := writeStream(conn, data)
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		if werr != nil {
+			return nil, werr
+		}
+		return nil, fmt.Errorf("error reading clamd reply - %w", err)
+	}
+
+	return parseReply(strings.TrimSuffix(reply, "\x00"))
+}
+
+// writeStream sends INSTREAM command, the data as length prefixed chunks and zero length chunk ending it
+func writeStream(w io.Writer, data io.Reader) error {
+	_, err := w.Write([]byte("zINSTREAM\x00"))
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, err := io.ReadFull(data, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			_, werr := w.Write(buf[:4+n])
+			if werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = w.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
+// parseReply reads "stream: OK" and "stream: <threat> FOUND" replies, others are clamd errors
+func parseReply(reply string) (*models.ScanResult, error) {
+	result := strings.TrimPrefix(reply, "stream:")
+	if result == reply {
+		return nil, fmt.Errorf("%w: %q", ErrUnexpectedReply, reply)
+	}
+	result = strings.TrimSpace(result)
+
+	switch {
+	case result == "OK":
+		return &models.ScanResult{}, nil
+	case strings.HasSuffix(result, foundSuffix):
+		return &models.ScanResult{Infected: true, Threat: strings.TrimSuffix(result, foundSuffix)}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnexpectedReply, reply)
+}
@@ -0,0 +1,166 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"creatly-task/internal/models"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd answers INSTREAM commands like clamd, streams containing EICAR test string are infected.
+// Streams longer than limit get the size limit error
+func fakeClamd(t *testing.T, limit int) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, limit)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func serveClamd(conn net.Conn, limit int) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString(0)
+	if err != nil || command != "zINSTREAM\x00" {
+		_, _ = conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+
+	var data bytes.Buffer
+	for {
+		var size uint32
+		err = binary.Read(r, binary.BigEndian, &size)
+		if err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+
+		_, err = io.CopyN(&data, r, int64(size))
+		if err != nil {
+			return
+		}
+		if data.Len() > limit {
+			_, _ = conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+			return
+		}
+	}
+
+	reply := "stream: OK\x00"
+	if strings.Contains(data.String(), eicar) {
+		reply = "stream: Eicar-Test-Signature FOUND\x00"
+	}
+	_, _ = conn.Write([]byte(reply))
+}
+
+func Test_Scan(t *testing.T) {
+	address := fakeClamd(t, 200*1024)
+
+	testTable := []struct {
+		name      string
+		data      []byte
+		expect    *models.ScanResult
+		wantError bool
+	}{
+		{
+			name:   "OK: clean",
+			data:   bytes.Repeat([]byte("png"), 50*1024),
+			expect: &models.ScanResult{},
+		},
+		{
+			name:   "OK: empty",
+			expect: &models.ScanResult{},
+		},
+		{
+			name:   "OK: infected",
+			data:   []byte(eicar),
+			expect: &models.ScanResult{Infected: true, Threat: "Eicar-Test-Signature"},
+		},
+		{
+			name:      "ERROR: size limit",
+			data:      bytes.Repeat([]byte("a"), 300*1024),
+			wantError: true,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := NewClamd(address, time.Second).Scan(context.Background(), bytes.NewReader(test.data))
+			if (err != nil) != test.wantError {
+				t.Fatalf("unexpected error - %v\n", err)
+			}
+
+			assert.Equal(t, test.expect, result)
+		})
+	}
+}
+
+func Test_Scan_Unavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	_, err = NewClamd(address, time.Second).Scan(context.Background(), strings.NewReader("png"))
+	assert.Error(t, err)
+}
+
+func Test_Scan_Timeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Accepts connections and never answers
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(ioutil.Discard, conn)
+		}
+	}()
+
+	start := time.Now()
+	_, err = NewClamd(listener.Addr().String(), 100*time.Millisecond).Scan(context.Background(), strings.NewReader("png"))
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second, "scan is stopped by timeout")
+}
+
+func Test_parseReply(t *testing.T) {
+	_, err := parseReply("UNKNOWN COMMAND")
+	assert.True(t, errors.Is(err, ErrUnexpectedReply))
+
+	_, err = parseReply("stream: lstat() failed. ERROR")
+	assert.True(t, errors.Is(err, ErrUnexpectedReply))
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"creatly-task/internal/models"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory keeps uploaded files in process memory. Used by the demo mode and tests
+type Memory struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+type memoryFile struct {
+	data         []byte
+	lastModified int64
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		files: make(map[string]memoryFile),
+	}
+}
+
+func (m *Memory) UploadFile(ctx context.Context, file []byte, filesize int64, filename string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[filename] = memoryFile{
+		data:         append([]byte(nil), file...),
+		lastModified: time.Now().Unix(),
+	}
+
+	return memoryURL(filename), nil
+}
+
+func memoryURL(filename string) string {
+	return fmt.Sprintf("memory://%s", filename)
+}
+
+func (m *Memory) DeleteFile(ctx context.Context, filename string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.files, filename)
+
+	return nil
+}
+
+func (m *Memory) DownloadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.files[filename]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(file.data)), nil
+}
+
+func (m *Memory) ListFiles(ctx context.Context) ([]models.StoredObject, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	objects := make([]models.StoredObject, 0, len(m.files))
+	for key, file := range m.files {
+		objects = append(objects, models.StoredObject{
+			Key:          key,
+			Size:         int64(len(file.data)),
+			LastModified: file.lastModified,
+			URL:          memoryURL(key),
+		})
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+
+	return objects, nil
+}
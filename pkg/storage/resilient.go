@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/pkg/resilience"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Cloud is implemented by S3 and memory storages
+type Cloud interface {
+	UploadFile(ctx context.Context, file []byte, filesize int64, filename string) (string, error)
+	DeleteFile(ctx context.Context, filename string) error
+	DownloadFile(ctx context.Context, filename string) (io.ReadCloser, error)
+	ListFiles(ctx context.Context) ([]models.StoredObject, error)
+}
+
+// Resilient retries failed storage calls and fails fast while the storage is down.
+// All calls are idempotent: objects are put and deleted by key
+type Resilient struct {
+	cloud Cloud
+	guard *resilience.Guard
+}
+
+func NewResilient(cloud Cloud, cfg *resilience.Config) *Resilient {
+	return &Resilient{
+		cloud: cloud,
+		guard: resilience.New("storage", cfg, Classify),
+	}
+}
+
+func (r *Resilient) Guard() *resilience.Guard {
+	return r.guard
+}
+
+func (r *Resilient) UploadFile(ctx context.Context, file []byte, filesize int64, filename string) (string, error) {
+	var url string
+	err := r.guard.Do(ctx, true, func(ctx context.Context) error {
+		var err error
+		url, err = r.cloud.UploadFile(ctx, file, filesize, filename)
+		return err
+	})
+	return url, err
+}
+
+func (r *Resilient) DeleteFile(ctx context.Context, filename string) error {
+	return r.guard.Do(ctx, true, func(ctx context.Context) error {
+		return r.cloud.DeleteFile(ctx, filename)
+	})
+}
+
+// DownloadFile retries opening the object, reading it is not retried
+func (r *Resilient) DownloadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	var file io.ReadCloser
+	err := r.guard.Do(ctx, true, func(ctx context.Context) error {
+		var err error
+		file, err = r.cloud.DownloadFile(ctx, filename)
+		return err
+	})
+	return file, err
+}
+
+func (r *Resilient) ListFiles(ctx context.Context) ([]models.StoredObject, error) {
+	var objects []models.StoredObject
+	err := r.guard.Do(ctx, true, func(ctx context.Context) error {
+		var err error
+		objects, err = r.cloud.ListFiles(ctx)
+		return err
+	})
+	return objects, err
+}
+
+// Classify sorts S3 errors: throttling, 5xx responses and network failures are transient,
+// failed connection means the request wasn't sent
+func Classify(err error) resilience.Class {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return resilience.Transient
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return resilience.Unsent
+	}
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		if awsErr.OrigErr() != nil {
+			if class := Classify(awsErr.OrigErr()); class != resilience.Permanent {
+				return class
+			}
+		}
+		var failure awserr.RequestFailure
+		if errors.As(err, &failure) && failure.StatusCode() >= 500 {
+			return resilience.Transient
+		}
+		if request.IsErrorRetryable(err) || request.IsErrorThrottle(err) {
+			return resilience.Transient
+		}
+		return resilience.Permanent
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return resilience.Transient
+	}
+
+	return resilience.Permanent
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"creatly-task/internal/models"
+	"creatly-task/pkg/resilience"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+)
+
+// faultyCloud fails calls with queued errors, then passes them to memory storage
+type faultyCloud struct {
+	*Memory
+	errs  []error
+	calls int
+}
+
+func (f *faultyCloud) fault() error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *faultyCloud) UploadFile(ctx context.Context, file []byte, filesize int64, filename string) (string, error) {
+	if err := f.fault(); err != nil {
+		return "", err
+	}
+	return f.Memory.UploadFile(ctx, file, filesize, filename)
+}
+
+func (f *faultyCloud) DownloadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	if err := f.fault(); err != nil {
+		return nil, err
+	}
+	return f.Memory.DownloadFile(ctx, filename)
+}
+
+func (f *faultyCloud) ListFiles(ctx context.Context) ([]models.StoredObject, error) {
+	if err := f.fault(); err != nil {
+		return nil, err
+	}
+	return f.Memory.ListFiles(ctx)
+}
+
+var (
+	errThrottled    = awserr.NewRequestFailure(awserr.New("SlowDown", "reduce your request rate", nil), 503, "req")
+	errServer       = awserr.NewRequestFailure(awserr.New("InternalError", "internal error", nil), 500, "req")
+	errNoSuchKey    = awserr.NewRequestFailure(awserr.New("NoSuchKey", "key not found", nil), 404, "req")
+	errRefused      = awserr.New(request.ErrCodeRequestError, "send request failed", &url.Error{Op: "Put", URL: "https://s3", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}})
+	errConnReset    = awserr.New(request.ErrCodeRequestError, "send request failed", &url.Error{Op: "Put", URL: "https://s3", Err: &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}})
+	errAccessDenied = awserr.NewRequestFailure(awserr.New("AccessDenied", "access denied", nil), 403, "req")
+)
+
+func Test_Classify(t *testing.T) {
+	testTable := []struct {
+		name   string
+		err    error
+		expect resilience.Class
+	}{
+		{name: "throttling", err: errThrottled, expect: resilience.Transient},
+		{name: "server error", err: errServer, expect: resilience.Transient},
+		{name: "connection refused", err: errRefused, expect: resilience.Unsent},
+		{name: "connection reset", err: errConnReset, expect: resilience.Transient},
+		{name: "deadline", err: context.DeadlineExceeded, expect: resilience.Transient},
+		{name: "no such key", err: errNoSuchKey, expect: resilience.Permanent},
+		{name: "access denied", err: errAccessDenied, expect: resilience.Permanent},
+		{name: "missing memory file", err: os.ErrNotExist, expect: resilience.Permanent},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, Classify(test.err))
+		})
+	}
+}
+
+func Test_Resilient(t *testing.T) {
+	cfg := &resilience.Config{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, BreakerThreshold: 3}
+	ctx := context.Background()
+
+	t.Run("transient failures are retried", func(t *testing.T) {
+		cloud := &faultyCloud{Memory: NewMemory(), errs: []error{errThrottled, errConnReset}}
+		storage := NewResilient(cloud, cfg)
+
+		url, err := storage.UploadFile(ctx, []byte("png"), 3, "a.png")
+		assert.NoError(t, err)
+		assert.Equal(t, "memory://a.png", url)
+		assert.Equal(t, 3, cloud.calls)
+
+		file, err := storage.DownloadFile(ctx, "a.png")
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, "png", string(data))
+	})
+
+	t.Run("permanent failure is returned right away", func(t *testing.T) {
+		cloud := &faultyCloud{Memory: NewMemory()}
+		storage := NewResilient(cloud, cfg)
+
+		_, err := storage.DownloadFile(ctx, "missing.png")
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+		assert.Equal(t, 1, cloud.calls)
+		assert.Equal(t, resilience.Closed, storage.Guard().State())
+	})
+
+	t.Run("storage down opens the circuit", func(t *testing.T) {
+		cloud := &faultyCloud{Memory: NewMemory(), errs: []error{errServer, errServer, errServer, errServer}}
+		storage := NewResilient(cloud, cfg)
+
+		_, err := storage.ListFiles(ctx)
+		assert.Equal(t, errServer, err)
+		assert.Equal(t, resilience.Open, storage.Guard().State())
+
+		_, err = storage.ListFiles(ctx)
+		assert.True(t, errors.Is(err, resilience.ErrCircuitOpen))
+		assert.Equal(t, 3, cloud.calls)
+	})
+}
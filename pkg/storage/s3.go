@@ -2,9 +2,13 @@ package storage
 
 import (
 	"bytes"
+	"context"
 	"creatly-task/internal/config"
+	"creatly-task/internal/models"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -18,6 +22,7 @@ type Storage struct {
 	timeout    time.Duration
 	bucketName string
 	region     string
+	keyPrefix  string
 }
 
 type Config struct {
@@ -43,7 +48,14 @@ func New(cfg *config.Storage) (*Storage, error) {
 
 	svc := s3.New(session)
 
-	_, err = svc.ListObjects(&s3.ListObjectsInput{
+	ctx := context.Background()
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	_, err = svc.ListObjectsWithContext(ctx, &s3.ListObjectsInput{
 		Bucket: &cfg.BucketName,
 	})
 	if err != nil {
@@ -55,14 +67,17 @@ func New(cfg *config.Storage) (*Storage, error) {
 		timeout:    cfg.Timeout,
 		bucketName: cfg.BucketName,
 		region:     cfg.Region,
+		keyPrefix:  cfg.KeyPrefix,
 	}, nil
 }
 
-func (s *Storage) UploadFile(file []byte, filesize int64, filename string) (string, error) {
+func (s *Storage) UploadFile(ctx context.Context, file []byte, filesize int64, filename string) (string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
 
-	_, err := s.connection.PutObject(&s3.PutObjectInput{
+	_, err := s.connection.PutObjectWithContext(ctx, &s3.PutObjectInput{
 		Bucket:               aws.String(s.bucketName),
-		Key:                  aws.String(filename),
+		Key:                  aws.String(s.keyPrefix + filename),
 		ACL:                  aws.String("public-read"),
 		Body:                 bytes.NewReader(file),
 		ContentLength:        aws.Int64(filesize),
@@ -71,6 +86,90 @@ func (s *Storage) UploadFile(file []byte, filesize int64, filename string) (stri
 		ServerSideEncryption: aws.String("AES256"),
 	})
 
-	fileUrl := fmt.Sprintf("https://%s.s3-%s.amazonaws.com/%s", s.bucketName, s.region, filename)
-	return fileUrl, err
+	return s.url(filename), contextError(ctx, err)
+}
+
+func (s *Storage) url(filename string) string {
+	return fmt.Sprintf("https://%s.s3-%s.amazonaws.com/%s%s", s.bucketName, s.region, s.keyPrefix, filename)
+}
+
+func (s *Storage) DeleteFile(ctx context.Context, filename string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.connection.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.keyPrefix + filename),
+	})
+	return contextError(ctx, err)
+}
+
+// DownloadFile returns object content, the caller closes it.
+// The timeout covers reading the content as well
+func (s *Storage) DownloadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	ctx, cancel := s.withTimeout(ctx)
+
+	output, err := s.connection.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.keyPrefix + filename),
+	})
+	if err != nil {
+		cancel()
+		return nil, contextError(ctx, err)
+	}
+
+	return &cancelReadCloser{ReadCloser: output.Body, cancel: cancel}, nil
+}
+
+// ListFiles returns objects under the key prefix, keys are without it. Listing takes a call
+// per 1000 objects, so STORAGE_TIMEOUT is not applied to it, only the ctx deadline
+func (s *Storage) ListFiles(ctx context.Context) ([]models.StoredObject, error) {
+	objects := []models.StoredObject{}
+
+	err := s.connection.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(s.keyPrefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			key := strings.TrimPrefix(aws.StringValue(object.Key), s.keyPrefix)
+			objects = append(objects, models.StoredObject{
+				Key:          key,
+				Size:         aws.Int64Value(object.Size),
+				LastModified: aws.TimeValue(object.LastModified).Unix(),
+				URL:          s.url(key),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	return objects, nil
+}
+
+// withTimeout limits every S3 call with configured timeout, 0 - no limit
+func (s *Storage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// contextError replaces SDK error with the context one, so callers can detect timeouts with errors.Is
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelReadCloser) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
 }
@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Verify(t *testing.T) {
+	body := []byte(`{"type":"file.uploaded"}`)
+	now := time.Unix(1700000000, 0)
+	signed := Sign("secret", now.Unix(), body)
+
+	testTable := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		now       time.Time
+		wantError error
+	}{
+		{
+			name:   "OK",
+			secret: "secret",
+			header: signed,
+			body:   body,
+			now:    now.Add(time.Minute),
+		},
+		{
+			name:   "OK: one of rotated signatures",
+			secret: "secret",
+			header: "t=1700000000,v1=00ff," + signed[len("t=1700000000,"):],
+			body:   body,
+			now:    now,
+		},
+		{
+			name:      "ERROR: other secret",
+			secret:    "other",
+			header:    signed,
+			body:      body,
+			now:       now,
+			wantError: ErrInvalidSignature,
+		},
+		{
+			name:      "ERROR: changed body",
+			secret:    "secret",
+			header:    signed,
+			body:      []byte(`{"type":"file.deleted"}`),
+			now:       now,
+			wantError: ErrInvalidSignature,
+		},
+		{
+			name:      "ERROR: replayed later",
+			secret:    "secret",
+			header:    signed,
+			body:      body,
+			now:       now.Add(10 * time.Minute),
+			wantError: ErrSignatureExpired,
+		},
+		{
+			name:      "ERROR: malformed header",
+			secret:    "secret",
+			header:    "v1",
+			body:      body,
+			now:       now,
+			wantError: ErrInvalidSignature,
+		},
+		{
+			name:      "ERROR: no signature",
+			secret:    "secret",
+			header:    "t=1700000000",
+			body:      body,
+			now:       now,
+			wantError: ErrInvalidSignature,
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.name, func(t *testing.T) {
+			err := Verify(test.secret, test.header, test.body, 5*time.Minute, test.now)
+			assert.Equal(t, test.wantError, err)
+		})
+	}
+}
+
+func Test_Send(t *testing.T) {
+	body := []byte(`{"type":"file.uploaded"}`)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, body, received)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "file.uploaded", r.Header.Get(EventHeader))
+		assert.Equal(t, "delivery-1", r.Header.Get(DeliveryHeader))
+		assert.NoError(t, Verify("secret", r.Header.Get(SignatureHeader), received, time.Minute, time.Now()))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer receiver.Close()
+
+	code, err := New(time.Second).Send(context.Background(), receiver.URL, "secret", "file.uploaded", "delivery-1", body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+
+	receiver.Close()
+	_, err = New(time.Second).Send(context.Background(), receiver.URL, "secret", "file.uploaded", "delivery-1", body)
+	assert.Error(t, err, "no response")
+}